@@ -58,6 +58,7 @@ func buildClientCommand(clientFactory func() (apiclient.FFTMClient, error)) *cob
 
 	clientCmd.AddCommand(clientEventStreamsCommand(clientFactory))
 	clientCmd.AddCommand(clientListenersCommand(clientFactory))
+	clientCmd.AddCommand(clientSignersCommand(clientFactory))
 
 	return clientCmd
 }
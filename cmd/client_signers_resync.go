@@ -0,0 +1,54 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/firefly-transaction-manager/internal/apiclient"
+	"github.com/spf13/cobra"
+)
+
+var renumber bool
+
+func clientSignersResyncCommand(clientFactory func() (apiclient.FFTMClient, error)) *cobra.Command {
+	clientSignersResyncCmd := &cobra.Command{
+		Use:   "resync",
+		Short: "Discard the cached nonce for a signer, and re-query it from the blockchain",
+		Long:  "",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := clientFactory()
+			if err != nil {
+				return err
+			}
+			if signer == "" {
+				return fmt.Errorf("signer flag not set")
+			}
+			result, err := client.ResyncSigner(context.Background(), signer, renumber)
+			if err != nil {
+				return err
+			}
+			json, _ := json.MarshalIndent(result, "", "  ")
+			fmt.Println(string(json))
+			return nil
+		},
+	}
+	clientSignersResyncCmd.Flags().BoolVarP(&renumber, "renumber", "", false, "Move pending transactions that have not been submitted onto the next nonce from the blockchain")
+	return clientSignersResyncCmd
+}
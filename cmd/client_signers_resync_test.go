@@ -0,0 +1,65 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-transaction-manager/internal/apiclient"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/apiclientmocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSignersResync(t *testing.T) {
+	mc := apiclientmocks.NewFFTMClient(t)
+	cmd := buildClientCommand(func() (apiclient.FFTMClient, error) { return mc, nil })
+	cmd.SetArgs([]string{"signers", "resync", "--signer", "0xaaaaa", "--renumber"})
+	mc.On("ResyncSigner", mock.Anything, "0xaaaaa", true).Return(&apitypes.SignerResyncResult{Signer: "0xaaaaa"}, nil)
+	err := cmd.Execute()
+	assert.NoError(t, err)
+	mc.AssertExpectations(t)
+}
+
+func TestSignersResyncNoSigner(t *testing.T) {
+	mc := apiclientmocks.NewFFTMClient(t)
+	cmd := buildClientCommand(func() (apiclient.FFTMClient, error) { return mc, nil })
+	cmd.SetArgs([]string{"signers", "resync", "--signer", ""})
+	err := cmd.Execute()
+	assert.Regexp(t, "signer flag not set", err)
+}
+
+func TestSignersResyncError(t *testing.T) {
+	mc := apiclientmocks.NewFFTMClient(t)
+	cmd := buildClientCommand(func() (apiclient.FFTMClient, error) { return mc, nil })
+	cmd.SetArgs([]string{"signers", "resync", "--signer", "0xaaaaa", "--renumber=false"})
+	mc.On("ResyncSigner", mock.Anything, "0xaaaaa", false).Return(nil, fmt.Errorf("pop"))
+	err := cmd.Execute()
+	assert.Regexp(t, "pop", err)
+	mc.AssertExpectations(t)
+}
+
+func TestSignersResyncBadClientConf(t *testing.T) {
+	mc := apiclientmocks.NewFFTMClient(t)
+	cmd := buildClientCommand(func() (apiclient.FFTMClient, error) { return mc, fmt.Errorf("pop") })
+	cmd.SetArgs([]string{"signers", "resync", "--signer", "0xaaaaa"})
+	err := cmd.Execute()
+	assert.Regexp(t, "pop", err)
+	mc.AssertExpectations(t)
+}
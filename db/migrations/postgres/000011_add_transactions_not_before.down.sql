@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE transactions DROP COLUMN not_before;
+ALTER TABLE transactions DROP COLUMN not_before_block;
+COMMIT;
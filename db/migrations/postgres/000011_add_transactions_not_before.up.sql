@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE transactions ADD COLUMN not_before BIGINT;
+ALTER TABLE transactions ADD COLUMN not_before_block VARCHAR(65);
+COMMIT;
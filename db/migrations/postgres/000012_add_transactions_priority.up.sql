@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE transactions ADD COLUMN priority INTEGER DEFAULT 0;
+COMMIT;
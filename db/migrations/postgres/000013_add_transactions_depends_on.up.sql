@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE transactions ADD COLUMN depends_on TEXT;
+COMMIT;
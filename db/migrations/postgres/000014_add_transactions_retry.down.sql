@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE transactions DROP COLUMN retried_by;
+ALTER TABLE transactions DROP COLUMN retry_of;
+COMMIT;
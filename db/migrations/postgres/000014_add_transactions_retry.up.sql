@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE transactions ADD COLUMN retry_of TEXT;
+ALTER TABLE transactions ADD COLUMN retried_by TEXT;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+DROP INDEX transaction_hashes_id;
+DROP INDEX transaction_hashes_txid;
+DROP INDEX transaction_hashes_hash;
+DROP TABLE transaction_hashes;
+COMMIT;
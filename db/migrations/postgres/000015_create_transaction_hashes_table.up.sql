@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE transaction_hashes (
+  seq               SERIAL          PRIMARY KEY,
+  id                UUID            NOT NULL,
+  created           BIGINT          NOT NULL,
+  updated           BIGINT          NOT NULL,
+  tx_id             TEXT            NOT NULL,
+  tx_hash           TEXT            NOT NULL,
+  submitted         BIGINT
+);
+CREATE UNIQUE INDEX transaction_hashes_id ON transaction_hashes(id);
+CREATE INDEX transaction_hashes_txid ON transaction_hashes(tx_id);
+CREATE UNIQUE INDEX transaction_hashes_hash ON transaction_hashes(tx_hash);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX address_lists_id;
+DROP INDEX address_lists_address;
+DROP TABLE address_lists;
+COMMIT;
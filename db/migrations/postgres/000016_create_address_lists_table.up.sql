@@ -0,0 +1,14 @@
+BEGIN;
+CREATE TABLE address_lists (
+  seq               SERIAL          PRIMARY KEY,
+  id                UUID            NOT NULL,
+  created           BIGINT          NOT NULL,
+  updated           BIGINT          NOT NULL,
+  field             TEXT            NOT NULL,
+  list              TEXT            NOT NULL,
+  address           TEXT            NOT NULL,
+  reason            TEXT
+);
+CREATE UNIQUE INDEX address_lists_id ON address_lists(id);
+CREATE INDEX address_lists_address ON address_lists(address);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+CREATE INDEX transactions_status_from_nonce ON transactions(status, tx_from, tx_nonce);
+COMMIT;
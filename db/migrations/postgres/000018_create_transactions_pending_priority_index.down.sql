@@ -0,0 +1,3 @@
+BEGIN;
+DROP INDEX transactions_status_priority;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+CREATE INDEX transactions_status_priority ON transactions(status, priority DESC, seq);
+COMMIT;
@@ -32,6 +32,7 @@ type FFTMClient interface {
 	DeleteEventStreamsByName(ctx context.Context, nameRegex string) error
 	DeleteListener(ctx context.Context, eventStreamID, listenerID string) error
 	DeleteListenersByName(ctx context.Context, eventStreamID, nameRegex string) error
+	ResyncSigner(ctx context.Context, signer string, renumber bool) (*apitypes.SignerResyncResult, error)
 }
 
 type fftmClient struct {
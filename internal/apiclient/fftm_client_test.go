@@ -29,6 +29,7 @@ import (
 
 func newTestClientServer(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) (FFTMClient, *httptest.Server) {
 	server := httptest.NewServer(http.HandlerFunc(handler))
+	config.RootConfigReset()
 	config := config.RootSection("fftm_client")
 	InitConfig(config)
 	config.Set("url", server.URL)
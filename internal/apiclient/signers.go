@@ -0,0 +1,40 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+func (c *fftmClient) ResyncSigner(ctx context.Context, signer string, renumber bool) (*apitypes.SignerResyncResult, error) {
+	var result apitypes.SignerResyncResult
+	resp, err := c.client.R().
+		SetContext(ctx).
+		SetBody(&apitypes.SignerResyncRequest{Renumber: renumber}).
+		SetResult(&result).
+		Post(fmt.Sprintf("signers/%s/resync", signer))
+	if err != nil {
+		return nil, err
+	}
+	if !resp.IsSuccess() {
+		return nil, fmt.Errorf(string(resp.Body()))
+	}
+	return &result, nil
+}
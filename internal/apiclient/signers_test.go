@@ -0,0 +1,72 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResyncSigner(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/signers/0xaaaaa/resync" && r.Method == http.MethodPost {
+			var req apitypes.SignerResyncRequest
+			err := json.NewDecoder(r.Body).Decode(&req)
+			assert.NoError(t, err)
+			assert.True(t, req.Renumber)
+			responseJSON, _ := json.Marshal(&apitypes.SignerResyncResult{
+				Signer:         "0xaaaaa",
+				ChainNextNonce: fftypes.NewFFBigInt(15),
+			})
+			w.Header().Add("Content-type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write(responseJSON)
+		}
+	}
+	client, server := newTestClientServer(t, handler)
+	defer server.Close()
+
+	result, err := client.ResyncSigner(context.Background(), "0xaaaaa", true)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), result.ChainNextNonce.Int64())
+}
+
+func TestResyncSignerError(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	client, server := newTestClientServer(t, handler)
+	defer server.Close()
+
+	_, err := client.ResyncSigner(context.Background(), "0xaaaaa", false)
+	assert.Error(t, err)
+}
+
+func TestResyncSignerRequestFail(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	client, server := newTestClientServer(t, handler)
+	server.Close()
+
+	_, err := client.ResyncSigner(context.Background(), "0xaaaaa", false)
+	assert.Error(t, err)
+}
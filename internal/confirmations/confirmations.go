@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hyperledger/firefly-common/pkg/config"
@@ -45,6 +46,7 @@ type Manager interface {
 	Stop()
 	NewBlockHashes() chan<- *ffcapi.BlockHashEvent
 	CheckInFlight(listenerID *fftypes.UUID) bool
+	HighestBlockSeen() uint64
 }
 
 type NotificationType string
@@ -94,7 +96,7 @@ type blockConfirmationManager struct {
 	requiredConfirmations int
 	staleReceiptTimeout   time.Duration
 	bcmNotifications      chan *Notification
-	highestBlockSeen      uint64
+	highestBlockSeen      uint64 // only written by the confirmation loop, and read atomically by other routines
 	pending               map[string]*pendingItem
 	pendingMux            sync.Mutex
 	receiptChecker        *receiptChecker
@@ -282,6 +284,11 @@ func (bcm *blockConfirmationManager) CheckInFlight(listenerID *fftypes.UUID) boo
 	return false
 }
 
+// HighestBlockSeen returns the highest block number notified by the block listener, or zero if none has been notified yet
+func (bcm *blockConfirmationManager) HighestBlockSeen() uint64 {
+	return atomic.LoadUint64(&bcm.highestBlockSeen)
+}
+
 func (bcm *blockConfirmationManager) getBlockByHash(blockHash string) (*apitypes.BlockInfo, error) {
 	res, reason, err := bcm.connector.BlockInfoByHash(bcm.ctx, &ffcapi.BlockInfoByHashRequest{
 		BlockHash: blockHash,
@@ -515,7 +522,7 @@ func (bcm *blockConfirmationManager) processBlockHashes(blockHashes []string) {
 
 		// Update the highest block (used for efficiency in chain walks)
 		if block.BlockNumber.Uint64() > bcm.highestBlockSeen {
-			bcm.highestBlockSeen = block.BlockNumber.Uint64()
+			atomic.StoreUint64(&bcm.highestBlockSeen, block.BlockNumber.Uint64())
 		}
 		bcm.metricsEmitter.RecordBlockHashProcessMetrics(bcm.ctx, time.Since(startTime).Seconds())
 
@@ -173,6 +173,7 @@ func TestBlockConfirmationManagerE2ENewEvent(t *testing.T) {
 	}, dispatched.Confirmations)
 	assert.False(t, dispatched.NewFork)
 	assert.True(t, dispatched.Confirmed)
+	assert.Equal(t, uint64(1004), bcm.HighestBlockSeen())
 
 	bcm.Stop()
 
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"sync"
 	"time"
@@ -46,6 +47,7 @@ type leveldbPersistence struct {
 	maxHistoryCount   int
 	nonceMux          sync.Mutex
 	lockedNonces      map[string]*lockedNonce
+	staleNonces       map[string]bool
 	nonceStateTimeout time.Duration
 	txMux             sync.RWMutex // allows us to draw conclusions on the cleanup of indexes
 }
@@ -61,13 +63,27 @@ func NewLevelDBPersistence(ctx context.Context, nonceStateTimeout time.Duration)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgPersistenceInitFailed, dbPath)
 	}
-	return &leveldbPersistence{
+	p := &leveldbPersistence{
 		db:                db,
 		syncWrites:        config.GetBool(tmconfig.PersistenceLevelDBSyncWrites),
 		maxHistoryCount:   config.GetInt(tmconfig.TransactionsMaxHistoryCount),
 		nonceStateTimeout: nonceStateTimeout,
 		lockedNonces:      map[string]*lockedNonce{},
-	}, nil
+		staleNonces:       map[string]bool{},
+	}
+	if err := p.backfillTXHashIndex(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := p.backfillTXPendingSignerIndex(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := p.backfillTXPendingPriorityIndex(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
 }
 
 const checkpointsPrefix = "checkpoints_0/"
@@ -75,12 +91,23 @@ const eventstreamsPrefix = "eventstreams_0/"
 const eventstreamsEnd = "eventstreams_1"
 const listenersPrefix = "listeners_0/"
 const listenersEnd = "listeners_1"
+const addressListsPrefix = "addresslists_0/"
+const addressListsEnd = "addresslists_1"
 const transactionsPrefix = "tx_0/"
 const nonceAllocationPrefix = "nonce_0/"
+const nonceAllocationEnd = "nonce_1"
 const txPendingIndexPrefix = "tx_inflight_0/"
 const txPendingIndexEnd = "tx_inflight_1"
 const txCreatedIndexPrefix = "tx_created_0/"
 const txCreatedIndexEnd = "tx_created_1"
+const txHashIndexPrefix = "tx_hash_0/"
+const txHashIndexBackfilledKey = "migrations_0/tx_hash_0"
+const txPendingSignerIndexPrefix = "tx_pending_signer_0/"
+const txPendingSignerIndexEnd = "tx_pending_signer_1"
+const txPendingSignerIndexBackfilledKey = "migrations_0/tx_pending_signer_0"
+const txPendingPriorityIndexPrefix = "tx_pending_priority_0/"
+const txPendingPriorityIndexEnd = "tx_pending_priority_1"
+const txPendingPriorityIndexBackfilledKey = "migrations_0/tx_pending_priority_0"
 
 func signerNoncePrefix(signer string) string {
 	return fmt.Sprintf("%s%s_0/", nonceAllocationPrefix, signer)
@@ -94,6 +121,32 @@ func txNonceAllocationKey(signer string, nonce *fftypes.FFBigInt) []byte {
 	return []byte(fmt.Sprintf("%s%s_0/%.24d", nonceAllocationPrefix, signer, nonce.Int()))
 }
 
+func signerPendingPrefix(signer string) string {
+	return fmt.Sprintf("%s%s_0/", txPendingSignerIndexPrefix, signer)
+}
+
+func signerPendingEnd(signer string) string {
+	return fmt.Sprintf("%s%s_1", txPendingSignerIndexPrefix, signer)
+}
+
+// txPendingSignerIndexKey orders the pending transactions of a signer by nonce, with any that are still
+// waiting for a nonce after all of those that have one
+func txPendingSignerIndexKey(tx *apitypes.ManagedTX) []byte {
+	if tx.Nonce == nil {
+		return []byte(fmt.Sprintf("%s~%s", signerPendingPrefix(tx.From), tx.SequenceID))
+	}
+	return []byte(fmt.Sprintf("%s%.24d", signerPendingPrefix(tx.From), tx.Nonce.Int()))
+}
+
+// txPendingPriorityIndexKey orders the pending transactions with a priority by highest priority first, then the
+// order they were received. Only transactions with a priority above zero are indexed.
+func txPendingPriorityIndexKey(tx *apitypes.ManagedTX) []byte {
+	if tx.Priority <= 0 {
+		return nil
+	}
+	return []byte(fmt.Sprintf("%s%.19d/%s", txPendingPriorityIndexPrefix, math.MaxInt64-int64(tx.Priority), tx.SequenceID))
+}
+
 func txPendingIndexKey(sequenceID string) []byte {
 	return []byte(fmt.Sprintf("%s%s", txPendingIndexPrefix, sequenceID))
 }
@@ -102,6 +155,10 @@ func txCreatedIndexKey(tx *apitypes.ManagedTX) []byte {
 	return []byte(fmt.Sprintf("%s%.19d/%s", txCreatedIndexPrefix, tx.Created.UnixNano(), tx.SequenceID))
 }
 
+func txHashIndexKey(transactionHash string) []byte {
+	return []byte(fmt.Sprintf("%s%s", txHashIndexPrefix, transactionHash))
+}
+
 func txDataKey(k string) []byte {
 	return []byte(fmt.Sprintf("%s%s", transactionsPrefix, k))
 }
@@ -332,6 +389,31 @@ func (p *leveldbPersistence) DeleteListener(ctx context.Context, listenerID *fft
 	return p.deleteKeys(ctx, prefixedKey(listenersPrefix, listenerID))
 }
 
+func (p *leveldbPersistence) ListAddressListEntriesByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection) ([]*apitypes.AddressListEntry, error) {
+	entries := make([]*apitypes.AddressListEntry, 0)
+	if _, err := p.listJSON(ctx, addressListsPrefix, addressListsEnd, after.String(), limit, dir,
+		func() interface{} { var v *apitypes.AddressListEntry; return &v },
+		func(v interface{}) { entries = append(entries, *(v.(**apitypes.AddressListEntry))) },
+		nil,
+	); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (p *leveldbPersistence) GetAddressListEntry(ctx context.Context, entryID *fftypes.UUID) (e *apitypes.AddressListEntry, err error) {
+	err = p.readJSON(ctx, prefixedKey(addressListsPrefix, entryID), &e)
+	return e, err
+}
+
+func (p *leveldbPersistence) WriteAddressListEntry(ctx context.Context, entry *apitypes.AddressListEntry) error {
+	return p.writeJSON(ctx, prefixedKey(addressListsPrefix, entry.ID), entry)
+}
+
+func (p *leveldbPersistence) DeleteAddressListEntry(ctx context.Context, entryID *fftypes.UUID) error {
+	return p.deleteKeys(ctx, prefixedKey(addressListsPrefix, entryID))
+}
+
 func (p *leveldbPersistence) indexLookupCallback(ctx context.Context, key []byte) ([]byte, error) {
 	b, err := p.getKeyValue(ctx, key)
 	switch {
@@ -352,7 +434,7 @@ func (p *leveldbPersistence) cleanupOrphanedTXIdxKeys(ctx context.Context, orpha
 	}
 }
 
-func (p *leveldbPersistence) listTransactionsByIndex(ctx context.Context, collectionPrefix, collectionEnd, afterStr string, limit int, dir txhandler.SortDirection) ([]*apitypes.ManagedTX, error) {
+func (p *leveldbPersistence) listTransactionsByIndex(ctx context.Context, collectionPrefix, collectionEnd, afterStr string, limit int, dir txhandler.SortDirection, filters ...func(interface{}) bool) ([]*apitypes.ManagedTX, error) {
 
 	p.txMux.RLock()
 	transactions := make([]*apitypes.ManagedTX, 0)
@@ -364,6 +446,7 @@ func (p *leveldbPersistence) listTransactionsByIndex(ctx context.Context, collec
 			transactions = append(transactions, tx)
 		},
 		p.indexLookupCallback,
+		filters...,
 	)
 	p.txMux.RUnlock()
 	if err != nil {
@@ -396,6 +479,117 @@ func (p *leveldbPersistence) ListTransactionsPending(ctx context.Context, afterS
 	return p.listTransactionsByIndex(ctx, txPendingIndexPrefix, txPendingIndexEnd, afterSequenceID, limit, dir)
 }
 
+func (p *leveldbPersistence) ListSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	p.txMux.RLock()
+	defer p.txMux.RUnlock()
+
+	collectionRange := &util.Range{
+		Start: []byte(nonceAllocationPrefix),
+		Limit: []byte(nonceAllocationEnd),
+	}
+	if after != "" {
+		collectionRange.Start = []byte(signerNonceEnd(after))
+	}
+	it := p.db.NewIterator(collectionRange, &opt.ReadOptions{DontFillCache: true})
+	defer it.Release()
+	signers := make([]string, 0)
+	for valid := it.Next(); valid; {
+		key := string(it.Key())
+		signer := key[len(nonceAllocationPrefix):strings.LastIndex(key, "_0/")]
+		signers = append(signers, signer)
+		if limit > 0 && len(signers) >= limit {
+			break
+		}
+		// Each signer has a range of nonce index keys, so we jump over that range to find the next signer
+		valid = it.Seek([]byte(signerNonceEnd(signer)))
+	}
+	return signers, it.Error()
+}
+
+func (p *leveldbPersistence) ListPendingSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	p.txMux.RLock()
+	defer p.txMux.RUnlock()
+
+	collectionRange := &util.Range{
+		Start: []byte(txPendingSignerIndexPrefix),
+		Limit: []byte(txPendingSignerIndexEnd),
+	}
+	if after != "" {
+		collectionRange.Start = []byte(signerPendingEnd(after))
+	}
+	it := p.db.NewIterator(collectionRange, &opt.ReadOptions{DontFillCache: true})
+	defer it.Release()
+	signers := make([]string, 0)
+	for valid := it.Next(); valid; {
+		key := string(it.Key())
+		signer := key[len(txPendingSignerIndexPrefix):strings.LastIndex(key, "_0/")]
+		signers = append(signers, signer)
+		if limit > 0 && len(signers) >= limit {
+			break
+		}
+		// As in ListSigners, we jump over the range of keys of each signer
+		valid = it.Seek([]byte(signerPendingEnd(signer)))
+	}
+	return signers, it.Error()
+}
+
+func (p *leveldbPersistence) ListSignerTransactionsPending(ctx context.Context, signer string, limit int) ([]*apitypes.ManagedTX, error) {
+	// An index key left behind by a crash part way through an update is skipped, rather than listing the
+	// transaction twice or listing one that is no longer pending
+	listed := make(map[string]bool)
+	return p.listTransactionsByIndex(ctx, signerPendingPrefix(signer), signerPendingEnd(signer), "", limit, txhandler.SortDirectionAscending,
+		func(v interface{}) bool {
+			tx := *(v.(**apitypes.ManagedTX))
+			if tx.Status != apitypes.TxStatusPending || tx.From != signer || listed[tx.ID] {
+				return false
+			}
+			listed[tx.ID] = true
+			return true
+		},
+	)
+}
+
+func (p *leveldbPersistence) ListTransactionsPendingByPriority(ctx context.Context, limit int) ([]*apitypes.ManagedTX, error) {
+	return p.listTransactionsByIndex(ctx, txPendingPriorityIndexPrefix, txPendingPriorityIndexEnd, "", limit, txhandler.SortDirectionAscending,
+		func(v interface{}) bool {
+			// As the index key is removed after the status is updated, skip any left behind by a crash
+			return (*(v.(**apitypes.ManagedTX))).Status == apitypes.TxStatusPending
+		},
+	)
+}
+
+// backfillTXPendingSignerIndex indexes the pending transactions written before the index of pending transactions
+// by signer was added. As with backfillTXHashIndex, this only runs once.
+func (p *leveldbPersistence) backfillTXPendingSignerIndex(ctx context.Context) error {
+	return p.backfillPendingIndex(ctx, txPendingSignerIndexBackfilledKey, "signer", txPendingSignerIndexKey)
+}
+
+// backfillTXPendingPriorityIndex indexes the pending transactions written before the index of pending transactions
+// by priority was added. As with backfillTXHashIndex, this only runs once.
+func (p *leveldbPersistence) backfillTXPendingPriorityIndex(ctx context.Context) error {
+	return p.backfillPendingIndex(ctx, txPendingPriorityIndexBackfilledKey, "priority", txPendingPriorityIndexKey)
+}
+
+func (p *leveldbPersistence) backfillPendingIndex(ctx context.Context, backfilledKey, indexName string, indexKey func(tx *apitypes.ManagedTX) []byte) error {
+	done, err := p.getKeyValue(ctx, []byte(backfilledKey))
+	if err != nil || done != nil {
+		return err
+	}
+	pending, err := p.ListTransactionsPending(ctx, "", 0, txhandler.SortDirectionAscending)
+	if err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Indexing %d pending transactions by %s", len(pending), indexName)
+	for _, tx := range pending {
+		if key := indexKey(tx); key != nil {
+			if err := p.writeKeyValue(ctx, key, txDataKey(tx.ID)); err != nil {
+				return err
+			}
+		}
+	}
+	return p.writeKeyValue(ctx, []byte(backfilledKey), []byte(fftypes.Now().String()))
+}
+
 func (p *leveldbPersistence) GetTransactionByID(ctx context.Context, txID string) (tx *apitypes.ManagedTX, err error) {
 	txh, err := p.GetTransactionByIDWithStatus(ctx, txID, false)
 	if err != nil || txh == nil {
@@ -420,6 +614,75 @@ func (p *leveldbPersistence) GetTransactionConfirmations(ctx context.Context, tx
 	return txh.Confirmations, err
 }
 
+func (p *leveldbPersistence) GetTransactionByHash(ctx context.Context, transactionHash string) (tx *apitypes.ManagedTX, err error) {
+	p.txMux.RLock()
+	defer p.txMux.RUnlock()
+	err = p.readJSONByIndex(ctx, txHashIndexKey(transactionHash), &tx)
+	if tx != nil {
+		migrateTX(tx)
+	}
+	return tx, err
+}
+
+// backfillTXHashIndex indexes the hashes of transactions written before the hash index was added, so they can be
+// found by GetTransactionByHash. This only runs once, as a marker key is written when it completes.
+func (p *leveldbPersistence) backfillTXHashIndex(ctx context.Context) error {
+	done, err := p.getKeyValue(ctx, []byte(txHashIndexBackfilledKey))
+	if err != nil || done != nil {
+		return err
+	}
+	log.L(ctx).Infof("Indexing the hashes of existing transactions")
+	it := p.db.NewIterator(util.BytesPrefix([]byte(transactionsPrefix)), &opt.ReadOptions{DontFillCache: true})
+	defer it.Release()
+	indexed := 0
+	for it.Next() {
+		var tx apitypes.TXWithStatus
+		if err := json.Unmarshal(it.Value(), &tx); err != nil {
+			return i18n.WrapError(ctx, err, tmmsgs.MsgPersistenceUnmarshalFailed)
+		}
+		if tx.ManagedTX == nil {
+			continue
+		}
+		hashes := make([]string, 0, len(tx.SubmittedHashes)+1)
+		for _, sh := range tx.SubmittedHashes {
+			hashes = append(hashes, sh.TransactionHash)
+		}
+		hashes = append(hashes, tx.TransactionHash)
+		for _, hash := range hashes {
+			if hash == "" {
+				continue
+			}
+			existing, err := p.getKeyValue(ctx, txHashIndexKey(hash))
+			if err != nil {
+				return err
+			}
+			if existing == nil {
+				if err := p.writeKeyValue(ctx, txHashIndexKey(hash), txDataKey(tx.ID)); err != nil {
+					return err
+				}
+				indexed++
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return i18n.WrapError(ctx, err, tmmsgs.MsgPersistenceReadFailed, transactionsPrefix)
+	}
+	log.L(ctx).Infof("Indexed %d hashes of existing transactions", indexed)
+	return p.writeKeyValue(ctx, []byte(txHashIndexBackfilledKey), []byte(fftypes.Now().String()))
+}
+
+func (p *leveldbPersistence) GetTransactionSubmittedHashes(ctx context.Context, txID string) (submittedHashes []*apitypes.SubmittedHash, err error) {
+	txh, err := p.GetTransactionByIDWithStatus(ctx, txID, false)
+	if err != nil || txh == nil {
+		return nil, err
+	}
+	if len(txh.SubmittedHashes) == 0 && txh.TransactionHash != "" {
+		// Transactions submitted before all hashes were recorded only have the latest one
+		return []*apitypes.SubmittedHash{{TransactionHash: txh.TransactionHash, Submitted: txh.LastSubmit}}, nil
+	}
+	return txh.SubmittedHashes, nil
+}
+
 func migrateTX(tx *apitypes.ManagedTX) {
 	// For historical reasons we had some fields stored twice in V1.2
 	// We now consistently tread the top level objects as the source of truth, but for any
@@ -494,8 +757,45 @@ func (p *leveldbPersistence) InsertTransactionWithNextNonce(ctx context.Context,
 
 }
 
+func (p *leveldbPersistence) InsertTransactionsWithNextNonce(ctx context.Context, txs []*apitypes.ManagedTX, nextNonceCB txhandler.NextNonceCallback) []error {
+	// There is no batch write optimization for LevelDB, so each is inserted in turn
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		errs[i] = p.InsertTransactionWithNextNonce(ctx, tx, nextNonceCB)
+	}
+	return errs
+}
+
+func (p *leveldbPersistence) AssignTransactionNextNonce(ctx context.Context, tx *apitypes.ManagedTX, nextNonceCB txhandler.NextNonceCallback) (err error) {
+	// Same nonce locking as an insert, so the nonce cannot be allocated concurrently to a new transaction
+	lockedNonce, err := p.assignAndLockNonce(ctx, tx.ID, tx.From, nextNonceCB)
+	if err != nil {
+		return err
+	}
+	defer lockedNonce.complete(ctx)
+
+	nonce := fftypes.NewFFBigInt(int64(lockedNonce.nonce))
+	if err = p.UpdateTransaction(ctx, tx.ID, &apitypes.TXUpdates{Nonce: nonce}); err != nil {
+		return err
+	}
+	tx.Nonce = nonce
+	lockedNonce.spent = true
+	return nil
+}
+
 func (p *leveldbPersistence) InsertTransactionPreAssignedNonce(ctx context.Context, tx *apitypes.ManagedTX) (err error) {
-	return p.writeTransaction(ctx, &apitypes.TXWithStatus{
+	p.txMux.Lock()
+	defer p.txMux.Unlock()
+
+	// The nonce was not allocated by us, so we must check nothing else is using it for the signer
+	if tx.From != "" && tx.Nonce != nil {
+		if existing, err := p.getKeyValue(ctx, txNonceAllocationKey(tx.From, tx.Nonce)); err != nil {
+			return err
+		} else if existing != nil {
+			return i18n.NewError(ctx, tmmsgs.MsgTransactionNonceConflict, tx.From, tx.Nonce.Int64())
+		}
+	}
+	return p.writeTransactionLocked(ctx, &apitypes.TXWithStatus{
 		ManagedTX: tx,
 	}, true)
 }
@@ -540,6 +840,15 @@ func (p *leveldbPersistence) UpdateTransaction(ctx context.Context, txID string,
 	if err != nil {
 		return err
 	}
+	var previousNonceKey []byte
+	if tx.Nonce != nil {
+		previousNonceKey = txNonceAllocationKey(tx.From, tx.Nonce)
+	}
+	var previousPendingSignerKey []byte
+	if tx.Status == apitypes.TxStatusPending {
+		previousPendingSignerKey = txPendingSignerIndexKey(tx.ManagedTX)
+	}
+	previousStatus := tx.Status
 	if updates.Status != nil {
 		tx.Status = *updates.Status
 	}
@@ -564,8 +873,12 @@ func (p *leveldbPersistence) UpdateTransaction(ctx context.Context, txID string,
 	if updates.TransactionData != nil {
 		tx.TransactionData = *updates.TransactionData
 	}
+	var newHash string
 	if updates.TransactionHash != nil {
 		tx.TransactionHash = *updates.TransactionHash
+		if addSubmittedHash(tx, tx.TransactionHash, updates.LastSubmit) {
+			newHash = tx.TransactionHash
+		}
 	}
 	if updates.GasPrice != nil {
 		tx.GasPrice = updates.GasPrice
@@ -582,8 +895,101 @@ func (p *leveldbPersistence) UpdateTransaction(ctx context.Context, txID string,
 	if updates.ErrorMessage != nil {
 		tx.ErrorMessage = *updates.ErrorMessage
 	}
+	if updates.RetriedBy != nil {
+		tx.RetriedBy = *updates.RetriedBy
+	}
+	if updates.SupersededBy != nil {
+		// The nonce now belongs to the transaction that superseded this one
+		tx.SupersededBy = *updates.SupersededBy
+		tx.Nonce = nil
+	}
 	tx.Updated = fftypes.Now()
-	return p.writeTransaction(ctx, tx, false)
+	if tx.Status == apitypes.TxStatusPending && previousStatus != apitypes.TxStatusPending {
+		// A transaction inserted with another status (such as awaiting approval) is only indexed
+		// as pending once it moves to pending, so it is picked up into the in-flight set
+		if err := p.writeKeyValue(ctx, txPendingIndexKey(tx.SequenceID), txDataKey(tx.ID)); err != nil {
+			return err
+		}
+		if priorityKey := txPendingPriorityIndexKey(tx.ManagedTX); priorityKey != nil {
+			if err := p.writeKeyValue(ctx, priorityKey, txDataKey(tx.ID)); err != nil {
+				return err
+			}
+		}
+	}
+	var stalePendingSignerKey []byte
+	if tx.Status == apitypes.TxStatusPending {
+		// The signer index moves when the transaction is assigned a nonce
+		if newKey := txPendingSignerIndexKey(tx.ManagedTX); string(newKey) != string(previousPendingSignerKey) {
+			if err := p.writeKeyValue(ctx, newKey, txDataKey(tx.ID)); err != nil {
+				return err
+			}
+			stalePendingSignerKey = previousPendingSignerKey
+		}
+	} else {
+		stalePendingSignerKey = previousPendingSignerKey
+	}
+	if newHash != "" {
+		// As with the other indexes, this is written before the transaction that it refers to
+		if err := p.writeKeyValue(ctx, txHashIndexKey(newHash), txDataKey(tx.ID)); err != nil {
+			return err
+		}
+	}
+	if tx.Nonce == nil {
+		err = p.writeTransaction(ctx, tx, false)
+		if err == nil && previousNonceKey != nil {
+			err = p.deleteKeys(ctx, previousNonceKey)
+		}
+	} else if newNonceKey := txNonceAllocationKey(tx.From, tx.Nonce); string(newNonceKey) != string(previousNonceKey) {
+		err = p.writeTransactionMoveNonce(ctx, tx, previousNonceKey, newNonceKey)
+	} else {
+		err = p.writeTransaction(ctx, tx, false)
+	}
+	if err == nil && stalePendingSignerKey != nil {
+		err = p.deleteKeys(ctx, stalePendingSignerKey)
+	}
+	return err
+}
+
+func addSubmittedHash(tx *apitypes.TXWithStatus, transactionHash string, submitted *fftypes.FFTime) bool {
+	if transactionHash == "" {
+		return false
+	}
+	for _, sh := range tx.SubmittedHashes {
+		if sh.TransactionHash == transactionHash {
+			return false
+		}
+	}
+	if submitted == nil {
+		submitted = fftypes.Now()
+	}
+	tx.SubmittedHashes = append(tx.SubmittedHashes, &apitypes.SubmittedHash{
+		TransactionHash: transactionHash,
+		Submitted:       submitted,
+	})
+	return true
+}
+
+func (p *leveldbPersistence) writeTransactionMoveNonce(ctx context.Context, tx *apitypes.TXWithStatus, previousNonceKey, newNonceKey []byte) error {
+	p.txMux.Lock()
+	defer p.txMux.Unlock()
+
+	// The nonce allocation index must stay unique for the signer
+	if existing, err := p.getKeyValue(ctx, newNonceKey); err != nil {
+		return err
+	} else if existing != nil {
+		return i18n.NewError(ctx, tmmsgs.MsgTransactionNonceConflict, tx.From, tx.Nonce.Int64())
+	}
+
+	// The new index is written first, and the old one removed last, so the transaction is
+	// always reachable by nonce if we crash part way through
+	err := p.writeKeyValue(ctx, newNonceKey, txDataKey(tx.ID))
+	if err == nil {
+		err = p.writeTransactionLocked(ctx, tx, false)
+	}
+	if err == nil && previousNonceKey != nil {
+		err = p.deleteKeys(ctx, previousNonceKey)
+	}
+	return err
 }
 
 func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.TXWithStatus, new bool) (err error) {
@@ -593,6 +999,10 @@ func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.
 	// The reading code detects partial writes and cleans them up if it finds them.
 	p.txMux.Lock()
 	defer p.txMux.Unlock()
+	return p.writeTransactionLocked(ctx, tx, new)
+}
+
+func (p *leveldbPersistence) writeTransactionLocked(ctx context.Context, tx *apitypes.TXWithStatus, new bool) (err error) {
 
 	// We don't double store these values.
 	// Would be great to reconcile out this historical oddity, once the only place it's available is on the
@@ -600,8 +1010,11 @@ func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.
 	// consistently.
 	tx.DeprecatedTransactionHeaders = nil
 
+	// A transaction can be stored without a nonce until it is submitted - such as while it waits for the
+	// transactions it depends on, to be approved, or for the policy hook. It can also fail there, such as when it is denied.
+	// Once another transaction has superseded it at its nonce, it no longer has one either.
 	if tx.From == "" ||
-		tx.Nonce == nil ||
+		(tx.Nonce == nil && tx.FirstSubmit != nil && tx.SupersededBy == "") ||
 		tx.Created == nil ||
 		tx.ID == "" ||
 		tx.Status == "" {
@@ -633,7 +1046,13 @@ func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.
 		if err == nil && tx.Status == apitypes.TxStatusPending {
 			err = p.writeKeyValue(ctx, txPendingIndexKey(tx.SequenceID), idKey)
 		}
-		if err == nil {
+		if err == nil && tx.Status == apitypes.TxStatusPending {
+			err = p.writeKeyValue(ctx, txPendingSignerIndexKey(tx.ManagedTX), idKey)
+		}
+		if priorityKey := txPendingPriorityIndexKey(tx.ManagedTX); err == nil && priorityKey != nil && tx.Status == apitypes.TxStatusPending {
+			err = p.writeKeyValue(ctx, priorityKey, idKey)
+		}
+		if err == nil && tx.Nonce != nil {
 			err = p.writeKeyValue(ctx, txNonceAllocationKey(tx.From, tx.Nonce), idKey)
 		}
 	}
@@ -641,6 +1060,9 @@ func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.
 	if err == nil && tx.Status != apitypes.TxStatusPending {
 		err = p.deleteKeys(ctx, txPendingIndexKey(tx.SequenceID))
 	}
+	if priorityKey := txPendingPriorityIndexKey(tx.ManagedTX); err == nil && priorityKey != nil && tx.Status != apitypes.TxStatusPending {
+		err = p.deleteKeys(ctx, priorityKey)
+	}
 	if err == nil {
 		err = p.writeJSON(ctx, idKey, tx)
 	}
@@ -648,17 +1070,23 @@ func (p *leveldbPersistence) writeTransaction(ctx context.Context, tx *apitypes.
 }
 
 func (p *leveldbPersistence) DeleteTransaction(ctx context.Context, txID string) error {
-	var tx *apitypes.ManagedTX
+	var tx *apitypes.TXWithStatus
 	err := p.readJSON(ctx, txDataKey(txID), &tx)
 	if err != nil || tx == nil {
 		return err
 	}
-	return p.deleteKeys(ctx,
+	keys := [][]byte{
 		txDataKey(txID),
-		txCreatedIndexKey(tx),
+		txCreatedIndexKey(tx.ManagedTX),
 		txPendingIndexKey(tx.SequenceID),
+		txPendingSignerIndexKey(tx.ManagedTX),
+		txPendingPriorityIndexKey(tx.ManagedTX),
 		txNonceAllocationKey(tx.TransactionHeaders.From, tx.TransactionHeaders.Nonce),
-	)
+	}
+	for _, sh := range tx.SubmittedHashes {
+		keys = append(keys, txHashIndexKey(sh.TransactionHash))
+	}
+	return p.deleteKeys(ctx, keys...)
 }
 
 func (p *leveldbPersistence) setSubStatusInStruct(ctx context.Context, tx *apitypes.TXWithStatus, subStatus apitypes.TxSubStatus, actionOccurred *fftypes.FFTime) {
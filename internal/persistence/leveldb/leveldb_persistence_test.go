@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 	"github.com/hyperledger/firefly-transaction-manager/internal/tmconfig"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
@@ -236,6 +237,64 @@ func TestReadWriteListeners(t *testing.T) {
 	assert.Nil(t, l)
 }
 
+func TestReadWriteAddressListEntries(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	e1 := &apitypes.AddressListEntry{
+		ResourceBase: dbsql.ResourceBase{ID: apitypes.NewULID()},
+		Field:        apitypes.AddressListFieldFrom,
+		List:         apitypes.AddressListAllow,
+		Address:      "0xaaaa",
+	}
+	err := p.WriteAddressListEntry(ctx, e1)
+	assert.NoError(t, err)
+
+	e2 := &apitypes.AddressListEntry{
+		ResourceBase: dbsql.ResourceBase{ID: apitypes.NewULID()},
+		Field:        apitypes.AddressListFieldTo,
+		List:         apitypes.AddressListDeny,
+		Address:      "0xbbbb",
+		Reason:       "sanctioned",
+	}
+	err = p.WriteAddressListEntry(ctx, e2)
+	assert.NoError(t, err)
+
+	entries, err := p.ListAddressListEntriesByCreateTime(ctx, nil, 0, txhandler.SortDirectionDescending)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, e2, entries[0])
+	assert.Equal(t, e1, entries[1])
+
+	entries, err = p.ListAddressListEntriesByCreateTime(ctx, e1.ID, 0, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, e2.ID, entries[0].ID)
+
+	e, err := p.GetAddressListEntry(ctx, e2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, e2, e)
+
+	err = p.DeleteAddressListEntry(ctx, e2.ID)
+	assert.NoError(t, err)
+
+	e, err = p.GetAddressListEntry(ctx, e2.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+}
+
+func TestListAddressListEntriesBadJSON(t *testing.T) {
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	err := p.db.Put(prefixedKey(addressListsPrefix, apitypes.NewULID()), []byte("{! not json"), &opt.WriteOptions{})
+	assert.NoError(t, err)
+
+	_, err = p.ListAddressListEntriesByCreateTime(ctx, nil, 0, txhandler.SortDirectionDescending)
+	assert.Error(t, err)
+}
+
 func TestReadWriteCheckpoints(t *testing.T) {
 
 	ctx, p, done := newTestLevelDBPersistence(t)
@@ -577,6 +636,41 @@ func TestListNonceAllocationsFail(t *testing.T) {
 
 }
 
+func TestListSigners(t *testing.T) {
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	signers, err := p.ListSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, signers)
+
+	// Signers that are a prefix of each other must not be confused
+	for _, signer := range []string{"0xabc", "0xab", "0xbb"} {
+		for nonce := int64(0); nonce < 3; nonce++ {
+			tx := newTestTX(signer, apitypes.TxStatusSucceeded)
+			tx.Nonce = fftypes.NewFFBigInt(nonce)
+			err := p.writeTransaction(ctx, &apitypes.TXWithStatus{ManagedTX: tx}, true)
+			assert.NoError(t, err)
+		}
+	}
+
+	signers, err = p.ListSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab", "0xabc", "0xbb"}, signers)
+
+	signers, err = p.ListSigners(ctx, "", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab", "0xabc"}, signers)
+
+	signers, err = p.ListSigners(ctx, "0xab", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc", "0xbb"}, signers)
+
+	signers, err = p.ListSigners(ctx, "0xbb", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, signers)
+}
+
 func TestListInflightTransactionFail(t *testing.T) {
 	ctx, p, done := newTestLevelDBPersistence(t)
 	defer done()
@@ -793,6 +887,7 @@ func TestManagedTXUpdate(t *testing.T) {
 		FirstSubmit:     firstSubmitTime,
 		LastSubmit:      lastSubmitTime,
 		ErrorMessage:    &newError,
+		RetriedBy:       strPtr("ns1:retry"),
 	})
 	assert.NoError(t, err)
 
@@ -812,6 +907,440 @@ func TestManagedTXUpdate(t *testing.T) {
 	assert.Equal(t, firstSubmitTime, tx.FirstSubmit)
 	assert.Equal(t, lastSubmitTime, tx.LastSubmit)
 	assert.Equal(t, newError, tx.ErrorMessage)
+	assert.Equal(t, "ns1:retry", tx.RetriedBy)
+
+}
+
+func TestManagedTXUpdateNonce(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	tx1 := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx1.Nonce = fftypes.NewFFBigInt(10)
+	err := p.InsertTransactionPreAssignedNonce(ctx, tx1)
+	assert.NoError(t, err)
+	tx2 := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx2.Nonce = fftypes.NewFFBigInt(11)
+	err = p.InsertTransactionPreAssignedNonce(ctx, tx2)
+	assert.NoError(t, err)
+
+	// Cannot move onto a nonce that is in use
+	err = p.UpdateTransaction(ctx, tx1.ID, &apitypes.TXUpdates{Nonce: fftypes.NewFFBigInt(11)})
+	assert.Regexp(t, "FF21090", err)
+
+	err = p.UpdateTransaction(ctx, tx1.ID, &apitypes.TXUpdates{Nonce: fftypes.NewFFBigInt(5)})
+	assert.NoError(t, err)
+
+	tx, err := p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(5))
+	assert.NoError(t, err)
+	assert.Equal(t, tx1.ID, tx.ID)
+	assert.Equal(t, int64(5), tx.Nonce.Int64())
+
+	tx, err = p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(10))
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	txs, err := p.ListTransactionsByNonce(ctx, "0x12345", nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.Equal(t, tx1.ID, txs[0].ID)
+	assert.Equal(t, tx2.ID, txs[1].ID)
+
+	// Nonce 10 is free now
+	err = p.UpdateTransaction(ctx, tx2.ID, &apitypes.TXUpdates{Nonce: fftypes.NewFFBigInt(10)})
+	assert.NoError(t, err)
+
+}
+
+func TestManagedTXSubmittedHashes(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	mtx := &apitypes.ManagedTX{
+		ID: fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0x11111",
+		},
+		Created: fftypes.Now(),
+		Status:  apitypes.TxStatusPending,
+	}
+	err := p.InsertTransactionWithNextNonce(ctx, mtx, func(ctx context.Context, signer string) (uint64, error) { return 1000, nil })
+	assert.NoError(t, err)
+
+	submittedHashes, err := p.GetTransactionSubmittedHashes(ctx, mtx.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, submittedHashes)
+
+	// Submit three times, re-submitting the first hash at the end
+	firstSubmit := fftypes.Now()
+	for _, hash := range []string{"0x111111", "0x222222", "0x111111"} {
+		err = p.UpdateTransaction(ctx, mtx.ID, &apitypes.TXUpdates{
+			TransactionHash: strPtr(hash),
+			LastSubmit:      firstSubmit,
+		})
+		assert.NoError(t, err)
+	}
+	// Clearing the hash does not record anything
+	err = p.UpdateTransaction(ctx, mtx.ID, &apitypes.TXUpdates{TransactionHash: strPtr("")})
+	assert.NoError(t, err)
+
+	submittedHashes, err = p.GetTransactionSubmittedHashes(ctx, mtx.ID)
+	assert.NoError(t, err)
+	assert.Len(t, submittedHashes, 2)
+	assert.Equal(t, "0x111111", submittedHashes[0].TransactionHash)
+	assert.Equal(t, firstSubmit.String(), submittedHashes[0].Submitted.String())
+	assert.Equal(t, "0x222222", submittedHashes[1].TransactionHash)
+
+	txh, err := p.GetTransactionByIDWithStatus(ctx, mtx.ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, submittedHashes, txh.SubmittedHashes)
+
+	// Lookup by any of the hashes
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		tx, err := p.GetTransactionByHash(ctx, hash)
+		assert.NoError(t, err)
+		assert.Equal(t, mtx.ID, tx.ID)
+	}
+	tx, err := p.GetTransactionByHash(ctx, "0x333333")
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	// Delete clears the index
+	err = p.DeleteTransaction(ctx, mtx.ID)
+	assert.NoError(t, err)
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		tx, err := p.GetTransactionByHash(ctx, hash)
+		assert.NoError(t, err)
+		assert.Nil(t, tx)
+	}
+	submittedHashes, err = p.GetTransactionSubmittedHashes(ctx, mtx.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, submittedHashes)
+}
+
+func TestGetTransactionSubmittedHashesBeforeRecorded(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// A transaction written before all submitted hashes were recorded
+	txh := apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{
+			ID:              fftypes.NewUUID().String(),
+			Created:         fftypes.Now(),
+			Status:          apitypes.TxStatusPending,
+			TransactionHash: "0x111111",
+			LastSubmit:      fftypes.Now(),
+		},
+	}
+	err := p.writeJSON(ctx, txDataKey(txh.ID), txh)
+	assert.NoError(t, err)
+
+	submittedHashes, err := p.GetTransactionSubmittedHashes(ctx, txh.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.SubmittedHash{
+		{TransactionHash: "0x111111", Submitted: txh.LastSubmit},
+	}, submittedHashes)
+}
+
+func TestBackfillTXHashIndex(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// Transactions written before the hash index was added
+	txh1 := apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{
+			ID:              fftypes.NewUUID().String(),
+			Status:          apitypes.TxStatusPending,
+			TransactionHash: "0x222222",
+		},
+		SubmittedHashes: []*apitypes.SubmittedHash{
+			{TransactionHash: "0x111111"},
+			{TransactionHash: "0x222222"},
+		},
+	}
+	txh2 := apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{
+			ID:     fftypes.NewUUID().String(),
+			Status: apitypes.TxStatusPending,
+		},
+	}
+	for _, txh := range []apitypes.TXWithStatus{txh1, txh2} {
+		err := p.writeJSON(ctx, txDataKey(txh.ID), txh)
+		assert.NoError(t, err)
+	}
+	tx, err := p.GetTransactionByHash(ctx, "0x222222")
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	// Only runs once
+	err = p.backfillTXHashIndex(ctx)
+	assert.NoError(t, err)
+	tx, err = p.GetTransactionByHash(ctx, "0x222222")
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	err = p.db.Delete([]byte(txHashIndexBackfilledKey), nil)
+	assert.NoError(t, err)
+	err = p.backfillTXHashIndex(ctx)
+	assert.NoError(t, err)
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		tx, err := p.GetTransactionByHash(ctx, hash)
+		assert.NoError(t, err)
+		assert.Equal(t, txh1.ID, tx.ID)
+	}
+	marker, err := p.getKeyValue(ctx, []byte(txHashIndexBackfilledKey))
+	assert.NoError(t, err)
+	assert.NotNil(t, marker)
+}
+
+func TestBackfillTXHashIndexBadData(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	err := p.db.Delete([]byte(txHashIndexBackfilledKey), nil)
+	assert.NoError(t, err)
+	err = p.writeKeyValue(ctx, txDataKey("bad"), []byte("!json"))
+	assert.NoError(t, err)
+	err = p.backfillTXHashIndex(ctx)
+	assert.Regexp(t, "FF21054", err)
+}
+
+func TestBackfillTXHashIndexFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	p.db.Close()
+	err := p.backfillTXHashIndex(ctx)
+	assert.Error(t, err)
+}
+
+func TestGetTransactionSubmittedHashesFail(t *testing.T) {
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	p.db.Close()
+
+	_, err := p.GetTransactionSubmittedHashes(ctx, "tx1")
+	assert.Error(t, err)
+
+}
+
+func TestInsertTransactionPreAssignedNonceConflict(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	tx1 := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx1.Nonce = fftypes.NewFFBigInt(10)
+	err := p.InsertTransactionPreAssignedNonce(ctx, tx1)
+	assert.NoError(t, err)
+
+	tx2 := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx2.Nonce = fftypes.NewFFBigInt(10)
+	err = p.InsertTransactionPreAssignedNonce(ctx, tx2)
+	assert.Regexp(t, "FF21090", err)
+
+	// Another signer can use the same nonce
+	tx3 := newTestTX("0x23456", apitypes.TxStatusPending)
+	tx3.Nonce = fftypes.NewFFBigInt(10)
+	err = p.InsertTransactionPreAssignedNonce(ctx, tx3)
+	assert.NoError(t, err)
+
+	tx, err := p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(10))
+	assert.NoError(t, err)
+	assert.Equal(t, tx1.ID, tx.ID)
+
+}
+
+func TestAssignTransactionNextNonce(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// A transaction that has been submitted cannot be stored without a nonce
+	submitted := newTestTX("0x12345", apitypes.TxStatusPending)
+	submitted.FirstSubmit = fftypes.Now()
+	err := p.InsertTransactionPreAssignedNonce(ctx, submitted)
+	assert.Regexp(t, "FF21059", err)
+
+	dependent := newTestTX("0x12345", apitypes.TxStatusPending)
+	dependent.DependsOn = []string{"ns1:prereq"}
+	err = p.InsertTransactionPreAssignedNonce(ctx, dependent)
+	assert.NoError(t, err)
+
+	tx1 := newTestTX("0x12345", apitypes.TxStatusPending)
+	err = p.InsertTransactionWithNextNonce(ctx, tx1, func(ctx context.Context, signer string) (uint64, error) {
+		return 10, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), tx1.Nonce.Int64())
+
+	// Updates do not need a nonce either
+	err = p.UpdateTransaction(ctx, dependent.ID, &apitypes.TXUpdates{ErrorMessage: strPtr("waiting")})
+	assert.NoError(t, err)
+	txs, err := p.ListTransactionsByNonce(ctx, "0x12345", nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+
+	// The nonce is allocated after the existing transactions
+	err = p.AssignTransactionNextNonce(ctx, dependent, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), dependent.Nonce.Int64())
+
+	tx, err := p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(11))
+	assert.NoError(t, err)
+	assert.Equal(t, dependent.ID, tx.ID)
+	assert.Equal(t, "waiting", tx.ErrorMessage)
+	assert.Equal(t, []string{"ns1:prereq"}, []string(tx.DependsOn))
+
+}
+
+func TestAssignTransactionNextNonceFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	dependent := newTestTX("0x12345", apitypes.TxStatusPending)
+	dependent.DependsOn = []string{"ns1:prereq"}
+
+	err := p.AssignTransactionNextNonce(ctx, dependent, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, fmt.Errorf("pop")
+	})
+	assert.Regexp(t, "pop", err)
+
+	// Not yet persisted
+	err = p.AssignTransactionNextNonce(ctx, dependent, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	})
+	assert.Regexp(t, "FF21067", err)
+	assert.Nil(t, dependent.Nonce)
+
+}
+
+func TestAwaitingApprovalTransactionLifecycle(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// Held for approval without a nonce, and not in the in-flight set
+	held := newTestTX("0x12345", apitypes.TxStatusAwaitingApproval)
+	err := p.InsertTransactionPreAssignedNonce(ctx, held)
+	assert.NoError(t, err)
+	pending, err := p.ListTransactionsPending(ctx, "", 0, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// Approval assigns the nonce, then moves it to pending
+	err = p.AssignTransactionNextNonce(ctx, held, func(ctx context.Context, signer string) (uint64, error) {
+		return 5, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), held.Nonce.Int64())
+	status := apitypes.TxStatusPending
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+
+	pending, err = p.ListTransactionsPending(ctx, "", 0, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, held.ID, pending[0].ID)
+	assert.Equal(t, int64(5), pending[0].Nonce.Int64())
+
+	// Completing it removes it from the in-flight set again
+	status = apitypes.TxStatusSucceeded
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	pending, err = p.ListTransactionsPending(ctx, "", 0, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+}
+
+func TestAwaitingApprovalTransactionRejected(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	held := newTestTX("0x12345", apitypes.TxStatusAwaitingApproval)
+	err := p.InsertTransactionPreAssignedNonce(ctx, held)
+	assert.NoError(t, err)
+
+	// Rejection fails it, without it ever being assigned a nonce
+	status := apitypes.TxStatusFailed
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+
+	tx, err := p.GetTransactionByID(ctx, held.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusFailed, tx.Status)
+	assert.Nil(t, tx.Nonce)
+	pending, err := p.ListTransactionsPending(ctx, "", 0, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+}
+
+func TestSupersedeTransactionReleasesNonce(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// Submitted, but failed without ever being mined
+	unmined := newTestTX("0x12345", apitypes.TxStatusFailed)
+	unmined.Nonce = fftypes.NewFFBigInt(5)
+	unmined.FirstSubmit = fftypes.Now()
+	err := p.InsertTransactionPreAssignedNonce(ctx, unmined)
+	assert.NoError(t, err)
+
+	filler := newTestTX("0x12345", apitypes.TxStatusPending)
+	filler.Nonce = fftypes.NewFFBigInt(5)
+	err = p.InsertTransactionPreAssignedNonce(ctx, filler)
+	assert.Regexp(t, "FF21090", err)
+
+	err = p.UpdateTransaction(ctx, unmined.ID, &apitypes.TXUpdates{SupersededBy: &filler.ID})
+	assert.NoError(t, err)
+	tx, err := p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(5))
+	assert.NoError(t, err)
+	assert.Nil(t, tx)
+
+	// The record is kept, linked to the transaction that takes over its nonce
+	err = p.InsertTransactionPreAssignedNonce(ctx, filler)
+	assert.NoError(t, err)
+	tx, err = p.GetTransactionByID(ctx, unmined.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, filler.ID, tx.SupersededBy)
+	assert.Nil(t, tx.Nonce)
+	tx, err = p.GetTransactionByNonce(ctx, "0x12345", fftypes.NewFFBigInt(5))
+	assert.NoError(t, err)
+	assert.Equal(t, filler.ID, tx.ID)
+
+}
+
+func TestManagedTXUpdateNonceReadFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	tx1 := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx1.Nonce = fftypes.NewFFBigInt(10)
+	err := p.InsertTransactionPreAssignedNonce(ctx, tx1)
+	assert.NoError(t, err)
+
+	tx, err := p.getPersistedTX(ctx, tx1.ID)
+	assert.NoError(t, err)
+	p.db.Close()
+
+	tx.Nonce = fftypes.NewFFBigInt(11)
+	err = p.writeTransactionMoveNonce(ctx, tx, txNonceAllocationKey(tx.From, tx1.Nonce), txNonceAllocationKey(tx.From, tx.Nonce))
+	assert.Error(t, err)
 
 }
 
@@ -1151,3 +1680,282 @@ func TestAddReceivedStatusWhenNothingSet(t *testing.T) {
 	assert.Equal(t, apitypes.TxSubStatusReceived, txh.History[0].Status)
 	assert.Equal(t, apitypes.TxActionSubmitTransaction, txh.History[0].Actions[0].Action)
 }
+
+func TestInsertTransactionsWithNextNonce(t *testing.T) {
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	txs := []*apitypes.ManagedTX{
+		newTestTX("0x12345", apitypes.TxStatusPending),
+		newTestTX("0x12345", apitypes.TxStatusPending),
+		{},
+	}
+	errs := p.InsertTransactionsWithNextNonce(ctx, txs, func(ctx context.Context, signer string) (uint64, error) { return 1000, nil })
+	assert.Len(t, errs, 3)
+	assert.NoError(t, errs[0])
+	assert.NoError(t, errs[1])
+	assert.Error(t, errs[2])
+	assert.Equal(t, int64(1000), txs[0].Nonce.Int64())
+	assert.Equal(t, int64(1001), txs[1].Nonce.Int64())
+}
+
+func TestListSignerTransactionsPending(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	signers, err := p.ListPendingSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, signers)
+
+	insert := func(signer string, nonce int64, status apitypes.TxStatus) *apitypes.ManagedTX {
+		tx := newTestTX(signer, status)
+		if nonce >= 0 {
+			tx.Nonce = fftypes.NewFFBigInt(nonce)
+		} else {
+			tx.DependsOn = []string{"ns1:prereq"}
+		}
+		err := p.InsertTransactionPreAssignedNonce(ctx, tx)
+		assert.NoError(t, err)
+		return tx
+	}
+	listIDs := func(signer string, limit int) []string {
+		txs, err := p.ListSignerTransactionsPending(ctx, signer, limit)
+		assert.NoError(t, err)
+		ids := make([]string, len(txs))
+		for i, tx := range txs {
+			ids[i] = tx.ID
+		}
+		return ids
+	}
+
+	// Signers that are a prefix of each other must not be confused
+	dependent := insert("0xab", -1, apitypes.TxStatusPending)
+	tx2 := insert("0xab", 2, apitypes.TxStatusPending)
+	tx1 := insert("0xab", 1, apitypes.TxStatusPending)
+	insert("0xabc", 0, apitypes.TxStatusSucceeded)
+	held := insert("0xabc", -1, apitypes.TxStatusAwaitingApproval)
+	insert("0xbb", 0, apitypes.TxStatusFailed)
+
+	signers, err = p.ListPendingSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab"}, signers)
+
+	// In nonce order, with those waiting for a nonce last
+	assert.Equal(t, []string{tx1.ID, tx2.ID, dependent.ID}, listIDs("0xab", 0))
+	assert.Equal(t, []string{tx1.ID, tx2.ID}, listIDs("0xab", 2))
+
+	// Assigning the nonce moves the transaction within the index, rather than listing it twice
+	err = p.AssignTransactionNextNonce(ctx, dependent, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{tx1.ID, tx2.ID, dependent.ID}, listIDs("0xab", 0))
+
+	// Approval moves the transaction into the index
+	err = p.AssignTransactionNextNonce(ctx, held, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	})
+	assert.NoError(t, err)
+	status := apitypes.TxStatusPending
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{held.ID}, listIDs("0xabc", 0))
+
+	signers, err = p.ListPendingSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab", "0xabc"}, signers)
+	signers, err = p.ListPendingSigners(ctx, "", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab"}, signers)
+	signers, err = p.ListPendingSigners(ctx, "0xab", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, signers)
+
+	// Completion and deletion remove transactions from the index
+	status = apitypes.TxStatusSucceeded
+	err = p.UpdateTransaction(ctx, tx1.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	err = p.DeleteTransaction(ctx, tx2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dependent.ID}, listIDs("0xab", 0))
+	signers, err = p.ListPendingSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab"}, signers)
+
+	// Index keys left behind part way through an update are skipped
+	err = p.writeKeyValue(ctx, []byte(signerPendingPrefix("0xab")+"~stale"), txDataKey(dependent.ID))
+	assert.NoError(t, err)
+	err = p.writeKeyValue(ctx, []byte(signerPendingPrefix("0xab")+"~completed"), txDataKey(tx1.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{dependent.ID}, listIDs("0xab", 0))
+
+}
+
+func TestListPendingSignersFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	p.db.Close()
+	_, err := p.ListPendingSigners(ctx, "", 0)
+	assert.Error(t, err)
+
+}
+
+func TestBackfillTXPendingSignerIndex(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// A pending transaction written before the index of pending transactions by signer was added
+	tx := newTestTX("0x12345", apitypes.TxStatusPending)
+	tx.Nonce = fftypes.NewFFBigInt(1)
+	tx.SequenceID = apitypes.NewULID().String()
+	err := p.writeJSON(ctx, txDataKey(tx.ID), &apitypes.TXWithStatus{ManagedTX: tx})
+	assert.NoError(t, err)
+	err = p.writeKeyValue(ctx, txPendingIndexKey(tx.SequenceID), txDataKey(tx.ID))
+	assert.NoError(t, err)
+
+	// Only runs once
+	err = p.backfillTXPendingSignerIndex(ctx)
+	assert.NoError(t, err)
+	txs, err := p.ListSignerTransactionsPending(ctx, "0x12345", 0)
+	assert.NoError(t, err)
+	assert.Empty(t, txs)
+
+	err = p.db.Delete([]byte(txPendingSignerIndexBackfilledKey), nil)
+	assert.NoError(t, err)
+	err = p.backfillTXPendingSignerIndex(ctx)
+	assert.NoError(t, err)
+	txs, err = p.ListSignerTransactionsPending(ctx, "0x12345", 0)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	assert.Equal(t, tx.ID, txs[0].ID)
+	marker, err := p.getKeyValue(ctx, []byte(txPendingSignerIndexBackfilledKey))
+	assert.NoError(t, err)
+	assert.NotNil(t, marker)
+}
+
+func TestBackfillTXPendingSignerIndexBadData(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	err := p.db.Delete([]byte(txPendingSignerIndexBackfilledKey), nil)
+	assert.NoError(t, err)
+	err = p.writeKeyValue(ctx, txPendingIndexKey(apitypes.NewULID().String()), txDataKey("bad"))
+	assert.NoError(t, err)
+	err = p.writeKeyValue(ctx, txDataKey("bad"), []byte("!json"))
+	assert.NoError(t, err)
+	err = p.backfillTXPendingSignerIndex(ctx)
+	assert.Regexp(t, "FF21054", err)
+}
+
+func TestBackfillTXPendingSignerIndexFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	p.db.Close()
+	err := p.backfillTXPendingSignerIndex(ctx)
+	assert.Error(t, err)
+}
+
+func TestListTransactionsPendingByPriority(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	insert := func(priority int, status apitypes.TxStatus) *apitypes.ManagedTX {
+		tx := newTestTX("0x12345", status)
+		tx.Priority = priority
+		if status == apitypes.TxStatusPending {
+			err := p.InsertTransactionWithNextNonce(ctx, tx, func(ctx context.Context, signer string) (uint64, error) {
+				return 0, nil
+			})
+			assert.NoError(t, err)
+		} else {
+			err := p.InsertTransactionPreAssignedNonce(ctx, tx)
+			assert.NoError(t, err)
+		}
+		return tx
+	}
+	listIDs := func(limit int) []string {
+		txs, err := p.ListTransactionsPendingByPriority(ctx, limit)
+		assert.NoError(t, err)
+		ids := make([]string, len(txs))
+		for i, tx := range txs {
+			ids[i] = tx.ID
+		}
+		return ids
+	}
+
+	insert(0, apitypes.TxStatusPending)
+	tx5a := insert(5, apitypes.TxStatusPending)
+	tx10 := insert(10, apitypes.TxStatusPending)
+	tx5b := insert(5, apitypes.TxStatusPending)
+	held := insert(7, apitypes.TxStatusAwaitingApproval)
+
+	// Highest priority first, then in the order received
+	assert.Equal(t, []string{tx10.ID, tx5a.ID, tx5b.ID}, listIDs(0))
+	assert.Equal(t, []string{tx10.ID, tx5a.ID}, listIDs(2))
+
+	// Approval moves the transaction into the index
+	err := p.AssignTransactionNextNonce(ctx, held, func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	})
+	assert.NoError(t, err)
+	status := apitypes.TxStatusPending
+	err = p.UpdateTransaction(ctx, held.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{tx10.ID, held.ID, tx5a.ID, tx5b.ID}, listIDs(0))
+
+	// Completion and deletion remove transactions from the index
+	status = apitypes.TxStatusSucceeded
+	err = p.UpdateTransaction(ctx, tx10.ID, &apitypes.TXUpdates{Status: &status})
+	assert.NoError(t, err)
+	err = p.DeleteTransaction(ctx, tx5a.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{held.ID, tx5b.ID}, listIDs(0))
+
+	// Index keys left behind part way through an update are skipped
+	err = p.writeKeyValue(ctx, []byte(txPendingPriorityIndexPrefix+"0/stale"), txDataKey(tx10.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{held.ID, tx5b.ID}, listIDs(0))
+
+}
+
+func TestBackfillTXPendingPriorityIndex(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// Pending transactions written before the index of pending transactions by priority was added
+	writeOld := func(priority int) *apitypes.ManagedTX {
+		tx := newTestTX("0x12345", apitypes.TxStatusPending)
+		tx.Priority = priority
+		tx.SequenceID = apitypes.NewULID().String()
+		err := p.writeJSON(ctx, txDataKey(tx.ID), &apitypes.TXWithStatus{ManagedTX: tx})
+		assert.NoError(t, err)
+		err = p.writeKeyValue(ctx, txPendingIndexKey(tx.SequenceID), txDataKey(tx.ID))
+		assert.NoError(t, err)
+		return tx
+	}
+	writeOld(0)
+	tx := writeOld(3)
+
+	err := p.db.Delete([]byte(txPendingPriorityIndexBackfilledKey), nil)
+	assert.NoError(t, err)
+	err = p.backfillTXPendingPriorityIndex(ctx)
+	assert.NoError(t, err)
+	txs, err := p.ListTransactionsPendingByPriority(ctx, 0)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	assert.Equal(t, tx.ID, txs[0].ID)
+	marker, err := p.getKeyValue(ctx, []byte(txPendingPriorityIndexBackfilledKey))
+	assert.NoError(t, err)
+	assert.NotNil(t, marker)
+}
@@ -95,9 +95,12 @@ func (p *leveldbPersistence) calcNextNonce(ctx context.Context, signer string, n
 	if err != nil {
 		return 0, err
 	}
+	p.nonceMux.Lock()
+	stale := p.staleNonces[signer]
+	p.nonceMux.Unlock()
 	if len(txns) > 0 {
 		lastTxn = txns[0]
-		if time.Since(*lastTxn.Created.Time()) < p.nonceStateTimeout {
+		if !stale && time.Since(*lastTxn.Created.Time()) < p.nonceStateTimeout {
 			nextNonce := lastTxn.Nonce.Uint64() + 1
 			log.L(ctx).Debugf("Allocating next nonce '%s' / '%d' after TX '%s' (status=%s)", signer, nextNonce, lastTxn.ID, lastTxn.Status)
 			return nextNonce, nil
@@ -109,6 +112,11 @@ func (p *leveldbPersistence) calcNextNonce(ctx context.Context, signer string, n
 	if err != nil {
 		return 0, err
 	}
+	if stale {
+		p.nonceMux.Lock()
+		delete(p.staleNonces, signer)
+		p.nonceMux.Unlock()
+	}
 
 	// If we had a stale answer in our state store, make sure this isn't re-used.
 	// This is important in case we have transactions that have expired from the TX pool of nodes, but we still have them
@@ -121,3 +129,14 @@ func (p *leveldbPersistence) calcNextNonce(ctx context.Context, signer string, n
 	return nextNonce, nil
 
 }
+
+// ClearCachedNonce ensures the next nonce allocated for the signer is checked against the node, even if
+// we have recently allocated one. Used when transactions have been submitted from the signing key outside
+// of this transaction manager.
+func (p *leveldbPersistence) ClearCachedNonce(ctx context.Context, signer string) error {
+	log.L(ctx).Infof("Clearing cached nonce state for signer %s", signer)
+	p.nonceMux.Lock()
+	defer p.nonceMux.Unlock()
+	p.staleNonces[signer] = true
+	return nil
+}
@@ -207,3 +207,59 @@ func TestNonceListStaleBehind(t *testing.T) {
 	assert.Equal(t, int64(1002), tx2.Nonce.Int64())
 
 }
+
+func TestNonceClearCachedNonce(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	// A fresh record would normally be trusted over the node
+	err := p.writeTransaction(ctx, &apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{
+			ID:      "fresh1",
+			Created: fftypes.Now(),
+			Status:  apitypes.TxStatusPending,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From:  "0x12345",
+				Nonce: fftypes.NewFFBigInt(1000),
+			},
+		},
+	}, true)
+	assert.NoError(t, err)
+
+	err = p.ClearCachedNonce(ctx, "0x12345")
+	assert.NoError(t, err)
+
+	nodeQueried := 0
+	nextNonceCB := func(ctx context.Context, signer string) (uint64, error) {
+		nodeQueried++
+		return 1111, nil
+	}
+	nextNonce, err := p.calcNextNonce(ctx, "0x12345", nextNonceCB)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1111), nextNonce)
+	assert.Equal(t, 1, nodeQueried)
+
+	// Back to trusting our own state after the node has been queried
+	nextNonce, err = p.calcNextNonce(ctx, "0x12345", nextNonceCB)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1001), nextNonce)
+	assert.Equal(t, 1, nodeQueried)
+
+}
+
+func TestNonceClearCachedNonceQueryFail(t *testing.T) {
+
+	ctx, p, done := newTestLevelDBPersistence(t)
+	defer done()
+
+	err := p.ClearCachedNonce(ctx, "0x12345")
+	assert.NoError(t, err)
+
+	_, err = p.calcNextNonce(ctx, "0x12345", func(ctx context.Context, signer string) (uint64, error) {
+		return 0, fmt.Errorf("pop")
+	})
+	assert.Regexp(t, "pop", err)
+	assert.True(t, p.staleNonces["0x12345"])
+
+}
@@ -36,6 +36,7 @@ type Persistence interface {
 	ListenerPersistence
 	TransactionPersistence
 	TransactionHistoryPersistence
+	AddressListPersistence
 
 	RichQuery() RichQuery      // panics if not supported
 	Close(ctx context.Context) // close function is controlled by the manager
@@ -71,6 +72,17 @@ var ListenerFilters = &ffapi.QueryFields{
 	"fromblock": &ffapi.StringField{},
 }
 
+var AddressListFilters = &ffapi.QueryFields{
+	"sequence": &ffapi.Int64Field{},
+	"id":       &ffapi.UUIDField{},
+	"created":  &ffapi.TimeField{},
+	"updated":  &ffapi.TimeField{},
+	"field":    &ffapi.StringField{},
+	"list":     &ffapi.StringField{},
+	"address":  &ffapi.StringField{},
+	"reason":   &ffapi.StringField{},
+}
+
 var TransactionFilters = &ffapi.QueryFields{
 	"sequence":        &ffapi.Int64Field{},
 	"id":              &ffapi.StringField{},
@@ -90,6 +102,14 @@ var TransactionFilters = &ffapi.QueryFields{
 	"firstsubmit":     &ffapi.TimeField{},
 	"lastsubmit":      &ffapi.TimeField{},
 	"errormessage":    &ffapi.StringField{},
+	"expiry":          &ffapi.TimeField{},
+	"notbefore":       &ffapi.TimeField{},
+	"notbeforeblock":  &ffapi.BigIntField{},
+	"priority":        &ffapi.Int64Field{},
+	"dependson":       &ffapi.FFStringArrayField{},
+	"retryof":         &ffapi.StringField{},
+	"retriedby":       &ffapi.StringField{},
+	"supersededby":    &ffapi.StringField{},
 }
 
 var ConfirmationFilters = &ffapi.QueryFields{
@@ -101,6 +121,14 @@ var ConfirmationFilters = &ffapi.QueryFields{
 	"parenthash":  &ffapi.StringField{},
 }
 
+var SubmittedHashFilters = &ffapi.QueryFields{
+	"sequence":    &ffapi.Int64Field{},
+	"id":          &ffapi.UUIDField{},
+	"transaction": &ffapi.StringField{},
+	"hash":        &ffapi.StringField{},
+	"submitted":   &ffapi.TimeField{},
+}
+
 var ReceiptFilters = &ffapi.QueryFields{
 	"sequence":         &ffapi.Int64Field{},
 	"transaction":      &ffapi.StringField{},
@@ -154,8 +182,16 @@ type ListenerPersistence interface {
 	DeleteListener(ctx context.Context, listenerID *fftypes.UUID) error
 }
 
+type AddressListPersistence interface {
+	ListAddressListEntriesByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection) ([]*apitypes.AddressListEntry, error)
+	GetAddressListEntry(ctx context.Context, entryID *fftypes.UUID) (*apitypes.AddressListEntry, error)
+	WriteAddressListEntry(ctx context.Context, entry *apitypes.AddressListEntry) error
+	DeleteAddressListEntry(ctx context.Context, entryID *fftypes.UUID) error
+}
+
 type TransactionPersistence interface {
 	txhandler.TransactionPersistence
+	ClearCachedNonce(ctx context.Context, signer string) error // next nonce allocation for the signer must be re-queried from the node
 }
 
 type TransactionHistoryPersistence interface {
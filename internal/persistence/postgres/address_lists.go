@@ -0,0 +1,97 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/internal/persistence"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+func (p *sqlPersistence) newAddressListsCollection() *dbsql.CrudBase[*apitypes.AddressListEntry] {
+	collection := &dbsql.CrudBase[*apitypes.AddressListEntry]{
+		DB:    p.db,
+		Table: "address_lists",
+		Columns: []string{
+			dbsql.ColumnID,
+			dbsql.ColumnCreated,
+			dbsql.ColumnUpdated,
+			"field",
+			"list",
+			"address",
+			"reason",
+		},
+		FilterFieldMap: map[string]string{
+			"sequence": p.db.SequenceColumn(),
+		},
+		PatchDisabled: true,
+		NilValue:      func() *apitypes.AddressListEntry { return nil },
+		NewInstance:   func() *apitypes.AddressListEntry { return &apitypes.AddressListEntry{} },
+		GetFieldPtr: func(inst *apitypes.AddressListEntry, col string) interface{} {
+			switch col {
+			case dbsql.ColumnID:
+				return &inst.ID
+			case dbsql.ColumnCreated:
+				return &inst.Created
+			case dbsql.ColumnUpdated:
+				return &inst.Updated
+			case "field":
+				return &inst.Field
+			case "list":
+				return &inst.List
+			case "address":
+				return &inst.Address
+			case "reason":
+				return &inst.Reason
+			}
+			return nil
+		},
+	}
+	collection.Validate()
+	return collection
+}
+
+func (p *sqlPersistence) ListAddressListEntriesByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection) ([]*apitypes.AddressListEntry, error) {
+	var afterSeq *int64
+	if after != nil {
+		seq, err := p.addressLists.GetSequenceForID(ctx, after.String())
+		if err != nil {
+			return nil, err
+		}
+		afterSeq = &seq
+	}
+	filter := p.seqAfterFilter(ctx, persistence.AddressListFilters, afterSeq, limit, dir)
+	entries, _, err := p.addressLists.GetMany(ctx, filter)
+	return entries, err
+}
+
+func (p *sqlPersistence) GetAddressListEntry(ctx context.Context, entryID *fftypes.UUID) (*apitypes.AddressListEntry, error) {
+	return p.addressLists.GetByID(ctx, entryID.String())
+}
+
+func (p *sqlPersistence) WriteAddressListEntry(ctx context.Context, entry *apitypes.AddressListEntry) error {
+	_, err := p.addressLists.Upsert(ctx, entry, dbsql.UpsertOptimizationNew)
+	return err
+}
+
+func (p *sqlPersistence) DeleteAddressListEntry(ctx context.Context, entryID *fftypes.UUID) error {
+	return p.addressLists.Delete(ctx, entryID.String())
+}
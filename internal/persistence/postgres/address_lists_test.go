@@ -0,0 +1,92 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddressListsPSQL(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	var entries []*apitypes.AddressListEntry
+	for i := 0; i < 5; i++ {
+		e := &apitypes.AddressListEntry{
+			ResourceBase: dbsql.ResourceBase{ID: fftypes.NewUUID()},
+			Field:        apitypes.AddressListFieldTo,
+			List:         apitypes.AddressListDeny,
+			Address:      fmt.Sprintf("0x%.40d", i),
+			Reason:       "sanctioned",
+		}
+		err := p.WriteAddressListEntry(ctx, e)
+		assert.NoError(t, err)
+		entries = append(entries, e)
+	}
+
+	// Get one back
+	e1, err := p.GetAddressListEntry(ctx, entries[0].ID)
+	assert.NoError(t, err)
+	assert.NotNil(t, e1.Created)
+	assert.Equal(t, entries[0].Address, e1.Address)
+	assert.Equal(t, "sanctioned", e1.Reason)
+
+	// List them backwards, with no limit
+	list1, err := p.ListAddressListEntriesByCreateTime(ctx, nil, 0, txhandler.SortDirectionDescending)
+	assert.NoError(t, err)
+	assert.Len(t, list1, len(entries))
+	for i := 0; i < len(entries); i++ {
+		assert.Equal(t, entries[len(entries)-i-1].Address, list1[i].Address)
+	}
+
+	// List them forwards with pagination
+	list2, err := p.ListAddressListEntriesByCreateTime(ctx, entries[1].ID, 2, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, list2, 2)
+	assert.Equal(t, entries[2].Address, list2[0].Address)
+	assert.Equal(t, entries[3].Address, list2[1].Address)
+
+	// Delete one
+	err = p.DeleteAddressListEntry(ctx, entries[0].ID)
+	assert.NoError(t, err)
+	e1, err = p.GetAddressListEntry(ctx, entries[0].ID)
+	assert.NoError(t, err)
+	assert.Nil(t, e1)
+}
+
+func TestListAddressListEntriesAfterNotFound(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT.*address_lists").WillReturnRows(sqlmock.NewRows([]string{"seq"}))
+
+	_, err := p.ListAddressListEntriesByCreateTime(ctx, fftypes.NewUUID(), 0, txhandler.SortDirectionAscending)
+	assert.Regexp(t, "FF00164", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
@@ -46,10 +46,12 @@ type sqlPersistence struct {
 	transactions  *dbsql.CrudBase[*apitypes.ManagedTX]
 	checkpoints   *dbsql.CrudBase[*apitypes.EventStreamCheckpoint]
 	confirmations *dbsql.CrudBase[*apitypes.ConfirmationRecord]
+	txHashes      *dbsql.CrudBase[*apitypes.SubmittedHashRecord]
 	receipts      *dbsql.CrudBase[*apitypes.ReceiptRecord]
 	txHistory     *dbsql.CrudBase[*apitypes.TXHistoryRecord]
 	eventStreams  *dbsql.CrudBase[*apitypes.EventStream]
 	listeners     *dbsql.CrudBase[*apitypes.Listener]
+	addressLists  *dbsql.CrudBase[*apitypes.AddressListEntry]
 
 	historySummaryLimit int
 	nonceStateTimeout   time.Duration
@@ -86,8 +88,10 @@ func newSQLPersistence(bgCtx context.Context, db *dbsql.Database, conf config.Se
 	p.listeners = p.newListenersCollection(forMigration)
 	p.transactions = p.newTransactionCollection(forMigration)
 	p.confirmations = p.newConfirmationsCollection()
+	p.txHashes = p.newTransactionHashesCollection()
 	p.receipts = p.newReceiptsCollection()
 	p.txHistory = p.newTXHistoryCollection()
+	p.addressLists = p.newAddressListsCollection()
 
 	p.historySummaryLimit = conf.GetInt(ConfigTXWriterHistorySummaryLimit)
 	p.nonceStateTimeout = nonceStateTimeout
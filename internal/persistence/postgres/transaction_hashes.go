@@ -0,0 +1,148 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/internal/persistence"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+func (p *sqlPersistence) newTransactionHashesCollection() *dbsql.CrudBase[*apitypes.SubmittedHashRecord] {
+	collection := &dbsql.CrudBase[*apitypes.SubmittedHashRecord]{
+		DB:    p.db,
+		Table: "transaction_hashes",
+		Columns: []string{
+			dbsql.ColumnID,
+			dbsql.ColumnCreated,
+			dbsql.ColumnUpdated,
+			"tx_id",
+			"tx_hash",
+			"submitted",
+		},
+		FilterFieldMap: map[string]string{
+			"sequence":    p.db.SequenceColumn(),
+			"transaction": "tx_id",
+			"hash":        "tx_hash",
+		},
+		PatchDisabled: true,
+		NilValue:      func() *apitypes.SubmittedHashRecord { return nil },
+		NewInstance: func() *apitypes.SubmittedHashRecord {
+			return &apitypes.SubmittedHashRecord{
+				SubmittedHash: &apitypes.SubmittedHash{},
+			}
+		},
+		GetFieldPtr: func(inst *apitypes.SubmittedHashRecord, col string) interface{} {
+			switch col {
+			case dbsql.ColumnID:
+				return &inst.ID
+			case dbsql.ColumnCreated:
+				return &inst.Created
+			case dbsql.ColumnUpdated:
+				return &inst.Updated
+			case "tx_id":
+				return &inst.TransactionID
+			case "tx_hash":
+				return &inst.TransactionHash
+			case "submitted":
+				return &inst.Submitted
+			}
+			return nil
+		},
+	}
+	collection.Validate()
+	return collection
+}
+
+func (p *sqlPersistence) GetTransactionByHash(ctx context.Context, transactionHash string) (*apitypes.ManagedTX, error) {
+	filter := persistence.SubmittedHashFilters.NewFilterLimit(ctx, 1).Eq("hash", transactionHash)
+	records, _, err := p.txHashes.GetMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		// Transactions submitted before all hashes were recorded (or migrated from LevelDB) only have the latest one
+		transactions, _, err := p.transactions.GetMany(ctx, persistence.TransactionFilters.NewFilterLimit(ctx, 1).Eq("transactionhash", transactionHash))
+		if len(transactions) == 0 || err != nil {
+			return nil, err
+		}
+		return transactions[0], nil
+	}
+	return p.GetTransactionByID(ctx, records[0].TransactionID)
+}
+
+func (p *sqlPersistence) GetTransactionSubmittedHashes(ctx context.Context, txID string) ([]*apitypes.SubmittedHash, error) {
+	// We query in increasing insertion order, which is the order of submission
+	filter := persistence.SubmittedHashFilters.NewFilter(ctx).Eq("transaction", txID).Sort("sequence").Ascending()
+	records, _, err := p.txHashes.GetMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	submittedHashes := make([]*apitypes.SubmittedHash, len(records))
+	for i, r := range records {
+		submittedHashes[i] = r.SubmittedHash
+	}
+	return submittedHashes, nil
+}
+
+// insertSubmittedHashes records any hashes that are not already recorded, as a transaction can be resubmitted
+// with the same hash (for example when the gas price has not changed)
+func (p *sqlPersistence) insertSubmittedHashes(ctx context.Context, records []*apitypes.SubmittedHashRecord) error {
+	hashes := make([]driver.Value, len(records))
+	for i, r := range records {
+		hashes[i] = r.TransactionHash
+	}
+	existing, _, err := p.txHashes.GetMany(ctx, persistence.SubmittedHashFilters.NewFilter(ctx).In("hash", hashes))
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]bool, len(existing))
+	for _, r := range existing {
+		recorded[r.TransactionHash] = true
+	}
+	newRecords := make([]*apitypes.SubmittedHashRecord, 0, len(records))
+	for _, r := range records {
+		if !recorded[r.TransactionHash] {
+			newRecords = append(newRecords, r)
+		}
+	}
+	if len(newRecords) == 0 {
+		return nil
+	}
+	return p.txHashes.InsertMany(ctx, newRecords, false)
+}
+
+func newSubmittedHashRecord(txID string, updates *apitypes.TXUpdates) *apitypes.SubmittedHashRecord {
+	submitted := updates.LastSubmit
+	if submitted == nil {
+		submitted = fftypes.Now()
+	}
+	return &apitypes.SubmittedHashRecord{
+		ResourceBase: dbsql.ResourceBase{
+			ID: fftypes.NewUUID(),
+		},
+		TransactionID: txID,
+		SubmittedHash: &apitypes.SubmittedHash{
+			TransactionHash: *updates.TransactionHash,
+			Submitted:       submitted,
+		},
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionHashesPSQL(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	txID := fmt.Sprintf("ns1:%s", fftypes.NewUUID())
+	tx := &apitypes.ManagedTX{ID: txID, Status: apitypes.TxStatusPending, TransactionHeaders: ffcapi.TransactionHeaders{From: "0x122345"}}
+	err := p.InsertTransactionWithNextNonce(ctx, tx, func(ctx context.Context, signer string) (uint64, error) { return 0, nil })
+	assert.NoError(t, err)
+
+	// Submit three times, re-submitting the first hash at the end
+	for _, hash := range []string{"0x111111", "0x222222", "0x111111"} {
+		err = p.UpdateTransaction(ctx, txID, &apitypes.TXUpdates{
+			TransactionHash: strPtr(hash),
+			LastSubmit:      fftypes.Now(),
+		})
+		assert.NoError(t, err)
+	}
+
+	submittedHashes, err := p.GetTransactionSubmittedHashes(ctx, txID)
+	assert.NoError(t, err)
+	assert.Len(t, submittedHashes, 2)
+	assert.Equal(t, "0x111111", submittedHashes[0].TransactionHash)
+	assert.Equal(t, "0x222222", submittedHashes[1].TransactionHash)
+
+	// Lookup by any of the hashes
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		mtx, err := p.GetTransactionByHash(ctx, hash)
+		assert.NoError(t, err)
+		assert.Equal(t, txID, mtx.ID)
+	}
+	mtx, err := p.GetTransactionByHash(ctx, "0x333333")
+	assert.NoError(t, err)
+	assert.Nil(t, mtx)
+
+	// A transaction with a hash that was never recorded separately, such as one migrated from LevelDB
+	migratedTXID := fmt.Sprintf("ns1:%s", fftypes.NewUUID())
+	migratedTX := &apitypes.ManagedTX{ID: migratedTXID, Status: apitypes.TxStatusPending, TransactionHash: "0x444444", TransactionHeaders: ffcapi.TransactionHeaders{From: "0x122345", Nonce: fftypes.NewFFBigInt(1)}}
+	err = p.InsertTransactionPreAssignedNonce(ctx, migratedTX)
+	assert.NoError(t, err)
+	mtx, err = p.GetTransactionByHash(ctx, "0x444444")
+	assert.NoError(t, err)
+	assert.Equal(t, migratedTXID, mtx.ID)
+
+	// Delete clears the hashes
+	err = p.DeleteTransaction(ctx, txID)
+	assert.NoError(t, err)
+	mtx, err = p.GetTransactionByHash(ctx, "0x111111")
+	assert.NoError(t, err)
+	assert.Nil(t, mtx)
+	submittedHashes, err = p.GetTransactionSubmittedHashes(ctx, txID)
+	assert.NoError(t, err)
+	assert.Empty(t, submittedHashes)
+}
+
+func TestGetTransactionByHashFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := p.GetTransactionByHash(ctx, "0x111111")
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestGetTransactionByHashFallbackFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnRows(sqlmock.NewRows([]string{"seq"}))
+	mdb.ExpectQuery("SELECT.*transactions").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := p.GetTransactionByHash(ctx, "0x111111")
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
@@ -44,6 +44,7 @@ type transactionOperation struct {
 	isShutdown         bool
 	txInsert           *apitypes.ManagedTX
 	noncePreAssigned   bool
+	nonceAssign        bool // txInsert is an existing transaction, that needs only a nonce allocating
 	nextNonceCB        txhandler.NextNonceCallback
 	txUpdate           *apitypes.TXUpdates
 	txDelete           *string
@@ -84,6 +85,7 @@ type transactionWriterBatch struct {
 	timeoutCancel  func()
 
 	txInsertsByFrom     map[string][]*transactionOperation
+	txNonceAssigns      []*transactionOperation
 	txUpdates           []*transactionOperation
 	txDeletes           []string
 	receiptInserts      map[string]*apitypes.ReceiptRecord
@@ -91,6 +93,7 @@ type transactionWriterBatch struct {
 	compressionChecks   map[string]bool
 	confirmationInserts []*apitypes.ConfirmationRecord
 	confirmationResets  map[string]bool
+	txHashInserts       map[string]*apitypes.SubmittedHashRecord
 }
 
 func newTransactionWriter(bgCtx context.Context, p *sqlPersistence, conf config.Section) (tw *transactionWriter, err error) {
@@ -246,12 +249,22 @@ func (tw *transactionWriter) runBatch(ctx context.Context, b *transactionWriterB
 		b.confirmationResets = make(map[string]bool)
 		b.receiptInserts = make(map[string]*apitypes.ReceiptRecord)
 		b.compressionChecks = make(map[string]bool)
+		b.txHashInserts = make(map[string]*apitypes.SubmittedHashRecord)
 		for _, op := range b.ops {
 			switch {
 			case op.txInsert != nil:
 				b.txInsertsByFrom[op.txInsert.From] = append(b.txInsertsByFrom[op.txInsert.From], op)
+				if op.nonceAssign {
+					b.txNonceAssigns = append(b.txNonceAssigns, op)
+				}
 			case op.txUpdate != nil:
 				b.txUpdates = append(b.txUpdates, op)
+				// Every hash is recorded before the updates are merged, so intermediate submissions are not lost
+				if op.txUpdate.TransactionHash != nil && *op.txUpdate.TransactionHash != "" {
+					if _, exists := b.txHashInserts[*op.txUpdate.TransactionHash]; !exists {
+						b.txHashInserts[*op.txUpdate.TransactionHash] = newSubmittedHashRecord(op.txID, op.txUpdate)
+					}
+				}
 			case op.txDelete != nil:
 				b.txDeletes = append(b.txDeletes, *op.txDelete)
 				delete(b.compressionChecks, op.txID)
@@ -301,6 +314,8 @@ func (tw *transactionWriter) assignNonces(ctx context.Context, txInsertsByFrom m
 	for signer, txs := range txInsertsByFrom {
 		cacheEntry, isCached := tw.nextNonceCache.Get(signer)
 		cacheExpired := false
+		// The next nonce we allocate must be beyond any nonce supplied with a transaction in this batch
+		var preAssignedNextNonce uint64
 		if isCached {
 			timeSinceCached := time.Since(*cacheEntry.cachedTime.Time())
 			if timeSinceCached > tw.p.nonceStateTimeout {
@@ -310,6 +325,14 @@ func (tw *transactionWriter) assignNonces(ctx context.Context, txInsertsByFrom m
 		}
 		for _, op := range txs {
 			if op.noncePreAssigned {
+				// A transaction waiting on dependencies is inserted without a nonce
+				if !op.sentConflict && op.txInsert.Nonce != nil && op.txInsert.Nonce.Uint64() >= preAssignedNextNonce {
+					preAssignedNextNonce = op.txInsert.Nonce.Uint64() + 1
+				}
+				if cacheEntry != nil && preAssignedNextNonce > cacheEntry.nextNonce {
+					cacheEntry.nextNonce = preAssignedNextNonce
+					tw.nextNonceCache.Add(signer, cacheEntry)
+				}
 				continue
 			}
 			if op.sentConflict {
@@ -332,7 +355,8 @@ func (tw *transactionWriter) assignNonces(ctx context.Context, txInsertsByFrom m
 					log.L(ctx).Tracef("Using the cached existing nonce %s / %d to compare with the queried next %d for transaction %s", signer, internalNextNonce, nextNonce, op.txInsert.ID)
 				} else {
 					// when there is no cached nonce we need to fetch the highest nonce in our DB
-					filter := persistence.TransactionFilters.NewFilterLimit(ctx, 1).Eq("from", signer).Sort("-nonce")
+					fb := persistence.TransactionFilters.NewFilterLimit(ctx, 1)
+					filter := fb.And(fb.Eq("from", signer), fb.Neq("nonce", nil)).Sort("-nonce")
 					existingTXs, _, err := tw.p.transactions.GetMany(ctx, filter)
 					if err != nil {
 						log.L(ctx).Errorf("Failed to query highest persisted nonce for '%s': %s", signer, err)
@@ -343,6 +367,9 @@ func (tw *transactionWriter) assignNonces(ctx context.Context, txInsertsByFrom m
 						log.L(ctx).Tracef("Using the next nonce calculated from DB %s / %d to compare with the queried next %d for transaction %s", signer, internalNextNonce, nextNonce, op.txInsert.ID)
 					}
 				}
+				if preAssignedNextNonce > internalNextNonce {
+					internalNextNonce = preAssignedNextNonce
+				}
 				if internalNextNonce > nextNonce {
 					log.L(ctx).Infof("Using next nonce %s / %d instead of queried next %d for transaction %s", signer, internalNextNonce, nextNonce, op.txInsert.ID)
 					nextNonce = internalNextNonce
@@ -377,7 +404,12 @@ func (tw *transactionWriter) preInsertIdempotencyCheck(ctx context.Context, b *t
 	// a very edge case of a 500 in cache expiry, if we somehow expired it from that cache in this
 	// small window.
 	for _, txOps := range b.txInsertsByFrom {
+		preAssignedNonces := make(map[uint64]bool)
 		for _, txOp := range txOps {
+			if txOp.nonceAssign {
+				// The transaction already exists, and only needs a nonce
+				continue
+			}
 			var existing *apitypes.ManagedTX
 			_, inCache := tw.txMetaCache.Get(txOp.txID)
 			if inCache {
@@ -387,10 +419,27 @@ func (tw *transactionWriter) preInsertIdempotencyCheck(ctx context.Context, b *t
 					return nil, err
 				}
 			}
+			nonceConflict := false
+			if existing == nil && txOp.noncePreAssigned && txOp.txInsert.Nonce != nil {
+				// A supplied nonce must not already be in use by the signer, including by another insert in this batch
+				nonceConflict = preAssignedNonces[txOp.txInsert.Nonce.Uint64()]
+				if !nonceConflict {
+					byNonce, err := tw.p.GetTransactionByNonce(ctx, txOp.txInsert.From, txOp.txInsert.Nonce)
+					if err != nil {
+						log.L(ctx).Errorf("Pre-insert nonce check failed for transaction %s: %s", txOp.txID, err)
+						return nil, err
+					}
+					nonceConflict = byNonce != nil
+				}
+				preAssignedNonces[txOp.txInsert.Nonce.Uint64()] = true
+			}
 			if existing != nil {
 				// Send a conflict, and do not add it to the list
 				txOp.sentConflict = true
 				txOp.done <- i18n.NewError(ctx, tmmsgs.MsgDuplicateID, txOp.txID)
+			} else if nonceConflict {
+				txOp.sentConflict = true
+				txOp.done <- i18n.NewError(ctx, tmmsgs.MsgTransactionNonceConflict, txOp.txInsert.From, txOp.txInsert.Nonce.Int64())
 			} else {
 				log.L(ctx).Debugf("Adding TX %s from write operation %s to insert idx=%d", txOp.txID, txOp.opID, len(validInserts))
 				validInserts = append(validInserts, txOp.txInsert)
@@ -408,11 +457,13 @@ func (tw *transactionWriter) executeBatchOps(ctx context.Context, b *transaction
 	}
 
 	// Insert all the transactions
-	if len(txInserts) > 0 {
+	if len(txInserts) > 0 || len(b.txNonceAssigns) > 0 {
 		if err := tw.assignNonces(ctx, b.txInsertsByFrom); err != nil {
 			log.L(ctx).Errorf("InsertMany transactions (%d) nonce assignment failed: %s", len(b.historyInserts), err)
 			return err
 		}
+	}
+	if len(txInserts) > 0 {
 		if err := tw.p.transactions.InsertMany(ctx, txInserts, false); err != nil {
 			log.L(ctx).Errorf("InsertMany transactions (%d) failed: %s", len(b.historyInserts), err)
 			return err
@@ -422,6 +473,13 @@ func (tw *transactionWriter) executeBatchOps(ctx context.Context, b *transaction
 			_ = tw.txMetaCache.Add(t.ID, &txCacheEntry{lastCompacted: fftypes.Now()})
 		}
 	}
+	// Store the nonces allocated to existing transactions
+	for _, op := range b.txNonceAssigns {
+		if err := tw.p.updateTransaction(ctx, op.txID, &apitypes.TXUpdates{Nonce: op.txInsert.Nonce}); err != nil {
+			log.L(ctx).Errorf("Nonce assignment to transaction %s failed: %s", op.txID, err)
+			return err
+		}
+	}
 	// Do all the transaction updates
 	mergedUpdates := make(map[string]*apitypes.TXUpdates)
 	for _, op := range b.txUpdates {
@@ -439,6 +497,17 @@ func (tw *transactionWriter) executeBatchOps(ctx context.Context, b *transaction
 			return err
 		}
 	}
+	// Record any newly submitted transaction hashes
+	if len(b.txHashInserts) > 0 {
+		txHashes := make([]*apitypes.SubmittedHashRecord, 0, len(b.txHashInserts))
+		for _, h := range b.txHashInserts {
+			txHashes = append(txHashes, h)
+		}
+		if err := tw.p.insertSubmittedHashes(ctx, txHashes); err != nil {
+			log.L(ctx).Errorf("Insert transaction hashes (%d) failed: %s", len(txHashes), err)
+			return err
+		}
+	}
 	// Then the receipts - which need to be an upsert
 	receipts := make([]*apitypes.ReceiptRecord, 0, len(b.receiptInserts))
 	for _, r := range b.receiptInserts {
@@ -505,6 +574,11 @@ func (tw *transactionWriter) executeBatchOps(ctx context.Context, b *transaction
 			log.L(ctx).Errorf("DeleteMany history records for transaction %s failed: %s", txID, err)
 			return err
 		}
+		// Clear submitted hashes
+		if err := tw.p.txHashes.DeleteMany(ctx, persistence.SubmittedHashFilters.NewFilter(ctx).Eq("transaction", txID)); err != nil {
+			log.L(ctx).Errorf("DeleteMany transaction hashes for transaction %s failed: %s", txID, err)
+			return err
+		}
 		// Delete the transaction
 		if err := tw.p.transactions.Delete(ctx, txID); err != nil {
 			log.L(ctx).Errorf("Delete transaction %s failed: %s", txID, err)
@@ -318,6 +318,97 @@ func TestExecuteBatchOpsUpdateTXMerge(t *testing.T) {
 	assert.NoError(t, mdb.ExpectationsWereMet())
 }
 
+func TestExecuteBatchOpsInsertTXHashes(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnRows(
+		sqlmock.NewRows([]string{p.db.SequenceColumn(), "id", "created", "updated", "tx_id", "tx_hash", "submitted"}).
+			AddRow(1, fftypes.NewUUID().String(), fftypes.Now().String(), fftypes.Now().String(), "tx1", "0x111111", fftypes.Now().String()),
+	)
+	mdb.ExpectExec("INSERT.*transaction_hashes").WillReturnResult(sqlmock.NewResult(-1, 1))
+	mdb.ExpectCommit()
+
+	err := p.db.RunAsGroup(ctx, func(ctx context.Context) error {
+		return p.writer.executeBatchOps(ctx, &transactionWriterBatch{
+			txHashInserts: map[string]*apitypes.SubmittedHashRecord{
+				"0x111111": newSubmittedHashRecord("tx1", &apitypes.TXUpdates{TransactionHash: strPtr("0x111111")}),
+				"0x222222": newSubmittedHashRecord("tx1", &apitypes.TXUpdates{TransactionHash: strPtr("0x222222"), LastSubmit: fftypes.Now()}),
+			},
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestExecuteBatchOpsInsertTXHashesAllExisting(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnRows(
+		sqlmock.NewRows([]string{p.db.SequenceColumn(), "id", "created", "updated", "tx_id", "tx_hash", "submitted"}).
+			AddRow(1, fftypes.NewUUID().String(), fftypes.Now().String(), fftypes.Now().String(), "tx1", "0x111111", fftypes.Now().String()),
+	)
+	mdb.ExpectCommit()
+
+	err := p.db.RunAsGroup(ctx, func(ctx context.Context) error {
+		return p.writer.executeBatchOps(ctx, &transactionWriterBatch{
+			txHashInserts: map[string]*apitypes.SubmittedHashRecord{
+				"0x111111": newSubmittedHashRecord("tx1", &apitypes.TXUpdates{TransactionHash: strPtr("0x111111")}),
+			},
+		})
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestExecuteBatchOpsInsertTXHashesQueryFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnError(fmt.Errorf("pop"))
+	mdb.ExpectRollback()
+
+	err := p.db.RunAsGroup(ctx, func(ctx context.Context) error {
+		return p.writer.executeBatchOps(ctx, &transactionWriterBatch{
+			txHashInserts: map[string]*apitypes.SubmittedHashRecord{
+				"0x111111": newSubmittedHashRecord("tx1", &apitypes.TXUpdates{TransactionHash: strPtr("0x111111")}),
+			},
+		})
+	})
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestExecuteBatchOpsInsertTXHashesFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectBegin()
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnRows(
+		sqlmock.NewRows([]string{p.db.SequenceColumn(), "id", "created", "updated", "tx_id", "tx_hash", "submitted"}),
+	)
+	mdb.ExpectExec("INSERT.*transaction_hashes").WillReturnError(fmt.Errorf("pop"))
+	mdb.ExpectRollback()
+
+	err := p.db.RunAsGroup(ctx, func(ctx context.Context) error {
+		return p.writer.executeBatchOps(ctx, &transactionWriterBatch{
+			txHashInserts: map[string]*apitypes.SubmittedHashRecord{
+				"0x111111": newSubmittedHashRecord("tx1", &apitypes.TXUpdates{TransactionHash: strPtr("0x111111")}),
+			},
+		})
+	})
+	assert.Regexp(t, "FF00177", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
 func TestExecuteBatchOpsUpsertReceiptFail(t *testing.T) {
 	ctx, p, mdb, done := newMockSQLPersistence(t)
 	defer done()
@@ -396,6 +487,7 @@ func TestExecuteBatchOpsDeleteTXFail(t *testing.T) {
 	mdb.ExpectExec("DELETE.*receipts").WillReturnResult(driver.RowsAffected(0))
 	mdb.ExpectExec("DELETE.*confirmations").WillReturnResult(driver.RowsAffected(0))
 	mdb.ExpectExec("DELETE.*txhistory").WillReturnResult(driver.RowsAffected(0))
+	mdb.ExpectExec("DELETE.*transaction_hashes").WillReturnResult(driver.RowsAffected(0))
 	mdb.ExpectExec("DELETE.*transactions").WillReturnError(fmt.Errorf("pop"))
 	mdb.ExpectRollback()
 
@@ -409,6 +501,27 @@ func TestExecuteBatchOpsDeleteTXFail(t *testing.T) {
 	assert.NoError(t, mdb.ExpectationsWereMet())
 }
 
+func TestExecuteBatchOpsDeleteTXHashesFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectBegin()
+	mdb.ExpectExec("DELETE.*receipts").WillReturnResult(driver.RowsAffected(0))
+	mdb.ExpectExec("DELETE.*confirmations").WillReturnResult(driver.RowsAffected(0))
+	mdb.ExpectExec("DELETE.*txhistory").WillReturnResult(driver.RowsAffected(0))
+	mdb.ExpectExec("DELETE.*transaction_hashes").WillReturnError(fmt.Errorf("pop"))
+	mdb.ExpectRollback()
+
+	err := p.db.RunAsGroup(ctx, func(ctx context.Context) error {
+		return p.writer.executeBatchOps(ctx, &transactionWriterBatch{
+			txDeletes: []string{"1"},
+		})
+	})
+	assert.Regexp(t, "FF00179", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
 func TestExecuteBatchOpsDeleteTXHistoryFail(t *testing.T) {
 	ctx, p, mdb, done := newMockSQLPersistence(t)
 	defer done()
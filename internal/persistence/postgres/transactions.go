@@ -20,9 +20,12 @@ import (
 	"context"
 	"strconv"
 
+	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger/firefly-common/pkg/dbsql"
 	"github.com/hyperledger/firefly-common/pkg/ffapi"
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
 	"github.com/hyperledger/firefly-transaction-manager/internal/persistence"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
@@ -51,6 +54,14 @@ func (p *sqlPersistence) newTransactionCollection(forMigration bool) *dbsql.Crud
 			"first_submit",
 			"last_submit",
 			"error_message",
+			"expiry",
+			"not_before",
+			"not_before_block",
+			"priority",
+			"depends_on",
+			"retry_of",
+			"retried_by",
+			"superseded_by",
 		},
 		FilterFieldMap: map[string]string{
 			"sequence":        p.db.SequenceColumn(),
@@ -67,6 +78,12 @@ func (p *sqlPersistence) newTransactionCollection(forMigration bool) *dbsql.Crud
 			"firstsubmit":     "first_submit",
 			"lastsubmit":      "last_submit",
 			"errormessage":    "error_message",
+			"notbefore":       "not_before",
+			"notbeforeblock":  "not_before_block",
+			"dependson":       "depends_on",
+			"retryof":         "retry_of",
+			"retriedby":       "retried_by",
+			"supersededby":    "superseded_by",
 		},
 		PatchDisabled: true,
 		TimesDisabled: forMigration,
@@ -108,6 +125,22 @@ func (p *sqlPersistence) newTransactionCollection(forMigration bool) *dbsql.Crud
 				return &inst.LastSubmit
 			case "error_message":
 				return &inst.ErrorMessage
+			case "expiry":
+				return &inst.Expiry
+			case "not_before":
+				return &inst.NotBefore
+			case "not_before_block":
+				return &inst.NotBeforeBlock
+			case "priority":
+				return &inst.Priority
+			case "depends_on":
+				return &inst.DependsOn
+			case "retry_of":
+				return &inst.RetryOf
+			case "retried_by":
+				return &inst.RetriedBy
+			case "superseded_by":
+				return &inst.SupersededBy
 			}
 			return nil
 		},
@@ -144,6 +177,7 @@ func (p *sqlPersistence) ListTransactionsByNonce(ctx context.Context, signer str
 	fb := persistence.TransactionFilters.NewFilterLimit(ctx, uint64(limit))
 	conditions := []ffapi.Filter{
 		fb.Eq("from", signer),
+		fb.Neq("nonce", nil), // transactions waiting on dependencies do not have a nonce yet
 	}
 	if after != nil {
 		if dir == txhandler.SortDirectionDescending {
@@ -162,6 +196,41 @@ func (p *sqlPersistence) ListTransactionsByNonce(ctx context.Context, signer str
 	return transactions, err
 }
 
+func (p *sqlPersistence) ListSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	return p.listSigners(ctx, nil, after, limit)
+}
+
+func (p *sqlPersistence) ListPendingSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	return p.listSigners(ctx, sq.Eq{"status": apitypes.TxStatusPending}, after, limit)
+}
+
+func (p *sqlPersistence) listSigners(ctx context.Context, where sq.Sqlizer, after string, limit int) ([]string, error) {
+	q := sq.Select("tx_from").Distinct().From(p.transactions.Table).OrderBy("tx_from")
+	if where != nil {
+		q = q.Where(where)
+	}
+	if after != "" {
+		q = q.Where(sq.Gt{"tx_from": after})
+	}
+	if limit > 0 {
+		q = q.Limit(uint64(limit))
+	}
+	rows, _, err := p.db.Query(ctx, p.transactions.Table, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	signers := make([]string, 0)
+	for rows.Next() {
+		var signer string
+		if err := rows.Scan(&signer); err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, p.transactions.Table)
+		}
+		signers = append(signers, signer)
+	}
+	return signers, nil
+}
+
 func (p *sqlPersistence) ListTransactionsPending(ctx context.Context, afterSequenceID string, limit int, dir txhandler.SortDirection) ([]*apitypes.ManagedTX, error) {
 	var afterSeq *int64
 	if afterSequenceID != "" {
@@ -178,6 +247,27 @@ func (p *sqlPersistence) ListTransactionsPending(ctx context.Context, afterSeque
 	return transactions, err
 }
 
+func (p *sqlPersistence) ListSignerTransactionsPending(ctx context.Context, signer string, limit int) ([]*apitypes.ManagedTX, error) {
+	fb := persistence.TransactionFilters.NewFilterLimit(ctx, uint64(limit))
+	// Ascending order puts the transactions still waiting for a nonce last
+	filter := fb.And(
+		fb.Eq("status", apitypes.TxStatusPending),
+		fb.Eq("from", signer),
+	).Sort("nonce").Sort("sequence")
+	transactions, _, err := p.transactions.GetMany(ctx, filter)
+	return transactions, err
+}
+
+func (p *sqlPersistence) ListTransactionsPendingByPriority(ctx context.Context, limit int) ([]*apitypes.ManagedTX, error) {
+	fb := persistence.TransactionFilters.NewFilterLimit(ctx, uint64(limit))
+	filter := fb.And(
+		fb.Eq("status", apitypes.TxStatusPending),
+		fb.Gt("priority", 0),
+	).Sort("-priority").Sort("sequence")
+	transactions, _, err := p.transactions.GetMany(ctx, filter)
+	return transactions, err
+}
+
 func (p *sqlPersistence) GetTransactionByID(ctx context.Context, txID string) (*apitypes.ManagedTX, error) {
 	return p.transactions.GetByID(ctx, txID)
 }
@@ -199,10 +289,15 @@ func (p *sqlPersistence) GetTransactionByIDWithStatus(ctx context.Context, txID
 	if err != nil {
 		return nil, err
 	}
+	submittedHashes, err := p.GetTransactionSubmittedHashes(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
 	txh := &apitypes.TXWithStatus{
-		ManagedTX:     tx,
-		Receipt:       receipt,
-		Confirmations: confirmations,
+		ManagedTX:       tx,
+		Receipt:         receipt,
+		Confirmations:   confirmations,
+		SubmittedHashes: submittedHashes,
 	}
 	if withHistory {
 		history, err := p.buildHistorySummary(ctx, txID, true, p.historySummaryLimit, nil)
@@ -245,6 +340,33 @@ func (p *sqlPersistence) InsertTransactionWithNextNonce(ctx context.Context, tx
 	return op.flush(ctx) // wait for completion
 }
 
+func (p *sqlPersistence) AssignTransactionNextNonce(ctx context.Context, tx *apitypes.ManagedTX, nextNonceCB txhandler.NextNonceCallback) error {
+	// Dispatch to TX writer, which allocates the nonce in sequence with the inserts for the signer
+	op := newTransactionOperation(tx.ID)
+	op.txInsert = tx
+	op.nonceAssign = true
+	op.nextNonceCB = nextNonceCB
+	p.writer.queue(ctx, op)
+	return op.flush(ctx) // wait for completion
+}
+
+func (p *sqlPersistence) InsertTransactionsWithNextNonce(ctx context.Context, txs []*apitypes.ManagedTX, nextNonceCB txhandler.NextNonceCallback) []error {
+	// Queue all the inserts before waiting for any of them, so they are assigned nonces and
+	// inserted in as few writer batches as possible
+	ops := make([]*transactionOperation, len(txs))
+	for i, tx := range txs {
+		ops[i] = newTransactionOperation(tx.ID)
+		ops[i].txInsert = tx
+		ops[i].nextNonceCB = nextNonceCB
+		p.writer.queue(ctx, ops[i])
+	}
+	errs := make([]error, len(txs))
+	for i, op := range ops {
+		errs[i] = op.flush(ctx)
+	}
+	return errs
+}
+
 func (p *sqlPersistence) UpdateTransaction(ctx context.Context, txID string, updates *apitypes.TXUpdates) error {
 	// Dispatch to TX writer
 	op := newTransactionOperation(txID)
@@ -305,5 +427,25 @@ func (p *sqlPersistence) updateTransaction(ctx context.Context, txID string, upd
 	if updates.ErrorMessage != nil {
 		sqlUpdate = sqlUpdate.Set("errormessage", *updates.ErrorMessage)
 	}
-	return p.transactions.Update(ctx, txID, sqlUpdate)
+	if updates.RetriedBy != nil {
+		sqlUpdate = sqlUpdate.Set("retriedby", *updates.RetriedBy)
+	}
+	if updates.SupersededBy != nil {
+		sqlUpdate = sqlUpdate.Set("supersededby", *updates.SupersededBy)
+	}
+	if err := p.transactions.Update(ctx, txID, sqlUpdate); err != nil || updates.SupersededBy == nil {
+		return err
+	}
+	// The nonce now belongs to the transaction that superseded this one. The update builder
+	// cannot set a column to null, so this is a separate statement in the same DB transaction.
+	_, err := p.db.UpdateTx(ctx, p.transactions.Table, dbsql.GetTXFromContext(ctx),
+		sq.Update(p.transactions.Table).Set("tx_nonce", nil).Where(sq.Eq{dbsql.ColumnID: txID}),
+		nil)
+	return err
+}
+
+func (p *sqlPersistence) ClearCachedNonce(ctx context.Context, signer string) error {
+	log.L(ctx).Infof("Clearing cached nonce state for signer %s", signer)
+	_ = p.writer.nextNonceCache.Remove(signer)
+	return nil
 }
@@ -46,6 +46,9 @@ func TestTransactionBasicValidationPSQL(t *testing.T) {
 		ID:              txID,
 		Status:          apitypes.TxStatusPending,
 		DeleteRequested: nil,
+		Expiry:          fftypes.Now(),
+		NotBeforeBlock:  fftypes.NewFFBigInt(12345),
+		Priority:        10,
 		TransactionHeaders: ffcapi.TransactionHeaders{
 			From:  "0x111111",
 			To:    "0x222222",
@@ -129,6 +132,7 @@ func TestTransactionBasicValidationPSQL(t *testing.T) {
 		FirstSubmit:     fftypes.Now(),
 		LastSubmit:      fftypes.Now(),
 		ErrorMessage:    strPtr("error bbbbbb"),
+		RetriedBy:       strPtr("ns1:retry"),
 	}
 	err = p.UpdateTransaction(ctx, txID, txUpdates)
 	assert.NoError(t, err)
@@ -160,9 +164,13 @@ func TestTransactionBasicValidationPSQL(t *testing.T) {
 			FirstSubmit:     txUpdates.FirstSubmit,
 			LastSubmit:      txUpdates.LastSubmit,
 			ErrorMessage:    *txUpdates.ErrorMessage,
+			RetriedBy:       *txUpdates.RetriedBy,
 		},
 		Receipt:       receipt,
 		Confirmations: confirmations,
+		SubmittedHashes: []*apitypes.SubmittedHash{
+			{TransactionHash: "0xaaaaaa", Submitted: txUpdates.LastSubmit},
+		},
 		History: []*apitypes.TxHistoryStateTransitionEntry{
 			{
 				Status: apitypes.TxSubStatusTracking,
@@ -407,6 +415,38 @@ func TestTransactionPendingPSQL(t *testing.T) {
 
 }
 
+func TestListSignersPSQL(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	for _, signer := range []string{"0xbb", "0xab", "0xabc"} {
+		for i := 0; i < 3; i++ {
+			tx := &apitypes.ManagedTX{
+				ID:     fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+				Status: apitypes.TxStatusSucceeded,
+				TransactionHeaders: ffcapi.TransactionHeaders{
+					From: signer,
+				},
+			}
+			err := p.InsertTransactionWithNextNonce(ctx, tx, func(ctx context.Context, signer string) (uint64, error) {
+				return 0, nil
+			})
+			assert.NoError(t, err)
+		}
+	}
+
+	signers, err := p.ListSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab", "0xabc", "0xbb"}, signers)
+
+	signers, err = p.ListSigners(ctx, "0xab", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, signers)
+
+}
+
 func TestTransactionMixConflictAndOkPSQL(t *testing.T) {
 	logrus.SetLevel(logrus.TraceLevel)
 
@@ -481,6 +521,13 @@ func newTXRow(p *sqlPersistence) *sqlmock.Rows {
 		nil,                    // "first_submit",
 		nil,                    // "last_submit",
 		"",                     // "error_message",
+		nil,                    // "expiry",
+		nil,                    // "not_before",
+		nil,                    // "not_before_block",
+		0,                      // "priority",
+		nil,                    // "depends_on",
+		"",                     // "retry_of",
+		"",                     // "retried_by",
 	)
 }
 
@@ -495,6 +542,9 @@ func TestGetTransactionByIDWithStatusHistorySummaryFail(t *testing.T) {
 	mdb.ExpectQuery("SELECT.*confirmations").WillReturnRows(
 		sqlmock.NewRows(append([]string{p.db.SequenceColumn()}, p.confirmations.Columns...)),
 	)
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnRows(
+		sqlmock.NewRows(append([]string{p.db.SequenceColumn()}, p.txHashes.Columns...)),
+	)
 	mdb.ExpectQuery("SELECT.*txhistory").WillReturnError(fmt.Errorf("pop"))
 
 	_, err := p.GetTransactionByIDWithStatus(ctx, "tx1", true)
@@ -503,6 +553,25 @@ func TestGetTransactionByIDWithStatusHistorySummaryFail(t *testing.T) {
 	assert.NoError(t, mdb.ExpectationsWereMet())
 }
 
+func TestGetTransactionByIDWithStatusSubmittedHashesFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT.*transactions").WillReturnRows(newTXRow(p))
+	mdb.ExpectQuery("SELECT.*receipts").WillReturnRows(
+		sqlmock.NewRows(append([]string{p.db.SequenceColumn()}, p.receipts.Columns...)),
+	)
+	mdb.ExpectQuery("SELECT.*confirmations").WillReturnRows(
+		sqlmock.NewRows(append([]string{p.db.SequenceColumn()}, p.confirmations.Columns...)),
+	)
+	mdb.ExpectQuery("SELECT.*transaction_hashes").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := p.GetTransactionByIDWithStatus(ctx, "tx1", true)
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
 func TestGetTransactionByIDWithStatusConfirmationsFail(t *testing.T) {
 	ctx, p, mdb, done := newMockSQLPersistence(t)
 	defer done()
@@ -543,3 +612,294 @@ func TestGetTransactionByIDTXFail(t *testing.T) {
 
 	assert.NoError(t, mdb.ExpectationsWereMet())
 }
+
+func TestListPendingSignersPSQL(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	insert := func(signer string, status apitypes.TxStatus) *apitypes.ManagedTX {
+		tx := &apitypes.ManagedTX{
+			ID:     fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+			Status: status,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From: signer,
+			},
+		}
+		err := p.InsertTransactionWithNextNonce(ctx, tx, func(ctx context.Context, signer string) (uint64, error) {
+			return 0, nil
+		})
+		assert.NoError(t, err)
+		return tx
+	}
+	insert("0xab", apitypes.TxStatusSucceeded)
+	tx1 := insert("0xab", apitypes.TxStatusPending)
+	tx2 := insert("0xab", apitypes.TxStatusPending)
+	insert("0xabc", apitypes.TxStatusPending)
+	insert("0xbb", apitypes.TxStatusFailed)
+
+	signers, err := p.ListPendingSigners(ctx, "", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xab", "0xabc"}, signers)
+
+	signers, err = p.ListPendingSigners(ctx, "0xab", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xabc"}, signers)
+
+	txs, err := p.ListSignerTransactionsPending(ctx, "0xab", 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	assert.Equal(t, tx1.ID, txs[0].ID)
+	assert.Equal(t, tx2.ID, txs[1].ID)
+
+	txs, err = p.ListSignerTransactionsPending(ctx, "0xab", 1)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+	assert.Equal(t, tx1.ID, txs[0].ID)
+
+}
+
+func TestListTransactionsPendingByPriorityPSQL(t *testing.T) {
+	logrus.SetLevel(logrus.TraceLevel)
+
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	insert := func(priority int, status apitypes.TxStatus) *apitypes.ManagedTX {
+		tx := &apitypes.ManagedTX{
+			ID:       fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+			Status:   status,
+			Priority: priority,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From: "0xaaaa",
+			},
+		}
+		err := p.InsertTransactionWithNextNonce(ctx, tx, func(ctx context.Context, signer string) (uint64, error) {
+			return 0, nil
+		})
+		assert.NoError(t, err)
+		return tx
+	}
+	insert(0, apitypes.TxStatusPending)
+	tx5a := insert(5, apitypes.TxStatusPending)
+	tx10 := insert(10, apitypes.TxStatusPending)
+	tx5b := insert(5, apitypes.TxStatusPending)
+	insert(20, apitypes.TxStatusSucceeded)
+
+	txs, err := p.ListTransactionsPendingByPriority(ctx, 10)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 3)
+	assert.Equal(t, tx10.ID, txs[0].ID)
+	assert.Equal(t, tx5a.ID, txs[1].ID)
+	assert.Equal(t, tx5b.ID, txs[2].ID)
+
+}
+
+func TestListPendingSignersQueryFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT DISTINCT tx_from FROM transactions WHERE status").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := p.ListPendingSigners(ctx, "", 0)
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestListSignersQueryFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT DISTINCT tx_from FROM transactions").WillReturnError(fmt.Errorf("pop"))
+
+	_, err := p.ListSigners(ctx, "", 0)
+	assert.Regexp(t, "FF00176", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestListSignersScanFail(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	mdb.ExpectQuery("SELECT DISTINCT tx_from FROM transactions").WillReturnRows(sqlmock.NewRows([]string{"tx_from", "extra"}).AddRow("0xaaa", "unexpected"))
+
+	_, err := p.ListSigners(ctx, "", 0)
+	assert.Regexp(t, "FF00182", err)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestClearCachedNonce(t *testing.T) {
+	ctx, p, mdb, done := newMockSQLPersistence(t)
+	defer done()
+
+	p.writer.nextNonceCache.Add("0xaaa", &nonceCacheEntry{cachedTime: fftypes.Now(), nextNonce: 12345})
+
+	err := p.ClearCachedNonce(ctx, "0xaaa")
+	assert.NoError(t, err)
+	_, isCached := p.writer.nextNonceCache.Get("0xaaa")
+	assert.False(t, isCached)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+}
+
+func TestInsertTransactionsWithNextNoncePSQL(t *testing.T) {
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	txs := make([]*apitypes.ManagedTX, 5)
+	for i := range txs {
+		txs[i] = &apitypes.ManagedTX{
+			ID:     fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+			Status: apitypes.TxStatusPending,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From: "0xbatch",
+			},
+		}
+	}
+	// Re-use of an ID fails just that transaction
+	txs[4].ID = txs[0].ID
+
+	errs := p.InsertTransactionsWithNextNonce(ctx, txs, func(ctx context.Context, signer string) (uint64, error) {
+		return 100, nil
+	})
+	assert.Len(t, errs, 5)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, int64(100+i), txs[i].Nonce.Int64())
+	}
+	assert.Regexp(t, "FF21065", errs[4])
+}
+
+func TestInsertTransactionPreAssignedNonceConflictPSQL(t *testing.T) {
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	newTX := func(nonce *fftypes.FFBigInt) *apitypes.ManagedTX {
+		return &apitypes.ManagedTX{
+			ID:     fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+			Status: apitypes.TxStatusPending,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From:  "0xsupplied",
+				Nonce: nonce,
+			},
+		}
+	}
+	nextNonce := func(ctx context.Context, signer string) (uint64, error) {
+		return 0, nil
+	}
+
+	// Allocate a nonce, so the next nonce is cached
+	tx := newTX(nil)
+	err := p.InsertTransactionWithNextNonce(ctx, tx, nextNonce)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), tx.Nonce.Int64())
+
+	// Supply a nonce ahead of the cached next nonce
+	err = p.InsertTransactionPreAssignedNonce(ctx, newTX(fftypes.NewFFBigInt(10)))
+	assert.NoError(t, err)
+
+	// Neither a supplied nonce, nor an allocated one, can be used again
+	err = p.InsertTransactionPreAssignedNonce(ctx, newTX(fftypes.NewFFBigInt(10)))
+	assert.Regexp(t, "FF21090", err)
+	err = p.InsertTransactionPreAssignedNonce(ctx, newTX(fftypes.NewFFBigInt(0)))
+	assert.Regexp(t, "FF21090", err)
+
+	// Allocation continues after the supplied nonce
+	tx = newTX(nil)
+	err = p.InsertTransactionWithNextNonce(ctx, tx, nextNonce)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), tx.Nonce.Int64())
+}
+
+func TestAssignTransactionNextNoncePSQL(t *testing.T) {
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	nextNonce := func(ctx context.Context, signer string) (uint64, error) {
+		return 5, nil
+	}
+
+	// Inserted without a nonce, while it waits on a dependency
+	dependent := &apitypes.ManagedTX{
+		ID:        fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+		Status:    apitypes.TxStatusPending,
+		DependsOn: []string{"ns1:prereq"},
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0xdependent",
+		},
+	}
+	err := p.InsertTransactionPreAssignedNonce(ctx, dependent)
+	assert.NoError(t, err)
+
+	tx := &apitypes.ManagedTX{
+		ID:     fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+		Status: apitypes.TxStatusPending,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0xdependent",
+		},
+	}
+	err = p.InsertTransactionWithNextNonce(ctx, tx, nextNonce)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), tx.Nonce.Int64())
+
+	// Transactions without a nonce are not listed by nonce
+	txs, err := p.ListTransactionsByNonce(ctx, "0xdependent", nil, 10, txhandler.SortDirectionDescending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+
+	// The nonce is allocated in sequence with the inserts for the signer
+	p.writer.nextNonceCache.Purge()
+	err = p.AssignTransactionNextNonce(ctx, dependent, nextNonce)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), dependent.Nonce.Int64())
+
+	stored, err := p.GetTransactionByNonce(ctx, "0xdependent", fftypes.NewFFBigInt(6))
+	assert.NoError(t, err)
+	assert.Equal(t, dependent.ID, stored.ID)
+	assert.Equal(t, []string{"ns1:prereq"}, []string(stored.DependsOn))
+}
+
+func TestSupersedeTransactionPSQL(t *testing.T) {
+	ctx, p, _, done := initTestPSQL(t)
+	defer done()
+
+	// Submitted, but failed without ever being mined
+	unmined := &apitypes.ManagedTX{
+		ID:          fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+		Status:      apitypes.TxStatusFailed,
+		FirstSubmit: fftypes.Now(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0xsuperseded",
+			Nonce: fftypes.NewFFBigInt(5),
+		},
+	}
+	err := p.InsertTransactionPreAssignedNonce(ctx, unmined)
+	assert.NoError(t, err)
+
+	fillerID := fmt.Sprintf("ns1:%s", fftypes.NewUUID())
+	err = p.UpdateTransaction(ctx, unmined.ID, &apitypes.TXUpdates{SupersededBy: &fillerID})
+	assert.NoError(t, err)
+
+	// The record is kept, linked to the transaction that takes over its nonce
+	stored, err := p.GetTransactionByID(ctx, unmined.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fillerID, stored.SupersededBy)
+	assert.Nil(t, stored.Nonce)
+	stored, err = p.GetTransactionByNonce(ctx, "0xsuperseded", fftypes.NewFFBigInt(5))
+	assert.NoError(t, err)
+	assert.Nil(t, stored)
+
+	err = p.InsertTransactionPreAssignedNonce(ctx, &apitypes.ManagedTX{
+		ID:     fillerID,
+		Status: apitypes.TxStatusPending,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0xsuperseded",
+			Nonce: fftypes.NewFFBigInt(5),
+		},
+	})
+	assert.NoError(t, err)
+}
@@ -27,21 +27,28 @@ var ffm = func(key, translation string) i18n.MessageKey {
 
 //revive:disable
 var (
+	APIEndpointDeleteAddressListEntry       = ffm("api.endpoints.delete.addresslist", "Delete an entry from the address allow/deny lists, and reload the lists used to screen new transactions")
 	APIEndpointDeleteEventStream            = ffm("api.endpoints.delete.eventstream", "Delete an event stream")
 	APIEndpointDeleteEventStreamListener    = ffm("api.endpoints.delete.eventstream.listener", "Delete event stream listener")
 	APIEndpointDeleteSubscription           = ffm("api.endpoints.delete.subscription", "Delete listener - route deprecated in favor of /eventstreams/{streamId}/listeners/{listenerId}")
 	APIEndpointDeleteTransaction            = ffm("api.endpoints.delete.transaction", "Request transaction deletion by the policy engine. Result could be immediate (200), asynchronous (202), or rejected with an error")
+	APIEndpointGetAddressListEntries        = ffm("api.endpoints.get.addresslists", "List the entries of the allow/deny lists for the signer (from) and destination (to) addresses of new transactions")
 	APIEndpointGetAddressBalance            = ffm("api.endpoints.get.address.balance", "Get gas token balance for a signer address")
 	APIEndpointGetEventStream               = ffm("api.endpoints.get.eventstream", "Get an event stream with status")
 	APIEndpointGetEventStreamListener       = ffm("api.endpoints.get.eventstream.listener", "Get event stream listener")
 	APIEndpointGetEventStreamListeners      = ffm("api.endpoints.get.eventstream.listeners", "List event stream listeners")
 	APIEndpointGetEventStreams              = ffm("api.endpoints.get.eventstreams", "List event streams")
 	APIEndpointGetGasPrice                  = ffm("api.endpoints.get.gasprice", "Get the current gas price of the connector's chain")
+	APIEndpointGetGasPriceHistory           = ffm("api.endpoints.get.gasprice.history", "List the gas prices recently obtained by the transaction handler, newest first, with the value returned by each gas oracle source")
+	APIEndpointGetSigner                    = ffm("api.endpoints.get.signer", "Get the nonce and pending transaction state of a signer")
+	APIEndpointGetSigners                   = ffm("api.endpoints.get.signers", "List the signers that have submitted transactions, with their nonce and pending transaction state")
+	APIEndpointGetSignerNonces              = ffm("api.endpoints.get.signer.nonces", "Compare the nonces of pending transactions for a signer with the next nonce of the chain, reporting any gaps that block those transactions")
 	APIEndpointGetStatusLive                = ffm("api.endpoints.get.status.live", "Get the liveness status of the connector")
 	APIEndpointGetStatusReady               = ffm("api.endpoints.get.status.ready", "Get the readiness status of the connector")
 	APIEndpointGetSubscription              = ffm("api.endpoints.get.subscription", "Get listener - route deprecated in favor of /eventstreams/{streamId}/listeners/{listenerId}")
 	APIEndpointGetSubscriptions             = ffm("api.endpoints.get.subscriptions", "Get listeners - route deprecated in favor of /eventstreams/{streamId}/listeners")
 	APIEndpointGetTransaction               = ffm("api.endpoints.get.transaction", "Get individual transaction with a status summary")
+	APIEndpointGetTransactionByHash         = ffm("api.endpoints.get.transactionhash", "Get the transaction that was submitted with a transaction hash, which can be the hash of any of its submissions")
 	APIEndpointGetTransactions              = ffm("api.endpoints.get.transactions", "List transactions")
 	APIEndpointGetTransactionConfirmations  = ffm("api.endpoints.get.transactions.confirmations", "List transaction confirmations")
 	APIEndpointGetTransactionHistory        = ffm("api.endpoints.get.transactions.history", "List transaction history records")
@@ -54,21 +61,33 @@ var (
 	APIEndpointPostEventStreamListenerReset = ffm("api.endpoints.post.eventstream.listener.reset", "Reset an event stream listener, to redeliver all events since the specified block")
 	APIEndpointPostEventStreamResume        = ffm("api.endpoints.post.eventstream.resume", "Resume an event stream")
 	APIEndpointPostEventStreamSuspend       = ffm("api.endpoints.post.eventstream.suspend", "Suspend an event stream")
+	APIEndpointPostAddressListEntry         = ffm("api.endpoints.post.addresslists", "Add an entry to the allow or deny list for the signer (from) or destination (to) addresses of new transactions")
+	APIEndpointPostAddressListsReload       = ffm("api.endpoints.post.addresslists.reload", "Reload the address allow/deny lists from persistence, returning all the entries now used to screen new transactions")
+	APIEndpointPostBatch                    = ffm("api.endpoints.post.batch", "Submit a batch of transactions and contract deployments in a single call, with a result returned for each")
 	APIEndpointPostRoot                     = ffm("api.endpoints.post.root", "RPC/webhook style interface initiate a submit transactions, and execute queries")
 	APIEndpointPostRootQueryOutput          = ffm("api.endpoints.post.root.query.output", "The data result of a query against a smart contract")
+	APIEndpointPostSignerResync             = ffm("api.endpoints.post.signer.resync", "Discard the cached nonce state for a signer and re-query the next nonce from the blockchain, optionally renumbering pending transactions that have not been submitted onto that nonce")
 	APIEndpointPostSubscriptionReset        = ffm("api.endpoints.post.subscription.reset", "Reset listener - route deprecated in favor of /eventstreams/{streamId}/listeners/{listenerId}/reset")
 	APIEndpointPostSubscriptions            = ffm("api.endpoints.post.subscriptions", "Create new listener - route deprecated in favor of /eventstreams/{streamId}/listeners")
 	APIEndpointPostTransactionSuspend       = ffm("api.endpoints.post.transactions.suspend", "Suspend processing on a pending transaction (no-op for completed transactions)")
 	APIEndpointPostTransactionResume        = ffm("api.endpoints.post.transactions.resume", "Resume processing on a suspended transaction")
+	APIEndpointPostTransactionRetry         = ffm("api.endpoints.post.transactions.retry", "Retry a failed transaction as a new transaction with a fresh nonce, linked to the failed transaction")
+	APIEndpointPostTransactionTrack         = ffm("api.endpoints.post.transactions.track", "Adopt a transaction that was submitted by other tooling, to track its receipt and confirmations without submitting anything")
+	APIEndpointPostTransactionApprove       = ffm("api.endpoints.post.transactions.approve", "Approve a transaction that is awaiting approval, so it is assigned a nonce and submitted")
+	APIEndpointPostTransactionReject        = ffm("api.endpoints.post.transactions.reject", "Reject a transaction that is awaiting approval, so it fails without being submitted")
+	APIEndpointPostTransactionSpeedUp       = ffm("api.endpoints.post.transactions.speedup", "Resubmit a pending transaction immediately at the same nonce, with an explicit gas price or a percentage increase on the last gas price")
 
 	APIParamStreamID      = ffm("api.params.streamId", "Event Stream ID")
 	APIParamListenerID    = ffm("api.params.listenerId", "Listener ID")
+	APIParamAddressListID = ffm("api.params.addressListEntryId", "Address list entry ID")
 	APIParamTransactionID = ffm("api.params.transactionId", "Transaction ID")
+	APIParamTXHash        = ffm("api.params.transactionHash", "Transaction hash")
 	APIParamLimit         = ffm("api.params.limit", "Maximum number of entries to return")
 	APIParamAfter         = ffm("api.params.after", "Return entries after this ID - for pagination (non-inclusive)")
 	APIParamTXSigner      = ffm("api.params.txSigner", "Return only transactions for a specific signing address, in reverse nonce order")
 	APIParamTXPending     = ffm("api.params.txPending", "Return only pending transactions, in reverse submission sequence (a 'sequenceId' is assigned to each transaction to determine its sequence")
 	APIParamSortDirection = ffm("api.params.sortDirection", "Sort direction: 'asc'/'ascending' or 'desc'/'descending'")
+	APIParamAfterSigner   = ffm("api.params.afterSigner", "Return signers after this address - for pagination (non-inclusive)")
 	APIParamSignerAddress = ffm("api.params.signerAddress", "A signing address, for example to get the gas token balance for")
 	APIParamBlocktag      = ffm("api.params.blocktag", "The optional block tag to use when making a gas token balance query")
 	APIParamHistory       = ffm("api.params.history", "Include transaction history summary information")
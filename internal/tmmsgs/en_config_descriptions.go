@@ -69,18 +69,58 @@ var (
 	ConfigTXHandlerName        = ffc("config.transactions.handler.name", "The name of the transaction handler to use", i18n.StringType)
 	ConfigTXHandlerMaxInflight = ffc("config.transactions.handler.simple.maxInFlight", "The maximum number of transactions to have in-flight with the transaction handler / blockchain transaction pool", i18n.IntType)
 
-	ConfigTXHandlerSimpleInterval               = ffc("config.transactions.handler.simple.interval", "Interval at which to invoke the transaction handler loop to evaluate outstanding transactions", i18n.TimeDurationType)
-	ConfigTXHandlerSimpleFixedGasPrice          = ffc("config.transactions.handler.simple.fixedGasPrice", "A fixed gasPrice value/structure to pass to the connector", "Raw JSON")
-	ConfigTXHandlerSimpleResubmitInterval       = ffc("config.transactions.handler.simple.resubmitInterval", "The time between warning and re-sending a transaction (same nonce) when a blockchain transaction has not been allocated a receipt", i18n.TimeDurationType)
-	ConfigTXHandlerSimpleRetryInitDelay         = ffc("config.transactions.handler.simple.retry.initialDelay", "Initial retry delay for retrieving transactions from the persistence", i18n.TimeDurationType)
-	ConfigTXHandlerSimpleRetryMaxDelay          = ffc("config.transactions.handler.simple.retry.maxDelay", "Maximum delay between retries for retrieving transactions from the persistence", i18n.TimeDurationType)
-	ConfigTXHandlerSimpleRetryFactor            = ffc("config.transactions.handler.simple.retry.factor", "Factor to increase the delay by, between each retry for retrieving transactions from the persistence", i18n.FloatType)
-	ConfigTXHandlerSimpleGasOracleEnabled       = ffc("config.transactions.handler.simple.gasOracle.mode", "The gas oracle mode", "'connector', 'restapi', 'fixed', or 'disabled'")
-	ConfigTXHandlerSimpleGasOracleGoTemplate    = ffc("config.transactions.handler.simple.gasOracle.template", "REST API Gas Oracle: A go template to execute against the result from the Gas Oracle, to create a JSON block that will be passed as the gas price to the connector", i18n.GoTemplateType)
-	ConfigTXHandlerSimpleGasOracleURL           = ffc("config.transactions.handler.simple.gasOracle.url", "REST API Gas Oracle: The URL of a Gas Oracle REST API to call", i18n.StringType)
-	ConfigTXHandlerSimpleGasOracleProxyURL      = ffc("config.transactions.handler.simple.gasOracle.proxy.url", "Optional HTTP proxy URL to use for the Gas Oracle REST API", i18n.StringType)
-	ConfigPTXHandlerSimpleGasOracleMethod       = ffc("config.transactions.handler.simple.gasOracle.method", "The HTTP Method to use when invoking the Gas Oracle REST API", i18n.StringType)
-	ConfigTXHandlerSimpleGasOracleQueryInterval = ffc("config.transactions.handler.simple.gasOracle.queryInterval", "The minimum interval between queries to the Gas Oracle", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleInterval                  = ffc("config.transactions.handler.simple.interval", "Interval at which to invoke the transaction handler loop to evaluate outstanding transactions", i18n.TimeDurationType)
+	ConfigTXHandlerSimplePolicyWorkers             = ffc("config.transactions.handler.simple.policyWorkers", "The number of workers executing the policy engine against the in-flight transactions, which limits how many signers are executed at once. The transactions of a signer are always executed in order by one worker, independently of the loop and the other signers, so a slow connector call only holds up the signer it is for. A signer still being executed from an earlier cycle is skipped", i18n.IntType)
+	ConfigTXHandlerSimpleClientNonces              = ffc("config.transactions.handler.simple.clientSuppliedNonces", "Use the nonce supplied in the headers of a transaction request, rather than assigning the next nonce for the signer. For clients that coordinate nonces themselves. A request with a nonce the signer has already used is rejected", i18n.BooleanType)
+	ConfigTXHandlerSimpleFixedGasPrice             = ffc("config.transactions.handler.simple.fixedGasPrice", "A fixed gasPrice value/structure to pass to the connector", "Raw JSON")
+	ConfigTXHandlerSimpleResubmitInterval          = ffc("config.transactions.handler.simple.resubmitInterval", "The time between warning and re-sending a transaction (same nonce) when a blockchain transaction has not been allocated a receipt", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleRetryInitDelay            = ffc("config.transactions.handler.simple.retry.initialDelay", "Initial retry delay for retrieving transactions from the persistence", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleRetryMaxDelay             = ffc("config.transactions.handler.simple.retry.maxDelay", "Maximum delay between retries for retrieving transactions from the persistence", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleRetryFactor               = ffc("config.transactions.handler.simple.retry.factor", "Factor to increase the delay by, between each retry for retrieving transactions from the persistence", i18n.FloatType)
+	ConfigTXHandlerSimpleGasOracleEnabled          = ffc("config.transactions.handler.simple.gasOracle.mode", "The gas oracle mode", "'connector', 'restapi', 'fixed', or 'disabled'")
+	ConfigTXHandlerSimpleGasOracleGoTemplate       = ffc("config.transactions.handler.simple.gasOracle.template", "REST API Gas Oracle: A go template to execute against the result from the Gas Oracle, to create a JSON block that will be passed as the gas price to the connector", i18n.GoTemplateType)
+	ConfigTXHandlerSimpleGasOracleURL              = ffc("config.transactions.handler.simple.gasOracle.url", "REST API Gas Oracle: The URL of a Gas Oracle REST API to call", i18n.StringType)
+	ConfigTXHandlerSimpleGasOracleProxyURL         = ffc("config.transactions.handler.simple.gasOracle.proxy.url", "Optional HTTP proxy URL to use for the Gas Oracle REST API", i18n.StringType)
+	ConfigPTXHandlerSimpleGasOracleMethod          = ffc("config.transactions.handler.simple.gasOracle.method", "The HTTP Method to use when invoking the Gas Oracle REST API", i18n.StringType)
+	ConfigTXHandlerSimpleGasOracleQueryInterval    = ffc("config.transactions.handler.simple.gasOracle.queryInterval", "The minimum interval between queries to the Gas Oracle", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleGasOracleAggregation      = ffc("config.transactions.handler.simple.gasOracle.aggregation", "How to combine the gas prices from multiple gas oracle sources. 'firstSuccess' uses the first source in the list that returns a gas price. 'median' and 'max' combine a numeric gas price, or each numeric field of a gas price object, across every source that returns one", "'firstSuccess', 'median' or 'max'")
+	ConfigTXHandlerSimpleGasOracleHistorySize      = ffc("config.transactions.handler.simple.gasOracle.historySize", "The number of recent gas price queries to keep in memory, and return from the gas price history API", i18n.IntType)
+	ConfigTXHandlerSimpleGasOracleSourceName       = ffc("config.transactions.handler.simple.gasOracle.sources[].name", "A name for the gas oracle source, shown in the gas price history. Defaults to 'source' followed by the index of the entry", i18n.StringType)
+	ConfigTXHandlerSimpleGasOracleSourceMode       = ffc("config.transactions.handler.simple.gasOracle.sources[].mode", "The gas oracle mode of the source. When any sources are configured they are used instead of the mode of the gasOracle section", "'connector' or 'restapi'")
+	ConfigTXHandlerSimpleGasOracleSourceMethod     = ffc("config.transactions.handler.simple.gasOracle.sources[].method", "The HTTP Method to use when invoking a REST API gas oracle source", i18n.StringType)
+	ConfigTXHandlerSimpleGasOracleSourceTemplate   = ffc("config.transactions.handler.simple.gasOracle.sources[].template", "REST API Gas Oracle: A go template to execute against the result from the source, to create a JSON block that will be passed as the gas price to the connector", i18n.GoTemplateType)
+	ConfigTXHandlerSimpleGasOracleSourceInterval   = ffc("config.transactions.handler.simple.gasOracle.sources[].queryInterval", "The minimum interval between queries to the source", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleGasOracleSourceFailures   = ffc("config.transactions.handler.simple.gasOracle.sources[].maxFailures", "The number of consecutive failures after which the source is dropped from the set of sources queried. Set to 0 to never drop the source", i18n.IntType)
+	ConfigTXHandlerSimpleGasOracleSourceRecovery   = ffc("config.transactions.handler.simple.gasOracle.sources[].recoveryInterval", "How long a dropped source is left out, before it is queried again", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleGasOracleSourceURL        = ffc("config.transactions.handler.simple.gasOracle.sources[].http.url", "REST API Gas Oracle: The URL of the gas oracle REST API to call", i18n.StringType)
+	ConfigTXHandlerSimpleGasEscalationPercentage   = ffc("config.transactions.handler.simple.gasEscalation.percentage", "Percentage to increase the gas price of a stale transaction by, each time it is resubmitted. The previously submitted gas price is escalated, unless the current gas price is higher. Set to 0 to disable escalation", i18n.FloatType)
+	ConfigTXHandlerSimpleGasEscalationMaxGasPrice  = ffc("config.transactions.handler.simple.gasEscalation.maxGasPrice", "The maximum value any numeric field of the gas price (such as maxFeePerGas and maxPriorityFeePerGas in an EIP-1559 structure) can be escalated to", i18n.StringType)
+	ConfigTXHandlerSimpleCancelMode                = ffc("config.transactions.handler.simple.cancel.mode", "How to cancel a transaction that has been submitted, but not yet mined. 'delete' stops tracking the transaction immediately. 'replace' submits a zero value transfer to the signing address at the same nonce, and keeps the transaction until the replacement is mined", "'delete' or 'replace'")
+	ConfigTXHandlerSimpleCancelGasBumpPercentage   = ffc("config.transactions.handler.simple.cancel.gasBumpPercentage", "Percentage to increase the gas price by, over the gas price of the last submission, for a cancel replacement transaction", i18n.FloatType)
+	ConfigTXHandlerSimpleNonceGapCheckEnabled      = ffc("config.transactions.handler.simple.nonceGapCheck.enabled", "Periodically compare the nonces of the transactions for each signer with the next nonce of the chain, and log a warning for any nonce that blocks them from being mined", i18n.BooleanType)
+	ConfigTXHandlerSimpleNonceGapCheckInterval     = ffc("config.transactions.handler.simple.nonceGapCheck.interval", "How often to check for nonce gaps", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleNonceGapCheckFill         = ffc("config.transactions.handler.simple.nonceGapCheck.fill", "Fill each nonce gap that is found by submitting a zero value transfer from the signer to itself. A transaction that held the nonce, but was never mined, is kept with supersededBy set to the transfer that takes over its nonce", i18n.BooleanType)
+	ConfigTXHandlerSimpleExpirySubmittedStrategy   = ffc("config.transactions.handler.simple.expiry.submittedStrategy", "What to do with a transaction that passes its expiry after it has been submitted, but before it is mined. 'stopTracking' marks the transaction failed straight away, although it might still be mined. 'cancel' submits a zero value transfer to the signing address at the same nonce, and marks the transaction failed once the replacement is mined. A transaction that expires before it is submitted, but after it is assigned a nonce, is always replaced in the same way - so the later transactions of the signer can still be mined", "'stopTracking' or 'cancel'")
+	ConfigTXHandlerSimpleSpendGuardMaxGasPrice     = ffc("config.transactions.handler.simple.spendGuard.maxGasPrice", "Transactions are not submitted while any numeric field of the gas price is above this value. They stay pending in the AwaitingGasPrice sub-status, and are retried on each cycle", i18n.StringType)
+	ConfigTXHandlerSimpleSpendGuardNamespaceMaxFee = ffc("config.transactions.handler.simple.spendGuard.namespaceMaxFee", "The maximum total fee (gas limit multiplied by gas price) of the transactions submitted for each namespace within the rolling window. Transactions that would exceed it stay pending in the AwaitingGasPrice sub-status. A transaction with a fee above the limit on its own is failed before it is first submitted. Submissions made before a restart are loaded from the database", i18n.StringType)
+	ConfigTXHandlerSimpleSpendGuardNamespaceWindow = ffc("config.transactions.handler.simple.spendGuard.namespaceWindow", "The rolling time window for the namespace fee limit", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleBalanceCheckInitialDelay  = ffc("config.transactions.handler.simple.balanceCheck.initialDelay", "When the node rejects a transaction for insufficient funds, submission is paused for the signer. This is the delay before the balance of the signer is first checked", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleBalanceCheckMaxDelay      = ffc("config.transactions.handler.simple.balanceCheck.maxDelay", "Maximum delay between checks of the balance of a paused signer", i18n.TimeDurationType)
+	ConfigTXHandlerSimpleBalanceCheckFactor        = ffc("config.transactions.handler.simple.balanceCheck.factor", "Factor to increase the delay by, after each check that finds the balance of a paused signer does not cover the estimated cost of the transaction", i18n.FloatType)
+	ConfigTXHandlerSimpleSchedulingFair            = ffc("config.transactions.handler.simple.scheduling.fair", "Fill spaces in the in-flight set by taking transactions from each signer in turn, rather than in the order they were received, so a signer with a large queue cannot starve the others", i18n.BooleanType)
+	ConfigTXHandlerSimpleSchedulingMaxPerSigner    = ffc("config.transactions.handler.simple.scheduling.maxInFlightPerSigner", "The maximum number of transactions for a single signer in the in-flight set at once. Zero for no limit", i18n.IntType)
+	ConfigTXHandlerSimpleSchedulingScanLimit       = ffc("config.transactions.handler.simple.scheduling.pendingScanLimit", "The number of signers with pending transactions to read at a time, when choosing which transactions to add to the in-flight set. Only used with fair or priority scheduling, or a per signer limit", i18n.IntType)
+	ConfigTXHandlerSimpleSchedulingNamespace       = ffc("config.transactions.handler.simple.scheduling.namespaceWeights[].namespace", "The namespace the weight applies to", i18n.StringType)
+	ConfigTXHandlerSimpleSchedulingWeight          = ffc("config.transactions.handler.simple.scheduling.namespaceWeights[].weight", "With fair scheduling, the number of transactions a signer takes on each turn when its next transaction is in this namespace. Namespaces that are not listed have a weight of 1", i18n.IntType)
+	ConfigTXHandlerSimplePriorityScheduling        = ffc("config.transactions.handler.simple.priority.scheduling", "Fill spaces in the in-flight set from the signers with the highest priority transactions waiting first. A signer's earlier nonces are taken ahead of its high priority transaction, so they do not hold it back", i18n.BooleanType)
+	ConfigTXHandlerSimplePriorityMinPriority       = ffc("config.transactions.handler.simple.priority.gasPriceMultipliers[].minPriority", "The lowest transaction priority the multiplier applies to. The entry with the highest minPriority that is not above the priority of a transaction is used", i18n.IntType)
+	ConfigTXHandlerSimplePriorityMultiplier        = ffc("config.transactions.handler.simple.priority.gasPriceMultipliers[].multiplier", "The multiplier applied to each numeric field of the gas price, before any gas escalation", i18n.FloatType)
+	ConfigTXHandlerSimpleApprovalSigners           = ffc("config.transactions.handler.simple.approval.signers", "New transactions from these signers are held in the AwaitingApproval status without a nonce, and are only submitted once approved", i18n.ArrayStringType)
+	ConfigTXHandlerSimpleApprovalNamespaces        = ffc("config.transactions.handler.simple.approval.namespaces", "New transactions in these namespaces are held in the AwaitingApproval status without a nonce, and are only submitted once approved", i18n.ArrayStringType)
+	ConfigTXHandlerSimpleApprovalApproverHeader    = ffc("config.transactions.handler.simple.approval.approverHeader", "The HTTP header identifying who approved or rejected a transaction, which is recorded in the transaction history. Must also be listed in api.passthroughHeaders", i18n.StringType)
+	ConfigTXHandlerSimplePolicyHookURL             = ffc("config.transactions.handler.simple.policyHook.url", "The URL of a policy decision endpoint, called with the transaction as JSON before it is first submitted. Unless the client supplied one, the transaction is not assigned a nonce until it is allowed, so a denied transaction leaves no gap in the nonces of its signer. It must answer with a decision of 'allow', 'deny' to fail the transaction, or 'defer' to ask again later, and optionally a reason", i18n.StringType)
+	ConfigTXHandlerSimplePolicyHookProxyURL        = ffc("config.transactions.handler.simple.policyHook.proxy.url", "Optional HTTP proxy URL to use for the policy hook", i18n.StringType)
+	ConfigTXHandlerSimplePolicyHookUnavailable     = ffc("config.transactions.handler.simple.policyHook.unavailable", "The decision to apply when the policy hook cannot be called, or does not return a valid decision", "'allow', 'deny' or 'defer'")
 
 	ConfigEventStreamsDefaultsBatchSize                 = ffc("config.eventstreams.defaults.batchSize", "Default batch size for newly created event streams", i18n.IntType)
 	ConfigEventStreamsDefaultsBatchTimeout              = ffc("config.eventstreams.defaults.batchTimeout", "Default batch timeout for newly created event streams", i18n.TimeDurationType)
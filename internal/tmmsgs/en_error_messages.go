@@ -101,4 +101,51 @@ var (
 	MsgTransactionPersistenceError             = ffe("FF21084", "Failed to persist transaction data", 500)
 	MsgOpNotSupportedWithoutRichQuery          = ffe("FF21085", "Not supported: The connector must be configured with a rich query database to support this operation", 501)
 	MsgTransactionOpInvalid                    = ffe("FF21086", "Transaction operation is missing required fields", 400)
+	MsgInvalidGasEscalationMaxGasPrice         = ffe("FF21087", "Invalid gas escalation max gas price '%s'")
+	MsgInvalidCancelMode                       = ffe("FF21088", "Invalid cancel mode '%s'")
+	MsgSignerNotFound                          = ffe("FF21089", "No transactions found for signer '%s'", http.StatusNotFound)
+	MsgTransactionNonceConflict                = ffe("FF21090", "Signer '%s' already has a transaction with nonce %d", http.StatusConflict)
+	MsgInvalidTransactionExpiry                = ffe("FF21091", "Invalid transaction expiry '%s' - must be a duration, or an RFC3339 or unix timestamp", http.StatusBadRequest)
+	MsgTransactionExpired                      = ffe("FF21092", "Transaction expired at %s before it was mined")
+	MsgInvalidExpiryStrategy                   = ffe("FF21093", "Invalid expiry strategy '%s'")
+	MsgInvalidTransactionNotBefore             = ffe("FF21094", "Invalid transaction notBefore '%s' - must be a block number, a duration, or an RFC3339 timestamp", http.StatusBadRequest)
+	MsgEmptyBatch                              = ffe("FF21095", "Batch must contain at least one request", http.StatusBadRequest)
+	MsgDryRunNotSupported                      = ffe("FF21096", "Dry run is only supported for individual SendTransaction requests", http.StatusBadRequest)
+	MsgInvalidGasOracleAggregation             = ffe("FF21097", "Invalid gas oracle aggregation '%s'")
+	MsgInvalidGasOracleSourceMode              = ffe("FF21098", "Invalid mode '%s' for gas oracle source '%s' - must be 'connector' or 'restapi'")
+	MsgNoGasOracleSourcesAvailable             = ffe("FF21099", "All gas oracle sources have been dropped after repeated failures")
+	MsgGasPriceHistoryNotSupported             = ffe("FF21100", "The transaction handler does not record a gas price history", http.StatusNotImplemented)
+	MsgInvalidSpendLimit                       = ffe("FF21101", "Invalid %s '%s' - must be a positive integer")
+	MsgInvalidNamespaceWeight                  = ffe("FF21102", "Invalid weight %d for namespace '%s' - must be a positive integer")
+	MsgInvalidPriorityGasPriceMultiplier       = ffe("FF21103", "Invalid gas price multiplier %v for priority %d - must be greater than zero")
+	MsgDependencyNotFound                      = ffe("FF21104", "Transaction '%s' listed in dependsOn does not exist", http.StatusBadRequest)
+	MsgDependencyFailed                        = ffe("FF21105", "Transaction '%s' that this transaction depends on did not succeed (status=%s)")
+	MsgDependsOnWithNonce                      = ffe("FF21106", "A transaction with dependsOn cannot be supplied with a nonce, as the nonce is assigned once its dependencies succeed", http.StatusBadRequest)
+	MsgRetryNotSupported                       = ffe("FF21107", "The transaction handler does not support retrying transactions", http.StatusNotImplemented)
+	MsgTransactionNotFailed                    = ffe("FF21108", "Transaction '%s' cannot be retried, as it has not failed (status=%s)", http.StatusConflict)
+	MsgTransactionAlreadyRetried               = ffe("FF21109", "Transaction '%s' has already been retried as transaction '%s'", http.StatusConflict)
+	MsgSpeedUpNotSupported                     = ffe("FF21110", "The transaction handler does not support speeding up transactions", http.StatusNotImplemented)
+	MsgInvalidSpeedUpRequest                   = ffe("FF21111", "Exactly one of gasPrice, or a positive percentage, must be supplied to speed up a transaction", http.StatusBadRequest)
+	MsgTransactionNotSubmitted                 = ffe("FF21112", "Transaction '%s' cannot be sped up, as it is not a pending transaction that has been submitted (status=%s)", http.StatusConflict)
+	MsgGasPriceNotBumpable                     = ffe("FF21113", "The gas price '%s' of transaction '%s' has no numeric value that can be increased by a percentage", http.StatusBadRequest)
+	MsgTrackNotSupported                       = ffe("FF21114", "The transaction handler does not support tracking externally submitted transactions", http.StatusNotImplemented)
+	MsgInvalidTrackRequest                     = ffe("FF21115", "A transactionHash, from and nonce must be supplied to track an externally submitted transaction", http.StatusBadRequest)
+	MsgTransactionSubmittedExternally          = ffe("FF21116", "Transaction '%s' was submitted externally, so cannot be resubmitted without preparing it again", http.StatusConflict)
+	MsgApprovalNotSupported                    = ffe("FF21117", "The transaction handler does not support approving transactions", http.StatusNotImplemented)
+	MsgTransactionNotAwaitingApproval          = ffe("FF21118", "Transaction '%s' is not awaiting approval (status=%s)", http.StatusConflict)
+	MsgApproverRequired                        = ffe("FF21119", "The '%s' header must be supplied to identify who is approving or rejecting the transaction", http.StatusBadRequest)
+	MsgTransactionRejected                     = ffe("FF21120", "Transaction rejected by '%s'")
+	MsgPolicyHookDenied                        = ffe("FF21121", "Transaction denied by the policy hook: %s")
+	MsgErrorCallingPolicyHook                  = ffe("FF21122", "Error from policy hook [%d]: %s")
+	MsgInvalidPolicyDecision                   = ffe("FF21123", "Invalid decision '%s' from policy hook")
+	MsgInvalidPolicyHookUnavailable            = ffe("FF21124", "Invalid policy hook unavailable behavior '%s'")
+	MsgInvalidAddressListEntry                 = ffe("FF21125", "Invalid address list entry - field must be 'from' or 'to', list must be 'allow' or 'deny', and an address must be supplied", http.StatusBadRequest)
+	MsgAddressDenied                           = ffe("FF21126", "The %s address '%s' is on the deny list", http.StatusBadRequest)
+	MsgAddressNotAllowed                       = ffe("FF21127", "The %s address '%s' is not on the allow list", http.StatusBadRequest)
+	MsgAddressListEntryNotFound                = ffe("FF21128", "Address list entry '%s' not found", http.StatusNotFound)
+	MsgSpeedUpSpendLimitReached                = ffe("FF21129", "Transaction '%s' was not sped up, as the gas price '%s' would break the spend limits", http.StatusBadRequest)
+	MsgRetryNamespaceMismatch                  = ffe("FF21130", "ID '%s' for the retry is not in namespace '%s' of transaction '%s'", http.StatusBadRequest)
+	MsgRetryRejectedTransaction                = ffe("FF21131", "Transaction '%s' was rejected by '%s', so cannot be retried", http.StatusConflict)
+	MsgRetryPolicyDeniedTransaction            = ffe("FF21132", "Transaction '%s' was denied by the policy hook, so cannot be retried", http.StatusConflict)
+	MsgFeeExceedsNamespaceMaxFee               = ffe("FF21133", "Transaction fee %s is above the fee limit of %s for namespace '%s'")
 )
@@ -123,6 +123,32 @@ func (_m *FFTMClient) GetListeners(ctx context.Context, eventStreamID string) ([
 	return r0, r1
 }
 
+// ResyncSigner provides a mock function with given fields: ctx, signer, renumber
+func (_m *FFTMClient) ResyncSigner(ctx context.Context, signer string, renumber bool) (*apitypes.SignerResyncResult, error) {
+	ret := _m.Called(ctx, signer, renumber)
+
+	var r0 *apitypes.SignerResyncResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) (*apitypes.SignerResyncResult, error)); ok {
+		return rf(ctx, signer, renumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, bool) *apitypes.SignerResyncResult); ok {
+		r0 = rf(ctx, signer, renumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apitypes.SignerResyncResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, bool) error); ok {
+		r1 = rf(ctx, signer, renumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewFFTMClient creates a new instance of FFTMClient. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFFTMClient(t interface {
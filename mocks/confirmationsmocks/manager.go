@@ -30,6 +30,20 @@ func (_m *Manager) CheckInFlight(listenerID *fftypes.UUID) bool {
 	return r0
 }
 
+// HighestBlockSeen provides a mock function with given fields:
+func (_m *Manager) HighestBlockSeen() uint64 {
+	ret := _m.Called()
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	return r0
+}
+
 // NewBlockHashes provides a mock function with given fields:
 func (_m *Manager) NewBlockHashes() chan<- *ffcapi.BlockHashEvent {
 	ret := _m.Called()
@@ -58,11 +58,53 @@ func (_m *Persistence) AddTransactionConfirmations(ctx context.Context, txID str
 	return r0
 }
 
+// AssignTransactionNextNonce provides a mock function with given fields: ctx, tx, lookupNextNonce
+func (_m *Persistence) AssignTransactionNextNonce(ctx context.Context, tx *apitypes.ManagedTX, lookupNextNonce txhandler.NextNonceCallback) error {
+	ret := _m.Called(ctx, tx, lookupNextNonce)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *apitypes.ManagedTX, txhandler.NextNonceCallback) error); ok {
+		r0 = rf(ctx, tx, lookupNextNonce)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearCachedNonce provides a mock function with given fields: ctx, signer
+func (_m *Persistence) ClearCachedNonce(ctx context.Context, signer string) error {
+	ret := _m.Called(ctx, signer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, signer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields: ctx
 func (_m *Persistence) Close(ctx context.Context) {
 	_m.Called(ctx)
 }
 
+// DeleteAddressListEntry provides a mock function with given fields: ctx, entryID
+func (_m *Persistence) DeleteAddressListEntry(ctx context.Context, entryID *fftypes.UUID) error {
+	ret := _m.Called(ctx, entryID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) error); ok {
+		r0 = rf(ctx, entryID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteCheckpoint provides a mock function with given fields: ctx, streamID
 func (_m *Persistence) DeleteCheckpoint(ctx context.Context, streamID *fftypes.UUID) error {
 	ret := _m.Called(ctx, streamID)
@@ -119,6 +161,32 @@ func (_m *Persistence) DeleteTransaction(ctx context.Context, txID string) error
 	return r0
 }
 
+// GetAddressListEntry provides a mock function with given fields: ctx, entryID
+func (_m *Persistence) GetAddressListEntry(ctx context.Context, entryID *fftypes.UUID) (*apitypes.AddressListEntry, error) {
+	ret := _m.Called(ctx, entryID)
+
+	var r0 *apitypes.AddressListEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) (*apitypes.AddressListEntry, error)); ok {
+		return rf(ctx, entryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID) *apitypes.AddressListEntry); ok {
+		r0 = rf(ctx, entryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apitypes.AddressListEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, entryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCheckpoint provides a mock function with given fields: ctx, streamID
 func (_m *Persistence) GetCheckpoint(ctx context.Context, streamID *fftypes.UUID) (*apitypes.EventStreamCheckpoint, error) {
 	ret := _m.Called(ctx, streamID)
@@ -197,6 +265,32 @@ func (_m *Persistence) GetStream(ctx context.Context, streamID *fftypes.UUID) (*
 	return r0, r1
 }
 
+// GetTransactionByHash provides a mock function with given fields: ctx, transactionHash
+func (_m *Persistence) GetTransactionByHash(ctx context.Context, transactionHash string) (*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, transactionHash)
+
+	var r0 *apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, transactionHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *apitypes.ManagedTX); ok {
+		r0 = rf(ctx, transactionHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, transactionHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionByID provides a mock function with given fields: ctx, txID
 func (_m *Persistence) GetTransactionByID(ctx context.Context, txID string) (*apitypes.ManagedTX, error) {
 	ret := _m.Called(ctx, txID)
@@ -327,6 +421,32 @@ func (_m *Persistence) GetTransactionReceipt(ctx context.Context, txID string) (
 	return r0, r1
 }
 
+// GetTransactionSubmittedHashes provides a mock function with given fields: ctx, txID
+func (_m *Persistence) GetTransactionSubmittedHashes(ctx context.Context, txID string) ([]*apitypes.SubmittedHash, error) {
+	ret := _m.Called(ctx, txID)
+
+	var r0 []*apitypes.SubmittedHash
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*apitypes.SubmittedHash, error)); ok {
+		return rf(ctx, txID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*apitypes.SubmittedHash); ok {
+		r0 = rf(ctx, txID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.SubmittedHash)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, txID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertTransactionPreAssignedNonce provides a mock function with given fields: ctx, tx
 func (_m *Persistence) InsertTransactionPreAssignedNonce(ctx context.Context, tx *apitypes.ManagedTX) error {
 	ret := _m.Called(ctx, tx)
@@ -355,6 +475,48 @@ func (_m *Persistence) InsertTransactionWithNextNonce(ctx context.Context, tx *a
 	return r0
 }
 
+// InsertTransactionsWithNextNonce provides a mock function with given fields: ctx, txs, lookupNextNonce
+func (_m *Persistence) InsertTransactionsWithNextNonce(ctx context.Context, txs []*apitypes.ManagedTX, lookupNextNonce txhandler.NextNonceCallback) []error {
+	ret := _m.Called(ctx, txs, lookupNextNonce)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*apitypes.ManagedTX, txhandler.NextNonceCallback) []error); ok {
+		r0 = rf(ctx, txs, lookupNextNonce)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	return r0
+}
+
+// ListAddressListEntriesByCreateTime provides a mock function with given fields: ctx, after, limit, dir
+func (_m *Persistence) ListAddressListEntriesByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection) ([]*apitypes.AddressListEntry, error) {
+	ret := _m.Called(ctx, after, limit, dir)
+
+	var r0 []*apitypes.AddressListEntry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, int, txhandler.SortDirection) ([]*apitypes.AddressListEntry, error)); ok {
+		return rf(ctx, after, limit, dir)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, int, txhandler.SortDirection) []*apitypes.AddressListEntry); ok {
+		r0 = rf(ctx, after, limit, dir)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.AddressListEntry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, int, txhandler.SortDirection) error); ok {
+		r1 = rf(ctx, after, limit, dir)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListListenersByCreateTime provides a mock function with given fields: ctx, after, limit, dir
 func (_m *Persistence) ListListenersByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection) ([]*apitypes.Listener, error) {
 	ret := _m.Called(ctx, after, limit, dir)
@@ -381,6 +543,84 @@ func (_m *Persistence) ListListenersByCreateTime(ctx context.Context, after *fft
 	return r0, r1
 }
 
+// ListPendingSigners provides a mock function with given fields: ctx, after, limit
+func (_m *Persistence) ListPendingSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	ret := _m.Called(ctx, after, limit)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSignerTransactionsPending provides a mock function with given fields: ctx, signer, limit
+func (_m *Persistence) ListSignerTransactionsPending(ctx context.Context, signer string, limit int) ([]*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, signer, limit)
+
+	var r0 []*apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, signer, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*apitypes.ManagedTX); ok {
+		r0 = rf(ctx, signer, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, signer, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSigners provides a mock function with given fields: ctx, after, limit
+func (_m *Persistence) ListSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	ret := _m.Called(ctx, after, limit)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListStreamListenersByCreateTime provides a mock function with given fields: ctx, after, limit, dir, streamID
 func (_m *Persistence) ListStreamListenersByCreateTime(ctx context.Context, after *fftypes.UUID, limit int, dir txhandler.SortDirection, streamID *fftypes.UUID) ([]*apitypes.Listener, error) {
 	ret := _m.Called(ctx, after, limit, dir, streamID)
@@ -511,6 +751,32 @@ func (_m *Persistence) ListTransactionsPending(ctx context.Context, afterSequenc
 	return r0, r1
 }
 
+// ListTransactionsPendingByPriority provides a mock function with given fields: ctx, limit
+func (_m *Persistence) ListTransactionsPendingByPriority(ctx context.Context, limit int) ([]*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []*apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*apitypes.ManagedTX); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // RichQuery provides a mock function with given fields:
 func (_m *Persistence) RichQuery() persistence.RichQuery {
 	ret := _m.Called()
@@ -555,6 +821,20 @@ func (_m *Persistence) UpdateTransaction(ctx context.Context, txID string, updat
 	return r0
 }
 
+// WriteAddressListEntry provides a mock function with given fields: ctx, entry
+func (_m *Persistence) WriteAddressListEntry(ctx context.Context, entry *apitypes.AddressListEntry) error {
+	ret := _m.Called(ctx, entry)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *apitypes.AddressListEntry) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WriteCheckpoint provides a mock function with given fields: ctx, checkpoint
 func (_m *Persistence) WriteCheckpoint(ctx context.Context, checkpoint *apitypes.EventStreamCheckpoint) error {
 	ret := _m.Called(ctx, checkpoint)
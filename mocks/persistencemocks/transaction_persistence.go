@@ -42,6 +42,34 @@ func (_m *TransactionPersistence) AddTransactionConfirmations(ctx context.Contex
 	return r0
 }
 
+// AssignTransactionNextNonce provides a mock function with given fields: ctx, tx, lookupNextNonce
+func (_m *TransactionPersistence) AssignTransactionNextNonce(ctx context.Context, tx *apitypes.ManagedTX, lookupNextNonce txhandler.NextNonceCallback) error {
+	ret := _m.Called(ctx, tx, lookupNextNonce)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *apitypes.ManagedTX, txhandler.NextNonceCallback) error); ok {
+		r0 = rf(ctx, tx, lookupNextNonce)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClearCachedNonce provides a mock function with given fields: ctx, signer
+func (_m *TransactionPersistence) ClearCachedNonce(ctx context.Context, signer string) error {
+	ret := _m.Called(ctx, signer)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, signer)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // DeleteTransaction provides a mock function with given fields: ctx, txID
 func (_m *TransactionPersistence) DeleteTransaction(ctx context.Context, txID string) error {
 	ret := _m.Called(ctx, txID)
@@ -56,6 +84,32 @@ func (_m *TransactionPersistence) DeleteTransaction(ctx context.Context, txID st
 	return r0
 }
 
+// GetTransactionByHash provides a mock function with given fields: ctx, transactionHash
+func (_m *TransactionPersistence) GetTransactionByHash(ctx context.Context, transactionHash string) (*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, transactionHash)
+
+	var r0 *apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, transactionHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *apitypes.ManagedTX); ok {
+		r0 = rf(ctx, transactionHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, transactionHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTransactionByID provides a mock function with given fields: ctx, txID
 func (_m *TransactionPersistence) GetTransactionByID(ctx context.Context, txID string) (*apitypes.ManagedTX, error) {
 	ret := _m.Called(ctx, txID)
@@ -186,6 +240,32 @@ func (_m *TransactionPersistence) GetTransactionReceipt(ctx context.Context, txI
 	return r0, r1
 }
 
+// GetTransactionSubmittedHashes provides a mock function with given fields: ctx, txID
+func (_m *TransactionPersistence) GetTransactionSubmittedHashes(ctx context.Context, txID string) ([]*apitypes.SubmittedHash, error) {
+	ret := _m.Called(ctx, txID)
+
+	var r0 []*apitypes.SubmittedHash
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*apitypes.SubmittedHash, error)); ok {
+		return rf(ctx, txID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*apitypes.SubmittedHash); ok {
+		r0 = rf(ctx, txID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.SubmittedHash)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, txID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // InsertTransactionPreAssignedNonce provides a mock function with given fields: ctx, tx
 func (_m *TransactionPersistence) InsertTransactionPreAssignedNonce(ctx context.Context, tx *apitypes.ManagedTX) error {
 	ret := _m.Called(ctx, tx)
@@ -214,6 +294,100 @@ func (_m *TransactionPersistence) InsertTransactionWithNextNonce(ctx context.Con
 	return r0
 }
 
+// InsertTransactionsWithNextNonce provides a mock function with given fields: ctx, txs, lookupNextNonce
+func (_m *TransactionPersistence) InsertTransactionsWithNextNonce(ctx context.Context, txs []*apitypes.ManagedTX, lookupNextNonce txhandler.NextNonceCallback) []error {
+	ret := _m.Called(ctx, txs, lookupNextNonce)
+
+	var r0 []error
+	if rf, ok := ret.Get(0).(func(context.Context, []*apitypes.ManagedTX, txhandler.NextNonceCallback) []error); ok {
+		r0 = rf(ctx, txs, lookupNextNonce)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]error)
+		}
+	}
+
+	return r0
+}
+
+// ListPendingSigners provides a mock function with given fields: ctx, after, limit
+func (_m *TransactionPersistence) ListPendingSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	ret := _m.Called(ctx, after, limit)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSignerTransactionsPending provides a mock function with given fields: ctx, signer, limit
+func (_m *TransactionPersistence) ListSignerTransactionsPending(ctx context.Context, signer string, limit int) ([]*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, signer, limit)
+
+	var r0 []*apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, signer, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []*apitypes.ManagedTX); ok {
+		r0 = rf(ctx, signer, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, signer, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSigners provides a mock function with given fields: ctx, after, limit
+func (_m *TransactionPersistence) ListSigners(ctx context.Context, after string, limit int) ([]string, error) {
+	ret := _m.Called(ctx, after, limit)
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) ([]string, error)); ok {
+		return rf(ctx, after, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) []string); ok {
+		r0 = rf(ctx, after, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, after, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListTransactionsByCreateTime provides a mock function with given fields: ctx, after, limit, dir
 func (_m *TransactionPersistence) ListTransactionsByCreateTime(ctx context.Context, after *apitypes.ManagedTX, limit int, dir txhandler.SortDirection) ([]*apitypes.ManagedTX, error) {
 	ret := _m.Called(ctx, after, limit, dir)
@@ -292,6 +466,32 @@ func (_m *TransactionPersistence) ListTransactionsPending(ctx context.Context, a
 	return r0, r1
 }
 
+// ListTransactionsPendingByPriority provides a mock function with given fields: ctx, limit
+func (_m *TransactionPersistence) ListTransactionsPendingByPriority(ctx context.Context, limit int) ([]*apitypes.ManagedTX, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []*apitypes.ManagedTX
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]*apitypes.ManagedTX, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []*apitypes.ManagedTX); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*apitypes.ManagedTX)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SetTransactionReceipt provides a mock function with given fields: ctx, txID, receipt
 func (_m *TransactionPersistence) SetTransactionReceipt(ctx context.Context, txID string, receipt *ffcapi.TransactionReceiptResponse) error {
 	ret := _m.Called(ctx, txID, receipt)
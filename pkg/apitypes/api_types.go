@@ -254,6 +254,23 @@ type LiveGasPrice struct {
 	ffcapi.GasPriceEstimateResponse
 }
 
+// GasPriceHistoryEntry records a gas price obtained by a transaction handler, and the value each gas oracle source
+// contributed to it
+type GasPriceHistoryEntry struct {
+	Time        *fftypes.FFTime        `json:"time"`
+	Aggregation string                 `json:"aggregation,omitempty"`
+	GasPrice    *fftypes.JSONAny       `json:"gasPrice,omitempty"`
+	Sources     []*GasPriceSourceValue `json:"sources"`
+}
+
+// GasPriceSourceValue is the gas price returned by a single gas oracle source, or the error querying it
+type GasPriceSourceValue struct {
+	Source   string           `json:"source"`
+	GasPrice *fftypes.JSONAny `json:"gasPrice,omitempty"`
+	Cached   bool             `json:"cached,omitempty"`
+	Error    string           `json:"error,omitempty"`
+}
+
 // CheckUpdateString helper merges supplied configuration, with a base, and applies a default if unset
 func CheckUpdateString(changed bool, merged **string, old *string, new *string, defValue string) bool {
 	if new != nil {
@@ -427,3 +444,34 @@ type ConfirmationRecord struct {
 	TransactionID      string `json:"transaction"` // owning transaction
 	*Confirmation
 }
+
+type SubmittedHashRecord struct {
+	dbsql.ResourceBase        // default persistence headers for this micro object
+	TransactionID      string `json:"transaction"` // owning transaction
+	*SubmittedHash
+}
+
+type AddressListField string
+
+const (
+	AddressListFieldFrom AddressListField = "from"
+	AddressListFieldTo   AddressListField = "to"
+)
+
+type AddressListType string
+
+const (
+	AddressListAllow AddressListType = "allow"
+	AddressListDeny  AddressListType = "deny"
+)
+
+// AddressListEntry is a persisted entry on the allow or deny list for the signer (from) or
+// destination (to) address of new transactions. Once any allow entry exists for a field,
+// only the addresses on the allow list are accepted for that field.
+type AddressListEntry struct {
+	dbsql.ResourceBase
+	Field   AddressListField `json:"field"`
+	List    AddressListType  `json:"list"`
+	Address string           `json:"address"` // stored in lower case
+	Reason  string           `json:"reason,omitempty"`
+}
@@ -16,7 +16,16 @@
 
 package apitypes
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+)
 
 // BaseRequest is the common headers to all requests, and captures the full input payload for later decoding to a specific type
 type BaseRequest struct {
@@ -38,8 +47,52 @@ func (br *BaseRequest) UnmarshalTo(o interface{}) error {
 }
 
 type RequestHeaders struct {
-	ID   string      `ffstruct:"fftmrequest" json:"id"`
-	Type RequestType `json:"type"`
+	ID        string      `ffstruct:"fftmrequest" json:"id"`
+	Type      RequestType `json:"type"`
+	Expiry    string      `json:"expiry,omitempty"`    // give up on the transaction if it is not mined by this time - an absolute time, or a duration such as "10m"
+	NotBefore string      `json:"notBefore,omitempty"` // do not submit the transaction before this time (an RFC3339 timestamp, or a duration) or block number
+	DryRun    bool        `json:"dryRun,omitempty"`    // simulate the transaction against the connector, without persisting it or assigning a nonce
+	Priority  int         `json:"priority,omitempty"`  // higher priority transactions are submitted ahead of others, but never ahead of an earlier nonce from the same signer
+	DependsOn []string    `json:"dependsOn,omitempty"` // IDs of transactions that must succeed before this one is assigned a nonce and submitted
+}
+
+// ExpiryTime resolves the expiry header to an absolute time, or nil if no expiry was requested
+func (rh *RequestHeaders) ExpiryTime(ctx context.Context) (*fftypes.FFTime, error) {
+	if rh.Expiry == "" {
+		return nil, nil
+	}
+	if d, err := time.ParseDuration(rh.Expiry); err == nil {
+		expiry := fftypes.FFTime(time.Now().Add(d))
+		return &expiry, nil
+	}
+	expiry, err := fftypes.ParseTimeString(rh.Expiry)
+	if err != nil {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidTransactionExpiry, rh.Expiry)
+	}
+	return expiry, nil
+}
+
+// NotBeforeCondition resolves the notBefore header to either a time or a block number. An integer (decimal or 0x prefixed hex)
+// is always a block number, so a time must be supplied as an RFC3339 timestamp, or a duration from now.
+func (rh *RequestHeaders) NotBeforeCondition(ctx context.Context) (notBefore *fftypes.FFTime, notBeforeBlock *fftypes.FFBigInt, err error) {
+	if rh.NotBefore == "" {
+		return nil, nil, nil
+	}
+	if i, ok := new(big.Int).SetString(rh.NotBefore, 0); ok {
+		if i.Sign() < 0 {
+			return nil, nil, i18n.NewError(ctx, tmmsgs.MsgInvalidTransactionNotBefore, rh.NotBefore)
+		}
+		return nil, (*fftypes.FFBigInt)(i), nil
+	}
+	if d, err := time.ParseDuration(rh.NotBefore); err == nil {
+		t := fftypes.FFTime(time.Now().Add(d))
+		return &t, nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, rh.NotBefore)
+	if err != nil {
+		return nil, nil, i18n.NewError(ctx, tmmsgs.MsgInvalidTransactionNotBefore, rh.NotBefore)
+	}
+	return (*fftypes.FFTime)(&t), nil, nil
 }
 
 type RequestType string
@@ -17,8 +17,10 @@
 package apitypes
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
@@ -56,3 +58,61 @@ func TestBaseRequestDecoding(t *testing.T) {
 	assert.Equal(t, "0x12345", receivedRequest.TransactionInput.From)
 
 }
+
+func TestRequestHeadersExpiryTime(t *testing.T) {
+	ctx := context.Background()
+
+	expiry, err := (&RequestHeaders{}).ExpiryTime(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, expiry)
+
+	before := time.Now()
+	expiry, err = (&RequestHeaders{Expiry: "10m"}).ExpiryTime(ctx)
+	assert.NoError(t, err)
+	assert.False(t, expiry.Time().Before(before.Add(10*time.Minute)))
+
+	expiry, err = (&RequestHeaders{Expiry: "2024-01-02T03:04:05Z"}).ExpiryTime(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1704164645), expiry.Time().Unix())
+
+	expiry, err = (&RequestHeaders{Expiry: "1704164645"}).ExpiryTime(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1704164645), expiry.Time().Unix())
+
+	_, err = (&RequestHeaders{Expiry: "next tuesday"}).ExpiryTime(ctx)
+	assert.Regexp(t, "FF21091", err)
+}
+
+func TestRequestHeadersNotBeforeCondition(t *testing.T) {
+	ctx := context.Background()
+
+	notBefore, notBeforeBlock, err := (&RequestHeaders{}).NotBeforeCondition(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, notBefore)
+	assert.Nil(t, notBeforeBlock)
+
+	notBefore, notBeforeBlock, err = (&RequestHeaders{NotBefore: "12345"}).NotBeforeCondition(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, notBefore)
+	assert.Equal(t, int64(12345), notBeforeBlock.Int64())
+
+	_, notBeforeBlock, err = (&RequestHeaders{NotBefore: "0x3039"}).NotBeforeCondition(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12345), notBeforeBlock.Int64())
+
+	before := time.Now()
+	notBefore, notBeforeBlock, err = (&RequestHeaders{NotBefore: "1h"}).NotBeforeCondition(ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, notBeforeBlock)
+	assert.False(t, notBefore.Time().Before(before.Add(1*time.Hour)))
+
+	notBefore, _, err = (&RequestHeaders{NotBefore: "2024-01-02T03:04:05Z"}).NotBeforeCondition(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1704164645), notBefore.Time().Unix())
+
+	_, _, err = (&RequestHeaders{NotBefore: "-1"}).NotBeforeCondition(ctx)
+	assert.Regexp(t, "FF21094", err)
+
+	_, _, err = (&RequestHeaders{NotBefore: "tomorrow"}).NotBeforeCondition(ctx)
+	assert.Regexp(t, "FF21094", err)
+}
@@ -36,6 +36,8 @@ const (
 	TxStatusFailed TxStatus = "Failed"
 	// TxStatusSuspended indicates we are not actively doing any work with this transaction right now, until it's resumed to pending again
 	TxStatusSuspended TxStatus = "Suspended"
+	// TxStatusAwaitingApproval indicates the transaction is held without a nonce, and will not be submitted until it is explicitly approved
+	TxStatusAwaitingApproval TxStatus = "AwaitingApproval"
 )
 
 // TxSubStatus is an intermediate status a transaction may go through
@@ -52,6 +54,14 @@ const (
 	TxSubStatusConfirmed TxSubStatus = "Confirmed"
 	// TxSubStatusFailed indicates we have failed to process the transaction and it will no longer be tracked
 	TxSubStatusFailed TxSubStatus = "Failed"
+	// TxSubStatusAwaitingGasPrice indicates submission is held back, as the gas price or the fee exceeds a configured limit
+	TxSubStatusAwaitingGasPrice TxSubStatus = "AwaitingGasPrice"
+	// TxSubStatusAwaitingFunds indicates submission is held back, as the signer is paused until its balance covers the cost of a transaction
+	TxSubStatusAwaitingFunds TxSubStatus = "AwaitingFunds"
+	// TxSubStatusAwaitingDependencies indicates the transaction is held without a nonce, until the transactions it depends on have succeeded
+	TxSubStatusAwaitingDependencies TxSubStatus = "AwaitingDependencies"
+	// TxSubStatusAwaitingPolicy indicates the first submission is held back, as the policy hook deferred its decision
+	TxSubStatusAwaitingPolicy TxSubStatus = "AwaitingPolicy"
 )
 
 // TxHistoryStateTransitionEntry represents a state that the policy engine that manages transaction submission has entered,
@@ -85,12 +95,42 @@ const (
 	TxActionRetrieveGasPrice TxAction = "RetrieveGasPrice"
 	// TxActionTimeout indicates that the transaction has timed out may need intervention to progress it
 	TxActionTimeout TxAction = "Timeout"
+	// TxActionSignTransaction indicates that the transaction has been signed ahead of submission, to record the hash we expect
+	TxActionSignTransaction TxAction = "SignTransaction"
 	// TxActionSubmitTransaction indicates that the transaction has been submitted
 	TxActionSubmitTransaction TxAction = "SubmitTransaction"
 	// TxActionReceiveReceipt indicates that we have received a receipt for the transaction
 	TxActionReceiveReceipt TxAction = "ReceiveReceipt"
 	// TxActionConfirmTransaction indicates that the transaction has been confirmed
 	TxActionConfirmTransaction TxAction = "Confirm"
+	// TxActionSubmitCancelReplacement indicates that a replacement transaction has been submitted at the same nonce, to cancel the transaction
+	TxActionSubmitCancelReplacement TxAction = "SubmitCancelReplacement"
+	// TxActionExpired indicates that the expiry time requested for the transaction passed before it was mined
+	TxActionExpired TxAction = "Expired"
+	// TxActionSpendLimitReached indicates that submission was held back, as it would exceed a gas price or fee limit
+	TxActionSpendLimitReached TxAction = "SpendLimitReached"
+	// TxActionSignerPaused indicates that submission was held back, as the signer is paused after a rejection for insufficient funds
+	TxActionSignerPaused TxAction = "SignerPaused"
+	// TxActionSignerResumed indicates that the balance of a paused signer now covers the cost of the transaction that paused it
+	TxActionSignerResumed TxAction = "SignerResumed"
+	// TxActionAwaitingDependency indicates that nonce assignment was held back, as a transaction this one depends on has not yet succeeded
+	TxActionAwaitingDependency TxAction = "AwaitingDependency"
+	// TxActionDependencyFailed indicates that a transaction this one depends on did not succeed, so this transaction will not be submitted
+	TxActionDependencyFailed TxAction = "DependencyFailed"
+	// TxActionRetry indicates that the transaction was created as a new attempt at a failed transaction
+	TxActionRetry TxAction = "Retry"
+	// TxActionRetried indicates that a new attempt at this failed transaction has been created
+	TxActionRetried TxAction = "Retried"
+	// TxActionSpeedUp indicates that an operator requested the transaction be resubmitted immediately at a higher gas price
+	TxActionSpeedUp TxAction = "SpeedUp"
+	// TxActionTrackExternal indicates that the transaction was submitted outside of the transaction manager, and adopted for tracking
+	TxActionTrackExternal TxAction = "TrackExternal"
+	// TxActionApprove indicates that a transaction awaiting approval was approved for submission
+	TxActionApprove TxAction = "Approve"
+	// TxActionReject indicates that a transaction awaiting approval was rejected, so will not be submitted
+	TxActionReject TxAction = "Reject"
+	// TxActionPolicyDecision indicates that the policy hook was asked whether the transaction can be submitted, recording its answer
+	TxActionPolicyDecision TxAction = "PolicyDecision"
 )
 
 // An action taken in order to progress a transaction, e.g. retrieve gas price from an oracle.
@@ -145,12 +185,20 @@ func (r *TXHistoryRecord) SetUpdated(_ *fftypes.FFTime) {}
 //   - When listing back entries, the persistence layer will automatically clean up indexes if the underlying
 //     TX they refer to is not available. For this reason the index records are written first.
 type ManagedTX struct {
-	ID              string          `json:"id"`
-	Created         *fftypes.FFTime `json:"created"`
-	Updated         *fftypes.FFTime `json:"updated"`
-	Status          TxStatus        `json:"status"`
-	DeleteRequested *fftypes.FFTime `json:"deleteRequested,omitempty"`
-	SequenceID      string          `json:"sequenceId,omitempty"`
+	ID              string                `json:"id"`
+	Created         *fftypes.FFTime       `json:"created"`
+	Updated         *fftypes.FFTime       `json:"updated"`
+	Status          TxStatus              `json:"status"`
+	DeleteRequested *fftypes.FFTime       `json:"deleteRequested,omitempty"`
+	SequenceID      string                `json:"sequenceId,omitempty"`
+	Expiry          *fftypes.FFTime       `json:"expiry,omitempty"`
+	NotBefore       *fftypes.FFTime       `json:"notBefore,omitempty"`
+	NotBeforeBlock  *fftypes.FFBigInt     `json:"notBeforeBlock,omitempty"`
+	Priority        int                   `json:"priority,omitempty"`
+	DependsOn       fftypes.FFStringArray `json:"dependsOn,omitempty"`
+	RetryOf         string                `json:"retryOf,omitempty"`      // the failed transaction this is a new attempt at
+	RetriedBy       string                `json:"retriedBy,omitempty"`    // the new attempt at this transaction, once it has failed
+	SupersededBy    string                `json:"supersededBy,omitempty"` // the transaction that filled the nonce of this one, as it was never mined
 	ffcapi.TransactionHeaders
 	GasPrice                     *fftypes.JSONAny           `json:"gasPrice"`
 	TransactionData              string                     `json:"transactionData"`
@@ -206,6 +254,8 @@ type TXUpdates struct {
 	FirstSubmit     *fftypes.FFTime   `json:"firstSubmit,omitempty"`
 	LastSubmit      *fftypes.FFTime   `json:"lastSubmit,omitempty"`
 	ErrorMessage    *string           `json:"errorMessage,omitempty"`
+	RetriedBy       *string           `json:"retriedBy,omitempty"`
+	SupersededBy    *string           `json:"supersededBy,omitempty"` // also releases the nonce of the transaction, for the one that supersedes it
 }
 
 func (txu *TXUpdates) Merge(txu2 *TXUpdates) {
@@ -251,6 +301,38 @@ func (txu *TXUpdates) Merge(txu2 *TXUpdates) {
 	if txu2.ErrorMessage != nil {
 		txu.ErrorMessage = txu2.ErrorMessage
 	}
+	if txu2.RetriedBy != nil {
+		txu.RetriedBy = txu2.RetriedBy
+	}
+	if txu2.SupersededBy != nil {
+		txu.SupersededBy = txu2.SupersededBy
+	}
+}
+
+// RetryTransactionRequest is the input to retry a failed transaction as a new attempt, with a fresh nonce.
+// The transaction data of the failed transaction is re-used, unless a method is supplied to prepare it again.
+type RetryTransactionRequest struct {
+	ID     string             `json:"id,omitempty"`     // the ID of the new transaction - generated if not supplied
+	Gas    *fftypes.FFBigInt  `json:"gas,omitempty"`    // the gas limit for the new transaction, instead of the one the failed transaction was prepared with
+	Method *fftypes.JSONAny   `json:"method,omitempty"` // prepare the transaction again with this method and params, re-estimating the gas if it is not supplied
+	Params []*fftypes.JSONAny `json:"params,omitempty"`
+	Errors []*fftypes.JSONAny `json:"errors,omitempty"`
+}
+
+// SpeedUpTransactionRequest is the input to resubmit a pending transaction at the same nonce with a higher gas price.
+// Exactly one of an explicit gas price, or a percentage to increase the gas price of the last submission by, must be supplied.
+type SpeedUpTransactionRequest struct {
+	GasPrice   *fftypes.JSONAny `json:"gasPrice,omitempty"`   // the gas price to resubmit with, in the format the connector accepts
+	Percentage float64          `json:"percentage,omitempty"` // the percentage to increase each numeric field of the gas price by
+}
+
+// TrackTransactionRequest is the input to adopt a transaction that was submitted by other tooling, so that its receipt,
+// confirmations and websocket replies are handled in the same way as a transaction submitted by the transaction manager.
+type TrackTransactionRequest struct {
+	ID              string            `json:"id,omitempty"`    // the ID of the managed transaction - generated if not supplied
+	TransactionHash string            `json:"transactionHash"` // the hash the transaction was submitted with
+	From            string            `json:"from"`            // the signer that submitted the transaction
+	Nonce           *fftypes.FFBigInt `json:"nonce"`           // the nonce the transaction was submitted with
 }
 
 // TXWithStatus is a convenience object that fetches all data about a transaction into one
@@ -262,6 +344,23 @@ type TXWithStatus struct {
 	Confirmations            []*Confirmation                    `json:"confirmations,omitempty"`
 	DeprecatedHistorySummary []*TxHistorySummaryEntry           `json:"historySummary,omitempty"` // LevelDB only: maintains a summary to retain data while limiting single JSON payload size
 	History                  []*TxHistoryStateTransitionEntry   `json:"history,omitempty"`
+	SubmittedHashes          []*SubmittedHash                   `json:"submittedHashes,omitempty"`
+	Dependencies             []*TXDependency                    `json:"dependencies,omitempty"` // API only: the transactions this one depends on, directly or indirectly
+}
+
+// SubmittedHash is a transaction hash that a managed transaction has been submitted with. Each resubmission at a
+// different gas price results in a new hash, and any one of them might be the one that is mined.
+type SubmittedHash struct {
+	TransactionHash string          `json:"transactionHash"`
+	Submitted       *fftypes.FFTime `json:"submitted"`
+}
+
+// TXDependency is an entry in the dependency graph of a transaction, giving the current status of a
+// prerequisite transaction and the transactions it depends on in turn
+type TXDependency struct {
+	ID        string                `json:"id"`
+	Status    TxStatus              `json:"status,omitempty"` // empty if the transaction no longer exists
+	DependsOn fftypes.FFStringArray `json:"dependsOn,omitempty"`
 }
 
 func (mtx *ManagedTX) Namespace(ctx context.Context) string {
@@ -103,6 +103,8 @@ func TestTXUpdatesMerge(t *testing.T) {
 		FirstSubmit:     fftypes.Now(),
 		LastSubmit:      fftypes.Now(),
 		ErrorMessage:    ptrTo("pop"),
+		RetriedBy:       ptrTo("zzzz"),
+		SupersededBy:    ptrTo("wwww"),
 	}
 	txu.Merge(txu2)
 	assert.Equal(t, *txu2, *txu)
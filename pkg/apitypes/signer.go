@@ -0,0 +1,73 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apitypes
+
+import (
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+)
+
+// SignerNonces compares the nonces allocated to transactions for a signer, with the view of the blockchain.
+// Any nonce from the chain's next nonce, up to the highest allocated nonce, that does not have a transaction
+// that can still be mined is a gap - and will stop all later transactions from that signer being mined.
+type SignerNonces struct {
+	Signer         string              `json:"signer"`
+	ChainNextNonce *fftypes.FFBigInt   `json:"chainNextNonce"` // the next nonce according to the blockchain connector
+	PendingNonces  []*fftypes.FFBigInt `json:"pendingNonces"`  // pending transactions with a nonce the chain has not yet consumed
+	Gaps           []*fftypes.FFBigInt `json:"gaps"`           // nonces with no transaction that can be mined, that are blocking the pending nonces
+}
+
+// SignerStatus summarizes the state of the transactions being managed for a signer
+type SignerStatus struct {
+	Signer         string            `json:"signer"`
+	NextNonce      *fftypes.FFBigInt `json:"nextNonce"`               // the nonce that will be assigned to the next transaction
+	ChainNextNonce *fftypes.FFBigInt `json:"chainNextNonce"`          // the next nonce according to the blockchain connector
+	PendingCount   int               `json:"pendingCount"`            // the number of transactions in pending state
+	OldestPending  *ManagedTX        `json:"oldestPending,omitempty"` // the first pending transaction to have been received
+	LastSubmit     *fftypes.FFTime   `json:"lastSubmit,omitempty"`    // the most recent submission of a transaction to the blockchain
+	Paused         *SignerPause      `json:"paused,omitempty"`        // set while the transaction handler is holding back submissions for the signer
+}
+
+// SignerPause is reported while submission is paused for a signer, because the node rejected one of its transactions
+// for insufficient funds. The balance of the signer is checked with a backoff, until it covers the estimated cost.
+type SignerPause struct {
+	Reason           string            `json:"reason"`
+	Since            *fftypes.FFTime   `json:"since"`
+	TransactionID    string            `json:"transactionId"`             // the transaction that was rejected
+	RequiredBalance  *fftypes.FFBigInt `json:"requiredBalance,omitempty"` // the estimated cost of the transaction, if known
+	LastBalance      *fftypes.FFBigInt `json:"lastBalance,omitempty"`
+	LastBalanceCheck *fftypes.FFTime   `json:"lastBalanceCheck,omitempty"`
+	NextBalanceCheck *fftypes.FFTime   `json:"nextBalanceCheck"`
+}
+
+// SignerResyncRequest is the request body to flush the cached nonce state for a signer
+type SignerResyncRequest struct {
+	Renumber bool `json:"renumber"` // move pending transactions that have not been submitted onto the next nonce from the chain
+}
+
+// SignerResyncResult is the result of flushing the cached nonce state for a signer
+type SignerResyncResult struct {
+	Signer         string                   `json:"signer"`
+	ChainNextNonce *fftypes.FFBigInt        `json:"chainNextNonce"`       // the next nonce according to the blockchain connector
+	Renumbered     []*RenumberedTransaction `json:"renumbered,omitempty"` // pending transactions that were assigned a new nonce
+}
+
+// RenumberedTransaction records a pending transaction that was moved to a new nonce
+type RenumberedTransaction struct {
+	ID            string            `json:"id"`
+	PreviousNonce *fftypes.FFBigInt `json:"previousNonce"`
+	Nonce         *fftypes.FFBigInt `json:"nonce"`
+}
@@ -17,6 +17,7 @@
 package apitypes
 
 import (
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
 )
 
@@ -31,3 +32,21 @@ type ContractDeployRequest struct {
 	Headers RequestHeaders `json:"headers"`
 	ffcapi.ContractDeployPrepareRequest
 }
+
+// BatchResult is the outcome of one request in a batch. When the request fails, the error and submissionRejected
+// fields are set with the same meaning as for a failed individual submission.
+type BatchResult struct {
+	ID          string     `json:"id,omitempty"`
+	Transaction *ManagedTX `json:"transaction,omitempty"`
+	*ffcapi.SubmissionError
+}
+
+// DryRunResult is the outcome of simulating a transaction, without submitting it
+type DryRunResult struct {
+	Gas          *fftypes.FFBigInt `json:"gas,omitempty"`
+	GasPrice     *fftypes.JSONAny  `json:"gasPrice,omitempty"`
+	EstimatedFee *fftypes.FFBigInt `json:"estimatedFee,omitempty"` // gas multiplied by the gas price (or the maxFeePerGas, as the worst case for EIP-1559), if numeric
+	Outputs      *fftypes.JSONAny  `json:"outputs,omitempty"`
+	Reverted     bool              `json:"reverted"`
+	RevertReason string            `json:"revertReason,omitempty"`
+}
@@ -0,0 +1,45 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ffcapi
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+)
+
+// TransactionSigner is an optional capability of a connector, to sign a transaction without submitting it.
+// When available, the transaction hash is known before the transaction is sent to the node - so a policy engine
+// can track the transaction even if the response to the TransactionSend is lost.
+// For example using `eth_signTransaction` for EVM JSON/RPC.
+type TransactionSigner interface {
+	// TransactionSign combines a previously prepared encoded transaction with a current gas price, and signs it without submission
+	TransactionSign(ctx context.Context, req *TransactionSignRequest) (*TransactionSignResponse, ErrorReason, error)
+}
+
+// TransactionSignRequest has the same inputs as a TransactionSendRequest, as the signed payload
+// returned can be submitted with TransactionSend using PreSigned
+type TransactionSignRequest struct {
+	GasPrice *fftypes.JSONAny `json:"gasPrice,omitempty"` // can be a simple string/number, or a complex object - contract is between policy engine and blockchain connector
+	TransactionHeaders
+	TransactionData string `json:"transactionData"`
+}
+
+type TransactionSignResponse struct {
+	SignedTransaction string `json:"signedTransaction"`
+	TransactionHash   string `json:"transactionHash"`
+}
@@ -0,0 +1,152 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+// addressLists is an immutable in-memory snapshot of the persisted address list entries,
+// replaced as a whole each time the lists are reloaded
+type addressLists struct {
+	allow map[apitypes.AddressListField]map[string]bool
+	deny  map[apitypes.AddressListField]map[string]bool
+}
+
+func newAddressLists(entries []*apitypes.AddressListEntry) *addressLists {
+	al := &addressLists{
+		allow: map[apitypes.AddressListField]map[string]bool{},
+		deny:  map[apitypes.AddressListField]map[string]bool{},
+	}
+	for _, e := range entries {
+		lists := al.deny
+		if e.List == apitypes.AddressListAllow {
+			lists = al.allow
+		}
+		if lists[e.Field] == nil {
+			lists[e.Field] = map[string]bool{}
+		}
+		lists[e.Field][strings.ToLower(e.Address)] = true
+	}
+	return al
+}
+
+func (al *addressLists) screen(ctx context.Context, field apitypes.AddressListField, address string) error {
+	address = strings.ToLower(address)
+	if al.deny[field][address] {
+		return i18n.NewError(ctx, tmmsgs.MsgAddressDenied, field, address)
+	}
+	if allowed := al.allow[field]; len(allowed) > 0 && !allowed[address] {
+		return i18n.NewError(ctx, tmmsgs.MsgAddressNotAllowed, field, address)
+	}
+	return nil
+}
+
+// ScreenAddresses implements txhandler.AddressScreener, against the lists last loaded from persistence
+func (m *manager) ScreenAddresses(ctx context.Context, from, to string) error {
+	m.addressListsMux.RLock()
+	lists := m.addressLists
+	m.addressListsMux.RUnlock()
+	if lists == nil {
+		return nil
+	}
+	if err := lists.screen(ctx, apitypes.AddressListFieldFrom, from); err != nil {
+		return err
+	}
+	if to != "" {
+		return lists.screen(ctx, apitypes.AddressListFieldTo, to)
+	}
+	return nil
+}
+
+// reloadAddressLists reads every address list entry from persistence, and swaps them in for screening
+func (m *manager) reloadAddressLists(ctx context.Context) ([]*apitypes.AddressListEntry, error) {
+	entries := []*apitypes.AddressListEntry{}
+	var lastInPage *fftypes.UUID
+	for {
+		page, err := m.persistence.ListAddressListEntriesByCreateTime(ctx, lastInPage, startupPaginationLimit, txhandler.SortDirectionAscending)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		lastInPage = page[len(page)-1].ID
+		entries = append(entries, page...)
+	}
+	lists := newAddressLists(entries)
+	m.addressListsMux.Lock()
+	m.addressLists = lists
+	m.addressListsMux.Unlock()
+	log.L(ctx).Infof("Loaded %d address list entries", len(entries))
+	return entries, nil
+}
+
+func (m *manager) getAddressListEntries(ctx context.Context, afterStr, limitStr string) ([]*apitypes.AddressListEntry, error) {
+	after, limit, err := m.parseAfterAndLimit(ctx, afterStr, limitStr)
+	if err != nil {
+		return nil, err
+	}
+	return m.persistence.ListAddressListEntriesByCreateTime(ctx, after, limit, txhandler.SortDirectionDescending)
+}
+
+func (m *manager) createAddressListEntry(ctx context.Context, entry *apitypes.AddressListEntry) (*apitypes.AddressListEntry, error) {
+	switch {
+	case entry.Field != apitypes.AddressListFieldFrom && entry.Field != apitypes.AddressListFieldTo,
+		entry.List != apitypes.AddressListAllow && entry.List != apitypes.AddressListDeny,
+		entry.Address == "":
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidAddressListEntry)
+	}
+	entry.ID = apitypes.NewULID()
+	entry.Address = strings.ToLower(entry.Address)
+	entry.Created = fftypes.Now()
+	entry.Updated = entry.Created
+	if err := m.persistence.WriteAddressListEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	if _, err := m.reloadAddressLists(ctx); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (m *manager) deleteAddressListEntry(ctx context.Context, idStr string) error {
+	id, err := fftypes.ParseUUID(ctx, idStr)
+	if err != nil {
+		return err
+	}
+	entry, err := m.persistence.GetAddressListEntry(ctx, id)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return i18n.NewError(ctx, tmmsgs.MsgAddressListEntryNotFound, id)
+	}
+	if err := m.persistence.DeleteAddressListEntry(ctx, id); err != nil {
+		return err
+	}
+	_, err = m.reloadAddressLists(ctx)
+	return err
+}
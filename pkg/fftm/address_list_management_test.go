@@ -0,0 +1,191 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testAddressListEntry(field apitypes.AddressListField, list apitypes.AddressListType, address string) *apitypes.AddressListEntry {
+	return &apitypes.AddressListEntry{
+		ResourceBase: dbsql.ResourceBase{ID: apitypes.NewULID()},
+		Field:        field,
+		List:         list,
+		Address:      address,
+	}
+}
+
+func TestScreenAddresses(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	// Nothing is screened before the lists are loaded
+	assert.NoError(t, m.ScreenAddresses(m.ctx, "0xaaaa", "0xbbbb"))
+
+	m.addressLists = newAddressLists([]*apitypes.AddressListEntry{
+		testAddressListEntry(apitypes.AddressListFieldFrom, apitypes.AddressListAllow, "0xAAAA"),
+		testAddressListEntry(apitypes.AddressListFieldFrom, apitypes.AddressListAllow, "0xcccc"),
+		testAddressListEntry(apitypes.AddressListFieldFrom, apitypes.AddressListDeny, "0xcccc"),
+		testAddressListEntry(apitypes.AddressListFieldTo, apitypes.AddressListDeny, "0xdddd"),
+	})
+
+	assert.NoError(t, m.ScreenAddresses(m.ctx, "0xaaaa", "0xbbbb"))
+	assert.NoError(t, m.ScreenAddresses(m.ctx, "0xAaAa", ""))
+	assert.Regexp(t, "FF21127.*from.*0xbbbb", m.ScreenAddresses(m.ctx, "0xbbbb", ""))
+	assert.Regexp(t, "FF21126.*from.*0xcccc", m.ScreenAddresses(m.ctx, "0xcccc", ""))
+	assert.Regexp(t, "FF21126.*to.*0xdddd", m.ScreenAddresses(m.ctx, "0xaaaa", "0xDDDD"))
+}
+
+func TestReloadAddressListsPaginates(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	e1 := testAddressListEntry(apitypes.AddressListFieldTo, apitypes.AddressListDeny, "0xaaaa")
+	e2 := testAddressListEntry(apitypes.AddressListFieldTo, apitypes.AddressListDeny, "0xbbbb")
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListAddressListEntriesByCreateTime", m.ctx, (*fftypes.UUID)(nil), startupPaginationLimit, txhandler.SortDirectionAscending).
+		Return([]*apitypes.AddressListEntry{e1}, nil).Once()
+	mp.On("ListAddressListEntriesByCreateTime", m.ctx, e1.ID, startupPaginationLimit, txhandler.SortDirectionAscending).
+		Return([]*apitypes.AddressListEntry{e2}, nil).Once()
+	mp.On("ListAddressListEntriesByCreateTime", m.ctx, e2.ID, startupPaginationLimit, txhandler.SortDirectionAscending).
+		Return([]*apitypes.AddressListEntry{}, nil).Once()
+
+	entries, err := m.reloadAddressLists(m.ctx)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Regexp(t, "FF21126", m.ScreenAddresses(m.ctx, "0x1111", "0xbbbb"))
+
+	mp.AssertExpectations(t)
+}
+
+func TestReloadAddressListsFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListAddressListEntriesByCreateTime", m.ctx, (*fftypes.UUID)(nil), startupPaginationLimit, txhandler.SortDirectionAscending).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := m.reloadAddressLists(m.ctx)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, m.addressLists)
+
+	mp.AssertExpectations(t)
+}
+
+func TestGetAddressListEntriesBadLimit(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	_, err := m.getAddressListEntries(m.ctx, "", "wrong")
+	assert.Regexp(t, "FF21044", err)
+}
+
+func TestCreateAddressListEntryWriteFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("WriteAddressListEntry", m.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := m.createAddressListEntry(m.ctx, &apitypes.AddressListEntry{
+		Field:   apitypes.AddressListFieldFrom,
+		List:    apitypes.AddressListAllow,
+		Address: "0xaaaa",
+	})
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestCreateAddressListEntryReloadFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("WriteAddressListEntry", m.ctx, mock.Anything).Return(nil)
+	mp.On("ListAddressListEntriesByCreateTime", m.ctx, mock.Anything, startupPaginationLimit, txhandler.SortDirectionAscending).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := m.createAddressListEntry(m.ctx, &apitypes.AddressListEntry{
+		Field:   apitypes.AddressListFieldTo,
+		List:    apitypes.AddressListDeny,
+		Address: "0xaaaa",
+	})
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestCreateAddressListEntryInvalid(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	for _, e := range []*apitypes.AddressListEntry{
+		{Field: "value", List: apitypes.AddressListAllow, Address: "0xaaaa"},
+		{Field: apitypes.AddressListFieldFrom, List: "block", Address: "0xaaaa"},
+		{Field: apitypes.AddressListFieldFrom, List: apitypes.AddressListAllow},
+	} {
+		_, err := m.createAddressListEntry(m.ctx, e)
+		assert.Regexp(t, "FF21125", err)
+	}
+}
+
+func TestDeleteAddressListEntryBadID(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	err := m.deleteAddressListEntry(m.ctx, "bad")
+	assert.Regexp(t, "FF00138", err)
+}
+
+func TestDeleteAddressListEntryGetFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("GetAddressListEntry", m.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := m.deleteAddressListEntry(m.ctx, fftypes.NewUUID().String())
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestDeleteAddressListEntryDeleteFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	e := testAddressListEntry(apitypes.AddressListFieldTo, apitypes.AddressListDeny, "0xaaaa")
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("GetAddressListEntry", m.ctx, e.ID).Return(e, nil)
+	mp.On("DeleteAddressListEntry", m.ctx, e.ID).Return(fmt.Errorf("pop"))
+
+	err := m.deleteAddressListEntry(m.ctx, e.ID.String())
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
@@ -405,3 +405,65 @@ func TestNotFound(t *testing.T) {
 	assert.Equal(t, 404, res.StatusCode())
 	assert.Regexp(t, "FF00167", errRes.Error)
 }
+
+func TestSendTransactionDryRun(t *testing.T) {
+
+	url, m, cancel := newTestManager(t)
+	defer cancel()
+
+	mFFC := m.connector.(*ffcapimocks.API)
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		Gas: fftypes.NewFFBigInt(1000000),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasEstimateResponse{
+		GasEstimate: fftypes.NewFFBigInt(21000),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`"0x3b9aca00"`),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("QueryInvoke", mock.Anything, mock.Anything).Return(&ffcapi.QueryInvokeResponse{
+		Outputs: fftypes.JSONAnyPtr(`[]`),
+	}, ffcapi.ErrorReason(""), nil)
+
+	m.Start()
+
+	req := strings.NewReader(strings.Replace(sampleSendTX, `"type": "SendTransaction"`, `"type": "SendTransaction", "dryRun": true`, 1))
+	var dryRunRes apitypes.DryRunResult
+	res, err := resty.New().R().
+		SetBody(req).
+		SetResult(&dryRunRes).
+		Post(url)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.False(t, dryRunRes.Reverted)
+	assert.Equal(t, int64(21000), dryRunRes.Gas.Int64())
+	assert.Equal(t, int64(21000000000000), dryRunRes.EstimatedFee.Int64())
+
+	// Nothing was persisted
+	txs, err := m.persistence.ListTransactionsByCreateTime(m.ctx, nil, 0, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, txs)
+
+	mFFC.AssertExpectations(t)
+
+}
+
+func TestDeployContractDryRunRejected(t *testing.T) {
+
+	url, m, cancel := newTestManager(t)
+	defer cancel()
+
+	m.Start()
+
+	req := strings.NewReader(strings.Replace(sampleDeployTX, `"type": "DeployContract"`, `"type": "DeployContract", "dryRun": true`, 1))
+	var errRes ffcapi.SubmissionError
+	res, err := resty.New().R().
+		SetBody(req).
+		SetError(&errRes).
+		Post(url)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.True(t, errRes.SubmissionRejected)
+	assert.Regexp(t, "FF21096", errRes.Error)
+
+}
@@ -0,0 +1,102 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+func (m *manager) submitBatch(ctx context.Context, requests []*apitypes.BaseRequest) ([]*apitypes.BatchResult, error) {
+	if len(requests) == 0 {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgEmptyBatch)
+	}
+
+	// Requests that cannot be decoded fail individually, without affecting the rest of the batch
+	items := make([]*txhandler.NewTransactionBatchItem, len(requests))
+	toSubmit := make([]*txhandler.NewTransactionBatchItem, 0, len(requests))
+	for i, baseReq := range requests {
+		items[i] = decodeBatchItem(ctx, baseReq)
+		if items[i].Err == nil {
+			toSubmit = append(toSubmit, items[i])
+		}
+	}
+
+	if len(toSubmit) > 0 {
+		if bth, ok := m.txHandler.(txhandler.BatchTransactionHandler); ok {
+			bth.HandleNewTransactionBatch(ctx, toSubmit)
+		} else {
+			for _, item := range toSubmit {
+				if item.TransactionRequest != nil {
+					item.ManagedTX, item.SubmissionRejected, item.Err = m.txHandler.HandleNewTransaction(ctx, item.TransactionRequest)
+				} else {
+					item.ManagedTX, item.SubmissionRejected, item.Err = m.txHandler.HandleNewContractDeployment(ctx, item.ContractDeployRequest)
+				}
+			}
+		}
+	}
+
+	results := make([]*apitypes.BatchResult, len(items))
+	for i, item := range items {
+		results[i] = &apitypes.BatchResult{
+			ID: requests[i].Headers.ID,
+		}
+		if item.Err != nil {
+			_, results[i].SubmissionError = newSubmissionError(ctx, item.SubmissionRejected, item.Err)
+		} else {
+			results[i].ID = item.ManagedTX.ID
+			results[i].Transaction = item.ManagedTX
+		}
+	}
+	return results, nil
+}
+
+func decodeBatchItem(ctx context.Context, baseReq *apitypes.BaseRequest) *txhandler.NewTransactionBatchItem {
+	item := &txhandler.NewTransactionBatchItem{}
+	var err error
+	if baseReq.Headers.DryRun {
+		item.SubmissionRejected = true
+		item.Err = i18n.NewError(ctx, tmmsgs.MsgDryRunNotSupported)
+		return item
+	}
+	switch baseReq.Headers.Type {
+	case apitypes.RequestTypeSendTransaction:
+		var tReq apitypes.TransactionRequest
+		if err = baseReq.UnmarshalTo(&tReq); err == nil {
+			item.TransactionRequest = &tReq
+		}
+	case apitypes.RequestTypeDeploy:
+		var tReq apitypes.ContractDeployRequest
+		if err = baseReq.UnmarshalTo(&tReq); err == nil {
+			item.ContractDeployRequest = &tReq
+		}
+	default:
+		// Queries are not supported in a batch, as there is no transaction to return
+		item.SubmissionRejected = true
+		item.Err = i18n.NewError(ctx, tmmsgs.MsgUnsupportedRequestType, baseReq.Headers.Type)
+		return item
+	}
+	if err != nil {
+		item.SubmissionRejected = true
+		item.Err = i18n.NewError(ctx, tmmsgs.MsgInvalidRequestErr, baseReq.Headers.Type, err)
+	}
+	return item
+}
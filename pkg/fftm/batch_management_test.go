@@ -0,0 +1,76 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSubmitBatchAssignsNonces(t *testing.T) {
+
+	_, m, done := newTestManager(t)
+	defer done()
+
+	mFFC := m.connector.(*ffcapimocks.API)
+	mFFC.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(12345),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("DeployContractPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_DEPLOY_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+
+	var requests []*apitypes.BaseRequest
+	deployTX := strings.Replace(sampleDeployTX, "ns1:904F177C-C790-4B01-BDF4-F2B4E52E607E", "ns1:deploy1", 1)
+	err := json.Unmarshal([]byte(`[`+sampleSendTX+`,`+deployTX+`,{"headers":{"type":"SendTransaction"},"from":{"Not":"a string"}}]`), &requests)
+	assert.NoError(t, err)
+
+	results, err := m.submitBatch(context.Background(), requests)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Nil(t, results[0].SubmissionError)
+	assert.Nil(t, results[1].SubmissionError)
+	assert.Equal(t, int64(12345), results[0].Transaction.Nonce.Int64())
+	assert.Equal(t, "RAW_UNSIGNED_BYTES", results[0].Transaction.TransactionData)
+	assert.Equal(t, "ns1:deploy1", results[1].ID)
+	assert.Equal(t, int64(12346), results[1].Transaction.Nonce.Int64())
+	assert.Equal(t, "RAW_DEPLOY_BYTES", results[1].Transaction.TransactionData)
+	assert.Regexp(t, "FF21022", results[2].Error)
+	assert.True(t, results[2].SubmissionRejected)
+
+}
+
+func TestDecodeBatchItemDryRun(t *testing.T) {
+	var baseReq apitypes.BaseRequest
+	err := json.Unmarshal([]byte(`{"headers":{"type":"SendTransaction","dryRun":true}}`), &baseReq)
+	assert.NoError(t, err)
+	item := decodeBatchItem(context.Background(), &baseReq)
+	assert.True(t, item.SubmissionRejected)
+	assert.Regexp(t, "FF21096", item.Err)
+}
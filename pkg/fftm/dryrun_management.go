@@ -0,0 +1,96 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// dryRunTransaction runs each of the connector functions used to submit a transaction, short of assigning
+// a nonce and sending it, so the caller can see whether it would revert and what it would cost.
+// A revert is reported in the result - any other failure is returned as an error.
+func (m *manager) dryRunTransaction(ctx context.Context, txReq *apitypes.TransactionRequest) (*apitypes.DryRunResult, error) {
+	result := &apitypes.DryRunResult{}
+
+	prepared, reason, err := m.connector.TransactionPrepare(ctx, &ffcapi.TransactionPrepareRequest{
+		TransactionInput: txReq.TransactionInput,
+	})
+	if err != nil {
+		return dryRunReverted(ctx, result, reason, err)
+	}
+	result.Gas = prepared.Gas
+
+	gasEstimate, reason, err := m.connector.GasEstimate(ctx, &txReq.TransactionInput)
+	if err != nil {
+		return dryRunReverted(ctx, result, reason, err)
+	}
+	result.Gas = gasEstimate.GasEstimate
+
+	gasPrice, _, err := m.connector.GasPriceEstimate(ctx, &ffcapi.GasPriceEstimateRequest{})
+	if err != nil {
+		return nil, err
+	}
+	result.GasPrice = gasPrice.GasPrice
+	result.EstimatedFee = estimateFee(result.Gas, result.GasPrice)
+
+	query, reason, err := m.connector.QueryInvoke(ctx, &ffcapi.QueryInvokeRequest{
+		TransactionInput: txReq.TransactionInput,
+	})
+	if err != nil {
+		return dryRunReverted(ctx, result, reason, err)
+	}
+	result.Outputs = query.Outputs
+
+	return result, nil
+}
+
+func dryRunReverted(ctx context.Context, result *apitypes.DryRunResult, reason ffcapi.ErrorReason, err error) (*apitypes.DryRunResult, error) {
+	if reason != ffcapi.ErrorReasonTransactionReverted {
+		return nil, err
+	}
+	log.L(ctx).Infof("Dry run transaction reverted: %s", err)
+	result.Reverted = true
+	result.RevertReason = err.Error()
+	return result, nil
+}
+
+// estimateFee multiplies the gas by a gas price that is a number or numeric string, or an object
+// with a maxFeePerGas (EIP-1559) or gasPrice field. Returns nil for any other gas price.
+func estimateFee(gas *fftypes.FFBigInt, gasPrice *fftypes.JSONAny) *fftypes.FFBigInt {
+	if gas == nil || gasPrice == nil {
+		return nil
+	}
+	var price fftypes.FFBigInt
+	if err := json.Unmarshal(gasPrice.Bytes(), &price); err != nil {
+		gasPriceObject := gasPrice.JSONObjectNowarn()
+		field := gasPriceObject.GetString("maxFeePerGas")
+		if field == "" {
+			field = gasPriceObject.GetString("gasPrice")
+		}
+		if _, ok := price.Int().SetString(field, 0); !ok {
+			return nil
+		}
+	}
+	return (*fftypes.FFBigInt)(new(big.Int).Mul(gas.Int(), price.Int()))
+}
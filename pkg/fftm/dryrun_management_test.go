@@ -0,0 +1,115 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDryRunManager(t *testing.T) (*manager, *ffcapimocks.API, func()) {
+	_, m, cancel := newTestManager(t)
+	return m, m.connector.(*ffcapimocks.API), cancel
+}
+
+func TestDryRunTransactionPrepareReverted(t *testing.T) {
+	m, mFFC, cancel := newTestDryRunManager(t)
+	defer cancel()
+
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionReverted, fmt.Errorf("execution reverted: not allowed"))
+
+	res, err := m.dryRunTransaction(m.ctx, &apitypes.TransactionRequest{})
+	assert.NoError(t, err)
+	assert.True(t, res.Reverted)
+	assert.Equal(t, "execution reverted: not allowed", res.RevertReason)
+}
+
+func TestDryRunGasEstimateReverted(t *testing.T) {
+	m, mFFC, cancel := newTestDryRunManager(t)
+	defer cancel()
+
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		Gas: fftypes.NewFFBigInt(100000),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasEstimate", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionReverted, fmt.Errorf("reverted"))
+
+	res, err := m.dryRunTransaction(m.ctx, &apitypes.TransactionRequest{})
+	assert.NoError(t, err)
+	assert.True(t, res.Reverted)
+	assert.Equal(t, int64(100000), res.Gas.Int64())
+}
+
+func TestDryRunGasEstimateFail(t *testing.T) {
+	m, mFFC, cancel := newTestDryRunManager(t)
+	defer cancel()
+
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasEstimate", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	_, err := m.dryRunTransaction(m.ctx, &apitypes.TransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestDryRunGasPriceFail(t *testing.T) {
+	m, mFFC, cancel := newTestDryRunManager(t)
+	defer cancel()
+
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasEstimateResponse{
+		GasEstimate: fftypes.NewFFBigInt(21000),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	_, err := m.dryRunTransaction(m.ctx, &apitypes.TransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestDryRunQueryReverted(t *testing.T) {
+	m, mFFC, cancel := newTestDryRunManager(t)
+	defer cancel()
+
+	mFFC.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasEstimateResponse{
+		GasEstimate: fftypes.NewFFBigInt(21000),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`{"maxFeePerGas":"0x64","maxPriorityFeePerGas":"0x1"}`),
+	}, ffcapi.ErrorReason(""), nil)
+	mFFC.On("QueryInvoke", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionReverted, fmt.Errorf("reverted"))
+
+	res, err := m.dryRunTransaction(m.ctx, &apitypes.TransactionRequest{})
+	assert.NoError(t, err)
+	assert.True(t, res.Reverted)
+	assert.Equal(t, int64(2100000), res.EstimatedFee.Int64())
+}
+
+func TestEstimateFee(t *testing.T) {
+	gas := fftypes.NewFFBigInt(10)
+	assert.Equal(t, int64(50), estimateFee(gas, fftypes.JSONAnyPtr(`5`)).Int64())
+	assert.Equal(t, int64(50), estimateFee(gas, fftypes.JSONAnyPtr(`"5"`)).Int64())
+	assert.Equal(t, int64(160), estimateFee(gas, fftypes.JSONAnyPtr(`{"gasPrice":"0x10"}`)).Int64())
+	assert.Nil(t, estimateFee(gas, fftypes.JSONAnyPtr(`{"other":"0x10"}`)))
+	assert.Nil(t, estimateFee(gas, fftypes.JSONAnyPtr(`true`)))
+	assert.Nil(t, estimateFee(nil, fftypes.JSONAnyPtr(`5`)))
+}
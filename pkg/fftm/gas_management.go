@@ -19,9 +19,12 @@ package fftm
 import (
 	"context"
 
+	"github.com/hyperledger/firefly-common/pkg/i18n"
 	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
 )
 
 func (m *manager) getLiveGasPrice(ctx context.Context) (resp *apitypes.LiveGasPrice, err error) {
@@ -35,3 +38,11 @@ func (m *manager) getLiveGasPrice(ctx context.Context) (resp *apitypes.LiveGasPr
 	}
 	return resp, nil
 }
+
+func (m *manager) getGasPriceHistory(ctx context.Context) ([]*apitypes.GasPriceHistoryEntry, error) {
+	gph, ok := m.txHandler.(txhandler.GasPriceHistoryHandler)
+	if !ok {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgGasPriceHistoryNotSupported)
+	}
+	return gph.GasPriceHistory(ctx)
+}
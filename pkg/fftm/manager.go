@@ -69,6 +69,8 @@ type manager struct {
 	metricsServerDone chan error
 	metricsEnabled    bool
 	metricsManager    metrics.Metrics
+	addressListsMux   sync.RWMutex
+	addressLists      *addressLists
 }
 
 func InitConfig() {
@@ -99,8 +101,9 @@ func newManager(ctx context.Context, connector ffcapi.API) *manager {
 		metricsManager:    metrics.NewMetricsManager(ctx),
 	}
 	m.toolkit = &txhandler.Toolkit{
-		Connector:      m.connector,
-		MetricsManager: m.metricsManager,
+		Connector:       m.connector,
+		MetricsManager:  m.metricsManager,
+		AddressScreener: m,
 	}
 	m.ctx, m.cancelCtx = context.WithCancel(ctx)
 	return m
@@ -127,6 +130,7 @@ func (m *manager) initServices(ctx context.Context) (err error) {
 		return err
 	}
 	m.toolkit.EventHandler = NewManagedTransactionEventHandler(ctx, m.confirmations, m.wsServer, m.txHandler)
+	m.toolkit.BlockHeight = m.confirmations
 	m.txHandler.Init(ctx, m.toolkit)
 
 	// metrics service must be initialized after transaction handler
@@ -179,6 +183,11 @@ func (m *manager) Start() error {
 		return err
 	}
 
+	// The address lists must be in place before any transactions are accepted
+	if _, err := m.reloadAddressLists(m.ctx); err != nil {
+		return err
+	}
+
 	go m.runAPIServer()
 	if m.metricsEnabled {
 		go m.runMetricsServer()
@@ -376,6 +376,22 @@ func TestStartBlockListenerFail(t *testing.T) {
 
 }
 
+func TestStartReloadAddressListsFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListStreamsByCreateTime", mock.Anything, mock.Anything, startupPaginationLimit, txhandler.SortDirectionAscending).Return(nil, nil)
+	mp.On("ListAddressListEntriesByCreateTime", mock.Anything, mock.Anything, startupPaginationLimit, txhandler.SortDirectionAscending).Return(nil, fmt.Errorf("pop"))
+
+	mca := m.connector.(*ffcapimocks.API)
+	mca.On("NewBlockListener", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), nil)
+
+	err := m.Start()
+	assert.Regexp(t, "pop", err)
+
+}
+
 func TestPSQLInitFail(t *testing.T) {
 
 	_ = testManagerCommonInit(t, false)
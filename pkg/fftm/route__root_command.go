@@ -72,7 +72,7 @@ var postRootCommand = func(m *manager) *ffapi.Route {
 				},
 			}, nil
 		},
-		JSONOutputCodes: []int{http.StatusAccepted},
+		JSONOutputCodes: []int{http.StatusAccepted, http.StatusOK},
 		JSONHandler: func(r *ffapi.APIRequest) (output interface{}, err error) {
 			baseReq := r.Input.(*apitypes.BaseRequest)
 			switch baseReq.Headers.Type {
@@ -83,6 +83,11 @@ var postRootCommand = func(m *manager) *ffapi.Route {
 					if err = baseReq.UnmarshalTo(&tReq); err != nil {
 						return nil, true /* reject */, i18n.NewError(r.Req.Context(), tmmsgs.MsgInvalidRequestErr, baseReq.Headers.Type, err)
 					}
+					if tReq.Headers.DryRun {
+						r.SuccessStatus = http.StatusOK
+						output, err = m.dryRunTransaction(r.Req.Context(), &tReq)
+						return output, true /* nothing was submitted */, err
+					}
 					return m.txHandler.HandleNewTransaction(r.Req.Context(), &tReq)
 				}), nil
 			case apitypes.RequestTypeDeploy:
@@ -92,6 +97,9 @@ var postRootCommand = func(m *manager) *ffapi.Route {
 					if err = baseReq.UnmarshalTo(&tReq); err != nil {
 						return nil, true /* reject */, i18n.NewError(r.Req.Context(), tmmsgs.MsgInvalidRequestErr, baseReq.Headers.Type, err)
 					}
+					if tReq.Headers.DryRun {
+						return nil, true /* reject */, i18n.NewError(r.Req.Context(), tmmsgs.MsgDryRunNotSupported)
+					}
 					return m.txHandler.HandleNewContractDeployment(r.Req.Context(), &tReq)
 				}), nil
 			case apitypes.RequestTypeQuery:
@@ -120,7 +128,13 @@ func mapSubmissionErrors(r *ffapi.APIRequest, handler func() (output interface{}
 	if err == nil {
 		return output
 	}
-	l := log.L(r.Req.Context())
+	status, submissionError := newSubmissionError(r.Req.Context(), submissionRejected, err)
+	r.SuccessStatus = status
+	return submissionError
+}
+
+func newSubmissionError(ctx context.Context, submissionRejected bool, err error) (int, *ffcapi.SubmissionError) {
+	l := log.L(ctx)
 	status := 500
 	if ffe, ok := (interface{}(err)).(i18n.FFError); ok {
 		if logrus.IsLevelEnabled(logrus.DebugLevel) {
@@ -128,9 +142,8 @@ func mapSubmissionErrors(r *ffapi.APIRequest, handler func() (output interface{}
 		}
 		status = ffe.HTTPStatus()
 	}
-	r.SuccessStatus = status
 	l.Errorf("Submission failed (submissionRejected=%t) [%d]: %s", submissionRejected, status, err)
-	return &ffcapi.SubmissionError{
+	return status, &ffcapi.SubmissionError{
 		Error:              err.Error(),
 		SubmissionRejected: submissionRejected,
 	}
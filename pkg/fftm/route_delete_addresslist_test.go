@@ -0,0 +1,65 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteAddressListEntry(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var entry apitypes.AddressListEntry
+	res, err := resty.New().R().
+		SetBody(&apitypes.AddressListEntry{
+			Field:   apitypes.AddressListFieldFrom,
+			List:    apitypes.AddressListDeny,
+			Address: "0xaaaa",
+		}).
+		SetResult(&entry).
+		Post(url + "/addresslists")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Regexp(t, "FF21126", m.ScreenAddresses(m.ctx, "0xaaaa", ""))
+
+	// Then delete it
+	res, err = resty.New().R().
+		Delete(url + "/addresslists/" + entry.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 204, res.StatusCode())
+	assert.NoError(t, m.ScreenAddresses(m.ctx, "0xaaaa", ""))
+
+	// Not found the second time
+	var errRes fftypes.RESTError
+	res, err = resty.New().R().
+		SetError(&errRes).
+		Delete(url + "/addresslists/" + entry.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode())
+	assert.Regexp(t, "FF21128", errRes.Error)
+
+}
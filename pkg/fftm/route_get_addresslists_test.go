@@ -0,0 +1,61 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAddressListEntries(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	// Create 3 entries
+	var e1, e2, e3 apitypes.AddressListEntry
+	for i, e := range []*apitypes.AddressListEntry{&e1, &e2, &e3} {
+		res, err := resty.New().R().
+			SetBody(&apitypes.AddressListEntry{
+				Field:   apitypes.AddressListFieldFrom,
+				List:    apitypes.AddressListAllow,
+				Address: []string{"0xaaaa", "0xbbbb", "0xcccc"}[i],
+			}).
+			SetResult(e).
+			Post(url + "/addresslists")
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode())
+	}
+
+	// Then list them, newest first
+	var entries []*apitypes.AddressListEntry
+	res, err := resty.New().R().
+		SetResult(&entries).
+		Get(url + "/addresslists?limit=1&after=" + e2.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+
+	assert.Len(t, entries, 1)
+	assert.Equal(t, e1.ID, entries[0].ID)
+
+}
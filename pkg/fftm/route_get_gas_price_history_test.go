@@ -0,0 +1,63 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetGasPriceHistoryOK(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var history []*apitypes.GasPriceHistoryEntry
+	res, err := resty.New().R().
+		SetResult(&history).
+		Get(url + "/gasprice/history")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Empty(t, history)
+}
+
+func TestGetGasPriceHistoryNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		Get(url + "/gasprice/history")
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21100", res.String())
+}
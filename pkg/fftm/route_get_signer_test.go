@@ -0,0 +1,67 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSigner(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, &ffcapi.NextNonceForSignerRequest{Signer: "0xaaaaa"}).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10001)}, ffcapi.ErrorReason(""), nil)
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	for _, tx := range []*apitypes.ManagedTX{
+		genTestTxn("0xaaaaa", 10000, apitypes.TxStatusSucceeded),
+		genTestTxn("0xbbbbb", 20000, apitypes.TxStatusPending),
+	} {
+		err := m.persistence.InsertTransactionPreAssignedNonce(context.Background(), tx)
+		assert.NoError(t, err)
+	}
+
+	var signer apitypes.SignerStatus
+	res, err := resty.New().R().
+		SetResult(&signer).
+		Get(url + "/signers/0xaaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, "0xaaaaa", signer.Signer)
+	assert.Equal(t, int64(10001), signer.NextNonce.Int64())
+	assert.Equal(t, 0, signer.PendingCount)
+	assert.Nil(t, signer.OldestPending)
+
+	res, err = resty.New().R().
+		Get(url + "/signers/0xccccc")
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode())
+}
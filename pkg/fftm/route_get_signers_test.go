@@ -0,0 +1,82 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSigners(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, &ffcapi.NextNonceForSignerRequest{Signer: "0xaaaaa"}).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10001)}, ffcapi.ErrorReason(""), nil)
+	mfc.On("NextNonceForSigner", mock.Anything, &ffcapi.NextNonceForSignerRequest{Signer: "0xbbbbb"}).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(20000)}, ffcapi.ErrorReason(""), nil)
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	txA1 := genTestTxn("0xaaaaa", 10000, apitypes.TxStatusSucceeded)
+	txA2 := genTestTxn("0xaaaaa", 10001, apitypes.TxStatusPending)
+	txA3 := genTestTxn("0xaaaaa", 10002, apitypes.TxStatusPending)
+	txB1 := genTestTxn("0xbbbbb", 20000, apitypes.TxStatusPending)
+	txA2.LastSubmit = fftypes.Now()
+	for _, tx := range []*apitypes.ManagedTX{txA1, txA2, txA3, txB1} {
+		err := m.persistence.InsertTransactionPreAssignedNonce(context.Background(), tx)
+		assert.NoError(t, err)
+	}
+
+	var signers []*apitypes.SignerStatus
+	res, err := resty.New().R().
+		SetResult(&signers).
+		Get(url + "/signers")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Len(t, signers, 2)
+	assert.Equal(t, "0xaaaaa", signers[0].Signer)
+	assert.Equal(t, int64(10003), signers[0].NextNonce.Int64())
+	assert.Equal(t, int64(10001), signers[0].ChainNextNonce.Int64())
+	assert.Equal(t, 2, signers[0].PendingCount)
+	assert.Equal(t, txA2.ID, signers[0].OldestPending.ID)
+	assert.Equal(t, txA2.LastSubmit.String(), signers[0].LastSubmit.String())
+	assert.Equal(t, "0xbbbbb", signers[1].Signer)
+	assert.Equal(t, int64(20001), signers[1].NextNonce.Int64())
+	assert.Equal(t, 1, signers[1].PendingCount)
+	assert.Equal(t, txB1.ID, signers[1].OldestPending.ID)
+	assert.Nil(t, signers[1].LastSubmit)
+
+	res, err = resty.New().R().
+		SetResult(&signers).
+		Get(url + "/signers?after=0xaaaaa&limit=1")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Len(t, signers, 1)
+	assert.Equal(t, "0xbbbbb", signers[0].Signer)
+}
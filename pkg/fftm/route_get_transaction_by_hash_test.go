@@ -0,0 +1,75 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTransactionByHash(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	err := m.Start()
+	assert.NoError(t, err)
+
+	txIn := newTestTxn(t, m, "0xaaaaa", 10001, apitypes.TxStatusPending)
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		err = m.persistence.UpdateTransaction(context.Background(), txIn.ID, &apitypes.TXUpdates{
+			TransactionHash: &hash,
+			LastSubmit:      fftypes.Now(),
+		})
+		assert.NoError(t, err)
+	}
+
+	// Both the earlier and the latest hash find the transaction
+	for _, hash := range []string{"0x111111", "0x222222"} {
+		var txOut *apitypes.ManagedTX
+		res, err := resty.New().R().
+			SetResult(&txOut).
+			Get(fmt.Sprintf("%s/transactionhashes/%s", url, hash))
+		assert.NoError(t, err)
+		assert.Equal(t, 200, res.StatusCode())
+		assert.Equal(t, txIn.ID, txOut.ID)
+		assert.Equal(t, "0x222222", txOut.TransactionHash)
+	}
+
+}
+
+func TestGetTransactionByHashNotFound(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var errorOut fftypes.RESTError
+	res, err := resty.New().R().
+		SetError(&errorOut).
+		Get(fmt.Sprintf("%s/transactionhashes/%s", url, "0x333333"))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode())
+	assert.Regexp(t, "FF21067", errorOut.Error)
+
+}
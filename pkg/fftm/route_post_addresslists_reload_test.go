@@ -0,0 +1,56 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/dbsql"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostAddressListsReload(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	// Written directly to persistence, so only picked up on reload
+	err = m.persistence.WriteAddressListEntry(m.ctx, &apitypes.AddressListEntry{
+		ResourceBase: dbsql.ResourceBase{ID: apitypes.NewULID()},
+		Field:        apitypes.AddressListFieldTo,
+		List:         apitypes.AddressListDeny,
+		Address:      "0xbbbb",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, m.ScreenAddresses(m.ctx, "0xaaaa", "0xbbbb"))
+
+	var entries []*apitypes.AddressListEntry
+	res, err := resty.New().R().
+		SetBody(struct{}{}).
+		SetResult(&entries).
+		Post(url + "/addresslists/reload")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Len(t, entries, 1)
+	assert.Regexp(t, "FF21126", m.ScreenAddresses(m.ctx, "0xaaaa", "0xbbbb"))
+
+}
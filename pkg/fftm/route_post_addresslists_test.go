@@ -0,0 +1,93 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostAddressListEntryScreensTransactions(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var entry apitypes.AddressListEntry
+	res, err := resty.New().R().
+		SetBody(&apitypes.AddressListEntry{
+			Field:   apitypes.AddressListFieldTo,
+			List:    apitypes.AddressListDeny,
+			Address: "0xE1A078B9E2B145D0A7387F09277C6AE1D9470771",
+			Reason:  "sanctioned",
+		}).
+		SetResult(&entry).
+		Post(url + "/addresslists")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.NotNil(t, entry.ID)
+	assert.NotNil(t, entry.Created)
+	assert.Equal(t, "0xe1a078b9e2b145d0a7387f09277c6ae1d9470771", entry.Address)
+
+	// A transaction to the denied address is refused before it is prepared
+	var errRes ffcapi.SubmissionError
+	res, err = resty.New().R().
+		SetBody(strings.NewReader(sampleSendTX)).
+		SetError(&errRes).
+		Post(url)
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.True(t, errRes.SubmissionRejected)
+	assert.Regexp(t, "FF21126", errRes.Error)
+
+	mFFC := m.connector.(*ffcapimocks.API)
+	mFFC.AssertNotCalled(t, "TransactionPrepare", mock.Anything, mock.Anything)
+
+}
+
+func TestPostAddressListEntryInvalid(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var errRes fftypes.RESTError
+	res, err := resty.New().R().
+		SetBody(&apitypes.AddressListEntry{
+			Field:   "value",
+			List:    apitypes.AddressListDeny,
+			Address: "0xaaaa",
+		}).
+		SetError(&errRes).
+		Post(url + "/addresslists")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21125", errRes.Error)
+
+}
@@ -0,0 +1,89 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostBatch(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	mth.On("HandleNewTransaction", mock.Anything, mock.MatchedBy(func(req *apitypes.TransactionRequest) bool {
+		return req.Headers.ID == "ns1:904F177C-C790-4B01-BDF4-F2B4E52E607E"
+	})).Return(&apitypes.ManagedTX{
+		ID:                 "ns1:904F177C-C790-4B01-BDF4-F2B4E52E607E",
+		TransactionHeaders: ffcapi.TransactionHeaders{Nonce: fftypes.NewFFBigInt(12345)},
+	}, false, nil)
+	mth.On("HandleNewContractDeployment", mock.Anything, mock.Anything).Return(nil, true, fmt.Errorf("pop"))
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var results []*apitypes.BatchResult
+	res, err := resty.New().R().
+		SetBody(`[`+sampleSendTX+`,`+sampleDeployTX+`,{"headers":{"id":"q1","type":"Query"}}]`).
+		SetHeader("Content-Type", "application/json").
+		SetResult(&results).
+		Post(url + "/batch")
+	assert.NoError(t, err)
+	assert.Equal(t, 202, res.StatusCode())
+	assert.Len(t, results, 3)
+	assert.Equal(t, "ns1:904F177C-C790-4B01-BDF4-F2B4E52E607E", results[0].ID)
+	assert.Equal(t, int64(12345), results[0].Transaction.Nonce.Int64())
+	assert.Nil(t, results[0].SubmissionError)
+	assert.Nil(t, results[1].Transaction)
+	assert.Regexp(t, "pop", results[1].Error)
+	assert.True(t, results[1].SubmissionRejected)
+	assert.Equal(t, "q1", results[2].ID)
+	assert.Regexp(t, "FF21023", results[2].Error)
+
+}
+
+func TestPostBatchEmpty(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(`[]`).
+		SetHeader("Content-Type", "application/json").
+		Post(url + "/batch")
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21095", res.String())
+
+}
@@ -0,0 +1,90 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostSignerResync(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, &ffcapi.NextNonceForSignerRequest{Signer: "0xaaaaa"}).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(15)}, ffcapi.ErrorReason(""), nil)
+
+	txHandlerDone := make(chan struct{})
+	close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	// Nonces 11 and 12 were consumed on chain by transactions sent outside of FFTM
+	submitted := genTestTxn("0xaaaaa", 10, apitypes.TxStatusPending)
+	unsubmitted1 := genTestTxn("0xaaaaa", 11, apitypes.TxStatusPending)
+	unsubmitted1.FirstSubmit = nil
+	unsubmitted2 := genTestTxn("0xaaaaa", 12, apitypes.TxStatusPending)
+	unsubmitted2.FirstSubmit = nil
+	for _, tx := range []*apitypes.ManagedTX{submitted, unsubmitted1, unsubmitted2} {
+		err := m.persistence.InsertTransactionPreAssignedNonce(context.Background(), tx)
+		assert.NoError(t, err)
+	}
+	for _, tx := range []*apitypes.ManagedTX{unsubmitted1, unsubmitted2} {
+		suspended := *tx
+		suspended.Status = apitypes.TxStatusSuspended
+		mth.On("HandleSuspendTransaction", mock.Anything, tx.ID).Return(&suspended, nil).Once()
+		mth.On("HandleResumeTransaction", mock.Anything, tx.ID).Return(tx, nil).Once()
+	}
+
+	var result apitypes.SignerResyncResult
+	res, err := resty.New().R().
+		SetBody(&apitypes.SignerResyncRequest{Renumber: true}).
+		SetResult(&result).
+		Post(url + "/signers/0xaaaaa/resync")
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, "0xaaaaa", result.Signer)
+	assert.Equal(t, int64(15), result.ChainNextNonce.Int64())
+	assert.Len(t, result.Renumbered, 2)
+
+	txs, err := m.persistence.ListTransactionsByNonce(context.Background(), "0xaaaaa", nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 3)
+	assert.Equal(t, submitted.ID, txs[0].ID)
+	assert.Equal(t, int64(10), txs[0].Nonce.Int64())
+	assert.Equal(t, unsubmitted1.ID, txs[1].ID)
+	assert.Equal(t, int64(15), txs[1].Nonce.Int64())
+	assert.Equal(t, unsubmitted2.ID, txs[2].ID)
+	assert.Equal(t, int64(16), txs[2].Nonce.Int64())
+
+}
@@ -0,0 +1,112 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/ffapi"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestApproverContext(approver string) context.Context {
+	headers := http.Header{}
+	headers.Set("X-FireFly-Approver", approver)
+	return context.WithValue(context.Background(), ffapi.CtxHeadersKey{}, headers)
+}
+
+func TestPostTransactionApprove(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	tx := newTestTxn(t, m, "0x0aaaaa", 10001, apitypes.TxStatusAwaitingApproval)
+
+	mca := m.connector.(*ffcapimocks.API)
+	mca.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil).Maybe()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	// The approver is only available from the passthrough headers configured for the API
+	status, txOut, err := m.requestTransactionApprove(newTestApproverContext("approver1"), tx.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, apitypes.TxStatusPending, txOut.Status)
+
+	var txh *apitypes.TXWithStatus
+	res, err := resty.New().R().
+		SetResult(&txh).
+		Get(fmt.Sprintf("%s/transactions/%s?history=true", url, tx.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, apitypes.TxActionApprove, txh.History[0].Actions[0].Action)
+	assert.JSONEq(t, `{"approver":"approver1"}`, txh.History[0].Actions[0].LastInfo.String())
+
+	// Only a transaction awaiting approval can be approved
+	_, _, err = m.requestTransactionApprove(newTestApproverContext("approver1"), tx.ID)
+	assert.Regexp(t, "FF21118", err)
+}
+
+func TestPostTransactionApproveNoApprover(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	tx := newTestTxn(t, m, "0x0aaaaa", 10001, apitypes.TxStatusAwaitingApproval)
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(fftypes.JSONObject{}).
+		Post(fmt.Sprintf("%s/transactions/%s/approve", url, tx.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21119", res.String())
+}
+
+func TestPostTransactionApproveNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(fftypes.JSONObject{}).
+		Post(fmt.Sprintf("%s/transactions/%s/approve", url, "1234"))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21117", res.String())
+}
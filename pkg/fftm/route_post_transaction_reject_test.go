@@ -0,0 +1,83 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostTransactionReject(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	tx := newTestTxn(t, m, "0x0aaaaa", 10001, apitypes.TxStatusAwaitingApproval)
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	status, txOut, err := m.requestTransactionReject(newTestApproverContext("approver1"), tx.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 200, status)
+	assert.Equal(t, apitypes.TxStatusFailed, txOut.Status)
+	assert.Regexp(t, "FF21120.*approver1", txOut.ErrorMessage)
+
+	var txh *apitypes.TXWithStatus
+	res, err := resty.New().R().
+		SetResult(&txh).
+		Get(fmt.Sprintf("%s/transactions/%s?history=true", url, tx.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, apitypes.TxStatusFailed, txh.Status)
+	assert.Equal(t, apitypes.TxSubStatusFailed, txh.History[0].Status)
+	assert.Equal(t, apitypes.TxActionReject, txh.History[0].Actions[0].Action)
+
+	res, err = resty.New().R().
+		SetBody(fftypes.JSONObject{}).
+		Post(fmt.Sprintf("%s/transactions/%s/reject", url, tx.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21119", res.String())
+}
+
+func TestPostTransactionRejectNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(fftypes.JSONObject{}).
+		Post(fmt.Sprintf("%s/transactions/%s/reject", url, "1234"))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21117", res.String())
+}
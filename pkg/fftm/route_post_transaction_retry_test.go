@@ -0,0 +1,95 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostTransactionRetry(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	tx := newTestTxn(t, m, "0x0aaaaa", 10001, apitypes.TxStatusFailed)
+	txID := tx.ID
+
+	mca := m.connector.(*ffcapimocks.API)
+	mca.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10002),
+	}, ffcapi.ErrorReason(""), nil)
+	mca.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil).Maybe()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var txOut *apitypes.ManagedTX
+	res, err := resty.New().R().
+		SetResult(&txOut).
+		SetBody(&apitypes.RetryTransactionRequest{ID: "ns1:retry1"}).
+		Post(fmt.Sprintf("%s/transactions/%s/retry", url, txID))
+	assert.NoError(t, err)
+	assert.Equal(t, 202, res.StatusCode())
+	assert.Equal(t, "ns1:retry1", txOut.ID)
+	assert.Equal(t, txID, txOut.RetryOf)
+	assert.Equal(t, int64(10002), txOut.Nonce.Int64())
+	assert.Equal(t, apitypes.TxStatusPending, txOut.Status)
+
+	failedTx, err := m.persistence.GetTransactionByID(m.ctx, txID)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1:retry1", failedTx.RetriedBy)
+
+	// Only one retry is allowed for each failed transaction
+	res, err = resty.New().R().
+		SetBody(&apitypes.RetryTransactionRequest{}).
+		Post(fmt.Sprintf("%s/transactions/%s/retry", url, txID))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, res.StatusCode())
+	assert.Regexp(t, "FF21109", res.String())
+}
+
+func TestPostTransactionRetryNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.RetryTransactionRequest{}).
+		Post(fmt.Sprintf("%s/transactions/%s/retry", url, "1234"))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21107", res.String())
+}
@@ -0,0 +1,82 @@
+// Copyright © 2022 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostTransactionSpeedUpNotPending(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+	tx := newTestTxn(t, m, "0x0aaaaa", 10001, apitypes.TxStatusSucceeded)
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.SpeedUpTransactionRequest{Percentage: 10}).
+		Post(fmt.Sprintf("%s/transactions/%s/speedup", url, tx.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, res.StatusCode())
+	assert.Regexp(t, "FF21112", res.String())
+}
+
+func TestPostTransactionSpeedUpInvalidRequest(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.SpeedUpTransactionRequest{}).
+		Post(fmt.Sprintf("%s/transactions/%s/speedup", url, "1234"))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21111", res.String())
+}
+
+func TestPostTransactionSpeedUpNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.SpeedUpTransactionRequest{Percentage: 10}).
+		Post(fmt.Sprintf("%s/transactions/%s/speedup", url, "1234"))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21110", res.String())
+}
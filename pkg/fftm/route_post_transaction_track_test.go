@@ -0,0 +1,123 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostTransactionTrack(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	var txOut *apitypes.ManagedTX
+	res, err := resty.New().R().
+		SetBody(&apitypes.TrackTransactionRequest{
+			ID:              "external1",
+			TransactionHash: "0x111111",
+			From:            "0x0aaaaa",
+			Nonce:           fftypes.NewFFBigInt(10001),
+		}).
+		SetResult(&txOut).
+		Post(fmt.Sprintf("%s/transactions/track", url))
+	assert.NoError(t, err)
+	assert.Equal(t, 202, res.StatusCode())
+	assert.Equal(t, "external1", txOut.ID)
+	assert.Equal(t, apitypes.TxStatusPending, txOut.Status)
+	assert.Equal(t, "0x111111", txOut.TransactionHash)
+	assert.NotNil(t, txOut.FirstSubmit)
+
+	var txh *apitypes.TXWithStatus
+	res, err = resty.New().R().
+		SetResult(&txh).
+		Get(fmt.Sprintf("%s/transactions/%s?history=true", url, txOut.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, apitypes.TxSubStatusTracking, txh.History[0].Status)
+	assert.Equal(t, apitypes.TxActionTrackExternal, txh.History[0].Actions[0].Action)
+
+	res, err = resty.New().R().
+		SetResult(&txOut).
+		Get(fmt.Sprintf("%s/transactionhashes/%s", url, "0x111111"))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode())
+	assert.Equal(t, "external1", txOut.ID)
+
+	// The nonce cannot be adopted twice
+	res, err = resty.New().R().
+		SetBody(&apitypes.TrackTransactionRequest{
+			TransactionHash: "0x222222",
+			From:            "0x0aaaaa",
+			Nonce:           fftypes.NewFFBigInt(10001),
+		}).
+		Post(fmt.Sprintf("%s/transactions/track", url))
+	assert.NoError(t, err)
+	assert.Equal(t, 409, res.StatusCode())
+}
+
+func TestPostTransactionTrackInvalidRequest(t *testing.T) {
+
+	url, m, done := newTestManager(t)
+	defer done()
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.TrackTransactionRequest{TransactionHash: "0x111111"}).
+		Post(fmt.Sprintf("%s/transactions/track", url))
+	assert.NoError(t, err)
+	assert.Equal(t, 400, res.StatusCode())
+	assert.Regexp(t, "FF21115", res.String())
+}
+
+func TestPostTransactionTrackNotSupported(t *testing.T) {
+	url, m, done := newTestManager(t)
+	defer done()
+
+	txHandlerDone := make(chan struct{})
+	defer close(txHandlerDone)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("Start", mock.Anything).Return((<-chan struct{})(txHandlerDone), nil)
+	m.txHandler = mth
+
+	err := m.Start()
+	assert.NoError(t, err)
+
+	res, err := resty.New().R().
+		SetBody(&apitypes.TrackTransactionRequest{
+			TransactionHash: "0x111111",
+			From:            "0x0aaaaa",
+			Nonce:           fftypes.NewFFBigInt(10001),
+		}).
+		Post(fmt.Sprintf("%s/transactions/track", url))
+	assert.NoError(t, err)
+	assert.Equal(t, 501, res.StatusCode())
+	assert.Regexp(t, "FF21114", res.String())
+}
@@ -33,7 +33,11 @@ func (m *manager) routes() []*ffapi.Route {
 		getSubscription(m),
 		getSubscriptions(m),
 		getReadyStatus(m),
+		getSigner(m),
+		getSignerNonces(m),
+		getSigners(m),
 		getTransaction(m),
+		getTransactionByHash(m),
 		getTransactionConfirmations(m),
 		getTransactionHistory(m),
 		getTransactionReceipt(m),
@@ -46,12 +50,24 @@ func (m *manager) routes() []*ffapi.Route {
 		postEventStreamListeners(m),
 		postEventStreamResume(m),
 		postEventStreamSuspend(m),
+		postBatch(m),
 		postRootCommand(m),
+		postSignerResync(m),
 		postSubscriptionReset(m),
 		postSubscriptions(m),
 		getAddressBalance(m),
 		getGasPrice(m),
+		getGasPriceHistory(m),
 		postTransactionSuspend(m),
 		postTransactionResume(m),
+		postTransactionRetry(m),
+		postTransactionSpeedUp(m),
+		postTransactionTrack(m),
+		postTransactionApprove(m),
+		postTransactionReject(m),
+		getAddressListEntries(m),
+		postAddressListEntry(m),
+		deleteAddressListEntry(m),
+		postAddressListsReload(m),
 	}
 }
@@ -0,0 +1,274 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmconfig"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+const signerPendingPageSize = 100
+
+func (m *manager) getSignerNonces(ctx context.Context, signer string) (*apitypes.SignerNonces, error) {
+	return txhandler.CheckSignerNonces(ctx, m.persistence, m.connector, signer)
+}
+
+func (m *manager) getSigners(ctx context.Context, afterStr, limitStr string) ([]*apitypes.SignerStatus, error) {
+	limit, err := m.parseLimit(ctx, limitStr)
+	if err != nil {
+		return nil, err
+	}
+	signers, err := m.persistence.ListSigners(ctx, afterStr, limit)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := m.pendingTransactionsBySigner(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*apitypes.SignerStatus, len(signers))
+	for i, signer := range signers {
+		if results[i], err = m.getSignerStatus(ctx, signer, pending[signer]); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (m *manager) getSigner(ctx context.Context, signer string) (*apitypes.SignerStatus, error) {
+	pending, err := m.pendingTransactionsBySigner(ctx, signer)
+	if err != nil {
+		return nil, err
+	}
+	status, err := m.getSignerStatus(ctx, signer, pending[signer])
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgSignerNotFound, signer)
+	}
+	return status, nil
+}
+
+// pendingTransactionsBySigner returns all pending transactions in the order they were received, grouped by signer.
+// There is no index of pending transactions by signer, but the pending set is bounded by how far the
+// transaction handler is behind, rather than growing with the history of each signer.
+func (m *manager) pendingTransactionsBySigner(ctx context.Context, signer string) (map[string][]*apitypes.ManagedTX, error) {
+	pending := make(map[string][]*apitypes.ManagedTX)
+	afterSequence := ""
+	for {
+		page, err := m.persistence.ListTransactionsPending(ctx, afterSequence, signerPendingPageSize, txhandler.SortDirectionAscending)
+		if err != nil {
+			return nil, err
+		}
+		for _, mtx := range page {
+			if signer == "" || mtx.From == signer {
+				pending[mtx.From] = append(pending[mtx.From], mtx)
+			}
+			afterSequence = mtx.SequenceID
+		}
+		if len(page) < signerPendingPageSize {
+			return pending, nil
+		}
+	}
+}
+
+// getSignerStatus returns nil if there are no transactions for the signer
+func (m *manager) getSignerStatus(ctx context.Context, signer string, pending []*apitypes.ManagedTX) (*apitypes.SignerStatus, error) {
+	latest, err := m.persistence.ListTransactionsByNonce(ctx, signer, nil, 1, txhandler.SortDirectionDescending)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return nil, nil
+	}
+	nextNonceRes, _, err := m.connector.NextNonceForSigner(ctx, &ffcapi.NextNonceForSignerRequest{
+		Signer: signer,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &apitypes.SignerStatus{
+		Signer:         signer,
+		ChainNextNonce: nextNonceRes.Nonce,
+		PendingCount:   len(pending),
+		LastSubmit:     latest[0].LastSubmit,
+	}
+	// Follows the same rules as nonce assignment for a new transaction - our own state is trusted while it is
+	// fresh, and after that we take whichever is further forwards of our own state and the node
+	status.NextNonce = (*fftypes.FFBigInt)(new(big.Int).Add(latest[0].Nonce.Int(), big.NewInt(1)))
+	stale := time.Since(*latest[0].Created.Time()) >= config.GetDuration(tmconfig.TransactionsNonceStateTimeout)
+	if stale && nextNonceRes.Nonce.Int().Cmp(status.NextNonce.Int()) > 0 {
+		status.NextNonce = nextNonceRes.Nonce
+	}
+	if len(pending) > 0 {
+		status.OldestPending = pending[0]
+	}
+	for _, mtx := range pending {
+		if mtx.LastSubmit != nil && (status.LastSubmit == nil || mtx.LastSubmit.Time().After(*status.LastSubmit.Time())) {
+			status.LastSubmit = mtx.LastSubmit
+		}
+	}
+	if sph, ok := m.txHandler.(txhandler.SignerPauseHandler); ok {
+		status.Paused = sph.SignerPause(ctx, signer)
+	}
+	return status, nil
+}
+
+// resyncSigner discards any nonce state we have cached for the signer, so that the next nonce allocated is checked
+// against the blockchain. This is needed when the signing key has been used to submit transactions outside of this
+// transaction manager, and it cannot wait for the cache to expire.
+func (m *manager) resyncSigner(ctx context.Context, signer string, req *apitypes.SignerResyncRequest) (*apitypes.SignerResyncResult, error) {
+	if err := m.persistence.ClearCachedNonce(ctx, signer); err != nil {
+		return nil, err
+	}
+	nextNonceRes, _, err := m.connector.NextNonceForSigner(ctx, &ffcapi.NextNonceForSignerRequest{
+		Signer: signer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &apitypes.SignerResyncResult{
+		Signer:         signer,
+		ChainNextNonce: nextNonceRes.Nonce,
+	}
+	log.L(ctx).Infof("Resync of signer %s: chain next nonce %s", signer, nextNonceRes.Nonce)
+	if req.Renumber {
+		result.Renumbered, err = m.renumberUnsubmitted(ctx, signer, nextNonceRes.Nonce)
+	}
+	return result, err
+}
+
+// renumberUnsubmitted moves the pending transactions for the signer that have never been submitted, onto
+// consecutive nonces from the chain's next nonce. The transactions are suspended for the duration, so the
+// transaction handler cannot submit them while their nonce is changing.
+func (m *manager) renumberUnsubmitted(ctx context.Context, signer string, chainNextNonce *fftypes.FFBigInt) (renumbered []*apitypes.RenumberedTransaction, err error) {
+	pending, err := m.pendingTransactionsBySigner(ctx, signer)
+	if err != nil {
+		return nil, err
+	}
+
+	var suspended []*apitypes.ManagedTX
+	defer func() {
+		// Always resume everything we suspended, in nonce order
+		for _, mtx := range suspended {
+			if _, resumeErr := m.txHandler.HandleResumeTransaction(ctx, mtx.ID); resumeErr != nil {
+				log.L(ctx).Errorf("Failed to resume transaction %s after nonce resync: %s", mtx.ID, resumeErr)
+				if err == nil {
+					err = resumeErr
+				}
+			}
+		}
+	}()
+
+	var candidates []*apitypes.ManagedTX
+	candidateIDs := make(map[string]bool)
+	for _, mtx := range pending[signer] {
+		if mtx.FirstSubmit != nil {
+			continue
+		}
+		suspendedTX, err := m.txHandler.HandleSuspendTransaction(ctx, mtx.ID)
+		if err != nil {
+			return renumbered, err
+		}
+		if suspendedTX.Status != apitypes.TxStatusSuspended {
+			continue
+		}
+		suspended = append(suspended, suspendedTX)
+		// It might have been submitted since we listed it
+		if suspendedTX.FirstSubmit == nil {
+			candidates = append(candidates, suspendedTX)
+			candidateIDs[suspendedTX.ID] = true
+		}
+	}
+	sort.Slice(suspended, func(i, j int) bool { return suspended[i].Nonce.Int().Cmp(suspended[j].Nonce.Int()) < 0 })
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Nonce.Int().Cmp(candidates[j].Nonce.Int()) < 0 })
+	if len(candidates) == 0 {
+		return renumbered, nil
+	}
+
+	// We cannot go below the nonce of any transaction we are not moving
+	nextNonce := new(big.Int).Set(chainNextNonce.Int())
+	var after *fftypes.FFBigInt
+	for {
+		page, err := m.persistence.ListTransactionsByNonce(ctx, signer, after, signerPendingPageSize, txhandler.SortDirectionDescending)
+		if err != nil {
+			return renumbered, err
+		}
+		found := false
+		for _, mtx := range page {
+			if !candidateIDs[mtx.ID] {
+				if mtx.Nonce.Int().Cmp(nextNonce) >= 0 {
+					nextNonce.Add(mtx.Nonce.Int(), big.NewInt(1))
+				}
+				found = true
+				break
+			}
+			after = mtx.Nonce
+		}
+		if found || len(page) < signerPendingPageSize {
+			break
+		}
+	}
+
+	moves := make([]*apitypes.RenumberedTransaction, len(candidates))
+	for i, mtx := range candidates {
+		moves[i] = &apitypes.RenumberedTransaction{
+			ID:            mtx.ID,
+			PreviousNonce: mtx.Nonce,
+			Nonce:         (*fftypes.FFBigInt)(new(big.Int).Add(nextNonce, big.NewInt(int64(i)))),
+		}
+	}
+	// To keep every nonce unique through the process, the transactions moving down are moved lowest first,
+	// then the transactions moving up are moved highest first
+	var ordered []*apitypes.RenumberedTransaction
+	for _, move := range moves {
+		if move.Nonce.Int().Cmp(move.PreviousNonce.Int()) < 0 {
+			ordered = append(ordered, move)
+		}
+	}
+	for i := len(moves) - 1; i >= 0; i-- {
+		if moves[i].Nonce.Int().Cmp(moves[i].PreviousNonce.Int()) > 0 {
+			ordered = append(ordered, moves[i])
+		}
+	}
+	for _, move := range ordered {
+		log.L(ctx).Infof("Renumbering transaction %s from nonce %s / %s to %s", move.ID, signer, move.PreviousNonce, move.Nonce)
+		if err := m.persistence.UpdateTransaction(ctx, move.ID, &apitypes.TXUpdates{Nonce: move.Nonce}); err != nil {
+			return renumbered, err
+		}
+		renumbered = append(renumbered, move)
+		if err := m.persistence.AddSubStatusAction(ctx, move.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce,
+			fftypes.JSONAnyPtr(`{"nonce":"`+move.Nonce.String()+`","previousNonce":"`+move.PreviousNonce.String()+`"}`), nil, fftypes.Now()); err != nil {
+			return renumbered, err
+		}
+	}
+	return renumbered, nil
+}
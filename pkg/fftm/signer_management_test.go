@@ -0,0 +1,311 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetSignerStatusStaleNonceState(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	staleTX := genTestTxn("0xaaaaa", 10, apitypes.TxStatusSucceeded)
+	created := fftypes.FFTime(time.Now().Add(-100 * time.Hour))
+	staleTX.Created = &created
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), 1, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{staleTX}, nil)
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(15)}, ffcapi.ErrorReason(""), nil)
+
+	status, err := m.getSignerStatus(m.ctx, "0xaaaaa", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), status.NextNonce.Int64())
+	assert.Equal(t, int64(15), status.ChainNextNonce.Int64())
+
+	mp.AssertExpectations(t)
+}
+
+type testPausingTransactionHandler struct {
+	*txhandlermocks.TransactionHandler
+	pause *apitypes.SignerPause
+}
+
+func (th *testPausingTransactionHandler) SignerPause(ctx context.Context, signer string) *apitypes.SignerPause {
+	return th.pause
+}
+
+func TestGetSignerStatusPaused(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	pause := &apitypes.SignerPause{
+		Reason:          "insufficient funds",
+		Since:           fftypes.Now(),
+		TransactionID:   "tx1",
+		RequiredBalance: fftypes.NewFFBigInt(1000),
+	}
+	m.txHandler = &testPausingTransactionHandler{
+		TransactionHandler: txhandlermocks.NewTransactionHandler(t),
+		pause:              pause,
+	}
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), 1, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{
+		genTestTxn("0xaaaaa", 10, apitypes.TxStatusPending),
+	}, nil)
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10)}, ffcapi.ErrorReason(""), nil)
+
+	status, err := m.getSignerStatus(m.ctx, "0xaaaaa", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, pause, status.Paused)
+
+	mp.AssertExpectations(t)
+}
+
+func TestGetSignersErrors(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListSigners", m.ctx, "", 0).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("ListSigners", m.ctx, "", 0).Return([]string{"0xaaaaa"}, nil)
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), 1, txhandler.SortDirectionDescending).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+
+	_, err := m.getSigners(m.ctx, "", "bad limit")
+	assert.Regexp(t, "FF21044", err)
+
+	_, err = m.getSigners(m.ctx, "", "")
+	assert.Regexp(t, "pop", err)
+
+	_, err = m.getSigners(m.ctx, "", "")
+	assert.Regexp(t, "pop", err)
+
+	_, err = m.getSigners(m.ctx, "", "")
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestGetSignerErrors(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), 1, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{
+		genTestTxn("0xaaaaa", 10, apitypes.TxStatusSucceeded),
+	}, nil)
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	_, err := m.getSigner(m.ctx, "0xaaaaa")
+	assert.Regexp(t, "pop", err)
+
+	_, err = m.getSigner(m.ctx, "0xaaaaa")
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPendingTransactionsBySignerPaging(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	page1 := make([]*apitypes.ManagedTX, signerPendingPageSize)
+	for i := range page1 {
+		page1[i] = genTestTxn("0xaaaaa", int64(i), apitypes.TxStatusPending)
+		page1[i].SequenceID = fmt.Sprintf("%.3d", i)
+	}
+	lastSequence := page1[len(page1)-1].SequenceID
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return(page1, nil).Once()
+	mp.On("ListTransactionsPending", m.ctx, lastSequence, signerPendingPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{
+		genTestTxn("0xbbbbb", 0, apitypes.TxStatusPending),
+	}, nil).Once()
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+
+	pending, err := m.pendingTransactionsBySigner(m.ctx, "0xaaaaa")
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Len(t, pending["0xaaaaa"], signerPendingPageSize)
+
+	mp.AssertExpectations(t)
+}
+
+func TestResyncSignerRenumberDown(t *testing.T) {
+
+	_, m, done := newTestManager(t)
+	defer done()
+
+	// The chain has forgotten about transactions that were dropped from the node's pool,
+	// and there are enough unsubmitted transactions to need paging to find the one below
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(0)}, ffcapi.ErrorReason(""), nil)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	m.txHandler = mth
+
+	err := m.persistence.InsertTransactionPreAssignedNonce(context.Background(), genTestTxn("0xaaaaa", 0, apitypes.TxStatusSucceeded))
+	assert.NoError(t, err)
+	unsubmittedCount := signerPendingPageSize + 1
+	for i := 0; i < unsubmittedCount; i++ {
+		tx := genTestTxn("0xaaaaa", int64(i+2), apitypes.TxStatusPending)
+		tx.FirstSubmit = nil
+		err := m.persistence.InsertTransactionPreAssignedNonce(context.Background(), tx)
+		assert.NoError(t, err)
+		suspended := *tx
+		suspended.Status = apitypes.TxStatusSuspended
+		mth.On("HandleSuspendTransaction", mock.Anything, tx.ID).Return(&suspended, nil).Once()
+		mth.On("HandleResumeTransaction", mock.Anything, tx.ID).Return(tx, nil).Once()
+	}
+
+	result, err := m.resyncSigner(m.ctx, "0xaaaaa", &apitypes.SignerResyncRequest{Renumber: true})
+	assert.NoError(t, err)
+	assert.Len(t, result.Renumbered, unsubmittedCount)
+
+	txs, err := m.persistence.ListTransactionsByNonce(m.ctx, "0xaaaaa", nil, unsubmittedCount+1, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	for i, tx := range txs {
+		assert.Equal(t, int64(i), tx.Nonce.Int64())
+	}
+
+}
+
+func TestResyncSignerNoRenumber(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ClearCachedNonce", m.ctx, "0xaaaaa").Return(nil)
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(15)}, ffcapi.ErrorReason(""), nil)
+
+	result, err := m.resyncSigner(m.ctx, "0xaaaaa", &apitypes.SignerResyncRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(15), result.ChainNextNonce.Int64())
+	assert.Empty(t, result.Renumbered)
+
+	mp.AssertExpectations(t)
+}
+
+func TestResyncSignerSkipsSubmitted(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	completed := genTestTxn("0xaaaaa", 11, apitypes.TxStatusPending)
+	completed.FirstSubmit = nil
+	raced := genTestTxn("0xaaaaa", 12, apitypes.TxStatusPending)
+	raced.FirstSubmit = nil
+	racedSuspended := *raced
+	racedSuspended.Status = apitypes.TxStatusSuspended
+	racedSuspended.FirstSubmit = fftypes.Now()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ClearCachedNonce", m.ctx, "0xaaaaa").Return(nil)
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{
+		genTestTxn("0xaaaaa", 10, apitypes.TxStatusPending), completed, raced,
+	}, nil)
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(15)}, ffcapi.ErrorReason(""), nil)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("HandleSuspendTransaction", m.ctx, completed.ID).Return(genTestTxn("0xaaaaa", 11, apitypes.TxStatusSucceeded), nil)
+	mth.On("HandleSuspendTransaction", m.ctx, raced.ID).Return(&racedSuspended, nil)
+	mth.On("HandleResumeTransaction", m.ctx, raced.ID).Return(raced, nil)
+	m.txHandler = mth
+
+	result, err := m.resyncSigner(m.ctx, "0xaaaaa", &apitypes.SignerResyncRequest{Renumber: true})
+	assert.NoError(t, err)
+	assert.Empty(t, result.Renumbered)
+
+	mp.AssertExpectations(t)
+}
+
+func TestResyncSignerErrors(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	unsubmitted := genTestTxn("0xaaaaa", 11, apitypes.TxStatusPending)
+	unsubmitted.FirstSubmit = nil
+	suspended := *unsubmitted
+	suspended.Status = apitypes.TxStatusSuspended
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("ClearCachedNonce", m.ctx, "0xaaaaa").Return(fmt.Errorf("pop")).Once()
+	mp.On("ClearCachedNonce", m.ctx, "0xaaaaa").Return(nil)
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("ListTransactionsPending", m.ctx, "", signerPendingPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{unsubmitted}, nil)
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), signerPendingPageSize, txhandler.SortDirectionDescending).Return(nil, fmt.Errorf("pop")).Once()
+	mp.On("ListTransactionsByNonce", m.ctx, "0xaaaaa", (*fftypes.FFBigInt)(nil), signerPendingPageSize, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{unsubmitted}, nil)
+	mp.On("UpdateTransaction", m.ctx, unsubmitted.ID, mock.Anything).Return(fmt.Errorf("pop")).Once()
+	mp.On("UpdateTransaction", m.ctx, unsubmitted.ID, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", m.ctx, unsubmitted.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop")).Once()
+	mp.On("AddSubStatusAction", m.ctx, unsubmitted.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mfc := m.connector.(*ffcapimocks.API)
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Once()
+	mfc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(15)}, ffcapi.ErrorReason(""), nil)
+	mth := txhandlermocks.NewTransactionHandler(t)
+	mth.On("HandleSuspendTransaction", m.ctx, unsubmitted.ID).Return(nil, fmt.Errorf("pop")).Once()
+	mth.On("HandleSuspendTransaction", m.ctx, unsubmitted.ID).Return(&suspended, nil)
+	mth.On("HandleResumeTransaction", m.ctx, unsubmitted.ID).Return(unsubmitted, nil).Times(3)
+	mth.On("HandleResumeTransaction", m.ctx, unsubmitted.ID).Return(nil, fmt.Errorf("pop")).Once()
+	m.txHandler = mth
+
+	req := &apitypes.SignerResyncRequest{Renumber: true}
+	for i := 0; i < 8; i++ {
+		_, err := m.resyncSigner(m.ctx, "0xaaaaa", req)
+		assert.Regexp(t, "pop", err)
+	}
+
+	mp.AssertExpectations(t)
+	mfc.AssertExpectations(t)
+}
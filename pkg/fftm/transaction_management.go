@@ -28,6 +28,17 @@ import (
 	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
 )
 
+func (m *manager) getTransactionByHash(ctx context.Context, transactionHash string) (transaction *apitypes.ManagedTX, err error) {
+	tx, err := m.persistence.GetTransactionByHash(ctx, transactionHash)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgTransactionNotFound, transactionHash)
+	}
+	return tx, nil
+}
+
 func (m *manager) getTransactionByIDWithStatus(ctx context.Context, txID string, withHistory bool) (transaction *apitypes.TXWithStatus, err error) {
 	tx, err := m.persistence.GetTransactionByIDWithStatus(ctx, txID, withHistory)
 	if err != nil {
@@ -36,9 +47,42 @@ func (m *manager) getTransactionByIDWithStatus(ctx context.Context, txID string,
 	if tx == nil {
 		return nil, i18n.NewError(ctx, tmmsgs.MsgTransactionNotFound, txID)
 	}
+	if len(tx.DependsOn) > 0 {
+		if tx.Dependencies, err = m.getTransactionDependencies(ctx, tx.ManagedTX); err != nil {
+			return nil, err
+		}
+	}
 	return tx, nil
 }
 
+// getTransactionDependencies walks the dependency graph of a transaction, returning each of the transactions
+// it depends on, directly or indirectly, once in breadth first order
+func (m *manager) getTransactionDependencies(ctx context.Context, mtx *apitypes.ManagedTX) ([]*apitypes.TXDependency, error) {
+	dependencies := []*apitypes.TXDependency{}
+	visited := map[string]bool{mtx.ID: true}
+	queue := append([]string{}, mtx.DependsOn...)
+	for len(queue) > 0 {
+		depID := queue[0]
+		queue = queue[1:]
+		if visited[depID] {
+			continue
+		}
+		visited[depID] = true
+		dep, err := m.persistence.GetTransactionByID(ctx, depID)
+		if err != nil {
+			return nil, err
+		}
+		entry := &apitypes.TXDependency{ID: depID}
+		if dep != nil {
+			entry.Status = dep.Status
+			entry.DependsOn = dep.DependsOn
+			queue = append(queue, dep.DependsOn...)
+		}
+		dependencies = append(dependencies, entry)
+	}
+	return dependencies, nil
+}
+
 func (m *manager) getTransactions(ctx context.Context, afterStr, limitStr, signer string, pending bool, dirString string) (transactions []*apitypes.ManagedTX, err error) {
 	limit, err := m.parseLimit(ctx, limitStr)
 	if err != nil {
@@ -120,3 +164,88 @@ func (m *manager) requestTransactionResume(ctx context.Context, txID string) (st
 	return http.StatusAccepted, canceledTx, nil
 
 }
+
+func (m *manager) requestTransactionRetry(ctx context.Context, txID string, req *apitypes.RetryTransactionRequest) (status int, transaction *apitypes.ManagedTX, err error) {
+
+	rth, ok := m.txHandler.(txhandler.RetryTransactionHandler)
+	if !ok {
+		return http.StatusNotImplemented, nil, i18n.NewError(ctx, tmmsgs.MsgRetryNotSupported)
+	}
+
+	retryTx, err := rth.HandleRetryTransaction(ctx, txID, req)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusAccepted, retryTx, nil
+
+}
+
+func (m *manager) requestTransactionSpeedUp(ctx context.Context, txID string, req *apitypes.SpeedUpTransactionRequest) (status int, transaction *apitypes.ManagedTX, err error) {
+
+	suth, ok := m.txHandler.(txhandler.SpeedUpTransactionHandler)
+	if !ok {
+		return http.StatusNotImplemented, nil, i18n.NewError(ctx, tmmsgs.MsgSpeedUpNotSupported)
+	}
+
+	spedUpTx, err := suth.HandleSpeedUpTransaction(ctx, txID, req)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, spedUpTx, nil
+
+}
+
+func (m *manager) requestTransactionTrack(ctx context.Context, req *apitypes.TrackTransactionRequest) (status int, transaction *apitypes.ManagedTX, err error) {
+
+	tth, ok := m.txHandler.(txhandler.TrackTransactionHandler)
+	if !ok {
+		return http.StatusNotImplemented, nil, i18n.NewError(ctx, tmmsgs.MsgTrackNotSupported)
+	}
+
+	trackedTx, err := tth.HandleTrackTransaction(ctx, req)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusAccepted, trackedTx, nil
+
+}
+
+func (m *manager) requestTransactionApprove(ctx context.Context, txID string) (status int, transaction *apitypes.ManagedTX, err error) {
+
+	ath, ok := m.txHandler.(txhandler.ApprovalTransactionHandler)
+	if !ok {
+		return http.StatusNotImplemented, nil, i18n.NewError(ctx, tmmsgs.MsgApprovalNotSupported)
+	}
+
+	approvedTx, err := ath.HandleApproveTransaction(ctx, txID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, approvedTx, nil
+
+}
+
+func (m *manager) requestTransactionReject(ctx context.Context, txID string) (status int, transaction *apitypes.ManagedTX, err error) {
+
+	ath, ok := m.txHandler.(txhandler.ApprovalTransactionHandler)
+	if !ok {
+		return http.StatusNotImplemented, nil, i18n.NewError(ctx, tmmsgs.MsgApprovalNotSupported)
+	}
+
+	rejectedTx, err := ath.HandleRejectTransaction(ctx, txID)
+
+	if err != nil {
+		return http.StatusInternalServerError, nil, err
+	}
+
+	return http.StatusOK, rejectedTx, nil
+
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
 	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -47,6 +48,55 @@ func TestGetTransactionErrors(t *testing.T) {
 
 }
 
+func TestGetTransactionDependencies(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByIDWithStatus", m.ctx, "ns1:transfer", false).Return(&apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{ID: "ns1:transfer", DependsOn: []string{"ns1:approve", "ns1:mint"}},
+	}, nil)
+	mp.On("GetTransactionByID", m.ctx, "ns1:approve").Return(&apitypes.ManagedTX{
+		ID: "ns1:approve", Status: apitypes.TxStatusPending, DependsOn: []string{"ns1:mint", "ns1:deleted"},
+	}, nil)
+	mp.On("GetTransactionByID", m.ctx, "ns1:mint").Return(&apitypes.ManagedTX{
+		ID: "ns1:mint", Status: apitypes.TxStatusSucceeded,
+	}, nil).Once()
+	mp.On("GetTransactionByID", m.ctx, "ns1:deleted").Return(nil, nil)
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+
+	tx, err := m.getTransactionByIDWithStatus(m.ctx, "ns1:transfer", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.TXDependency{
+		{ID: "ns1:approve", Status: apitypes.TxStatusPending, DependsOn: []string{"ns1:mint", "ns1:deleted"}},
+		{ID: "ns1:mint", Status: apitypes.TxStatusSucceeded},
+		{ID: "ns1:deleted"},
+	}, tx.Dependencies)
+
+	mp.AssertExpectations(t)
+
+}
+
+func TestGetTransactionDependenciesFail(t *testing.T) {
+
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByIDWithStatus", m.ctx, "ns1:transfer", false).Return(&apitypes.TXWithStatus{
+		ManagedTX: &apitypes.ManagedTX{ID: "ns1:transfer", DependsOn: []string{"ns1:approve"}},
+	}, nil)
+	mp.On("GetTransactionByID", m.ctx, "ns1:approve").Return(nil, fmt.Errorf("pop"))
+	mp.On("Close", mock.Anything).Return(nil).Maybe()
+
+	_, err := m.getTransactionByIDWithStatus(m.ctx, "ns1:transfer", false)
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+
+}
+
 func TestGetTransactionsErrors(t *testing.T) {
 
 	_, m, close := newTestManagerMockPersistence(t)
@@ -92,3 +142,16 @@ func TestDeleteTransactionError(t *testing.T) {
 	mth.AssertExpectations(t)
 
 }
+
+func TestGetTransactionByHashFail(t *testing.T) {
+	_, m, close := newTestManagerMockPersistence(t)
+	defer close()
+
+	mp := m.persistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByHash", m.ctx, "0x111111").Return(nil, fmt.Errorf("pop"))
+
+	_, err := m.getTransactionByHash(m.ctx, "0x111111")
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
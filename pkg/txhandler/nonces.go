@@ -0,0 +1,88 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txhandler
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+const nonceCheckPageSize = 100
+
+// CheckSignerNonces compares the transactions in persistence for a signer, with the next nonce reported by the connector.
+// Only nonces the chain has not yet consumed are inspected, so the cost is proportional to the number of
+// outstanding transactions for the signer.
+// A transaction that has completed without a receipt (such as one that failed before it was ever submitted) does not
+// fill its nonce, as it will never be mined. Suspended transactions do, as they can be resumed.
+func CheckSignerNonces(ctx context.Context, p TransactionPersistence, connector ffcapi.API, signer string) (*apitypes.SignerNonces, error) {
+	nextNonceRes, _, err := connector.NextNonceForSigner(ctx, &ffcapi.NextNonceForSignerRequest{
+		Signer: signer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	chainNextNonce := nextNonceRes.Nonce.Int()
+	res := &apitypes.SignerNonces{
+		Signer:         signer,
+		ChainNextNonce: (*fftypes.FFBigInt)(new(big.Int).Set(chainNextNonce)),
+		PendingNonces:  []*fftypes.FFBigInt{},
+		Gaps:           []*fftypes.FFBigInt{},
+	}
+
+	var after *fftypes.FFBigInt
+	if chainNextNonce.Sign() > 0 {
+		after = (*fftypes.FFBigInt)(new(big.Int).Sub(chainNextNonce, big.NewInt(1)))
+	}
+	expected := new(big.Int).Set(chainNextNonce)
+	for {
+		page, err := p.ListTransactionsByNonce(ctx, signer, after, nonceCheckPageSize, SortDirectionAscending)
+		if err != nil {
+			return nil, err
+		}
+		for _, mtx := range page {
+			if mtx.Nonce == nil {
+				continue
+			}
+			nonce := mtx.Nonce.Int()
+			for ; expected.Cmp(nonce) < 0; expected.Add(expected, big.NewInt(1)) {
+				res.Gaps = append(res.Gaps, (*fftypes.FFBigInt)(new(big.Int).Set(expected)))
+			}
+			switch mtx.Status {
+			case apitypes.TxStatusPending:
+				res.PendingNonces = append(res.PendingNonces, mtx.Nonce)
+			case apitypes.TxStatusSuspended:
+			default:
+				receipt, err := p.GetTransactionReceipt(ctx, mtx.ID)
+				if err != nil {
+					return nil, err
+				}
+				if receipt == nil {
+					res.Gaps = append(res.Gaps, mtx.Nonce)
+				}
+			}
+			expected.Add(nonce, big.NewInt(1))
+			after = mtx.Nonce
+		}
+		if len(page) < nonceCheckPageSize {
+			return res, nil
+		}
+	}
+}
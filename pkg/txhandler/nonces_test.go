@@ -0,0 +1,143 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package txhandler_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func testNonceTX(nonce int64, status apitypes.TxStatus) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID:     fmt.Sprintf("tx-%d", nonce),
+		Status: status,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0xaaaa",
+			Nonce: fftypes.NewFFBigInt(nonce),
+		},
+	}
+}
+
+func TestCheckSignerNoncesGaps(t *testing.T) {
+	mc := &ffcapimocks.API{}
+	mp := &persistencemocks.TransactionPersistence{}
+
+	mc.On("NextNonceForSigner", mock.Anything, &ffcapi.NextNonceForSignerRequest{Signer: "0xaaaa"}).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10)}, ffcapi.ErrorReason(""), nil)
+
+	firstPage := make([]*apitypes.ManagedTX, 0, 100)
+	firstPage = append(firstPage, testNonceTX(12, apitypes.TxStatusPending))
+	for i := int64(13); len(firstPage) < 100; i++ {
+		firstPage = append(firstPage, testNonceTX(i, apitypes.TxStatusPending))
+	}
+	mp.On("ListTransactionsByNonce", mock.Anything, "0xaaaa", fftypes.NewFFBigInt(9), 100, txhandler.SortDirectionAscending).
+		Return(firstPage, nil).Once()
+	mp.On("ListTransactionsByNonce", mock.Anything, "0xaaaa", fftypes.NewFFBigInt(111), 100, txhandler.SortDirectionAscending).
+		Return([]*apitypes.ManagedTX{
+			{ID: "no-nonce"},
+			testNonceTX(113, apitypes.TxStatusSucceeded),
+			testNonceTX(114, apitypes.TxStatusPending),
+			testNonceTX(115, apitypes.TxStatusFailed),
+			testNonceTX(116, apitypes.TxStatusSuspended),
+		}, nil).Once()
+	// The node might be behind, so a completed transaction with a receipt still fills its nonce
+	mp.On("GetTransactionReceipt", mock.Anything, "tx-113").Return(&ffcapi.TransactionReceiptResponse{Success: true}, nil)
+	// A transaction that failed before it was mined does not
+	mp.On("GetTransactionReceipt", mock.Anything, "tx-115").Return(nil, nil)
+
+	res, err := txhandler.CheckSignerNonces(context.Background(), mp, mc, "0xaaaa")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xaaaa", res.Signer)
+	assert.Equal(t, int64(10), res.ChainNextNonce.Int64())
+	assert.Equal(t, []*fftypes.FFBigInt{
+		fftypes.NewFFBigInt(10),
+		fftypes.NewFFBigInt(11),
+		fftypes.NewFFBigInt(112),
+		fftypes.NewFFBigInt(115),
+	}, res.Gaps)
+	assert.Len(t, res.PendingNonces, 101)
+	assert.Equal(t, int64(114), res.PendingNonces[100].Int64())
+
+	mc.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestCheckSignerNoncesNoTransactions(t *testing.T) {
+	mc := &ffcapimocks.API{}
+	mp := &persistencemocks.TransactionPersistence{}
+
+	mc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(0)}, ffcapi.ErrorReason(""), nil)
+	mp.On("ListTransactionsByNonce", mock.Anything, "0xaaaa", (*fftypes.FFBigInt)(nil), 100, txhandler.SortDirectionAscending).
+		Return([]*apitypes.ManagedTX{}, nil)
+
+	res, err := txhandler.CheckSignerNonces(context.Background(), mp, mc, "0xaaaa")
+	assert.NoError(t, err)
+	assert.Empty(t, res.Gaps)
+	assert.Empty(t, res.PendingNonces)
+
+	mc.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestCheckSignerNoncesConnectorFail(t *testing.T) {
+	mc := &ffcapimocks.API{}
+	mp := &persistencemocks.TransactionPersistence{}
+
+	mc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	_, err := txhandler.CheckSignerNonces(context.Background(), mp, mc, "0xaaaa")
+	assert.Regexp(t, "pop", err)
+}
+
+func TestCheckSignerNoncesPersistenceFail(t *testing.T) {
+	mc := &ffcapimocks.API{}
+	mp := &persistencemocks.TransactionPersistence{}
+
+	mc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10)}, ffcapi.ErrorReason(""), nil)
+	mp.On("ListTransactionsByNonce", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, fmt.Errorf("pop"))
+
+	_, err := txhandler.CheckSignerNonces(context.Background(), mp, mc, "0xaaaa")
+	assert.Regexp(t, "pop", err)
+}
+
+func TestCheckSignerNoncesReceiptFail(t *testing.T) {
+	mc := &ffcapimocks.API{}
+	mp := &persistencemocks.TransactionPersistence{}
+
+	mc.On("NextNonceForSigner", mock.Anything, mock.Anything).
+		Return(&ffcapi.NextNonceForSignerResponse{Nonce: fftypes.NewFFBigInt(10)}, ffcapi.ErrorReason(""), nil)
+	mp.On("ListTransactionsByNonce", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]*apitypes.ManagedTX{testNonceTX(10, apitypes.TxStatusFailed)}, nil)
+	mp.On("GetTransactionReceipt", mock.Anything, "tx-10").Return(nil, fmt.Errorf("pop"))
+
+	_, err := txhandler.CheckSignerNonces(context.Background(), mp, mc, "0xaaaa")
+	assert.Regexp(t, "pop", err)
+}
@@ -0,0 +1,140 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/firefly-common/pkg/ffapi"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+func toLowerSet(values []string) map[string]bool {
+	if len(values) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// requiresApproval returns true if a new transaction must be held until it is approved, based on its signer or namespace
+func (sth *simpleTransactionHandler) requiresApproval(ctx context.Context, mtx *apitypes.ManagedTX) bool {
+	return sth.approvalSigners[strings.ToLower(mtx.From)] ||
+		sth.approvalNamespaces[strings.ToLower(mtx.Namespace(ctx))]
+}
+
+// approverFromContext returns the identity of the approver, from the passthrough headers of the API request
+func (sth *simpleTransactionHandler) approverFromContext(ctx context.Context) (string, error) {
+	var approver string
+	if headers, ok := ctx.Value(ffapi.CtxHeadersKey{}).(http.Header); ok {
+		approver = headers.Get(sth.approverHeader)
+	}
+	if approver == "" {
+		return "", i18n.NewError(ctx, tmmsgs.MsgApproverRequired, sth.approverHeader)
+	}
+	return approver, nil
+}
+
+func (sth *simpleTransactionHandler) HandleApproveTransaction(ctx context.Context, txID string) (mtx *apitypes.ManagedTX, err error) {
+	approver, err := sth.approverFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := sth.policyEngineAPIRequest(ctx, &policyEngineAPIRequest{
+		requestType: ActionApprove,
+		txID:        txID,
+		approver:    approver,
+	})
+	return res.tx, res.err
+}
+
+func (sth *simpleTransactionHandler) HandleRejectTransaction(ctx context.Context, txID string) (mtx *apitypes.ManagedTX, err error) {
+	approver, err := sth.approverFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := sth.policyEngineAPIRequest(ctx, &policyEngineAPIRequest{
+		requestType: ActionReject,
+		txID:        txID,
+		approver:    approver,
+	})
+	return res.tx, res.err
+}
+
+// approveTX moves a transaction that is awaiting approval to pending, so it is picked up into the in-flight set.
+// The nonce is assigned now, unless it was supplied by the client, or is held back until the dependencies succeed
+// or the policy hook allows the transaction.
+func (sth *simpleTransactionHandler) approveTX(ctx *RunContext) error {
+	mtx := ctx.TX
+	if mtx.Status != apitypes.TxStatusAwaitingApproval {
+		return i18n.NewError(ctx, tmmsgs.MsgTransactionNotAwaitingApproval, mtx.ID, mtx.Status)
+	}
+
+	ctx.SetSubStatus(apitypes.TxSubStatusReceived)
+	ctx.AddSubStatusAction(apitypes.TxActionApprove, approverInfo(ctx.approver), nil, fftypes.Now())
+	if mtx.Nonce == nil && !awaitingDependencies(mtx) && !sth.nonceAwaitsPolicyDecision(ctx) {
+		if err := sth.toolkit.TXPersistence.AssignTransactionNextNonce(ctx, mtx, sth.nextNonceForSigner); err != nil {
+			log.L(ctx).Errorf("Failed to assign nonce to approved transaction %s: %s", mtx.ID, err)
+			return err
+		}
+		ctx.AddSubStatusAction(apitypes.TxActionAssignNonce, fftypes.JSONAnyPtr(`{"nonce":"`+mtx.Nonce.String()+`"}`), nil, fftypes.Now())
+	}
+	ctx.UpdateType = Update
+	mtx.Status = apitypes.TxStatusPending
+	ctx.TXUpdates.Status = &mtx.Status
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "approved")
+	return nil
+}
+
+// rejectTX fails a transaction that is awaiting approval, without it ever being submitted
+func (sth *simpleTransactionHandler) rejectTX(ctx *RunContext) error {
+	mtx := ctx.TX
+	if mtx.Status != apitypes.TxStatusAwaitingApproval {
+		return i18n.NewError(ctx, tmmsgs.MsgTransactionNotAwaitingApproval, mtx.ID, mtx.Status)
+	}
+
+	log.L(ctx).Infof("Transaction %s rejected by '%s'", mtx.ID, ctx.approver)
+	ctx.UpdateType = Update
+	mtx.Status = apitypes.TxStatusFailed
+	ctx.TXUpdates.Status = &mtx.Status
+	errMsg := i18n.NewError(ctx, tmmsgs.MsgTransactionRejected, ctx.approver).Error()
+	mtx.ErrorMessage = errMsg
+	ctx.TXUpdates.ErrorMessage = &errMsg
+	ctx.UpdatedInfo = true
+	ctx.Info.RejectedBy = ctx.approver
+	ctx.SetSubStatus(apitypes.TxSubStatusFailed)
+	ctx.AddSubStatusAction(apitypes.TxActionReject, approverInfo(ctx.approver), nil, fftypes.Now())
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "rejected")
+	return nil
+}
+
+func approverInfo(approver string) *fftypes.JSONAny {
+	b, _ := json.Marshal(map[string]string{
+		"approver": approver,
+	})
+	return fftypes.JSONAnyPtrBytes(b)
+}
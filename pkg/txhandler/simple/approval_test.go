@@ -0,0 +1,390 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/ffapi"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestApprovalHandler(t *testing.T, signers, namespaces []string) (*simpleTransactionHandler, *persistencemocks.Persistence, *ffcapimocks.API, *txhandlermocks.ManagedTxEventHandler) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	approvalConf := conf.SubSection(ApprovalConfig)
+	approvalConf.Set(ApprovalSigners, signers)
+	approvalConf.Set(ApprovalNamespaces, namespaces)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	sth.toolkit.EventHandler = meh
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Maybe()
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), mockFFCAPI, meh
+}
+
+func newTestApprovalRequest(id, from string) *apitypes.TransactionRequest {
+	txReq := &apitypes.TransactionRequest{}
+	txReq.Headers.ID = id
+	txReq.From = from
+	txReq.Nonce = fftypes.NewFFBigInt(42)
+	return txReq
+}
+
+func newTestAwaitingApprovalTX(id string) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID:     id,
+		Status: apitypes.TxStatusAwaitingApproval,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0xaaaa",
+		},
+		PolicyInfo: fftypes.JSONAnyPtr(`{}`),
+	}
+}
+
+func approvalAPIRequest(sth *simpleTransactionHandler, requestType policyEngineAPIRequestType, txID, approver string) policyEngineAPIResponse {
+	apiReq := &policyEngineAPIRequest{
+		requestType: requestType,
+		txID:        txID,
+		approver:    approver,
+		response:    make(chan policyEngineAPIResponse, 1),
+	}
+	sth.policyEngineAPIRequests = append(sth.policyEngineAPIRequests, apiReq)
+	sth.processPolicyAPIRequests(sth.ctx)
+	return <-apiReq.response
+}
+
+func approverContext(header, approver string) context.Context {
+	headers := http.Header{}
+	headers.Set(header, approver)
+	return context.WithValue(context.Background(), ffapi.CtxHeadersKey{}, headers)
+}
+
+func TestNewTransactionAwaitingApprovalSigner(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestApprovalHandler(t, []string{"0xAAAA"}, nil)
+
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Status == apitypes.TxStatusAwaitingApproval && mtx.Nonce == nil
+	})).Return(nil)
+
+	mtx, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, newTestApprovalRequest("tx1", "0xaaaa"))
+	assert.NoError(t, err)
+	assert.False(t, submissionRejected)
+	assert.Equal(t, apitypes.TxStatusAwaitingApproval, mtx.Status)
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertNotCalled(t, "NextNonceForSigner", mock.Anything, mock.Anything)
+}
+
+func TestNewTransactionAwaitingApprovalNamespace(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, nil, []string{"ns1"})
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:tx1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Status == apitypes.TxStatusAwaitingApproval
+	})).Return(nil)
+
+	mtx, _, err := sth.HandleNewTransaction(sth.ctx, newTestApprovalRequest("ns1:tx1", "0xbbbb"))
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusAwaitingApproval, mtx.Status)
+
+	mp.AssertExpectations(t)
+}
+
+func TestNewTransactionAwaitingApprovalClientNonceConflict(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, []string{"0xaaaa"}, nil)
+	sth.clientSuppliedNonces = true
+
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Nonce.Int64() == 42
+	})).Return(i18n.NewError(context.Background(), tmmsgs.MsgTransactionNonceConflict, "0xaaaa", 42))
+
+	_, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, newTestApprovalRequest("tx1", "0xaaaa"))
+	assert.Regexp(t, "FF21090", err)
+	assert.True(t, submissionRejected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestNewTransactionAwaitingApprovalInsertFail(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, []string{"0xaaaa"}, nil)
+
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, newTestApprovalRequest("tx1", "0xaaaa"))
+	assert.Regexp(t, "pop", err)
+	assert.False(t, submissionRejected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestNewTransactionBatchAwaitingApproval(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, []string{"0xaaaa"}, nil)
+
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(nil)
+
+	batch := []*txhandler.NewTransactionBatchItem{
+		{TransactionRequest: newTestApprovalRequest("tx1", "0xaaaa")},
+	}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+	assert.NoError(t, batch[0].Err)
+	assert.Equal(t, apitypes.TxStatusAwaitingApproval, batch[0].ManagedTX.Status)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "InsertTransactionsWithNextNonce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleApproveTransactionQueued(t *testing.T) {
+	sth, _, _, _ := newTestApprovalHandler(t, nil, nil)
+
+	result := make(chan error)
+	go func() {
+		_, err := sth.HandleApproveTransaction(approverContext("X-FireFly-Approver", "approver1"), "tx1")
+		result <- err
+	}()
+
+	for {
+		sth.mux.Lock()
+		queued := len(sth.policyEngineAPIRequests)
+		sth.mux.Unlock()
+		if queued > 0 {
+			break
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+	sth.mux.Lock()
+	req := sth.policyEngineAPIRequests[0]
+	sth.mux.Unlock()
+	assert.Equal(t, ActionApprove, req.requestType)
+	assert.Equal(t, "approver1", req.approver)
+	req.response <- policyEngineAPIResponse{}
+	assert.NoError(t, <-result)
+}
+
+func TestHandleApproveTransactionNoApprover(t *testing.T) {
+	sth, _, _, _ := newTestApprovalHandler(t, nil, nil)
+
+	_, err := sth.HandleApproveTransaction(context.Background(), "tx1")
+	assert.Regexp(t, "FF21119.*X-FireFly-Approver", err)
+
+	_, err = sth.HandleRejectTransaction(approverContext("X-Other", "approver1"), "tx1")
+	assert.Regexp(t, "FF21119", err)
+}
+
+func TestApproveTransaction(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestApprovalHandler(t, nil, nil)
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+	mp.On("AssignTransactionNextNonce", mock.Anything, mtx, mock.Anything).Run(func(args mock.Arguments) {
+		nextNonceCB := args[2].(txhandler.NextNonceCallback)
+		nonce, err := nextNonceCB(context.Background(), "0xaaaa")
+		assert.NoError(t, err)
+		args[1].(*apitypes.ManagedTX).Nonce = fftypes.NewFFBigInt(int64(nonce))
+	}).Return(nil)
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(7),
+	}, ffcapi.ErrorReason(""), nil)
+	mp.On("AddSubStatusAction", mock.Anything, "tx1", apitypes.TxSubStatusReceived, apitypes.TxActionApprove, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"approver":"approver1"}`
+	}), mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "tx1", apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "tx1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.Status == apitypes.TxStatusPending
+	})).Return(nil)
+
+	res := approvalAPIRequest(sth, ActionApprove, "tx1", "approver1")
+	assert.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, apitypes.TxStatusPending, res.tx.Status)
+	assert.Equal(t, int64(7), res.tx.Nonce.Int64())
+
+	// The approved transaction is pulled into the in-flight set
+	assert.True(t, <-sth.inflightStale)
+
+	mp.AssertExpectations(t)
+}
+
+func TestApproveTransactionAwaitingDependencies(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, nil, nil)
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mtx.DependsOn = []string{"tx0"}
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+	mp.On("AddSubStatusAction", mock.Anything, "tx1", apitypes.TxSubStatusReceived, apitypes.TxActionApprove, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "tx1", mock.Anything).Return(nil)
+
+	res := approvalAPIRequest(sth, ActionApprove, "tx1", "approver1")
+	assert.NoError(t, res.err)
+	assert.Equal(t, apitypes.TxStatusPending, res.tx.Status)
+	assert.True(t, awaitingDependencies(res.tx))
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "AssignTransactionNextNonce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApproveTransactionAwaitingPolicyHook(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, nil, nil)
+	sth.policyHook = &policyHook{}
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+	mp.On("AddSubStatusAction", mock.Anything, "tx1", apitypes.TxSubStatusReceived, apitypes.TxActionApprove, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "tx1", mock.Anything).Return(nil)
+
+	// The nonce is assigned once the policy hook allows the transaction
+	res := approvalAPIRequest(sth, ActionApprove, "tx1", "approver1")
+	assert.NoError(t, res.err)
+	assert.Equal(t, apitypes.TxStatusPending, res.tx.Status)
+	assert.Nil(t, res.tx.Nonce)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "AssignTransactionNextNonce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApproveTransactionAssignNonceFail(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, nil, nil)
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+	mp.On("AssignTransactionNextNonce", mock.Anything, mtx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	res := approvalAPIRequest(sth, ActionApprove, "tx1", "approver1")
+	assert.Regexp(t, "pop", res.err)
+	assert.Equal(t, apitypes.TxStatusAwaitingApproval, mtx.Status)
+
+	mp.AssertExpectations(t)
+}
+
+func TestApproveTransactionNotAwaitingApproval(t *testing.T) {
+	sth, mp, _, _ := newTestApprovalHandler(t, nil, nil)
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mtx.Status = apitypes.TxStatusPending
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+
+	res := approvalAPIRequest(sth, ActionApprove, "tx1", "approver1")
+	assert.Regexp(t, "FF21118", res.err)
+
+	res = approvalAPIRequest(sth, ActionReject, "tx1", "approver1")
+	assert.Regexp(t, "FF21118", res.err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestRejectTransaction(t *testing.T) {
+	sth, mp, _, meh := newTestApprovalHandler(t, nil, nil)
+
+	mtx := newTestAwaitingApprovalTX("tx1")
+	mp.On("GetTransactionByID", mock.Anything, "tx1").Return(mtx, nil)
+	mp.On("AddSubStatusAction", mock.Anything, "tx1", apitypes.TxSubStatusFailed, apitypes.TxActionReject, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"approver":"approver1"}`
+	}), mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "tx1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.Status == apitypes.TxStatusFailed && *updates.ErrorMessage != "" &&
+			updates.PolicyInfo.JSONObject().GetString("rejectedBy") == "approver1"
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed
+	})).Return(nil)
+
+	res := approvalAPIRequest(sth, ActionReject, "tx1", "approver1")
+	assert.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, apitypes.TxStatusFailed, res.tx.Status)
+	assert.Regexp(t, "FF21120.*approver1", res.tx.ErrorMessage)
+	assert.Nil(t, res.tx.Nonce)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestApprovedTransactionJoinsBusyInflightSet(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactoryWithFilePersistence(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(MaxInFlight, 2)
+	conf.SubSection(ApprovalConfig).Set(ApprovalSigners, []string{"0xaaaa"})
+	conf.SubSection(PriorityConfig).Set(PriorityScheduling, false)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(100),
+	}, ffcapi.ErrorReason(""), nil)
+
+	// The transaction awaiting approval is received before the traffic of another signer
+	awaiting := newTestPendingTXs("ns1", "0xaaaa", 1)[0]
+	awaiting.Status = apitypes.TxStatusAwaitingApproval
+	awaiting.Nonce, awaiting.SequenceID = nil, ""
+	awaiting.PolicyInfo = fftypes.JSONAnyPtr(`{}`)
+	err = tk.TXPersistence.InsertTransactionPreAssignedNonce(context.Background(), awaiting)
+	assert.NoError(t, err)
+	traffic := newTestPendingTXs("ns1", "0xbbbb", 3)
+	for _, mtx := range traffic {
+		mtx.Status = apitypes.TxStatusPending
+		mtx.Nonce, mtx.SequenceID = nil, ""
+		err := tk.TXPersistence.InsertTransactionWithNextNonce(context.Background(), mtx, func(ctx context.Context, signer string) (uint64, error) {
+			return 0, nil
+		})
+		assert.NoError(t, err)
+	}
+	inflightIDs := func() []string {
+		ids := make([]string, len(sth.inflight))
+		for i, p := range sth.inflight {
+			ids[i] = p.mtx.ID
+		}
+		return ids
+	}
+
+	assert.True(t, sth.updateInflightSet(sth.ctx))
+	assert.Equal(t, []string{traffic[0].ID, traffic[1].ID}, inflightIDs())
+
+	res := approvalAPIRequest(sth, ActionApprove, awaiting.ID, "approver1")
+	assert.NoError(t, res.err)
+	assert.Equal(t, int64(100), res.tx.Nonce.Int64())
+
+	// The approved transaction takes the next space, although transactions received after it remain in-flight
+	sth.inflight[0].remove = true
+	assert.True(t, sth.updateInflightSet(sth.ctx))
+	assert.Equal(t, []string{traffic[1].ID, awaiting.ID}, inflightIDs())
+}
@@ -0,0 +1,70 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+// HandleNewTransactionBatch prepares each item individually, but persists all the prepared transactions together,
+// so the nonces for the whole batch are allocated in one go.
+func (sth *simpleTransactionHandler) HandleNewTransactionBatch(ctx context.Context, batch []*txhandler.NewTransactionBatchItem) {
+	prepared := make([]*txhandler.NewTransactionBatchItem, 0, len(batch))
+	mtxs := make([]*apitypes.ManagedTX, 0, len(batch))
+	for _, item := range batch {
+		var mtx *apitypes.ManagedTX
+		switch {
+		case item.TransactionRequest != nil:
+			mtx, item.SubmissionRejected, item.Err = sth.prepareNewTransaction(ctx, item.TransactionRequest)
+		case item.ContractDeployRequest != nil:
+			mtx, item.SubmissionRejected, item.Err = sth.prepareNewContractDeployment(ctx, item.ContractDeployRequest)
+		default:
+			item.SubmissionRejected, item.Err = true, i18n.NewError(ctx, tmmsgs.MsgTransactionOpInvalid)
+		}
+		if item.Err != nil {
+			continue
+		}
+		if (sth.clientSuppliedNonces && mtx.Nonce != nil) || len(mtx.DependsOn) > 0 || sth.requiresApproval(ctx, mtx) || sth.policyHook != nil {
+			// A supplied nonce is persisted as-is, and a transaction with dependencies, awaiting approval, or awaiting the
+			// policy hook is persisted without one, so none of these are part of the allocation for the batch
+			item.ManagedTX, item.SubmissionRejected, item.Err = sth.insertManagedTx(ctx, mtx)
+			continue
+		}
+		prepared = append(prepared, item)
+		mtxs = append(mtxs, mtx)
+	}
+	if len(mtxs) == 0 {
+		return
+	}
+
+	errs := sth.toolkit.TXPersistence.InsertTransactionsWithNextNonce(ctx, mtxs, sth.nextNonceForSigner)
+	for i, item := range prepared {
+		item.Err = errs[i]
+		if item.Err == nil {
+			item.Err = sth.recordNonceAssigned(ctx, mtxs[i])
+		}
+		if item.Err == nil {
+			item.ManagedTX = mtxs[i]
+		}
+	}
+	sth.markInflightStale()
+}
@@ -0,0 +1,108 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleNewTransactionBatch(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionReverted, fmt.Errorf("reverted")).Once()
+	mockFFCAPI.On("DeployContractPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_DEPLOY_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Twice()
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10),
+	}, ffcapi.ErrorReason(""), nil)
+
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("InsertTransactionsWithNextNonce", mock.Anything, mock.MatchedBy(func(txs []*apitypes.ManagedTX) bool {
+		return len(txs) == 3 && txs[0].TransactionData == "RAW_UNSIGNED_BYTES" && txs[1].TransactionData == "RAW_DEPLOY_BYTES"
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		txs := args[1].([]*apitypes.ManagedTX)
+		cb := args[2].(txhandler.NextNonceCallback)
+		nonce, err := cb(context.Background(), txs[0].From)
+		assert.NoError(t, err)
+		for i, tx := range txs {
+			tx.Nonce = fftypes.NewFFBigInt(int64(nonce) + int64(i))
+		}
+	}).Return([]error{nil, nil, fmt.Errorf("pop")})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("history fail")).Once()
+
+	batch := []*txhandler.NewTransactionBatchItem{
+		{TransactionRequest: &apitypes.TransactionRequest{}},
+		{TransactionRequest: &apitypes.TransactionRequest{}},
+		{ContractDeployRequest: &apitypes.ContractDeployRequest{}},
+		{},
+		{ContractDeployRequest: &apitypes.ContractDeployRequest{}},
+	}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+
+	assert.NoError(t, batch[0].Err)
+	assert.Equal(t, int64(10), batch[0].ManagedTX.Nonce.Int64())
+	assert.Regexp(t, "reverted", batch[1].Err)
+	assert.True(t, batch[1].SubmissionRejected)
+	assert.Regexp(t, "history fail", batch[2].Err)
+	assert.Nil(t, batch[2].ManagedTX)
+	assert.Regexp(t, "FF21086", batch[3].Err)
+	assert.Regexp(t, "pop", batch[4].Err)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestHandleNewTransactionBatchAllFailPrepare(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	batch := []*txhandler.NewTransactionBatchItem{
+		{TransactionRequest: &apitypes.TransactionRequest{
+			Headers: apitypes.RequestHeaders{Expiry: "wrong"},
+		}},
+	}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+	assert.Regexp(t, "FF21091", batch[0].Err)
+
+	mockFFCAPI.AssertExpectations(t)
+}
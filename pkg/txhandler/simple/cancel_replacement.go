@@ -0,0 +1,157 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// cancelReplacementInfo is stored in the policy info of a transaction that is being cancelled, by
+// replacing it on the blockchain with a zero value transfer to the signer at the same nonce.
+type cancelReplacementInfo struct {
+	OriginalTransactionHash string            `json:"originalTransactionHash"`
+	TransactionHash         string            `json:"transactionHash,omitempty"`
+	TransactionData         string            `json:"transactionData,omitempty"`
+	Gas                     *fftypes.FFBigInt `json:"gas,omitempty"`
+	GasPrice                *fftypes.JSONAny  `json:"gasPrice,omitempty"`
+	LastSubmit              *fftypes.FFTime   `json:"lastSubmit,omitempty"`
+	// Failed is set if the original transaction was mined before the replacement, so the
+	// transaction will complete based on the receipt of the original transaction
+	Failed bool `json:"failed,omitempty"`
+}
+
+// cancelReplacementMined returns true if the receipt we are processing is for a successful cancel replacement transaction.
+// The replacement is either for a deletion request, or for a transaction that expired after it was submitted.
+// The receipt must be for the hash of the replacement, as the original transaction might have been mined instead.
+func (ctx *RunContext) cancelReplacementMined() bool {
+	return ctx.Info != nil && ctx.Info.CancelReplacement != nil && !ctx.Info.CancelReplacement.Failed &&
+		ctx.Receipt != nil && ctx.Receipt.Success &&
+		ctx.TX.TransactionHash == ctx.Info.CancelReplacement.TransactionHash
+}
+
+// processCancelReplacement is called for a transaction where deletion has been requested, or that has expired, after it was
+// submitted to the blockchain. The transaction record is only removed (or failed) once the replacement has been mined and confirmed.
+func (sth *simpleTransactionHandler) processCancelReplacement(ctx *RunContext) error {
+	mtx := ctx.TX
+	cr := ctx.Info.CancelReplacement
+	if ctx.Receipt != nil || (cr != nil && cr.Failed) {
+		// Waiting for the confirmations to complete the transaction
+		return nil
+	}
+	if cr != nil && cr.LastSubmit != nil && time.Since(*cr.LastSubmit.Time()) < sth.resubmitInterval {
+		// Waiting for a receipt for the replacement
+		return nil
+	}
+
+	replacementHeaders := ffcapi.TransactionHeaders{
+		From:  mtx.From,
+		To:    mtx.From,
+		Nonce: mtx.Nonce,
+		Value: fftypes.NewFFBigInt(0),
+	}
+	previousGasPrice := mtx.GasPrice
+	if cr == nil {
+		prepared, _, err := sth.toolkit.Connector.TransactionPrepare(ctx, &ffcapi.TransactionPrepareRequest{
+			TransactionInput: ffcapi.TransactionInput{
+				TransactionHeaders: replacementHeaders,
+			},
+		})
+		if err != nil {
+			ctx.AddSubStatusAction(apitypes.TxActionSubmitCancelReplacement, nil, fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
+			return err
+		}
+		cr = &cancelReplacementInfo{
+			OriginalTransactionHash: mtx.TransactionHash,
+			TransactionData:         prepared.TransactionData,
+			Gas:                     prepared.Gas,
+		}
+	} else if cr.GasPrice != nil {
+		previousGasPrice = cr.GasPrice
+	}
+
+	// The replacement must be priced above the transaction it replaces to be accepted into the transaction pool
+	gasPrice, err := sth.getGasPrice(ctx, sth.toolkit.Connector)
+	if err != nil {
+		ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, nil, fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
+		return err
+	}
+	gasPrice, _ = sth.escalateGasPrice(previousGasPrice, gasPrice, sth.cancelGasBumpPercentage)
+	ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, fftypes.JSONAnyPtr(`{"gasPrice":`+string(*gasPrice)+`}`), nil, fftypes.Now())
+
+	replacementHeaders.Gas = cr.Gas
+	res, reason, err := sth.toolkit.Connector.TransactionSend(ctx, &ffcapi.TransactionSendRequest{
+		TransactionHeaders: replacementHeaders,
+		GasPrice:           gasPrice,
+		TransactionData:    cr.TransactionData,
+	})
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "cancel_replacement_submission")
+	ctx.UpdateType = Update
+	ctx.UpdatedInfo = true
+	ctx.Info.CancelReplacement = cr
+	if err != nil {
+		ctx.AddSubStatusAction(apitypes.TxActionSubmitCancelReplacement, fftypes.JSONAnyPtr(`{"reason":"`+string(reason)+`"}`), fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
+		switch reason {
+		case ffcapi.ErrorKnownTransaction, ffcapi.ErrorReasonNonceTooLow:
+			// The nonce has been consumed - either by a replacement we submitted previously, or by the original transaction
+			return sth.checkOriginalMined(ctx, cr, err)
+		default:
+			return err
+		}
+	}
+
+	log.L(ctx).Infof("Transaction %s at nonce %s / %d cancel replacement submitted. Hash: %s (replaces %s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), res.TransactionHash, cr.OriginalTransactionHash)
+	cr.TransactionHash = res.TransactionHash
+	cr.GasPrice = gasPrice
+	cr.LastSubmit = fftypes.Now()
+	ctx.AddSubStatusAction(apitypes.TxActionSubmitCancelReplacement, fftypes.JSONAnyPtr(`{"transactionHash":"`+cr.TransactionHash+`","originalTransactionHash":"`+cr.OriginalTransactionHash+`"}`), nil, fftypes.Now())
+
+	// Switching the transaction hash moves receipt tracking over to the replacement
+	mtx.TransactionHash = cr.TransactionHash
+	ctx.TXUpdates.TransactionHash = &mtx.TransactionHash
+	ctx.SetSubStatus(apitypes.TxSubStatusTracking)
+	return nil
+}
+
+func (sth *simpleTransactionHandler) checkOriginalMined(ctx *RunContext, cr *cancelReplacementInfo, sendErr error) error {
+	mtx := ctx.TX
+	// A transaction that expired before it was submitted has no original transaction that could have been mined
+	if cr.OriginalTransactionHash != "" {
+		mined, err := sth.transactionMined(ctx, cr.OriginalTransactionHash)
+		if err != nil {
+			return err
+		}
+		if mined {
+			// The original transaction won the race - we go back to tracking it, and it will complete as normal
+			log.L(ctx).Warnf("Transaction %s at nonce %s / %d could not be cancelled, as it was mined with hash %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), cr.OriginalTransactionHash)
+			cr.Failed = true
+			mtx.TransactionHash = cr.OriginalTransactionHash
+			ctx.TXUpdates.TransactionHash = &mtx.TransactionHash
+			return nil
+		}
+	}
+	if cr.TransactionHash != "" {
+		// Our replacement has been accepted previously - wait for the receipt
+		cr.LastSubmit = fftypes.Now()
+		return nil
+	}
+	return sendErr
+}
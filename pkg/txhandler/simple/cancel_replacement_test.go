@@ -0,0 +1,466 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testCancelSigner = "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712"
+
+func newTestCancelReplaceHandler(t *testing.T) (*simpleTransactionHandler, *ffcapimocks.API) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.Set(ResubmitInterval, "1h")
+	conf.SubSection(CancelConfig).Set(CancelMode, CancelModeReplace)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	return sth, mockFFCAPI
+}
+
+func newTestCancelledTX() *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID: "ns1:" + fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  testCancelSigner,
+			To:    "0xe1a078b9e2b145d0a7387f09277c6ae1d9470771",
+			Nonce: fftypes.NewFFBigInt(42),
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+		TransactionHash: "0xoriginal",
+		FirstSubmit:     fftypes.Now(),
+		GasPrice:        fftypes.JSONAnyPtr(`2000`),
+		DeleteRequested: fftypes.Now(),
+	}
+}
+
+func TestCancelModeInvalid(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(CancelConfig).Set(CancelMode, "wrong")
+	_, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21088", err)
+}
+
+func TestCancelReplaceNotSubmittedDeletes(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+	mtx.TransactionHash = ""
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Delete, rc.UpdateType)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceSubmitOk(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.From == testCancelSigner && req.To == testCancelSigner &&
+			req.Value.Int64() == 0 && req.Nonce.Int64() == 42 && req.Method == nil
+	})).Return(&ffcapi.TransactionPrepareResponse{
+		Gas:             fftypes.NewFFBigInt(21000),
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "CANCEL_TX_BYTES" && req.GasPrice.String() == `2200` &&
+			req.Nonce.Int64() == 42 && req.Gas.Int64() == 21000 && req.To == testCancelSigner
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xreplacement",
+	}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.True(t, rc.UpdatedInfo)
+	assert.Equal(t, "0xreplacement", *rc.TXUpdates.TransactionHash)
+	assert.Equal(t, "0xreplacement", mtx.TransactionHash)
+	assert.Equal(t, "SOME_RAW_TX_BYTES", mtx.TransactionData)
+	cr := rc.Info.CancelReplacement
+	assert.Equal(t, "0xoriginal", cr.OriginalTransactionHash)
+	assert.Equal(t, "0xreplacement", cr.TransactionHash)
+	assert.Equal(t, `2200`, cr.GasPrice.String())
+	assert.NotNil(t, cr.LastSubmit)
+	assert.Equal(t, apitypes.TxSubStatusTracking, rc.SubStatus)
+	assert.Len(t, rc.HistoryUpdates, 2)
+
+	// Nothing more happens until the resubmit interval
+	rc = newTestRunContext(mtx, nil)
+	rc.Info.CancelReplacement = cr
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+
+	// Nor once we have a receipt
+	sth.resubmitInterval = 0
+	rc = newTestRunContext(mtx, &ffcapi.TransactionReceiptResponse{Success: true})
+	rc.Info.CancelReplacement = cr
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceResubmitEscalatesFromReplacement(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+	sth.resubmitInterval = 0
+
+	mtx := newTestCancelledTX()
+	mtx.TransactionHash = "0xreplacement"
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "CANCEL_TX_BYTES" && req.GasPrice.String() == `2420`
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xreplacement2",
+	}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	rc.Info.CancelReplacement = &cancelReplacementInfo{
+		OriginalTransactionHash: "0xoriginal",
+		TransactionHash:         "0xreplacement",
+		TransactionData:         "CANCEL_TX_BYTES",
+		GasPrice:                fftypes.JSONAnyPtr(`2200`),
+		LastSubmit:              fftypes.Now(),
+	}
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xreplacement2", mtx.TransactionHash)
+	assert.Equal(t, "0xoriginal", rc.Info.CancelReplacement.OriginalTransactionHash)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplacePrepareFail(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	rc := newTestRunContext(newTestCancelledTX(), nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, rc.Info.CancelReplacement)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceGasPriceFail(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+	sth.gasOracleSources = []*gasOracleSource{{name: GasOracleModeConnector, mode: GasOracleModeConnector}}
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	rc := newTestRunContext(newTestCancelledTX(), nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceSendFail(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionUnderpriced, fmt.Errorf("pop"))
+
+	mtx := newTestCancelledTX()
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+	assert.Equal(t, "0xoriginal", mtx.TransactionHash)
+	// The prepared replacement is retained for the next attempt
+	assert.Equal(t, "CANCEL_TX_BYTES", rc.Info.CancelReplacement.TransactionData)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceOriginalMined(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNonceTooLow, fmt.Errorf("nonce too low"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, &ffcapi.TransactionReceiptRequest{
+		TransactionHash: "0xoriginal",
+	}).Return(&ffcapi.TransactionReceiptResponse{Success: true}, ffcapi.ErrorReason(""), nil)
+
+	mtx := newTestCancelledTX()
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.True(t, rc.Info.CancelReplacement.Failed)
+	assert.Equal(t, "0xoriginal", *rc.TXUpdates.TransactionHash)
+
+	// No further attempts to cancel
+	rc = newTestRunContext(mtx, nil)
+	rc.Info.CancelReplacement = &cancelReplacementInfo{Failed: true}
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceKnownAwaitingReceipt(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+	sth.resubmitInterval = 0
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorKnownTransaction, fmt.Errorf("known"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNotFound, fmt.Errorf("not found"))
+
+	mtx := newTestCancelledTX()
+	mtx.TransactionHash = "0xreplacement"
+	rc := newTestRunContext(mtx, nil)
+	lastSubmit := fftypes.FFTime(time.Now().Add(-1 * time.Hour))
+	rc.Info.CancelReplacement = &cancelReplacementInfo{
+		OriginalTransactionHash: "0xoriginal",
+		TransactionHash:         "0xreplacement",
+		TransactionData:         "CANCEL_TX_BYTES",
+		LastSubmit:              &lastSubmit,
+	}
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.False(t, rc.Info.CancelReplacement.Failed)
+	assert.True(t, rc.Info.CancelReplacement.LastSubmit.Time().After(*lastSubmit.Time()))
+	assert.Nil(t, rc.TXUpdates.TransactionHash)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceNonceTooLowNoReplacement(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNonceTooLow, fmt.Errorf("nonce too low"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNotFound, fmt.Errorf("not found"))
+
+	rc := newTestRunContext(newTestCancelledTX(), nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "nonce too low", err)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceReceiptQueryFail(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNonceTooLow, fmt.Errorf("nonce too low"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	rc := newTestRunContext(newTestCancelledTX(), nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestCancelReplaceMinedDeletesTransaction(t *testing.T) {
+	sth, _ := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+	mtx.TransactionHash = "0xreplacement"
+
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionConfirmTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, mtx.ID, false, mock.Anything).Return(nil)
+	mp.On("DeleteTransaction", mock.Anything, mtx.ID).Return(nil)
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXDeleted && e.Tx.ID == mtx.ID
+	})).Return(nil)
+	sth.toolkit.EventHandler = meh
+
+	pending := &pendingState{
+		mtx:     mtx,
+		receipt: &ffcapi.TransactionReceiptResponse{Success: true},
+		info: &simplePolicyInfo{
+			CancelReplacement: &cancelReplacementInfo{
+				OriginalTransactionHash: "0xoriginal",
+				TransactionHash:         "0xreplacement",
+			},
+		},
+		confirmed:     true,
+		confirmNotify: fftypes.Now(),
+		confirmations: &apitypes.ConfirmationsNotification{Confirmed: true},
+	}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatus(""), mtx.Status)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestCancelReplaceOriginalWinsRace(t *testing.T) {
+	sth, _ := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+	mtx.TransactionHash = "0xreplacement"
+
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("SetTransactionReceipt", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, mtx.ID, false, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return updates.Status != nil && *updates.Status == apitypes.TxStatusSucceeded &&
+			updates.TransactionHash != nil && *updates.TransactionHash == "0xoriginal"
+	})).Return(nil)
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessSucceeded && e.Tx.ID == mtx.ID
+	})).Return(nil)
+	sth.toolkit.EventHandler = meh
+
+	// The receipt arrives for the original hash, rather than the replacement
+	pending := &pendingState{
+		mtx:           mtx,
+		receipt:       &ffcapi.TransactionReceiptResponse{Success: true},
+		receiptHash:   "0xoriginal",
+		receiptNotify: fftypes.Now(),
+		info: &simplePolicyInfo{
+			CancelReplacement: &cancelReplacementInfo{
+				OriginalTransactionHash: "0xoriginal",
+				TransactionHash:         "0xreplacement",
+			},
+		},
+		confirmed:     true,
+		confirmNotify: fftypes.Now(),
+		confirmations: &apitypes.ConfirmationsNotification{Confirmed: true},
+	}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusSucceeded, mtx.Status)
+	assert.Equal(t, "0xoriginal", mtx.TransactionHash)
+	assert.True(t, pending.info.CancelReplacement.Failed)
+
+	mp.AssertNotCalled(t, "DeleteTransaction", mock.Anything, mock.Anything)
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestCancelReplaceFailedReportsStatus(t *testing.T) {
+	sth, _ := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionConfirmTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, mtx.ID, false, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed && e.Tx.ID == mtx.ID
+	})).Return(nil)
+	sth.toolkit.EventHandler = meh
+
+	pending := &pendingState{
+		mtx:     mtx,
+		receipt: &ffcapi.TransactionReceiptResponse{Success: false},
+		info: &simplePolicyInfo{
+			CancelReplacement: &cancelReplacementInfo{
+				OriginalTransactionHash: "0xoriginal",
+				TransactionHash:         "0xreplacement",
+			},
+		},
+		confirmed:     true,
+		confirmNotify: fftypes.Now(),
+		confirmations: &apitypes.ConfirmationsNotification{Confirmed: true},
+	}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusFailed, mtx.Status)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestCancelReplaceAPIRequestOutsideInflight(t *testing.T) {
+	sth, mockFFCAPI := newTestCancelReplaceHandler(t)
+
+	mtx := newTestCancelledTX()
+	mtx.DeleteRequested = nil
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{}`)
+
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(u *apitypes.TXUpdates) bool {
+		return u.DeleteRequested != nil && *u.TransactionHash == "0xreplacement" && u.PolicyInfo != nil
+	})).Return(nil)
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0xreplacement"
+	})).Return(nil)
+	sth.toolkit.EventHandler = meh
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xreplacement",
+	}, ffcapi.ErrorReason(""), nil)
+
+	req := &policyEngineAPIRequest{
+		requestType: ActionDelete,
+		txID:        mtx.ID,
+		response:    make(chan policyEngineAPIResponse, 1),
+	}
+	sth.policyEngineAPIRequests = append(sth.policyEngineAPIRequests, req)
+	sth.processPolicyAPIRequests(sth.ctx)
+
+	res := <-req.response
+	assert.NoError(t, res.err)
+	assert.Equal(t, http.StatusAccepted, res.status)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+	mockFFCAPI.AssertExpectations(t)
+}
@@ -0,0 +1,128 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestClientNoncesHandler(t *testing.T, clientSuppliedNonces bool) (*simpleTransactionHandler, *persistencemocks.Persistence, *ffcapimocks.API) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(ClientSuppliedNonces, clientSuppliedNonces)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), mockFFCAPI
+}
+
+func newTestClientNonceRequest(nonce int64) *apitypes.TransactionRequest {
+	txReq := &apitypes.TransactionRequest{}
+	txReq.From = "0xaaaa"
+	txReq.Nonce = fftypes.NewFFBigInt(nonce)
+	return txReq
+}
+
+func TestClientSuppliedNonce(t *testing.T) {
+	sth, mp, mockFFCAPI := newTestClientNoncesHandler(t, true)
+
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Nonce.Int64() == 42
+	})).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mtx, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, newTestClientNonceRequest(42))
+	assert.NoError(t, err)
+	assert.False(t, submissionRejected)
+	assert.Equal(t, int64(42), mtx.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertNotCalled(t, "NextNonceForSigner", mock.Anything, mock.Anything)
+}
+
+func TestClientSuppliedNonceConflict(t *testing.T) {
+	sth, mp, _ := newTestClientNoncesHandler(t, true)
+
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).
+		Return(i18n.NewError(context.Background(), tmmsgs.MsgTransactionNonceConflict, "0xaaaa", 42))
+
+	_, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, newTestClientNonceRequest(42))
+	assert.Regexp(t, "FF21090", err)
+	assert.True(t, submissionRejected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestClientSuppliedNonceDisabled(t *testing.T) {
+	sth, mp, _ := newTestClientNoncesHandler(t, false)
+
+	// The nonce in the request is replaced by the next nonce for the signer
+	mp.On("InsertTransactionWithNextNonce", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(*apitypes.ManagedTX).Nonce = fftypes.NewFFBigInt(10)
+	}).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mtx, _, err := sth.HandleNewTransaction(sth.ctx, newTestClientNonceRequest(42))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), mtx.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+}
+
+func TestClientSuppliedNonceBatch(t *testing.T) {
+	sth, mp, _ := newTestClientNoncesHandler(t, true)
+
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).
+		Return(i18n.NewError(context.Background(), tmmsgs.MsgTransactionNonceConflict, "0xaaaa", 42))
+	mp.On("InsertTransactionsWithNextNonce", mock.Anything, mock.MatchedBy(func(txs []*apitypes.ManagedTX) bool {
+		return len(txs) == 1 && txs[0].Nonce == nil
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		args[1].([]*apitypes.ManagedTX)[0].Nonce = fftypes.NewFFBigInt(10)
+	}).Return([]error{nil})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	batch := []*txhandler.NewTransactionBatchItem{
+		{TransactionRequest: newTestClientNonceRequest(42)},
+		{TransactionRequest: &apitypes.TransactionRequest{}},
+	}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+
+	assert.Regexp(t, "FF21090", batch[0].Err)
+	assert.True(t, batch[0].SubmissionRejected)
+	assert.NoError(t, batch[1].Err)
+	assert.Equal(t, int64(10), batch[1].ManagedTX.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+}
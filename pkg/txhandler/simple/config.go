@@ -25,7 +25,10 @@ import (
 )
 
 const (
-	MaxInFlight = "maxInFlight"
+	MaxInFlight   = "maxInFlight"
+	PolicyWorkers = "policyWorkers" // number of workers executing the policy engine against the in-flight transactions, which are shared out by signer
+
+	ClientSuppliedNonces = "clientSuppliedNonces" // persist the nonce supplied in the headers of a transaction request, instead of assigning the next nonce
 
 	Interval       = "interval"
 	RetryInitDelay = "retry.initialDelay"
@@ -39,6 +42,62 @@ const (
 	GasOracleMethod        = "method"
 	GasOracleTemplate      = "template"
 	GasOracleQueryInterval = "queryInterval"
+
+	GasOracleAggregation            = "aggregation"      // how to combine the gas prices from multiple sources
+	GasOracleHistorySize            = "historySize"      // number of recent gas price queries to keep in memory, for the gas price history
+	GasOracleSources                = "sources"          // an ordered list of gas oracles, each with its own mode, template and query interval
+	GasOracleSourceName             = "name"             // identifies the source in the gas price history
+	GasOracleSourceHTTP             = "http"             // the REST API client configuration of a restapi source
+	GasOracleSourceMaxFailures      = "maxFailures"      // consecutive failures after which a source is dropped
+	GasOracleSourceRecoveryInterval = "recoveryInterval" // how long a dropped source is left out, before it is queried again
+
+	GasEscalationConfig      = "gasEscalation"
+	GasEscalationPercentage  = "percentage"  // percentage to increase the previously submitted gas price by, on each resubmission of a stale transaction
+	GasEscalationMaxGasPrice = "maxGasPrice" // upper limit applied to each numeric field of the gas price after escalation
+
+	CancelConfig            = "cancel"
+	CancelMode              = "mode"              // how to cancel a transaction that has already been submitted to the blockchain
+	CancelGasBumpPercentage = "gasBumpPercentage" // percentage increase over the last gas price, for the replacement transaction to be accepted
+
+	NonceGapCheckConfig   = "nonceGapCheck"
+	NonceGapCheckEnabled  = "enabled"  // periodically compare the nonces of in-flight transactions with the next nonce on the chain
+	NonceGapCheckInterval = "interval" // how often to check for gaps
+	NonceGapCheckFill     = "fill"     // submit a zero value transfer to the signer, to fill each nonce gap that is found
+
+	ExpiryConfig            = "expiry"
+	ExpirySubmittedStrategy = "submittedStrategy" // what to do with a transaction that expires after it has been submitted to the blockchain
+
+	SpendGuardConfig          = "spendGuard"
+	SpendGuardMaxGasPrice     = "maxGasPrice"     // transactions are held back while any numeric field of the gas price is above this value
+	SpendGuardNamespaceMaxFee = "namespaceMaxFee" // maximum total fee (gas multiplied by gas price) submitted for each namespace within the window
+	SpendGuardNamespaceWindow = "namespaceWindow" // the rolling time window for the namespace fee limit
+
+	BalanceCheckConfig       = "balanceCheck"
+	BalanceCheckInitialDelay = "initialDelay" // delay before the first balance check of a signer paused for insufficient funds
+	BalanceCheckMaxDelay     = "maxDelay"     // maximum delay between balance checks
+	BalanceCheckFactor       = "factor"       // factor to increase the delay by, after each balance check that does not cover the cost
+
+	SchedulingConfig               = "scheduling"
+	SchedulingFair                 = "fair"                 // fill the in-flight set round-robin across signers, rather than in the order transactions were received
+	SchedulingMaxInFlightPerSigner = "maxInFlightPerSigner" // the most transactions of a single signer that can be in-flight at once
+	SchedulingPendingScanLimit     = "pendingScanLimit"     // how many signers with pending transactions to read at a time, when choosing which transactions to add to the in-flight set
+	SchedulingNamespaceWeights     = "namespaceWeights"     // a list of namespaces with a weight, for the share of the in-flight set their signers get
+	SchedulingNamespace            = "namespace"
+	SchedulingWeight               = "weight"
+
+	PriorityConfig              = "priority"
+	PriorityScheduling          = "scheduling"          // prefer the signers with the highest priority transactions waiting, when filling the in-flight set
+	PriorityGasPriceMultipliers = "gasPriceMultipliers" // a list of multipliers applied to the gas price of transactions, by priority
+	PriorityMinPriority         = "minPriority"
+	PriorityMultiplier          = "multiplier"
+
+	ApprovalConfig         = "approval"
+	ApprovalSigners        = "signers"        // new transactions from these signers are held until approved
+	ApprovalNamespaces     = "namespaces"     // new transactions in these namespaces are held until approved
+	ApprovalApproverHeader = "approverHeader" // the passthrough header that identifies who approved or rejected a transaction
+
+	PolicyHookConfig      = "policyHook"
+	PolicyHookUnavailable = "unavailable" // the decision to apply when the policy hook cannot be called
 )
 
 const (
@@ -46,7 +105,22 @@ const (
 	GasOracleModeRESTAPI   = "restapi"
 	GasOracleModeConnector = "connector"
 
+	GasOracleAggregationFirstSuccess = "firstSuccess"
+	GasOracleAggregationMedian       = "median"
+	GasOracleAggregationMax          = "max"
+
+	CancelModeDelete  = "delete"
+	CancelModeReplace = "replace"
+
+	ExpiryStrategyStopTracking = "stopTracking"
+	ExpiryStrategyCancel       = "cancel"
+
+	PolicyDecisionAllow = "allow"
+	PolicyDecisionDeny  = "deny"
+	PolicyDecisionDefer = "defer"
+
 	defaultMaxInFlight    = 100
+	defaultPolicyWorkers  = 1
 	defaultInterval       = "10s"
 	defaultRetryInitDelay = "250ms"
 	defaultRetryMaxDelay  = "30s"
@@ -54,10 +128,30 @@ const (
 )
 
 const (
-	defaultResubmitInterval       = "5m"
-	defaultGasOracleQueryInterval = "5m"
-	defaultGasOracleMethod        = http.MethodGet
-	defaultGasOracleMode          = GasOracleModeConnector
+	defaultResubmitInterval          = "5m"
+	defaultGasOracleQueryInterval    = "5m"
+	defaultGasOracleMethod           = http.MethodGet
+	defaultGasOracleMode             = GasOracleModeConnector
+	defaultGasOracleAggregation      = GasOracleAggregationFirstSuccess
+	defaultGasOracleHistorySize      = 50
+	defaultGasOracleMaxFailures      = 3
+	defaultGasOracleRecoveryInterval = "5m"
+	defaultGasEscalationPercentage   = 0
+	defaultCancelMode                = CancelModeDelete
+	defaultCancelGasBumpPercentage   = 10
+	defaultNonceGapCheckEnabled      = false
+	defaultNonceGapCheckInterval     = "1m"
+	defaultNonceGapCheckFill         = false
+	defaultExpirySubmittedStrategy   = ExpiryStrategyStopTracking
+	defaultSpendGuardNamespaceWindow = "1h"
+	defaultBalanceCheckInitialDelay  = "30s"
+	defaultBalanceCheckMaxDelay      = "10m"
+	defaultBalanceCheckFactor        = 2.0
+	defaultPendingScanLimit          = 1000
+	defaultNamespaceWeight           = 1
+	defaultPriorityScheduling        = true
+	defaultApprovalApproverHeader    = "X-FireFly-Approver"
+	defaultPolicyHookUnavailable     = PolicyDecisionDefer
 )
 
 func (f *TransactionHandlerFactory) InitConfig(conf config.Section) {
@@ -65,6 +159,8 @@ func (f *TransactionHandlerFactory) InitConfig(conf config.Section) {
 	conf.AddKnownKey(ResubmitInterval, defaultResubmitInterval)
 
 	conf.AddKnownKey(MaxInFlight, defaultMaxInFlight)
+	conf.AddKnownKey(PolicyWorkers, defaultPolicyWorkers)
+	conf.AddKnownKey(ClientSuppliedNonces, false)
 	conf.AddKnownKey(Interval, defaultInterval)
 	conf.AddKnownKey(RetryInitDelay, defaultRetryInitDelay)
 	conf.AddKnownKey(RetryMaxDelay, defaultRetryMaxDelay)
@@ -76,6 +172,54 @@ func (f *TransactionHandlerFactory) InitConfig(conf config.Section) {
 	gasOracleConfig.AddKnownKey(GasOracleMode, defaultGasOracleMode)
 	gasOracleConfig.AddKnownKey(GasOracleQueryInterval, defaultGasOracleQueryInterval)
 	gasOracleConfig.AddKnownKey(GasOracleTemplate)
+	gasOracleConfig.AddKnownKey(GasOracleAggregation, defaultGasOracleAggregation)
+	gasOracleConfig.AddKnownKey(GasOracleHistorySize, defaultGasOracleHistorySize)
+	initGasOracleSourcesConfig(gasOracleConfig)
+
+	gasEscalationConfig := conf.SubSection(GasEscalationConfig)
+	gasEscalationConfig.AddKnownKey(GasEscalationPercentage, defaultGasEscalationPercentage)
+	gasEscalationConfig.AddKnownKey(GasEscalationMaxGasPrice)
+
+	cancelConfig := conf.SubSection(CancelConfig)
+	cancelConfig.AddKnownKey(CancelMode, defaultCancelMode)
+	cancelConfig.AddKnownKey(CancelGasBumpPercentage, defaultCancelGasBumpPercentage)
+
+	nonceGapCheckConfig := conf.SubSection(NonceGapCheckConfig)
+	nonceGapCheckConfig.AddKnownKey(NonceGapCheckEnabled, defaultNonceGapCheckEnabled)
+	nonceGapCheckConfig.AddKnownKey(NonceGapCheckInterval, defaultNonceGapCheckInterval)
+	nonceGapCheckConfig.AddKnownKey(NonceGapCheckFill, defaultNonceGapCheckFill)
+
+	expiryConfig := conf.SubSection(ExpiryConfig)
+	expiryConfig.AddKnownKey(ExpirySubmittedStrategy, defaultExpirySubmittedStrategy)
+
+	spendGuardConfig := conf.SubSection(SpendGuardConfig)
+	spendGuardConfig.AddKnownKey(SpendGuardMaxGasPrice)
+	spendGuardConfig.AddKnownKey(SpendGuardNamespaceMaxFee)
+	spendGuardConfig.AddKnownKey(SpendGuardNamespaceWindow, defaultSpendGuardNamespaceWindow)
+
+	balanceCheckConfig := conf.SubSection(BalanceCheckConfig)
+	balanceCheckConfig.AddKnownKey(BalanceCheckInitialDelay, defaultBalanceCheckInitialDelay)
+	balanceCheckConfig.AddKnownKey(BalanceCheckMaxDelay, defaultBalanceCheckMaxDelay)
+	balanceCheckConfig.AddKnownKey(BalanceCheckFactor, defaultBalanceCheckFactor)
+
+	schedulingConfig := conf.SubSection(SchedulingConfig)
+	schedulingConfig.AddKnownKey(SchedulingFair, false)
+	schedulingConfig.AddKnownKey(SchedulingMaxInFlightPerSigner, 0)
+	schedulingConfig.AddKnownKey(SchedulingPendingScanLimit, defaultPendingScanLimit)
+	initNamespaceWeightsConfig(schedulingConfig)
+
+	priorityConfig := conf.SubSection(PriorityConfig)
+	priorityConfig.AddKnownKey(PriorityScheduling, defaultPriorityScheduling)
+	initPriorityGasPriceConfig(priorityConfig)
+
+	approvalConfig := conf.SubSection(ApprovalConfig)
+	approvalConfig.AddKnownKey(ApprovalSigners)
+	approvalConfig.AddKnownKey(ApprovalNamespaces)
+	approvalConfig.AddKnownKey(ApprovalApproverHeader, defaultApprovalApproverHeader)
+
+	policyHookConfig := conf.SubSection(PolicyHookConfig)
+	ffresty.InitConfig(policyHookConfig)
+	policyHookConfig.AddKnownKey(PolicyHookUnavailable, defaultPolicyHookUnavailable)
 
 	// Init the deprecated policy engine config in case people are still using them
 	legacyConfig := tmconfig.DeprecatedPolicyEngineBaseConfig.SubSection(f.Name())
@@ -89,3 +233,36 @@ func (f *TransactionHandlerFactory) InitConfig(conf config.Section) {
 	legacyGasOracleConfig.AddKnownKey(GasOracleQueryInterval, defaultGasOracleQueryInterval)
 	legacyGasOracleConfig.AddKnownKey(GasOracleTemplate)
 }
+
+// initGasOracleSourcesConfig returns the array of gas oracle sources. The keys of each entry are only known to the
+// array section they were added to, so this is called again before reading the entries.
+func initGasOracleSourcesConfig(gasOracleConfig config.Section) config.ArraySection {
+	gasOracleSources := gasOracleConfig.SubArray(GasOracleSources)
+	gasOracleSources.AddKnownKey(GasOracleSourceName)
+	gasOracleSources.AddKnownKey(GasOracleMode, defaultGasOracleMode)
+	gasOracleSources.AddKnownKey(GasOracleMethod, defaultGasOracleMethod)
+	gasOracleSources.AddKnownKey(GasOracleTemplate)
+	gasOracleSources.AddKnownKey(GasOracleQueryInterval, defaultGasOracleQueryInterval)
+	gasOracleSources.AddKnownKey(GasOracleSourceMaxFailures, defaultGasOracleMaxFailures)
+	gasOracleSources.AddKnownKey(GasOracleSourceRecoveryInterval, defaultGasOracleRecoveryInterval)
+	ffresty.InitConfig(gasOracleSources.SubSection(GasOracleSourceHTTP))
+	return gasOracleSources
+}
+
+// initNamespaceWeightsConfig returns the array of namespace weights, and like initGasOracleSourcesConfig is called
+// again before reading the entries
+func initNamespaceWeightsConfig(schedulingConfig config.Section) config.ArraySection {
+	namespaceWeights := schedulingConfig.SubArray(SchedulingNamespaceWeights)
+	namespaceWeights.AddKnownKey(SchedulingNamespace)
+	namespaceWeights.AddKnownKey(SchedulingWeight, defaultNamespaceWeight)
+	return namespaceWeights
+}
+
+// initPriorityGasPriceConfig returns the array of gas price multipliers, and like initGasOracleSourcesConfig is called
+// again before reading the entries
+func initPriorityGasPriceConfig(priorityConfig config.Section) config.ArraySection {
+	multipliers := priorityConfig.SubArray(PriorityGasPriceMultipliers)
+	multipliers.AddKnownKey(PriorityMinPriority)
+	multipliers.AddKnownKey(PriorityMultiplier)
+	return multipliers
+}
@@ -0,0 +1,128 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// checkDependsOn validates the dependsOn header of a new transaction. Every prerequisite must already exist,
+// which also means a transaction can never depend on itself, or on a transaction that depends on it.
+func (sth *simpleTransactionHandler) checkDependsOn(ctx context.Context, reqHeaders *apitypes.RequestHeaders, txHeaders *ffcapi.TransactionHeaders) error {
+	if len(reqHeaders.DependsOn) == 0 {
+		return nil
+	}
+	if sth.clientSuppliedNonces && txHeaders.Nonce != nil {
+		return i18n.NewError(ctx, tmmsgs.MsgDependsOnWithNonce)
+	}
+	for _, depID := range reqHeaders.DependsOn {
+		dep, err := sth.toolkit.TXPersistence.GetTransactionByID(ctx, depID)
+		if err != nil {
+			return err
+		}
+		if dep == nil {
+			return i18n.NewError(ctx, tmmsgs.MsgDependencyNotFound, depID)
+		}
+	}
+	return nil
+}
+
+// awaitingDependencies returns true for a transaction that is held without a nonce, until its prerequisites succeed
+func awaitingDependencies(mtx *apitypes.ManagedTX) bool {
+	return mtx.Nonce == nil && len(mtx.DependsOn) > 0
+}
+
+// processDependencies checks the prerequisites of a transaction that is awaiting dependencies. Once they have all
+// succeeded a nonce is assigned, so the transaction is submitted like any other - or, with a policy hook, the hook
+// is asked and assigns the nonce if it allows the transaction. If any one of them does not succeed, the transaction
+// is failed, and true is returned to remove it from the in-flight set.
+func (sth *simpleTransactionHandler) processDependencies(ctx *RunContext, pending *pendingState) (completed bool) {
+	if time.Since(pending.lastPolicyCycle) <= sth.policyLoopInterval {
+		return false
+	}
+	pending.lastPolicyCycle = time.Now()
+
+	mtx := ctx.TX
+	for _, depID := range mtx.DependsOn {
+		dep, err := sth.toolkit.TXPersistence.GetTransactionByID(ctx, depID)
+		if err != nil {
+			log.L(ctx).Errorf("Failed to check dependency %s of transaction %s: %s", depID, mtx.ID, err)
+			return false
+		}
+		switch {
+		case dep == nil:
+			// The prerequisite has been deleted, so can never succeed
+			sth.failDependency(ctx, depID, "", i18n.NewError(ctx, tmmsgs.MsgDependencyNotFound, depID).Error())
+			return true
+		case dep.Status == apitypes.TxStatusFailed:
+			sth.failDependency(ctx, depID, dep.Status, i18n.NewError(ctx, tmmsgs.MsgDependencyFailed, depID, dep.Status).Error())
+			return true
+		case dep.Status != apitypes.TxStatusSucceeded:
+			log.L(ctx).Debugf("Transaction %s awaiting dependency %s (status=%s)", mtx.ID, depID, dep.Status)
+			ctx.setHeldSubStatus(apitypes.TxSubStatusAwaitingDependencies, apitypes.TxActionAwaitingDependency, dependencyInfo(depID, dep.Status), nil)
+			return false
+		}
+	}
+
+	if sth.nonceAwaitsPolicyDecision(ctx) {
+		if !sth.notBeforeReached(ctx) {
+			return false
+		}
+		return sth.decidePolicy(ctx, pending)
+	}
+	if err := sth.toolkit.TXPersistence.AssignTransactionNextNonce(ctx, mtx, sth.nextNonceForSigner); err != nil {
+		log.L(ctx).Errorf("Failed to assign nonce to transaction %s after its dependencies succeeded: %s", mtx.ID, err)
+		return false
+	}
+	ctx.SetSubStatus(apitypes.TxSubStatusReceived)
+	ctx.AddSubStatusAction(apitypes.TxActionAssignNonce, fftypes.JSONAnyPtr(`{"nonce":"`+mtx.Nonce.String()+`"}`), nil, fftypes.Now())
+	log.L(ctx).Infof("Dependencies of transaction %s succeeded - tracking at nonce %s / %d", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64())
+	// Do not wait for the next interval to submit
+	pending.lastPolicyCycle = time.Time{}
+	sth.markInflightUpdate()
+	return false
+}
+
+func (sth *simpleTransactionHandler) failDependency(ctx *RunContext, depID string, depStatus apitypes.TxStatus, errMsg string) {
+	mtx := ctx.TX
+	log.L(ctx).Warnf("Transaction %s failed, as dependency %s did not succeed (status=%s)", mtx.ID, depID, depStatus)
+	ctx.UpdateType = Update
+	mtx.Status = apitypes.TxStatusFailed
+	ctx.TXUpdates.Status = &mtx.Status
+	mtx.ErrorMessage = errMsg
+	ctx.TXUpdates.ErrorMessage = &errMsg
+	ctx.SetSubStatus(apitypes.TxSubStatusFailed)
+	ctx.AddSubStatusAction(apitypes.TxActionDependencyFailed, dependencyInfo(depID, depStatus), nil, fftypes.Now())
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "dependency_failed")
+}
+
+func dependencyInfo(depID string, depStatus apitypes.TxStatus) *fftypes.JSONAny {
+	b, _ := json.Marshal(map[string]interface{}{
+		"dependency": depID,
+		"status":     depStatus,
+	})
+	return fftypes.JSONAnyPtrBytes(b)
+}
@@ -0,0 +1,290 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestDependenciesHandler(t *testing.T) (*simpleTransactionHandler, *persistencemocks.Persistence, *ffcapimocks.API, *txhandlermocks.ManagedTxEventHandler) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	sth.toolkit.EventHandler = meh
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Maybe()
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), mockFFCAPI, meh
+}
+
+func newTestDependentTX(dependsOn ...string) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID:        "ns1:" + fftypes.NewUUID().String(),
+		Status:    apitypes.TxStatusPending,
+		DependsOn: dependsOn,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0xaaaa",
+		},
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}
+}
+
+func TestDependsOnNewTransaction(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{ID: "ns1:approve", Status: apitypes.TxStatusPending}, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Nonce == nil && mtx.DependsOn[0] == "ns1:approve"
+	})).Return(nil)
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.Headers.DependsOn = []string{"ns1:approve"}
+	txReq.From = "0xaaaa"
+	txReq.Nonce = fftypes.NewFFBigInt(42) // ignored, as nonces are not supplied by the client
+	mtx, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.NoError(t, err)
+	assert.False(t, submissionRejected)
+	assert.Nil(t, mtx.Nonce)
+	assert.True(t, awaitingDependencies(mtx))
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertNotCalled(t, "NextNonceForSigner", mock.Anything, mock.Anything)
+}
+
+func TestDependsOnNewTransactionInsertFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{ID: "ns1:approve"}, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.Headers.DependsOn = []string{"ns1:approve"}
+	_, _, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestDependsOnNotFound(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:missing").Return(nil, nil)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:error").Return(nil, fmt.Errorf("pop"))
+
+	_, _, err := sth.HandleNewTransaction(sth.ctx, &apitypes.TransactionRequest{
+		Headers: apitypes.RequestHeaders{DependsOn: []string{"ns1:missing"}},
+	})
+	assert.Regexp(t, "FF21104", err)
+
+	_, _, err = sth.HandleNewContractDeployment(sth.ctx, &apitypes.ContractDeployRequest{
+		Headers: apitypes.RequestHeaders{DependsOn: []string{"ns1:error"}},
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestDependsOnWithClientSuppliedNonce(t *testing.T) {
+	sth, _, _, _ := newTestDependenciesHandler(t)
+	sth.clientSuppliedNonces = true
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.Headers.DependsOn = []string{"ns1:approve"}
+	txReq.Nonce = fftypes.NewFFBigInt(42)
+	_, _, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.Regexp(t, "FF21106", err)
+}
+
+func TestDependsOnBatch(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{ID: "ns1:approve"}, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Nonce == nil
+	})).Return(nil)
+	mp.On("InsertTransactionsWithNextNonce", mock.Anything, mock.MatchedBy(func(txs []*apitypes.ManagedTX) bool {
+		return len(txs) == 1 && len(txs[0].DependsOn) == 0
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		args[1].([]*apitypes.ManagedTX)[0].Nonce = fftypes.NewFFBigInt(10)
+	}).Return([]error{nil})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	dependent := &apitypes.TransactionRequest{}
+	dependent.Headers.DependsOn = []string{"ns1:approve"}
+	batch := []*txhandler.NewTransactionBatchItem{
+		{TransactionRequest: dependent},
+		{TransactionRequest: &apitypes.TransactionRequest{}},
+	}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+
+	assert.NoError(t, batch[0].Err)
+	assert.Nil(t, batch[0].ManagedTX.Nonce)
+	assert.NoError(t, batch[1].Err)
+	assert.Equal(t, int64(10), batch[1].ManagedTX.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+}
+
+func TestDependenciesAwaiting(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:done", "ns1:inflight")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:done").Return(&apitypes.ManagedTX{Status: apitypes.TxStatusSucceeded}, nil)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:inflight").Return(&apitypes.ManagedTX{Status: apitypes.TxStatusPending}, nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusAwaitingDependencies, apitypes.TxActionAwaitingDependency, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.JSONObject().GetString("dependency") == "ns1:inflight" && info.JSONObject().GetString("status") == "Pending"
+	}), mock.Anything, mock.Anything).Return(nil).Once()
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingDependencies, pending.subStatus)
+
+	// Not checked again until the policy loop interval has passed
+	err = sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingDependencies, pending.subStatus)
+
+	// Still waiting on the next cycle, which is not added to the history again
+	pending.lastPolicyCycle = time.Time{}
+	err = sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingDependencies, pending.subStatus)
+
+	mp.AssertExpectations(t)
+}
+
+func TestDependenciesLookupFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:approve")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(nil, fmt.Errorf("pop"))
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusPending, mtx.Status)
+
+	mp.AssertExpectations(t)
+}
+
+func TestDependenciesSucceeded(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:approve")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{Status: apitypes.TxStatusSucceeded}, nil)
+	mp.On("AssignTransactionNextNonce", mock.Anything, mtx, mock.Anything).Run(func(args mock.Arguments) {
+		nextNonceCB := args[2].(txhandler.NextNonceCallback)
+		nonce, err := nextNonceCB(context.Background(), "0xaaaa")
+		assert.NoError(t, err)
+		args[1].(*apitypes.ManagedTX).Nonce = fftypes.NewFFBigInt(int64(nonce))
+	}).Return(nil)
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(7),
+	}, ffcapi.ErrorReason(""), nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.JSONObject().GetString("nonce") == "7"
+	}), mock.Anything, mock.Anything).Return(nil)
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.Equal(t, int64(7), mtx.Nonce.Int64())
+	assert.False(t, awaitingDependencies(mtx))
+	assert.True(t, pending.lastPolicyCycle.IsZero())
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestDependenciesAssignNonceFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:approve")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{Status: apitypes.TxStatusSucceeded}, nil)
+	mp.On("AssignTransactionNextNonce", mock.Anything, mtx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.True(t, awaitingDependencies(mtx))
+
+	mp.AssertExpectations(t)
+}
+
+func TestDependenciesFailed(t *testing.T) {
+	sth, mp, _, meh := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:approve")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(&apitypes.ManagedTX{Status: apitypes.TxStatusFailed}, nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusFailed, apitypes.TxActionDependencyFailed, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(u *apitypes.TXUpdates) bool {
+		return *u.Status == apitypes.TxStatusFailed && *u.ErrorMessage != ""
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed && e.Tx.ID == mtx.ID
+	})).Return(nil)
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusFailed, mtx.Status)
+	assert.Regexp(t, "FF21105.*ns1:approve.*Failed", mtx.ErrorMessage)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestDependenciesDeleted(t *testing.T) {
+	sth, mp, _, meh := newTestDependenciesHandler(t)
+
+	mtx := newTestDependentTX("ns1:approve")
+	mp.On("GetTransactionByID", mock.Anything, "ns1:approve").Return(nil, nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusFailed, apitypes.TxActionDependencyFailed, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Regexp(t, "FF21104", mtx.ErrorMessage)
+
+	mp.AssertExpectations(t)
+}
@@ -0,0 +1,69 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+// expired returns true if the expiry requested for the transaction has passed, and we have not yet had a receipt.
+// Once we have a receipt we wait for it to be confirmed as normal.
+func (ctx *RunContext) expired() bool {
+	mtx := ctx.TX
+	return mtx.Expiry != nil && mtx.DeleteRequested == nil && ctx.Receipt == nil &&
+		!time.Now().Before(*mtx.Expiry.Time())
+}
+
+// expiryFailsTransaction returns true if the transaction has expired, and should be marked failed straight away.
+// A transaction that has been submitted might still be mined, so if configured we replace it to be sure it is not.
+// A transaction that has not been submitted is always replaced if it has a nonce, as the later transactions of the
+// signer cannot be mined until the nonce is used.
+func (sth *simpleTransactionHandler) expiryFailsTransaction(ctx *RunContext) bool {
+	mtx := ctx.TX
+	switch {
+	case !ctx.expired() || (ctx.Info != nil && ctx.Info.CancelReplacement != nil):
+		// Once a replacement has been submitted, we wait for it to be mined
+		return false
+	case mtx.FirstSubmit == nil:
+		return mtx.Nonce == nil
+	default:
+		return sth.expirySubmittedStrategy == ExpiryStrategyStopTracking
+	}
+}
+
+func (sth *simpleTransactionHandler) failExpired(ctx *RunContext, pending *pendingState) {
+	mtx := ctx.TX
+	log.L(ctx).Warnf("Transaction %s at nonce %s / %s expired at %s (submitted=%t)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce, mtx.Expiry, mtx.FirstSubmit != nil)
+	ctx.UpdateType = Update
+	mtx.Status = apitypes.TxStatusFailed
+	ctx.TXUpdates.Status = &mtx.Status
+	errMsg := i18n.NewError(ctx, tmmsgs.MsgTransactionExpired, mtx.Expiry).Error()
+	mtx.ErrorMessage = errMsg
+	ctx.TXUpdates.ErrorMessage = &errMsg
+	ctx.SetSubStatus(apitypes.TxSubStatusFailed)
+	ctx.AddSubStatusAction(apitypes.TxActionExpired, fftypes.JSONAnyPtr(`{"expiry":"`+mtx.Expiry.String()+`"}`), nil, fftypes.Now())
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "expired")
+
+	// Stop the confirmation manager looking for a receipt
+	sth.untrackHashes(ctx, pending, "")
+}
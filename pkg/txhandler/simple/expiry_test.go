@@ -0,0 +1,313 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestExpiryHandler(t *testing.T, strategy string) (*simpleTransactionHandler, *ffcapimocks.API, *persistencemocks.Persistence, *txhandlermocks.ManagedTxEventHandler) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.Set(ResubmitInterval, "1h")
+	conf.SubSection(ExpiryConfig).Set(ExpirySubmittedStrategy, strategy)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	sth.toolkit.EventHandler = meh
+	return sth, mockFFCAPI, tk.TXPersistence.(*persistencemocks.Persistence), meh
+}
+
+func newTestExpiredTX() *apitypes.ManagedTX {
+	expiry := fftypes.FFTime(time.Now().Add(-1 * time.Minute))
+	return &apitypes.ManagedTX{
+		ID: "ns1:" + fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+			Nonce: fftypes.NewFFBigInt(42),
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+		Status:          apitypes.TxStatusPending,
+		Expiry:          &expiry,
+	}
+}
+
+func TestExpirySubmittedStrategyInvalid(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(ExpiryConfig).Set(ExpirySubmittedStrategy, "wrong")
+	_, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21093", err)
+}
+
+func TestExpiryInvalidOnNewTransaction(t *testing.T) {
+	sth, _, _, _ := newTestExpiryHandler(t, ExpiryStrategyStopTracking)
+
+	_, _, err := sth.HandleNewTransaction(sth.ctx, &apitypes.TransactionRequest{
+		Headers: apitypes.RequestHeaders{
+			Expiry: "wrong",
+		},
+	})
+	assert.Regexp(t, "FF21091", err)
+
+	_, _, err = sth.HandleNewContractDeployment(sth.ctx, &apitypes.ContractDeployRequest{
+		Headers: apitypes.RequestHeaders{
+			Expiry: "wrong",
+		},
+	})
+	assert.Regexp(t, "FF21091", err)
+}
+
+func TestExpiryNotYetDue(t *testing.T) {
+	mtx := newTestExpiredTX()
+	future := fftypes.FFTime(time.Now().Add(1 * time.Hour))
+	mtx.Expiry = &future
+	assert.False(t, newTestRunContext(mtx, nil).expired())
+
+	mtx = newTestExpiredTX()
+	assert.True(t, newTestRunContext(mtx, nil).expired())
+	assert.False(t, newTestRunContext(mtx, &ffcapi.TransactionReceiptResponse{}).expired())
+}
+
+func TestExpiryUnsubmittedFails(t *testing.T) {
+	sth, mockFFCAPI, mp, meh := newTestExpiryHandler(t, ExpiryStrategyCancel)
+
+	// Without a nonce there is nothing to replace
+	mtx := newTestExpiredTX()
+	mtx.Nonce = nil
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusFailed, apitypes.TxActionExpired, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(u *apitypes.TXUpdates) bool {
+		return *u.Status == apitypes.TxStatusFailed && *u.ErrorMessage != ""
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed && e.Tx.ID == mtx.ID
+	})).Return(nil)
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusFailed, mtx.Status)
+	assert.Regexp(t, "FF21092", mtx.ErrorMessage)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestExpiryUnsubmittedNonceReplaced(t *testing.T) {
+	sth, mockFFCAPI, mp, meh := newTestExpiryHandler(t, ExpiryStrategyStopTracking)
+
+	expired := newTestExpiredTX()
+	next := newTestExpiredTX()
+	next.Expiry = nil
+	next.Nonce = fftypes.NewFFBigInt(43)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.To == expired.From && req.Nonce.Int64() == 42
+	})).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "CANCEL_TX_BYTES" && req.Nonce.Int64() == 42
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xreplacement",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "SOME_RAW_TX_BYTES" && req.Nonce.Int64() == 43
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xnext",
+	}, ffcapi.ErrorReason(""), nil)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, expired.ID, false, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0xreplacement"
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0xnext"
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed && e.Tx.ID == expired.ID
+	})).Return(nil)
+
+	// The nonce of the expired transaction is used by a replacement, which is tracked for its receipt
+	expiredPending := &pendingState{mtx: expired, info: &simplePolicyInfo{}}
+	err := sth.execPolicy(sth.ctx, expiredPending, nil)
+	assert.NoError(t, err)
+	assert.False(t, expiredPending.remove)
+	assert.Equal(t, "0xreplacement", expiredPending.trackingTransactionHash)
+
+	// So the next transaction of the signer can be mined after it
+	nextPending := &pendingState{mtx: next, info: &simplePolicyInfo{}}
+	err = sth.execPolicy(sth.ctx, nextPending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xnext", nextPending.trackingTransactionHash)
+
+	// The expired transaction fails once the replacement is mined
+	expiredPending.receipt = &ffcapi.TransactionReceiptResponse{Success: true}
+	expiredPending.confirmed = true
+	expiredPending.confirmNotify = fftypes.Now()
+	expiredPending.confirmations = &apitypes.ConfirmationsNotification{Confirmed: true}
+	err = sth.execPolicy(sth.ctx, expiredPending, nil)
+	assert.NoError(t, err)
+	assert.True(t, expiredPending.remove)
+	assert.Equal(t, apitypes.TxStatusFailed, expired.Status)
+	assert.Regexp(t, "FF21092", expired.ErrorMessage)
+
+	mockFFCAPI.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestExpiryUnsubmittedReplacementNonceTooLow(t *testing.T) {
+	sth, mockFFCAPI, _, _ := newTestExpiryHandler(t, ExpiryStrategyStopTracking)
+
+	mtx := newTestExpiredTX()
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonNonceTooLow, fmt.Errorf("nonce too low"))
+
+	// There is no original transaction to look for a receipt for
+	rc := newTestRunContext(mtx, nil)
+	assert.False(t, sth.expiryFailsTransaction(rc))
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "nonce too low", err)
+
+	mockFFCAPI.AssertExpectations(t)
+	mockFFCAPI.AssertNotCalled(t, "TransactionReceipt", mock.Anything, mock.Anything)
+}
+
+func TestExpirySubmittedStopTracking(t *testing.T) {
+	sth, mockFFCAPI, mp, meh := newTestExpiryHandler(t, ExpiryStrategyStopTracking)
+
+	mtx := newTestExpiredTX()
+	mtx.FirstSubmit = fftypes.Now()
+	mtx.TransactionHash = "0x12345"
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusFailed, apitypes.TxActionExpired, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashRemoved && e.Tx.TransactionHash == "0x11111"
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashRemoved && e.Tx.TransactionHash == "0x12345"
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed
+	})).Return(nil)
+
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}, trackingTransactionHash: "0x12345", trackedHashes: []string{"0x11111", "0x12345"}}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusFailed, mtx.Status)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestExpirySubmittedCancelSubmitsReplacement(t *testing.T) {
+	sth, mockFFCAPI, _, _ := newTestExpiryHandler(t, ExpiryStrategyCancel)
+
+	mtx := newTestExpiredTX()
+	mtx.FirstSubmit = fftypes.Now()
+	mtx.TransactionHash = "0xoriginal"
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.To == mtx.From && req.Nonce.Int64() == 42
+	})).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "CANCEL_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "CANCEL_TX_BYTES"
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0xreplacement",
+	}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	assert.False(t, sth.expiryFailsTransaction(rc))
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.Equal(t, "0xreplacement", mtx.TransactionHash)
+	assert.Equal(t, "0xoriginal", rc.Info.CancelReplacement.OriginalTransactionHash)
+	// expired, retrieved gas price, cancel replacement submission
+	assert.Len(t, rc.HistoryUpdates, 3)
+
+	// Waits for the replacement to be mined
+	cr := rc.Info.CancelReplacement
+	rc = newTestRunContext(mtx, nil)
+	rc.Info.CancelReplacement = cr
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestExpirySubmittedCancelReplacementMinedFails(t *testing.T) {
+	sth, _, mp, meh := newTestExpiryHandler(t, ExpiryStrategyCancel)
+
+	mtx := newTestExpiredTX()
+	mtx.FirstSubmit = fftypes.Now()
+	mtx.TransactionHash = "0xreplacement"
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionConfirmTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, mtx.ID, false, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(u *apitypes.TXUpdates) bool {
+		return *u.Status == apitypes.TxStatusFailed
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed && e.Tx.ID == mtx.ID
+	})).Return(nil)
+
+	pending := &pendingState{
+		mtx:     mtx,
+		receipt: &ffcapi.TransactionReceiptResponse{Success: true},
+		info: &simplePolicyInfo{
+			CancelReplacement: &cancelReplacementInfo{
+				OriginalTransactionHash: "0xoriginal",
+				TransactionHash:         "0xreplacement",
+			},
+		},
+		confirmed:     true,
+		confirmNotify: fftypes.Now(),
+		confirmations: &apitypes.ConfirmationsNotification{Confirmed: true},
+	}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, apitypes.TxStatusFailed, mtx.Status)
+	assert.Regexp(t, "FF21092", mtx.ErrorMessage)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
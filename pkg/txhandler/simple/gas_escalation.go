@@ -0,0 +1,160 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strings"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+)
+
+// gasPriceNumberFormat is how a numeric gas price was supplied, so a value calculated from it is returned the same way
+type gasPriceNumberFormat int
+
+const (
+	gasPriceJSONNumber gasPriceNumberFormat = iota
+	gasPriceDecimalString
+	gasPriceHexString
+)
+
+// parseGasPriceNumber accepts a JSON number, or a JSON string containing a decimal or 0x prefixed hex integer
+func parseGasPriceNumber(raw json.RawMessage) (value *big.Int, format gasPriceNumberFormat, ok bool) {
+	var s string
+	trimmed := strings.TrimSpace(string(raw))
+	format = gasPriceJSONNumber
+	if strings.HasPrefix(trimmed, `"`) {
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, format, false
+		}
+		format = gasPriceDecimalString
+		if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+			format = gasPriceHexString
+		}
+	} else {
+		s = trimmed
+	}
+	value, ok = new(big.Int).SetString(s, 0)
+	return value, format, ok
+}
+
+func formatGasPriceNumber(value *big.Int, format gasPriceNumberFormat) json.RawMessage {
+	switch format {
+	case gasPriceHexString:
+		return json.RawMessage(`"0x` + value.Text(16) + `"`)
+	case gasPriceDecimalString:
+		return json.RawMessage(`"` + value.String() + `"`)
+	default:
+		return json.RawMessage(value.String())
+	}
+}
+
+func parseMaxGasPrice(ctx context.Context, maxGasPrice string) (*big.Int, error) {
+	if maxGasPrice == "" {
+		return nil, nil
+	}
+	value, ok := new(big.Int).SetString(maxGasPrice, 0)
+	if !ok || value.Sign() <= 0 {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidGasEscalationMaxGasPrice, maxGasPrice)
+	}
+	return value, nil
+}
+
+// escalateGasPriceValue increases the previous value by the supplied percentage - capped at the max gas price if one
+// is configured. The current value is returned instead if it is at least as high, and escalated is only true when the
+// escalation raised the value above the previous one.
+func (sth *simpleTransactionHandler) escalateGasPriceValue(previous, current *big.Int, percentage float64) (value *big.Int, escalated bool) {
+	// Calculate in integer basis points to avoid floating point error on large values, rounding up
+	basisPoints := big.NewInt(10000 + int64(math.Round(percentage*100)))
+	result := new(big.Int).Mul(previous, basisPoints)
+	result.Add(result, big.NewInt(9999))
+	result.Quo(result, big.NewInt(10000))
+	// Always move by at least one, so a small value does not stall
+	if result.Cmp(previous) <= 0 {
+		result.Add(previous, big.NewInt(1))
+	}
+	if sth.gasEscalationMaxGasPrice != nil && result.Cmp(sth.gasEscalationMaxGasPrice) > 0 {
+		result = new(big.Int).Set(sth.gasEscalationMaxGasPrice)
+	}
+	if current != nil && current.Cmp(result) >= 0 {
+		return current, false
+	}
+	return result, result.Cmp(previous) > 0
+}
+
+// escalateGasPrice increases the gas price previously submitted for a transaction by a percentage.
+// The gas price is an opaque JSON structure interpreted by the connector, so we support:
+// - a simple numeric value, either as a JSON number or a JSON string (decimal or hex)
+// - an object, such as an EIP-1559 {"maxFeePerGas":...,"maxPriorityFeePerGas":...} structure, where every numeric field is escalated
+// Anything else is returned unchanged, with escalated=false.
+// Escalated is only true if the gas price was raised above the previous one by the escalation - not if the max gas
+// price stopped it, or the current gas price is already at least as high.
+func (sth *simpleTransactionHandler) escalateGasPrice(previous, current *fftypes.JSONAny, percentage float64) (gasPrice *fftypes.JSONAny, escalated bool) {
+	if previous.IsNil() {
+		return current, false
+	}
+
+	if prevValue, format, ok := parseGasPriceNumber(json.RawMessage(previous.Bytes())); ok {
+		var currentValue *big.Int
+		if !current.IsNil() {
+			currentValue, _, _ = parseGasPriceNumber(json.RawMessage(current.Bytes()))
+		}
+		newValue, escalated := sth.escalateGasPriceValue(prevValue, currentValue, percentage)
+		return fftypes.JSONAnyPtrBytes(formatGasPriceNumber(newValue, format)), escalated
+	}
+
+	var prevFields map[string]json.RawMessage
+	if err := json.Unmarshal(previous.Bytes(), &prevFields); err != nil || prevFields == nil {
+		return current, false
+	}
+	var currentFields map[string]json.RawMessage
+	if !current.IsNil() {
+		_ = json.Unmarshal(current.Bytes(), &currentFields)
+	}
+	result := make(map[string]json.RawMessage, len(prevFields))
+	for k, v := range currentFields {
+		result[k] = v
+	}
+	numeric := false
+	for k, v := range prevFields {
+		prevValue, format, ok := parseGasPriceNumber(v)
+		if !ok {
+			if _, exists := result[k]; !exists {
+				result[k] = v
+			}
+			continue
+		}
+		var currentValue *big.Int
+		if cv, exists := currentFields[k]; exists {
+			currentValue, _, _ = parseGasPriceNumber(cv)
+		}
+		newValue, fieldEscalated := sth.escalateGasPriceValue(prevValue, currentValue, percentage)
+		result[k] = formatGasPriceNumber(newValue, format)
+		numeric = true
+		escalated = escalated || fieldEscalated
+	}
+	if !numeric {
+		return current, false
+	}
+	b, _ := json.Marshal(result)
+	return fftypes.JSONAnyPtrBytes(b), escalated
+}
@@ -0,0 +1,216 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEscalateGasPriceOnStaleResubmit(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.Set(ResubmitInterval, "1s")
+	conf.SubSection(GasEscalationConfig).Set(GasEscalationPercentage, 10)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	submitTime := fftypes.FFTime(time.Now().Add(-100 * time.Hour))
+	mtx := &apitypes.ManagedTX{
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+		FirstSubmit:     &submitTime,
+		GasPrice:        fftypes.JSONAnyPtr(`2000`),
+	}
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.GasPrice.String() == `2200`
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.Equal(t, `2200`, rc.TXUpdates.GasPrice.String())
+	// timeout, retrieved gas price, escalated gas price, submission
+	assert.Len(t, rc.HistoryUpdates, 4)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestEscalateGasPriceNotAppliedOnFirstSubmit(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(GasEscalationConfig).Set(GasEscalationPercentage, 10)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	mtx := &apitypes.ManagedTX{
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+		GasPrice:        fftypes.JSONAnyPtr(`2000`),
+	}
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.GasPrice.String() == `1000`
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Len(t, rc.HistoryUpdates, 2)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestEscalateGasPriceBadMaxGasPrice(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(GasEscalationConfig).Set(GasEscalationMaxGasPrice, "not a number")
+	_, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21087", err)
+}
+
+func TestEscalateGasPriceNumeric(t *testing.T) {
+	sth := &simpleTransactionHandler{gasEscalationPercentage: 20}
+
+	// JSON number
+	gp, escalated := sth.escalateGasPrice(fftypes.JSONAnyPtr(`100`), fftypes.JSONAnyPtr(`50`), sth.gasEscalationPercentage)
+	assert.True(t, escalated)
+	assert.Equal(t, `120`, gp.String())
+
+	// Current price is higher than the escalated price, so it is used without escalation
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`100`), fftypes.JSONAnyPtr(`500`), sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, `500`, gp.String())
+
+	// Strings keep their type, and hex stays hex
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`"0x64"`), fftypes.JSONAnyPtr(`"50"`), sth.gasEscalationPercentage)
+	assert.True(t, escalated)
+	assert.Equal(t, `"0x78"`, gp.String())
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`"100"`), fftypes.JSONAnyPtr(`"50"`), sth.gasEscalationPercentage)
+	assert.True(t, escalated)
+	assert.Equal(t, `"120"`, gp.String())
+
+	// Rounding never stalls the escalation
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`1`), nil, sth.gasEscalationPercentage)
+	assert.True(t, escalated)
+	assert.Equal(t, `2`, gp.String())
+
+	// Capped at the max
+	sth.gasEscalationMaxGasPrice = big.NewInt(110)
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`100`), fftypes.JSONAnyPtr(`50`), sth.gasEscalationPercentage)
+	assert.True(t, escalated)
+	assert.Equal(t, `110`, gp.String())
+
+	// Not escalated once the previous price has reached the max
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`110`), fftypes.JSONAnyPtr(`50`), sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, `110`, gp.String())
+
+	// The max only limits escalation, not the current price
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`100`), fftypes.JSONAnyPtr(`500`), sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, `500`, gp.String())
+}
+
+func TestEscalateGasPriceEIP1559(t *testing.T) {
+	sth := &simpleTransactionHandler{
+		gasEscalationPercentage:  10,
+		gasEscalationMaxGasPrice: big.NewInt(1500),
+	}
+
+	gp, escalated := sth.escalateGasPrice(
+		fftypes.JSONAnyPtr(`{"maxFeePerGas":"1000","maxPriorityFeePerGas":100,"label":"fast"}`),
+		fftypes.JSONAnyPtr(`{"maxFeePerGas":"1050","maxPriorityFeePerGas":50,"extra":true}`),
+		sth.gasEscalationPercentage,
+	)
+	assert.True(t, escalated)
+	assert.JSONEq(t, `{
+		"maxFeePerGas": "1100",
+		"maxPriorityFeePerGas": 110,
+		"label": "fast",
+		"extra": true
+	}`, gp.String())
+
+	gp, escalated = sth.escalateGasPrice(
+		fftypes.JSONAnyPtr(`{"maxFeePerGas":"1400","maxPriorityFeePerGas":100}`),
+		nil,
+		sth.gasEscalationPercentage,
+	)
+	assert.True(t, escalated)
+	assert.JSONEq(t, `{"maxFeePerGas":"1500","maxPriorityFeePerGas":110}`, gp.String())
+
+	// Every field is already at the max
+	gp, escalated = sth.escalateGasPrice(
+		fftypes.JSONAnyPtr(`{"maxFeePerGas":"0x5dc","maxPriorityFeePerGas":1500}`),
+		nil,
+		sth.gasEscalationPercentage,
+	)
+	assert.False(t, escalated)
+	assert.JSONEq(t, `{"maxFeePerGas":"0x5dc","maxPriorityFeePerGas":1500}`, gp.String())
+}
+
+func TestEscalateGasPriceNotEscalatable(t *testing.T) {
+	sth := &simpleTransactionHandler{gasEscalationPercentage: 10}
+
+	current := fftypes.JSONAnyPtr(`100`)
+
+	gp, escalated := sth.escalateGasPrice(nil, current, sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, current, gp)
+
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`"fast"`), current, sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, current, gp)
+
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`{"speed":"fast"}`), current, sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, current, gp)
+
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`[1,2]`), current, sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, current, gp)
+
+	gp, escalated = sth.escalateGasPrice(fftypes.JSONAnyPtr(`"\u`), current, sth.gasEscalationPercentage)
+	assert.False(t, escalated)
+	assert.Equal(t, current, gp)
+}
@@ -0,0 +1,312 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/ffresty"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// gasOracleSource is a single gas oracle, which caches the last gas price it returned for its query interval
+type gasOracleSource struct {
+	name             string
+	mode             string
+	client           *resty.Client
+	method           string
+	template         *template.Template
+	queryInterval    time.Duration
+	maxFailures      int // zero means the source is never dropped
+	recoveryInterval time.Duration
+
+	mux           sync.Mutex
+	query         *gasOracleQuery
+	lastValue     *fftypes.JSONAny
+	lastQueryTime time.Time
+	failures      int
+	droppedUntil  time.Time
+}
+
+// gasOracleQuery is a query of a source in progress. Callers that need a gas price from the source
+// while it runs wait for its result, rather than each making their own query.
+type gasOracleQuery struct {
+	done     chan struct{}
+	gasPrice *fftypes.JSONAny
+	err      error
+}
+
+func newGasOracleSource(ctx context.Context, name string, conf, httpConf config.Section) (*gasOracleSource, error) {
+	gos := &gasOracleSource{
+		name:          name,
+		mode:          conf.GetString(GasOracleMode),
+		method:        conf.GetString(GasOracleMethod),
+		queryInterval: conf.GetDuration(GasOracleQueryInterval),
+	}
+	switch gos.mode {
+	case GasOracleModeConnector:
+		// No initialization required
+	case GasOracleModeRESTAPI:
+		goc, err := ffresty.New(ctx, httpConf)
+		if err != nil {
+			return nil, err
+		}
+		gos.client = goc
+		templateString := conf.GetString(GasOracleTemplate)
+		if templateString == "" {
+			return nil, i18n.NewError(ctx, tmmsgs.MsgMissingGOTemplate)
+		}
+		template, err := template.New("").Funcs(sprig.FuncMap()).Parse(templateString)
+		if err != nil {
+			return nil, i18n.NewError(ctx, tmmsgs.MsgBadGOTemplate, err)
+		}
+		gos.template = template
+	default:
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidGasOracleSourceMode, gos.mode, gos.name)
+	}
+	return gos, nil
+}
+
+func (gos *gasOracleSource) dropped(now time.Time) bool {
+	gos.mux.Lock()
+	defer gos.mux.Unlock()
+	return now.Before(gos.droppedUntil)
+}
+
+// getGasPrice returns the cached gas price, or queries the source if the cache has expired.
+// The source lock is only held to read and update the cache, not while the query runs.
+func (gos *gasOracleSource) getGasPrice(ctx context.Context, cAPI ffcapi.API, now time.Time) (gasPrice *fftypes.JSONAny, cached bool, err error) {
+	gos.mux.Lock()
+	if gos.lastValue != nil && now.Sub(gos.lastQueryTime) < gos.queryInterval {
+		gasPrice = gos.lastValue
+		gos.mux.Unlock()
+		return gasPrice, true, nil
+	}
+	if q := gos.query; q != nil {
+		gos.mux.Unlock()
+		select {
+		case <-q.done:
+			return q.gasPrice, true, q.err
+		case <-ctx.Done():
+			return nil, true, i18n.NewError(ctx, i18n.MsgContextCanceled)
+		}
+	}
+	q := &gasOracleQuery{done: make(chan struct{})}
+	gos.query = q
+	gos.mux.Unlock()
+
+	q.gasPrice, q.err = gos.queryGasPrice(ctx, cAPI)
+
+	gos.mux.Lock()
+	gos.query = nil
+	if q.err != nil {
+		gos.failures++
+		if gos.maxFailures > 0 && gos.failures >= gos.maxFailures {
+			log.L(ctx).Warnf("Gas oracle source '%s' dropped for %s after %d consecutive failures: %s", gos.name, gos.recoveryInterval, gos.failures, q.err)
+			gos.droppedUntil = now.Add(gos.recoveryInterval)
+		}
+	} else {
+		gos.failures = 0
+		gos.lastValue = q.gasPrice
+		gos.lastQueryTime = now
+	}
+	gos.mux.Unlock()
+	close(q.done)
+	return q.gasPrice, false, q.err
+}
+
+func (gos *gasOracleSource) queryGasPrice(ctx context.Context, cAPI ffcapi.API) (gasPrice *fftypes.JSONAny, err error) {
+	switch gos.mode {
+	case GasOracleModeRESTAPI:
+		// Make a REST call against an endpoint, and extract a value/structure to pass to the connector
+		return gos.getGasPriceAPI(ctx)
+	default:
+		// Call the connector
+		var res *ffcapi.GasPriceEstimateResponse
+		if res, _, err = cAPI.GasPriceEstimate(ctx, &ffcapi.GasPriceEstimateRequest{}); err != nil {
+			return nil, err
+		}
+		return res.GasPrice, nil
+	}
+}
+
+func (gos *gasOracleSource) getGasPriceAPI(ctx context.Context) (gasPrice *fftypes.JSONAny, err error) {
+	res, err := gos.client.R().
+		Execute(gos.method, "")
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgErrorQueryingGasOracleAPI, -1, err.Error())
+	}
+	if res.IsError() {
+		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgErrorQueryingGasOracleAPI, res.StatusCode(), res.RawResponse)
+	}
+	// Parse the response body as JSON
+	var data map[string]interface{}
+	err = json.Unmarshal(res.Body(), &data)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgInvalidJSONGasObject)
+	}
+	buff := new(bytes.Buffer)
+	err = gos.template.Execute(buff, data)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgGasOracleResultError)
+	}
+	return fftypes.JSONAnyPtr(buff.String()), nil
+}
+
+// queryGasOracles goes through the sources in order, skipping any that have been dropped.
+// With the firstSuccess aggregation we stop at the first source that returns a gas price, otherwise
+// we combine the gas prices from every source that returns one.
+// If no source returns a gas price, the error from the last source queried is returned.
+// No handler-wide lock is held while the sources are queried, so a slow source only delays the
+// callers that need a fresh gas price from it.
+func (sth *simpleTransactionHandler) queryGasOracles(ctx context.Context, cAPI ffcapi.API) (gasPrice *fftypes.JSONAny, err error) {
+	now := time.Now()
+	entry := &apitypes.GasPriceHistoryEntry{
+		Time:        (*fftypes.FFTime)(&now),
+		Aggregation: sth.gasOracleAggregation,
+		Sources:     []*apitypes.GasPriceSourceValue{},
+	}
+	queried := false
+	values := make([]*fftypes.JSONAny, 0, len(sth.gasOracleSources))
+	for _, source := range sth.gasOracleSources {
+		if source.dropped(now) {
+			continue
+		}
+		value, cached, sourceErr := source.getGasPrice(ctx, cAPI, now)
+		queried = queried || !cached
+		sourceValue := &apitypes.GasPriceSourceValue{Source: source.name, Cached: cached}
+		entry.Sources = append(entry.Sources, sourceValue)
+		if sourceErr != nil {
+			log.L(ctx).Warnf("Failed to get gas price from gas oracle source '%s': %s", source.name, sourceErr)
+			sourceValue.Error = sourceErr.Error()
+			err = sourceErr
+			continue
+		}
+		sourceValue.GasPrice = value
+		values = append(values, value)
+		if sth.gasOracleAggregation == GasOracleAggregationFirstSuccess {
+			break
+		}
+	}
+	if len(values) == 0 {
+		if err == nil {
+			err = i18n.NewError(ctx, tmmsgs.MsgNoGasOracleSourcesAvailable)
+		}
+		sth.recordGasPriceHistory(entry, queried)
+		return nil, err
+	}
+	entry.GasPrice = aggregateGasPrices(sth.gasOracleAggregation, values)
+	sth.recordGasPriceHistory(entry, queried)
+	return entry.GasPrice, nil
+}
+
+// recordGasPriceHistory only keeps entries where at least one source was queried, rather than
+// one for every transaction submitted while the gas prices are cached
+func (sth *simpleTransactionHandler) recordGasPriceHistory(entry *apitypes.GasPriceHistoryEntry, queried bool) {
+	if !queried || sth.gasPriceHistorySize <= 0 {
+		return
+	}
+	sth.gasOracleMux.Lock()
+	defer sth.gasOracleMux.Unlock()
+	sth.gasPriceHistory = append(sth.gasPriceHistory, entry)
+	if len(sth.gasPriceHistory) > sth.gasPriceHistorySize {
+		sth.gasPriceHistory = sth.gasPriceHistory[len(sth.gasPriceHistory)-sth.gasPriceHistorySize:]
+	}
+}
+
+func (sth *simpleTransactionHandler) GasPriceHistory(_ context.Context) ([]*apitypes.GasPriceHistoryEntry, error) {
+	sth.gasOracleMux.Lock()
+	defer sth.gasOracleMux.Unlock()
+	history := make([]*apitypes.GasPriceHistoryEntry, len(sth.gasPriceHistory))
+	for i, entry := range sth.gasPriceHistory {
+		history[len(history)-1-i] = entry
+	}
+	return history, nil
+}
+
+// aggregateGasPrices combines the gas prices returned by multiple sources. A numeric gas price, or each numeric
+// field of an object such as an EIP-1559 {"maxFeePerGas":...,"maxPriorityFeePerGas":...} structure, is combined
+// across all the sources that supply it. Any other gas price cannot be combined, so the first one is used.
+func aggregateGasPrices(aggregation string, values []*fftypes.JSONAny) *fftypes.JSONAny {
+	if aggregation == GasOracleAggregationFirstSuccess || len(values) == 1 {
+		return values[0]
+	}
+
+	if _, format, ok := parseGasPriceNumber(json.RawMessage(values[0].Bytes())); ok {
+		numbers := make([]*big.Int, 0, len(values))
+		for _, v := range values {
+			n, _, ok := parseGasPriceNumber(json.RawMessage(v.Bytes()))
+			if !ok {
+				return values[0]
+			}
+			numbers = append(numbers, n)
+		}
+		return fftypes.JSONAnyPtrBytes(formatGasPriceNumber(aggregateGasPriceValues(aggregation, numbers), format))
+	}
+
+	objects := make([]map[string]json.RawMessage, len(values))
+	for i, v := range values {
+		if err := json.Unmarshal(v.Bytes(), &objects[i]); err != nil || objects[i] == nil {
+			return values[0]
+		}
+	}
+	result := make(map[string]json.RawMessage, len(objects[0]))
+	for k, v := range objects[0] {
+		n, format, ok := parseGasPriceNumber(v)
+		if !ok {
+			result[k] = v
+			continue
+		}
+		numbers := []*big.Int{n}
+		for _, o := range objects[1:] {
+			if n, _, ok := parseGasPriceNumber(o[k]); ok {
+				numbers = append(numbers, n)
+			}
+		}
+		result[k] = formatGasPriceNumber(aggregateGasPriceValues(aggregation, numbers), format)
+	}
+	b, _ := json.Marshal(result)
+	return fftypes.JSONAnyPtrBytes(b)
+}
+
+func aggregateGasPriceValues(aggregation string, numbers []*big.Int) *big.Int {
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i].Cmp(numbers[j]) < 0 })
+	if aggregation == GasOracleAggregationMax {
+		return numbers[len(numbers)-1]
+	}
+	mid := len(numbers) / 2
+	if len(numbers)%2 == 1 {
+		return numbers[mid]
+	}
+	median := new(big.Int).Add(numbers[mid-1], numbers[mid])
+	return median.Quo(median, big.NewInt(2))
+}
@@ -0,0 +1,323 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/ffresty"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestGasOracleServer(t *testing.T, status int, body string) (string, *int, func()) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	return fmt.Sprintf("http://%s", server.Listener.Addr()), &calls, server.Close
+}
+
+func newTestGasOracleHandler(t *testing.T, aggregation string, sources []interface{}) (*simpleTransactionHandler, *ffcapimocks.API, error) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	gasOracleConfig := conf.SubSection(GasOracleConfig)
+	gasOracleConfig.Set(GasOracleAggregation, aggregation)
+	// Array entries are only read from a config file, not values set directly
+	configJSON, _ := json.Marshal(map[string]interface{}{
+		"unittest": map[string]interface{}{
+			"simple": map[string]interface{}{
+				GasOracleConfig: map[string]interface{}{
+					GasOracleSources: sources,
+				},
+			},
+		},
+	})
+	viper.SetConfigType("json")
+	err := viper.ReadConfig(bytes.NewReader(configJSON))
+	assert.NoError(t, err)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	th.Init(context.Background(), tk)
+	return th.(*simpleTransactionHandler), mockFFCAPI, nil
+}
+
+func TestGasOracleSourcesFallback(t *testing.T) {
+	url, calls, done := newTestGasOracleServer(t, 500, `{}`)
+	defer done()
+
+	sth, mockFFCAPI, err := newTestGasOracleHandler(t, GasOracleAggregationFirstSuccess, []interface{}{
+		map[string]interface{}{
+			GasOracleSourceName: "oracle1",
+			GasOracleMode:       GasOracleModeRESTAPI,
+			GasOracleTemplate:   "{{ .price }}",
+			GasOracleSourceHTTP: map[string]interface{}{
+				ffresty.HTTPConfigURL: url,
+			},
+			GasOracleSourceMaxFailures: 2,
+		},
+		map[string]interface{}{
+			GasOracleMode: GasOracleModeConnector,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, sth.gasOracleSources, 2)
+	assert.Equal(t, "source1", sth.gasOracleSources[1].name)
+
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`"12345"`),
+	}, ffcapi.ErrorReason(""), nil).Once()
+
+	gasPrice, err := sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, `"12345"`, gasPrice.String())
+
+	// The connector value is cached, but the REST API is retried until it is dropped
+	gasPrice, err = sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, `"12345"`, gasPrice.String())
+	assert.Equal(t, 2, *calls)
+	assert.True(t, sth.gasOracleSources[0].dropped(time.Now()))
+
+	// Dropped, and the connector is cached - so nothing is queried, or recorded in the history
+	_, err = sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+
+	history, err := sth.GasPriceHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "oracle1", history[0].Sources[0].Source)
+	assert.Regexp(t, "FF21021", history[0].Sources[0].Error)
+	assert.Equal(t, "source1", history[0].Sources[1].Source)
+	assert.True(t, history[0].Sources[1].Cached)
+	assert.False(t, history[1].Sources[1].Cached)
+	assert.Equal(t, `"12345"`, history[1].GasPrice.String())
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestGasOracleSourcesMedian(t *testing.T) {
+	url1, _, done1 := newTestGasOracleServer(t, 200, `{"price":100}`)
+	defer done1()
+	url2, _, done2 := newTestGasOracleServer(t, 200, `{"price":300}`)
+	defer done2()
+
+	sources := []interface{}{}
+	for _, url := range []string{url1, url2} {
+		sources = append(sources, map[string]interface{}{
+			GasOracleMode:     GasOracleModeRESTAPI,
+			GasOracleTemplate: "{{ .price }}",
+			GasOracleSourceHTTP: map[string]interface{}{
+				ffresty.HTTPConfigURL: url,
+			},
+		})
+	}
+	sources = append(sources, map[string]interface{}{
+		GasOracleMode: GasOracleModeConnector,
+	})
+	sth, mockFFCAPI, err := newTestGasOracleHandler(t, GasOracleAggregationMedian, sources)
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`250`),
+	}, ffcapi.ErrorReason(""), nil).Once()
+
+	gasPrice, err := sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, `250`, gasPrice.String())
+
+	history, err := sth.GasPriceHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, GasOracleAggregationMedian, history[0].Aggregation)
+	assert.Len(t, history[0].Sources, 3)
+	assert.Equal(t, `100`, history[0].Sources[0].GasPrice.String())
+	assert.Equal(t, `300`, history[0].Sources[1].GasPrice.String())
+}
+
+func TestGasOracleSourcesAllDropped(t *testing.T) {
+	sth, mockFFCAPI, err := newTestGasOracleHandler(t, GasOracleAggregationMax, []interface{}{
+		map[string]interface{}{
+			GasOracleMode:              GasOracleModeConnector,
+			GasOracleSourceMaxFailures: 1,
+		},
+	})
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Once()
+
+	_, err = sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.Regexp(t, "pop", err)
+
+	_, err = sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.Regexp(t, "FF21099", err)
+
+	// Queried again after the recovery interval
+	sth.gasOracleSources[0].droppedUntil = time.Now().Add(-1 * time.Second)
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`250`),
+	}, ffcapi.ErrorReason(""), nil).Once()
+	gasPrice, err := sth.getGasPrice(context.Background(), mockFFCAPI)
+	assert.NoError(t, err)
+	assert.Equal(t, `250`, gasPrice.String())
+	assert.Zero(t, sth.gasOracleSources[0].failures)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestGasOracleSlowQueryShared(t *testing.T) {
+	sth, mockFFCAPI, err := newTestGasOracleHandler(t, GasOracleAggregationFirstSuccess, []interface{}{
+		map[string]interface{}{
+			GasOracleMode: GasOracleModeConnector,
+		},
+	})
+	assert.NoError(t, err)
+
+	querying := make(chan struct{})
+	release := make(chan struct{})
+	mockFFCAPI.On("GasPriceEstimate", mock.Anything, mock.Anything).Return(&ffcapi.GasPriceEstimateResponse{
+		GasPrice: fftypes.JSONAnyPtr(`12345`),
+	}, ffcapi.ErrorReason(""), nil).Run(func(args mock.Arguments) {
+		close(querying)
+		<-release
+	}).Once()
+
+	results := make(chan string, 2)
+	go func() {
+		gasPrice, err := sth.getGasPrice(context.Background(), mockFFCAPI)
+		assert.NoError(t, err)
+		results <- gasPrice.String()
+	}()
+	<-querying
+
+	// The history can be read while the query is in progress
+	history, err := sth.GasPriceHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	// A second caller shares the query in progress, or the value it cached, rather than making its own
+	go func() {
+		gasPrice, err := sth.getGasPrice(context.Background(), mockFFCAPI)
+		assert.NoError(t, err)
+		results <- gasPrice.String()
+	}()
+	close(release)
+	assert.Equal(t, `12345`, <-results)
+	assert.Equal(t, `12345`, <-results)
+
+	history, err = sth.GasPriceHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestGasOracleWaitForQueryCancelled(t *testing.T) {
+	gos := &gasOracleSource{
+		name:  GasOracleModeConnector,
+		mode:  GasOracleModeConnector,
+		query: &gasOracleQuery{done: make(chan struct{})},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := gos.getGasPrice(ctx, nil, time.Now())
+	assert.Regexp(t, "FF00154", err)
+
+	gos.query.gasPrice = fftypes.JSONAnyPtr(`12345`)
+	close(gos.query.done)
+	gasPrice, cached, err := gos.getGasPrice(context.Background(), nil, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, `12345`, gasPrice.String())
+}
+
+func TestGasOracleInvalidAggregation(t *testing.T) {
+	_, _, err := newTestGasOracleHandler(t, "wrong", []interface{}{})
+	assert.Regexp(t, "FF21097", err)
+}
+
+func TestGasOracleSourceInvalidMode(t *testing.T) {
+	_, _, err := newTestGasOracleHandler(t, GasOracleAggregationFirstSuccess, []interface{}{
+		map[string]interface{}{
+			GasOracleSourceName: "oracle1",
+			GasOracleMode:       GasOracleModeDisabled,
+		},
+	})
+	assert.Regexp(t, "FF21098.*oracle1", err)
+}
+
+func TestGasOracleSourceMissingTemplate(t *testing.T) {
+	_, _, err := newTestGasOracleHandler(t, GasOracleAggregationFirstSuccess, []interface{}{
+		map[string]interface{}{
+			GasOracleMode: GasOracleModeRESTAPI,
+		},
+	})
+	assert.Regexp(t, "FF21024", err)
+}
+
+func TestGasPriceHistorySize(t *testing.T) {
+	sth := &simpleTransactionHandler{gasPriceHistorySize: 2}
+	sth.recordGasPriceHistory(&apitypes.GasPriceHistoryEntry{Aggregation: "1"}, true)
+	sth.recordGasPriceHistory(&apitypes.GasPriceHistoryEntry{Aggregation: "2"}, true)
+	sth.recordGasPriceHistory(&apitypes.GasPriceHistoryEntry{Aggregation: "3"}, false)
+	sth.recordGasPriceHistory(&apitypes.GasPriceHistoryEntry{Aggregation: "4"}, true)
+	history, err := sth.GasPriceHistory(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "4", history[0].Aggregation)
+	assert.Equal(t, "2", history[1].Aggregation)
+}
+
+func TestAggregateGasPrices(t *testing.T) {
+	values := func(v ...string) []*fftypes.JSONAny {
+		r := make([]*fftypes.JSONAny, len(v))
+		for i, s := range v {
+			r[i] = fftypes.JSONAnyPtr(s)
+		}
+		return r
+	}
+	assert.Equal(t, `1`, aggregateGasPrices(GasOracleAggregationFirstSuccess, values(`1`, `2`)).String())
+	assert.Equal(t, `3`, aggregateGasPrices(GasOracleAggregationMax, values(`1`, `3`, `2`)).String())
+	assert.Equal(t, `"15"`, aggregateGasPrices(GasOracleAggregationMedian, values(`"10"`, `"0x14"`)).String())
+	assert.Equal(t, `2`, aggregateGasPrices(GasOracleAggregationMedian, values(`1`, `3`, `2`)).String())
+	assert.Equal(t, `1.5`, aggregateGasPrices(GasOracleAggregationMax, values(`1.5`, `3`)).String())
+	assert.Equal(t, `1`, aggregateGasPrices(GasOracleAggregationMax, values(`1`, `"wrong"`)).String())
+	assert.Equal(t, `true`, aggregateGasPrices(GasOracleAggregationMax, values(`true`, `{}`)).String())
+	assert.Equal(t, `{"a":1}`, aggregateGasPrices(GasOracleAggregationMax, values(`{"a":1}`, `2`)).String())
+	assert.JSONEq(t, `{"maxFeePerGas":"0x0","maxPriorityFeePerGas":30,"label":"x"}`, aggregateGasPrices(GasOracleAggregationMax, values(
+		`{"maxFeePerGas":"0x0","maxPriorityFeePerGas":10,"label":"x"}`,
+		`{"maxPriorityFeePerGas":30}`,
+		`{"maxFeePerGas":"wrong","maxPriorityFeePerGas":20}`,
+	)).String())
+}
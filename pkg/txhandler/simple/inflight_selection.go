@@ -0,0 +1,205 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+// signerQueue holds the pending transactions of a signer that are candidates for the in-flight set,
+// in nonce order
+type signerQueue struct {
+	signer string
+	txs    []*apitypes.ManagedTX
+	// the effective priority of each transaction, which is the highest priority of that transaction and all of
+	// those behind it - so an earlier nonce inherits the priority of the transactions waiting on it
+	priorities []int
+}
+
+func (q *signerQueue) pop() *apitypes.ManagedTX {
+	mtx := q.txs[0]
+	q.txs, q.priorities = q.txs[1:], q.priorities[1:]
+	return mtx
+}
+
+// receivedBefore orders the heads of two signer queues by when they were received, to keep to the order
+// of the pending transactions across signers
+func receivedBefore(a, b *signerQueue) bool {
+	if aCreated, bCreated := a.txs[0].Created.UnixNano(), b.txs[0].Created.UnixNano(); aCreated != bCreated {
+		return aCreated < bCreated
+	}
+	return a.signer < b.signer
+}
+
+// selectPending chooses up to the requested number of pending transactions to add to the in-flight set. Every signer
+// with pending transactions is considered, reading the next transactions of each signer in nonce order, so a signer
+// with a large backlog cannot hide the transactions of the others. Each signer is limited to maxInFlightPerSigner
+// transactions in-flight at once. With priority scheduling, only the signers with the highest priority transaction
+// waiting are considered for each space. With fair scheduling the signers take turns, each taking as many transactions
+// per turn as the weight of the namespace of the next transaction in its queue.
+// The transactions of a signer are always taken in nonce order, so a later nonce is never in-flight while an earlier
+// one is left behind.
+func (sth *simpleTransactionHandler) selectPending(ctx context.Context, spaces int) ([]*apitypes.ManagedTX, error) {
+	inflightIDs := make(map[string]bool, len(sth.inflight))
+	inflightBySigner := make(map[string]int)
+	for _, pending := range sth.inflight {
+		inflightIDs[pending.mtx.ID] = true
+		inflightBySigner[pending.mtx.From]++
+	}
+
+	var queues []*signerQueue
+	// We retry the get from persistence indefinitely (until the context cancels)
+	err := sth.retry.Do(ctx, "get pending transactions", func(_ int) (retry bool, err error) {
+		queues, err = sth.readSignerQueues(ctx, spaces, inflightIDs, inflightBySigner)
+		if err == nil && sth.priorityScheduling && len(queues) > 0 {
+			err = sth.readQueuePriorities(ctx, queues, spaces, inflightIDs)
+		}
+		return true, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]int)
+	available := func(q *signerQueue) bool {
+		return len(q.txs) > 0 && (sth.maxInFlightPerSigner <= 0 || inflightBySigner[q.signer]+taken[q.signer] < sth.maxInFlightPerSigner)
+	}
+	selected := make([]*apitypes.ManagedTX, 0, spaces)
+	take := func(q *signerQueue) {
+		selected = append(selected, q.pop())
+		taken[q.signer]++
+	}
+	for len(selected) < spaces {
+		priority, found := 0, false
+		for _, q := range queues {
+			if available(q) && (!found || q.priorities[0] > priority) {
+				priority, found = q.priorities[0], true
+			}
+		}
+		if !found {
+			break
+		}
+
+		if !sth.fairScheduling {
+			// Keep the order the transactions were received in, only skipping the signers at their limit
+			var next *signerQueue
+			for _, q := range queues {
+				if available(q) && q.priorities[0] == priority && (next == nil || receivedBefore(q, next)) {
+					next = q
+				}
+			}
+			take(next)
+			continue
+		}
+
+		for _, q := range queues {
+			if !available(q) || q.priorities[0] != priority {
+				continue
+			}
+			turn := sth.namespaceWeight(q.txs[0].Namespace(ctx))
+			for i := 0; i < turn && len(selected) < spaces && available(q) && q.priorities[0] == priority; i++ {
+				take(q)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// readSignerQueues reads the next pending transactions of every signer that has pending transactions, paging
+// through the signers pendingScanLimit at a time. Each signer only needs as many transactions as it could
+// add to the in-flight set, after those it already has in-flight.
+func (sth *simpleTransactionHandler) readSignerQueues(ctx context.Context, spaces int, inflightIDs map[string]bool, inflightBySigner map[string]int) ([]*signerQueue, error) {
+	queues := make([]*signerQueue, 0)
+	after := ""
+	for {
+		signers, err := sth.toolkit.TXPersistence.ListPendingSigners(ctx, after, sth.pendingScanLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, signer := range signers {
+			want := spaces
+			if sth.maxInFlightPerSigner > 0 && sth.maxInFlightPerSigner-inflightBySigner[signer] < want {
+				want = sth.maxInFlightPerSigner - inflightBySigner[signer]
+			}
+			if want <= 0 {
+				continue
+			}
+			txs, err := sth.toolkit.TXPersistence.ListSignerTransactionsPending(ctx, signer, inflightBySigner[signer]+want)
+			if err != nil {
+				return nil, err
+			}
+			q := &signerQueue{signer: signer}
+			for _, mtx := range txs {
+				if !inflightIDs[mtx.ID] {
+					q.txs = append(q.txs, mtx)
+					q.priorities = append(q.priorities, 0)
+				}
+			}
+			if len(q.txs) > 0 {
+				queues = append(queues, q)
+			}
+		}
+		if sth.pendingScanLimit <= 0 || len(signers) < sth.pendingScanLimit {
+			return queues, nil
+		}
+		after = signers[len(signers)-1]
+	}
+}
+
+// readQueuePriorities sets the effective priority of each transaction in the signer queues. A queue only holds the
+// next few transactions of its signer, so the highest priority transactions waiting are read as well, and one behind
+// those in the queue raises the priority of the whole queue.
+func (sth *simpleTransactionHandler) readQueuePriorities(ctx context.Context, queues []*signerQueue, spaces int, inflightIDs map[string]bool) error {
+	waiting, err := sth.toolkit.TXPersistence.ListTransactionsPendingByPriority(ctx, len(inflightIDs)+spaces)
+	if err != nil {
+		return err
+	}
+	queued := make(map[string]bool)
+	for _, q := range queues {
+		for _, mtx := range q.txs {
+			queued[mtx.ID] = true
+		}
+	}
+	behindQueue := make(map[string]int)
+	for _, mtx := range waiting {
+		if !queued[mtx.ID] && !inflightIDs[mtx.ID] && mtx.Priority > behindQueue[mtx.From] {
+			behindQueue[mtx.From] = mtx.Priority
+		}
+	}
+	for _, q := range queues {
+		for i := len(q.txs) - 1; i >= 0; i-- {
+			behind := behindQueue[q.signer]
+			if i < len(q.txs)-1 {
+				behind = q.priorities[i+1]
+			}
+			q.priorities[i] = q.txs[i].Priority
+			if behind > q.priorities[i] {
+				q.priorities[i] = behind
+			}
+		}
+	}
+	return nil
+}
+
+func (sth *simpleTransactionHandler) namespaceWeight(namespace string) int {
+	if weight, ok := sth.namespaceWeights[namespace]; ok {
+		return weight
+	}
+	return defaultNamespaceWeight
+}
@@ -0,0 +1,324 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestSchedulingHandler(t *testing.T, scheduling map[string]interface{}) (*simpleTransactionHandler, *persistencemocks.Persistence, error) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	// Array entries are only read from a config file, not values set directly
+	configJSON, _ := json.Marshal(map[string]interface{}{
+		"unittest": map[string]interface{}{
+			"simple": map[string]interface{}{
+				SchedulingConfig: scheduling,
+			},
+		},
+	})
+	viper.SetConfigType("json")
+	err := viper.ReadConfig(bytes.NewReader(configJSON))
+	assert.NoError(t, err)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), nil
+}
+
+var testPendingCreated int64
+
+func newTestPendingTXs(namespace, signer string, count int) []*apitypes.ManagedTX {
+	txs := make([]*apitypes.ManagedTX, count)
+	for i := range txs {
+		// Each transaction is received after all those made before it
+		created := fftypes.FFTime(time.Unix(0, atomic.AddInt64(&testPendingCreated, 1)))
+		txs[i] = &apitypes.ManagedTX{
+			ID:         fmt.Sprintf("%s:%s", namespace, fftypes.NewUUID()),
+			SequenceID: fftypes.NewUUID().String(),
+			Created:    &created,
+		}
+		txs[i].From = signer
+		txs[i].Nonce = fftypes.NewFFBigInt(int64(i))
+	}
+	return txs
+}
+
+// mockPendingBySigner serves the pending transactions to selectPending as persistence would, by signer
+func mockPendingBySigner(mp *persistencemocks.Persistence, pending []*apitypes.ManagedTX) {
+	signers := make([]string, 0)
+	bySigner := make(map[string][]*apitypes.ManagedTX)
+	for _, mtx := range pending {
+		if bySigner[mtx.From] == nil {
+			signers = append(signers, mtx.From)
+		}
+		bySigner[mtx.From] = append(bySigner[mtx.From], mtx)
+	}
+	sort.Strings(signers)
+	mp.On("ListPendingSigners", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, after string, limit int) []string {
+		page := make([]string, 0)
+		for _, signer := range signers {
+			if signer > after && (limit <= 0 || len(page) < limit) {
+				page = append(page, signer)
+			}
+		}
+		return page
+	}, nil)
+	mp.On("ListSignerTransactionsPending", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, signer string, limit int) []*apitypes.ManagedTX {
+		txs := bySigner[signer]
+		if len(txs) > limit {
+			txs = txs[:limit]
+		}
+		return txs
+	}, nil)
+	byPriority := make([]*apitypes.ManagedTX, 0)
+	for _, mtx := range pending {
+		if mtx.Priority > 0 {
+			byPriority = append(byPriority, mtx)
+		}
+	}
+	sort.SliceStable(byPriority, func(i, j int) bool { return byPriority[i].Priority > byPriority[j].Priority })
+	mp.On("ListTransactionsPendingByPriority", mock.Anything, mock.Anything).Return(func(_ context.Context, limit int) []*apitypes.ManagedTX {
+		if len(byPriority) > limit {
+			return byPriority[:limit]
+		}
+		return byPriority
+	}, nil).Maybe()
+}
+
+func selectedSigners(selected []*apitypes.ManagedTX) []string {
+	signers := make([]string, len(selected))
+	for i, mtx := range selected {
+		signers[i] = mtx.From
+	}
+	return signers
+}
+
+func TestSelectPendingFairRoundRobin(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingFair:             true,
+		SchedulingPendingScanLimit: 50,
+	})
+	assert.NoError(t, err)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 5)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 2)
+	txsC := newTestPendingTXs("ns1", "0xcccc", 1)
+	pending := append(append(append([]*apitypes.ManagedTX{}, txsA...), txsB...), txsC...)
+	mockPendingBySigner(mp, pending)
+
+	selected, err := sth.selectPending(context.Background(), 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xaaaa", "0xbbbb", "0xcccc", "0xaaaa", "0xbbbb"}, selectedSigners(selected))
+	// Each signer keeps the order of its own transactions
+	assert.Equal(t, txsA[0], selected[0])
+	assert.Equal(t, txsA[1], selected[3])
+
+	// Everything is taken if there is room
+	selected, err = sth.selectPending(context.Background(), 20)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 8)
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingMaxInFlightPerSigner(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingMaxInFlightPerSigner: 2,
+	})
+	assert.NoError(t, err)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 4)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 3)
+	pending := append(append([]*apitypes.ManagedTX{}, txsA...), txsB...)
+	mockPendingBySigner(mp, pending)
+	sth.inflight = []*pendingState{{mtx: txsA[0]}}
+
+	// Without fair scheduling the order received is kept, only skipping signers at their limit
+	selected, err := sth.selectPending(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsA[1], txsB[0], txsB[1]}, selected)
+
+	selected, err = sth.selectPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsA[1], txsB[0]}, selected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingSignersAtLimitSkipped(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingMaxInFlightPerSigner: 1,
+	})
+	assert.NoError(t, err)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 2)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 1)
+	txsC := newTestPendingTXs("ns1", "0xcccc", 1)
+	// Transactions received at the same time are taken in signer order
+	txsC[0].Created = txsB[0].Created
+	pending := append(append(append([]*apitypes.ManagedTX{}, txsA...), txsC...), txsB...)
+	mockPendingBySigner(mp, pending)
+	sth.inflight = []*pendingState{{mtx: txsA[0]}}
+
+	selected, err := sth.selectPending(context.Background(), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsB[0], txsC[0]}, selected)
+
+	// The signer at its limit is not read at all
+	mp.AssertNotCalled(t, "ListSignerTransactionsPending", mock.Anything, "0xaaaa", mock.Anything)
+}
+
+func TestSelectPendingReadSignerFail(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingFair: true,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mp.On("ListPendingSigners", mock.Anything, "", defaultPendingScanLimit).Return([]string{"0xaaaa"}, nil)
+	mp.On("ListSignerTransactionsPending", mock.Anything, "0xaaaa", 5).Return(nil, fmt.Errorf("pop"))
+
+	_, err = sth.selectPending(ctx, 5)
+	assert.Error(t, err)
+}
+
+func TestSelectPendingNamespaceWeights(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingFair: true,
+		SchedulingNamespaceWeights: []interface{}{
+			map[string]interface{}{
+				SchedulingNamespace: "ops",
+				SchedulingWeight:    3,
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	txsBatch := newTestPendingTXs("batch", "0xaaaa", 10)
+	txsOps := newTestPendingTXs("ops", "0xbbbb", 5)
+	pending := append(append([]*apitypes.ManagedTX{}, txsBatch...), txsOps...)
+	mockPendingBySigner(mp, pending)
+
+	selected, err := sth.selectPending(context.Background(), 8)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xaaaa", "0xbbbb", "0xbbbb", "0xbbbb", "0xaaaa", "0xbbbb", "0xbbbb", "0xaaaa"}, selectedSigners(selected))
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingBadNamespaceWeight(t *testing.T) {
+	_, _, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingNamespaceWeights: []interface{}{
+			map[string]interface{}{
+				SchedulingNamespace: "ns1",
+				SchedulingWeight:    0,
+			},
+		},
+	})
+	assert.Regexp(t, "FF21102.*ns1", err)
+}
+
+func TestUpdateInflightSetFairScheduling(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingFair: true,
+	})
+	assert.NoError(t, err)
+	sth.maxInFlight = 3
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 3)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 1)
+	sth.inflight = []*pendingState{{mtx: txsA[0]}}
+	pending := append(append([]*apitypes.ManagedTX{}, txsA...), txsB...)
+	mockPendingBySigner(mp, pending)
+
+	assert.True(t, sth.updateInflightSet(context.Background()))
+	assert.Len(t, sth.inflight, 3)
+	assert.Equal(t, txsA[1], sth.inflight[1].mtx)
+	assert.Equal(t, txsB[0], sth.inflight[2].mtx)
+
+	mp.AssertExpectations(t)
+}
+
+func TestUpdateInflightSetFairSchedulingCancelled(t *testing.T) {
+	sth, mp, err := newTestSchedulingHandler(t, map[string]interface{}{
+		SchedulingFair: true,
+	})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mp.On("ListPendingSigners", mock.Anything, "", defaultPendingScanLimit).Return(nil, fmt.Errorf("pop"))
+
+	assert.False(t, sth.updateInflightSet(ctx))
+}
+
+func TestSelectPendingSignerBacklogBeyondScanLimit(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactoryWithFilePersistence(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.SubSection(SchedulingConfig).Set(SchedulingPendingScanLimit, 1)
+	conf.SubSection(SchedulingConfig).Set(SchedulingMaxInFlightPerSigner, 2)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	// The first signer has a backlog of many more transactions than are read at a time
+	insert := func(signer string, count int) []*apitypes.ManagedTX {
+		txs := newTestPendingTXs("ns1", signer, count)
+		for _, mtx := range txs {
+			mtx.Status = apitypes.TxStatusPending
+			mtx.Nonce, mtx.SequenceID = nil, ""
+			err := tk.TXPersistence.InsertTransactionWithNextNonce(context.Background(), mtx, func(ctx context.Context, signer string) (uint64, error) {
+				return 0, nil
+			})
+			assert.NoError(t, err)
+		}
+		return txs
+	}
+	txsA := insert("0xaaaa", 30)
+	txsB := insert("0xbbbb", 1)
+	txsC := insert("0xcccc", 1)
+
+	selected, err := sth.selectPending(context.Background(), 10)
+	assert.NoError(t, err)
+	selectedIDs := make([]string, len(selected))
+	for i, mtx := range selected {
+		selectedIDs[i] = mtx.ID
+	}
+	assert.Equal(t, []string{txsA[0].ID, txsA[1].ID, txsB[0].ID, txsC[0].ID}, selectedIDs)
+}
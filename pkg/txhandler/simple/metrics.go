@@ -27,6 +27,9 @@ func (sth *simpleTransactionHandler) initSimpleHandlerMetrics(ctx context.Contex
 	sth.toolkit.MetricsManager.InitTxHandlerHistogramMetricWithLabels(ctx, metricsHistogramTransactionProcessOperationsDuration, metricsHistogramTransactionProcessOperationsDurationDescription, []float64{} /*fallback to default buckets*/, []string{metricsLabelNameOperation}, true)
 	sth.toolkit.MetricsManager.InitTxHandlerGaugeMetric(ctx, metricsGaugeTransactionsInflightUsed, metricsGaugeTransactionsInflightUsedDescription, false)
 	sth.toolkit.MetricsManager.InitTxHandlerGaugeMetric(ctx, metricsGaugeTransactionsInflightFree, metricsGaugeTransactionsInflightFreeDescription, false)
+	sth.toolkit.MetricsManager.InitTxHandlerGaugeMetric(ctx, metricsGaugeSignersPaused, metricsGaugeSignersPausedDescription, false)
+	sth.toolkit.MetricsManager.InitTxHandlerCounterMetricWithLabels(ctx, metricsCounterPolicyWorkerTransactionsTotal, metricsCounterPolicyWorkerTransactionsTotalDescription, []string{metricsLabelNameWorker}, false)
+	sth.toolkit.MetricsManager.InitTxHandlerHistogramMetricWithLabels(ctx, metricsHistogramPolicyWorkerDuration, metricsHistogramPolicyWorkerDurationDescription, []float64{} /*fallback to default buckets*/, []string{metricsLabelNameWorker}, false)
 }
 
 func (sth *simpleTransactionHandler) setTransactionInflightQueueMetrics(ctx context.Context) {
@@ -34,6 +37,10 @@ func (sth *simpleTransactionHandler) setTransactionInflightQueueMetrics(ctx cont
 	sth.toolkit.MetricsManager.SetTxHandlerGaugeMetric(ctx, metricsGaugeTransactionsInflightFree, float64(sth.maxInFlight-len(sth.inflight)), nil)
 }
 
+func (sth *simpleTransactionHandler) setSignersPausedMetric(ctx context.Context, pausedCount int) {
+	sth.toolkit.MetricsManager.SetTxHandlerGaugeMetric(ctx, metricsGaugeSignersPaused, float64(pausedCount), nil)
+}
+
 func (sth *simpleTransactionHandler) incTransactionOperationCounter(ctx context.Context, fireflyNamespace string, operationName string) {
 	sth.toolkit.MetricsManager.IncTxHandlerCounterMetricWithLabels(ctx, metricsCounterTransactionProcessOperationsTotal, map[string]string{metricsLabelNameOperation: operationName}, &metric.FireflyDefaultLabels{Namespace: fireflyNamespace})
 }
@@ -0,0 +1,173 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+const nonceGapSignerPageSize = 100
+
+// startNonceGapCheck runs a nonce gap check on its own goroutine, as it makes a connector call for every signer.
+// The policy loop does not start another check until the one in progress completes.
+func (sth *simpleTransactionHandler) startNonceGapCheck(ctx context.Context) {
+	if sth.nonceGapCheckDone != nil {
+		select {
+		case <-sth.nonceGapCheckDone:
+		default:
+			log.L(ctx).Debugf("Nonce gap check still in progress")
+			return
+		}
+	}
+	sth.nonceGapLastCheck = time.Now()
+	done := make(chan struct{})
+	sth.nonceGapCheckDone = done
+	go func() {
+		defer close(done)
+		sth.checkNonceGaps(ctx)
+	}()
+}
+
+// waitNonceGapCheck waits for any nonce gap check in progress to complete
+func (sth *simpleTransactionHandler) waitNonceGapCheck() {
+	if sth.nonceGapCheckDone != nil {
+		<-sth.nonceGapCheckDone
+	}
+}
+
+// checkNonceGaps looks for nonces that the chain is waiting for, but that we have no transaction for that can be mined,
+// for each signer with transactions in persistence. A gap blocks every transaction behind it from being mined
+// indefinitely - for example after a transaction was deleted, or failed, before it was ever submitted.
+func (sth *simpleTransactionHandler) checkNonceGaps(ctx context.Context) {
+	after := ""
+	for {
+		signers, err := sth.toolkit.TXPersistence.ListSigners(ctx, after, nonceGapSignerPageSize)
+		if err != nil {
+			log.L(ctx).Warnf("Nonce gap check failed to list signers: %s", err)
+			return
+		}
+		sth.checkSignersNonceGaps(ctx, signers)
+		if len(signers) < nonceGapSignerPageSize {
+			return
+		}
+		after = signers[len(signers)-1]
+	}
+}
+
+func (sth *simpleTransactionHandler) checkSignersNonceGaps(ctx context.Context, signers []string) {
+	for _, signer := range signers {
+		nonces, err := txhandler.CheckSignerNonces(ctx, sth.toolkit.TXPersistence, sth.toolkit.Connector, signer)
+		if err != nil {
+			log.L(ctx).Warnf("Nonce gap check failed for signer %s: %s", signer, err)
+			continue
+		}
+		if len(nonces.Gaps) == 0 {
+			continue
+		}
+		log.L(ctx).Warnf("Detected %d nonce gap(s) for signer %s (chain next nonce %s): %v", len(nonces.Gaps), signer, nonces.ChainNextNonce, nonces.Gaps)
+		sth.incTransactionOperationCounter(ctx, "", "nonce_gap_detected")
+		if !sth.nonceGapFill {
+			continue
+		}
+		for _, nonce := range nonces.Gaps {
+			if err := sth.fillNonceGap(ctx, signer, nonce); err != nil {
+				// Later gaps cannot be mined before this one, so there is no point continuing
+				log.L(ctx).Errorf("Failed to fill nonce gap %s / %s: %s", signer, nonce, err)
+				break
+			}
+		}
+	}
+}
+
+// fillNonceGap creates a zero value transfer from the signer to itself at the missing nonce, and submits it.
+// A transaction that holds the nonce, but will never be mined, is kept - but marked as superseded by the
+// filler, which releases the nonce to it.
+func (sth *simpleTransactionHandler) fillNonceGap(ctx context.Context, signer string, nonce *fftypes.FFBigInt) error {
+	existing, err := sth.toolkit.TXPersistence.GetTransactionByNonce(ctx, signer, nonce)
+	if err != nil {
+		return err
+	}
+
+	txHeaders := ffcapi.TransactionHeaders{
+		From:  signer,
+		To:    signer,
+		Nonce: nonce,
+		Value: fftypes.NewFFBigInt(0),
+	}
+	prepared, _, err := sth.toolkit.Connector.TransactionPrepare(ctx, &ffcapi.TransactionPrepareRequest{
+		TransactionInput: ffcapi.TransactionInput{
+			TransactionHeaders: txHeaders,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	txHeaders.Gas = prepared.Gas
+
+	fillerID := fftypes.NewUUID().String()
+	nonceInfo := map[string]interface{}{
+		"nonce":        nonce.String(),
+		"nonceGapFill": true,
+	}
+	if existing != nil {
+		log.L(ctx).Warnf("Superseding %s transaction %s at nonce %s / %d, that was never mined, with %s to fill the nonce gap", existing.Status, existing.ID, signer, nonce.Int64(), fillerID)
+		if err := sth.toolkit.TXPersistence.UpdateTransaction(ctx, existing.ID, &apitypes.TXUpdates{SupersededBy: &fillerID}); err != nil {
+			return err
+		}
+		nonceInfo["supersedes"] = existing.ID
+	}
+	nonceInfoBytes, _ := json.Marshal(nonceInfo)
+
+	now := fftypes.Now()
+	mtx := &apitypes.ManagedTX{
+		ID:                 fillerID,
+		Created:            now,
+		Updated:            now,
+		TransactionHeaders: txHeaders,
+		TransactionData:    prepared.TransactionData,
+		Status:             apitypes.TxStatusPending,
+		PolicyInfo:         fftypes.JSONAnyPtr(`{}`),
+	}
+	if err := sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx); err != nil {
+		return err
+	}
+	if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, mtx.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, fftypes.JSONAnyPtrBytes(nonceInfoBytes), nil, fftypes.Now()); err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Filling nonce gap with transaction %s at nonce %s / %d", mtx.ID, signer, nonce.Int64())
+	sth.incTransactionOperationCounter(ctx, "", "nonce_gap_filled")
+
+	// Submit straight away, rather than waiting for a space in the in-flight set - as that
+	// set might be full of the transactions that are blocked behind this gap.
+	// The policy loop picks up the transaction from persistence to track it to completion.
+	pending := &pendingState{
+		mtx:       mtx,
+		info:      &simplePolicyInfo{},
+		subStatus: apitypes.TxSubStatusReceived,
+	}
+	err = sth.execPolicy(ctx, pending, nil)
+	sth.markInflightStale()
+	return err
+}
@@ -0,0 +1,370 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testGapSigner = "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712"
+
+func newTestNonceGapHandler(t *testing.T, fill bool) (*simpleTransactionHandler, *txhandler.Toolkit, *ffcapimocks.API) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactoryWithFilePersistence(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(ResubmitInterval, "100s")
+	conf.SubSection(NonceGapCheckConfig).Set(NonceGapCheckEnabled, true)
+	conf.SubSection(NonceGapCheckConfig).Set(NonceGapCheckFill, fill)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+	return sth, tk, mockFFCAPI
+}
+
+func insertTestGapTX(t *testing.T, sth *simpleTransactionHandler, nonce int64) *apitypes.ManagedTX {
+	mtx := &apitypes.ManagedTX{
+		ID:      fmt.Sprintf("ns1:%s", fftypes.NewUUID()),
+		Created: fftypes.Now(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  testGapSigner,
+			Nonce: fftypes.NewFFBigInt(nonce),
+		},
+		Status: apitypes.TxStatusPending,
+	}
+	err := sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(context.Background(), mtx)
+	assert.NoError(t, err)
+	return mtx
+}
+
+func TestNonceGapCheckDetectOnly(t *testing.T) {
+	sth, _, mockFFCAPI := newTestNonceGapHandler(t, false)
+	insertTestGapTX(t, sth, 12)
+
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10),
+	}, ffcapi.ErrorReason(""), nil)
+
+	sth.checkNonceGaps(context.Background())
+
+	txs, err := sth.toolkit.TXPersistence.ListTransactionsByNonce(context.Background(), testGapSigner, nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapCheckFill(t *testing.T) {
+	sth, tk, mockFFCAPI := newTestNonceGapHandler(t, true)
+	insertTestGapTX(t, sth, 12)
+	insertTestGapTX(t, sth, 13)
+
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10),
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.From == testGapSigner && req.To == testGapSigner && req.Value.Int64() == 0
+	})).Return(&ffcapi.TransactionPrepareResponse{
+		Gas:             fftypes.NewFFBigInt(21000),
+		TransactionData: "FILLER_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Twice()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.Nonce.Int64() == 10 && req.TransactionData == "FILLER_TX_BYTES"
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x10"}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.Nonce.Int64() == 11 && req.TransactionData == "FILLER_TX_BYTES"
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x11"}, ffcapi.ErrorReason(""), nil).Once()
+	eh := tk.EventHandler.(*txhandlermocks.ManagedTxEventHandler)
+	eh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded
+	})).Return(nil).Twice()
+
+	sth.checkNonceGaps(context.Background())
+
+	txs, err := sth.toolkit.TXPersistence.ListTransactionsByNonce(context.Background(), testGapSigner, nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 4)
+	for i, nonce := range []int64{10, 11} {
+		assert.Equal(t, nonce, txs[i].Nonce.Int64())
+		assert.Equal(t, testGapSigner, txs[i].To)
+		assert.Equal(t, fmt.Sprintf("0x%d", nonce), txs[i].TransactionHash)
+		assert.NotNil(t, txs[i].FirstSubmit)
+	}
+
+	// No gaps on the next check
+	sth.checkNonceGaps(context.Background())
+
+	mockFFCAPI.AssertExpectations(t)
+	eh.AssertExpectations(t)
+}
+
+func TestNonceGapCheckPolicyLoopInterval(t *testing.T) {
+	sth, _, mockFFCAPI := newTestNonceGapHandler(t, true)
+
+	sth.policyLoopCycle(context.Background(), false)
+	sth.waitSignerRuns()
+	sth.waitNonceGapCheck()
+	lastCheck := sth.nonceGapLastCheck
+	assert.False(t, lastCheck.IsZero())
+
+	// Not due again until the interval has passed
+	sth.policyLoopCycle(context.Background(), false)
+	sth.waitSignerRuns()
+	sth.waitNonceGapCheck()
+	assert.Equal(t, lastCheck, sth.nonceGapLastCheck)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapCheckStillInProgress(t *testing.T) {
+	sth, _, _ := newTestNonceGapHandler(t, true)
+	inProgress := make(chan struct{})
+	sth.nonceGapCheckDone = inProgress
+
+	// The check is due, but not started again while the previous one runs
+	sth.policyLoopCycle(context.Background(), false)
+	sth.waitSignerRuns()
+	assert.True(t, sth.nonceGapLastCheck.IsZero())
+	assert.Equal(t, inProgress, sth.nonceGapCheckDone)
+
+	close(inProgress)
+	sth.policyLoopCycle(context.Background(), false)
+	sth.waitSignerRuns()
+	sth.waitNonceGapCheck()
+	assert.False(t, sth.nonceGapLastCheck.IsZero())
+}
+
+func TestNonceGapCheckConnectorFail(t *testing.T) {
+	sth, _, mockFFCAPI := newTestNonceGapHandler(t, true)
+	insertTestGapTX(t, sth, 12)
+
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Once()
+
+	sth.checkNonceGaps(context.Background())
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapCheckPrepareFail(t *testing.T) {
+	sth, _, mockFFCAPI := newTestNonceGapHandler(t, true)
+	insertTestGapTX(t, sth, 12)
+
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10),
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Once()
+
+	sth.checkNonceGaps(context.Background())
+
+	txs, err := sth.toolkit.TXPersistence.ListTransactionsByNonce(context.Background(), testGapSigner, nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapFillInsertFail(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "FILLER_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByNonce", mock.Anything, testGapSigner, mock.Anything).Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(fmt.Errorf("pop")).Once()
+
+	err = sth.fillNonceGap(context.Background(), testGapSigner, fftypes.NewFFBigInt(10))
+	assert.Regexp(t, "pop", err)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestNonceGapFillHistoryFail(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "FILLER_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByNonce", mock.Anything, testGapSigner, mock.Anything).Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(nil).Once()
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop")).Once()
+
+	err = sth.fillNonceGap(context.Background(), testGapSigner, fftypes.NewFFBigInt(10))
+	assert.Regexp(t, "pop", err)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestNonceGapCheckFillReplacesFailedTX(t *testing.T) {
+	sth, tk, mockFFCAPI := newTestNonceGapHandler(t, true)
+	// A transaction that failed before it was ever submitted, for a signer with nothing in-flight
+	failedTX := insertTestGapTX(t, sth, 10)
+	failed := apitypes.TxStatusFailed
+	err := sth.toolkit.TXPersistence.UpdateTransaction(context.Background(), failedTX.ID, &apitypes.TXUpdates{
+		Status: &failed,
+	})
+	assert.NoError(t, err)
+	insertTestGapTX(t, sth, 11)
+	assert.Empty(t, sth.inflight)
+
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(&ffcapi.NextNonceForSignerResponse{
+		Nonce: fftypes.NewFFBigInt(10),
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		Gas:             fftypes.NewFFBigInt(21000),
+		TransactionData: "FILLER_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.Nonce.Int64() == 10 && req.TransactionData == "FILLER_TX_BYTES"
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x10"}, ffcapi.ErrorReason(""), nil).Once()
+	eh := tk.EventHandler.(*txhandlermocks.ManagedTxEventHandler)
+	eh.On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	sth.checkNonceGaps(context.Background())
+
+	txs, err := sth.toolkit.TXPersistence.ListTransactionsByNonce(context.Background(), testGapSigner, nil, 10, txhandler.SortDirectionAscending)
+	assert.NoError(t, err)
+	assert.Len(t, txs, 2)
+	filler := txs[0]
+	assert.NotEqual(t, failedTX.ID, filler.ID)
+	assert.Equal(t, testGapSigner, filler.To)
+	assert.Equal(t, "0x10", filler.TransactionHash)
+
+	// The failed transaction is kept, without its nonce, and linked in both directions with the filler
+	superseded, err := sth.toolkit.TXPersistence.GetTransactionByID(context.Background(), failedTX.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusFailed, superseded.Status)
+	assert.Equal(t, filler.ID, superseded.SupersededBy)
+	assert.Nil(t, superseded.Nonce)
+	fillerStatus, err := sth.toolkit.TXPersistence.GetTransactionByIDWithStatus(context.Background(), filler.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, failedTX.ID, fillerStatus.History[0].Actions[0].LastInfo.JSONObject().GetString("supersedes"))
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapCheckListSignersFail(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("ListSigners", mock.Anything, "", nonceGapSignerPageSize).Return(nil, fmt.Errorf("pop")).Once()
+
+	sth.checkNonceGaps(context.Background())
+
+	mp.AssertExpectations(t)
+}
+
+func TestNonceGapCheckSignerPages(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	firstPage := make([]string, nonceGapSignerPageSize)
+	for i := range firstPage {
+		firstPage[i] = fmt.Sprintf("0x%.4d", i)
+	}
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("ListSigners", mock.Anything, "", nonceGapSignerPageSize).Return(firstPage, nil).Once()
+	mp.On("ListSigners", mock.Anything, firstPage[nonceGapSignerPageSize-1], nonceGapSignerPageSize).Return([]string{"0xffff"}, nil).Once()
+	mockFFCAPI.On("NextNonceForSigner", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Times(nonceGapSignerPageSize + 1)
+
+	sth.checkNonceGaps(context.Background())
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNonceGapFillExistingLookupFail(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByNonce", mock.Anything, testGapSigner, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err = sth.fillNonceGap(context.Background(), testGapSigner, fftypes.NewFFBigInt(10))
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+}
+
+func TestNonceGapFillSupersedeExistingFail(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.Init(sth.ctx, tk)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "FILLER_TX_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByNonce", mock.Anything, testGapSigner, mock.Anything).Return(&apitypes.ManagedTX{ID: "tx1", Status: apitypes.TxStatusFailed}, nil)
+	mp.On("UpdateTransaction", mock.Anything, "tx1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return updates.SupersededBy != nil && *updates.SupersededBy != ""
+	})).Return(fmt.Errorf("pop"))
+
+	err = sth.fillNonceGap(context.Background(), testGapSigner, fftypes.NewFFBigInt(10))
+	assert.Regexp(t, "pop", err)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,183 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/ffresty"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+// policyHook is an external HTTP endpoint, that decides whether each transaction can be submitted
+type policyHook struct {
+	client      *resty.Client
+	unavailable string // the decision applied when the hook cannot be called
+}
+
+// policyHookResponse is the answer expected from the policy hook
+type policyHookResponse struct {
+	Decision string `json:"decision"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func newPolicyHook(ctx context.Context, conf config.Section) (*policyHook, error) {
+	if conf.GetString(ffresty.HTTPConfigURL) == "" {
+		return nil, nil
+	}
+	ph := &policyHook{
+		unavailable: conf.GetString(PolicyHookUnavailable),
+	}
+	if !validPolicyDecision(ph.unavailable) {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidPolicyHookUnavailable, ph.unavailable)
+	}
+	client, err := ffresty.New(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	ph.client = client
+	return ph, nil
+}
+
+func validPolicyDecision(decision string) bool {
+	switch decision {
+	case PolicyDecisionAllow, PolicyDecisionDeny, PolicyDecisionDefer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (ph *policyHook) decide(ctx context.Context, mtx *apitypes.ManagedTX) (*policyHookResponse, error) {
+	var decision policyHookResponse
+	res, err := ph.client.R().
+		SetContext(ctx).
+		SetBody(mtx).
+		SetResult(&decision).
+		Post("")
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgErrorCallingPolicyHook, -1, err.Error())
+	}
+	if res.IsError() {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgErrorCallingPolicyHook, res.StatusCode(), res.String())
+	}
+	if !validPolicyDecision(decision.Decision) {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidPolicyDecision, decision.Decision)
+	}
+	return &decision, nil
+}
+
+// policyDecisionRequired returns true for a transaction that is ready for its first submission, when a policy hook
+// is configured and has not yet allowed it. The hook is only called once any scheduled time or block is reached,
+// so any time window it enforces applies to when the transaction is submitted.
+func (sth *simpleTransactionHandler) policyDecisionRequired(ctx *RunContext) bool {
+	mtx := ctx.TX
+	return sth.policyHook != nil &&
+		mtx.FirstSubmit == nil &&
+		mtx.DeleteRequested == nil &&
+		!ctx.expired() &&
+		!ctx.Info.PolicyAllowed &&
+		sth.notBeforeReached(ctx)
+}
+
+// nonceAwaitsPolicyDecision returns true if a transaction without a nonce must not be assigned one until the policy
+// hook allows it, so a denied transaction does not leave a gap in the nonces of its signer
+func (sth *simpleTransactionHandler) nonceAwaitsPolicyDecision(ctx *RunContext) bool {
+	return sth.policyHook != nil && !ctx.Info.PolicyAllowed
+}
+
+// processPolicyDecision asks the policy hook whether a transaction can be submitted, at most once per policy loop interval
+func (sth *simpleTransactionHandler) processPolicyDecision(ctx *RunContext, pending *pendingState) (completed bool) {
+	if time.Since(pending.lastPolicyCycle) <= sth.policyLoopInterval {
+		return false
+	}
+	pending.lastPolicyCycle = time.Now()
+	return sth.decidePolicy(ctx, pending)
+}
+
+// decidePolicy asks the policy hook whether a transaction can be submitted, recording the answer in the history.
+// An allowed transaction is assigned a nonce if it does not have one, and is submitted on the next cycle. A deferred
+// one is asked about again after the policy loop interval. A denied transaction is failed, and true is returned to
+// remove it from the in-flight set.
+func (sth *simpleTransactionHandler) decidePolicy(ctx *RunContext, pending *pendingState) (completed bool) {
+	mtx := ctx.TX
+	var errInfo *fftypes.JSONAny
+	decision, err := sth.policyHook.decide(ctx, mtx)
+	if err != nil {
+		log.L(ctx).Warnf("Policy hook unavailable for transaction %s, applying '%s': %s", mtx.ID, sth.policyHook.unavailable, err)
+		decision = &policyHookResponse{Decision: sth.policyHook.unavailable, Reason: err.Error()}
+		errBytes, _ := json.Marshal(map[string]string{"error": err.Error()})
+		errInfo = fftypes.JSONAnyPtrBytes(errBytes)
+	}
+	info, _ := json.Marshal(map[string]interface{}{
+		"decision":    decision.Decision,
+		"reason":      decision.Reason,
+		"unavailable": err != nil,
+	})
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "policy_"+decision.Decision)
+
+	switch decision.Decision {
+	case PolicyDecisionAllow:
+		nonceAssigned := false
+		if mtx.Nonce == nil {
+			if err := sth.toolkit.TXPersistence.AssignTransactionNextNonce(ctx, mtx, sth.nextNonceForSigner); err != nil {
+				// The hook is asked again after the policy loop interval
+				log.L(ctx).Errorf("Failed to assign nonce to transaction %s allowed by the policy hook: %s", mtx.ID, err)
+				return false
+			}
+			nonceAssigned = true
+		}
+		log.L(ctx).Infof("Policy hook allowed transaction %s", mtx.ID)
+		ctx.SetSubStatus(apitypes.TxSubStatusReceived)
+		ctx.AddSubStatusAction(apitypes.TxActionPolicyDecision, fftypes.JSONAnyPtrBytes(info), errInfo, fftypes.Now())
+		if nonceAssigned {
+			ctx.AddSubStatusAction(apitypes.TxActionAssignNonce, fftypes.JSONAnyPtr(`{"nonce":"`+mtx.Nonce.String()+`"}`), nil, fftypes.Now())
+		}
+		ctx.UpdateType = Update
+		ctx.UpdatedInfo = true
+		ctx.Info.PolicyAllowed = true
+		// Do not wait for the next interval to submit
+		pending.lastPolicyCycle = time.Time{}
+		sth.markInflightUpdate()
+		return false
+	case PolicyDecisionDeny:
+		log.L(ctx).Warnf("Policy hook denied transaction %s: %s", mtx.ID, decision.Reason)
+		ctx.UpdateType = Update
+		mtx.Status = apitypes.TxStatusFailed
+		ctx.TXUpdates.Status = &mtx.Status
+		errMsg := i18n.NewError(ctx, tmmsgs.MsgPolicyHookDenied, decision.Reason).Error()
+		mtx.ErrorMessage = errMsg
+		ctx.TXUpdates.ErrorMessage = &errMsg
+		ctx.UpdatedInfo = true
+		ctx.Info.PolicyDenied = true
+		ctx.SetSubStatus(apitypes.TxSubStatusFailed)
+		ctx.AddSubStatusAction(apitypes.TxActionPolicyDecision, fftypes.JSONAnyPtrBytes(info), errInfo, fftypes.Now())
+		return true
+	default:
+		log.L(ctx).Debugf("Policy hook deferred transaction %s: %s", mtx.ID, decision.Reason)
+		ctx.setHeldSubStatus(apitypes.TxSubStatusAwaitingPolicy, apitypes.TxActionPolicyDecision, fftypes.JSONAnyPtrBytes(info), errInfo)
+		return false
+	}
+}
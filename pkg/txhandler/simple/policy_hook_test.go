@@ -0,0 +1,429 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/ffresty"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPolicyHookServer(t *testing.T, status int, body string) (string, *[]*apitypes.ManagedTX, func()) {
+	var requests []*apitypes.ManagedTX
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		b, _ := io.ReadAll(r.Body)
+		var mtx apitypes.ManagedTX
+		assert.NoError(t, json.Unmarshal(b, &mtx))
+		requests = append(requests, &mtx)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	return fmt.Sprintf("http://%s", server.Listener.Addr()), &requests, server.Close
+}
+
+func newTestPolicyHookHandler(t *testing.T, url, unavailable string) (*simpleTransactionHandler, *persistencemocks.Persistence, *txhandlermocks.ManagedTxEventHandler) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	policyHookConf := conf.SubSection(PolicyHookConfig)
+	policyHookConf.Set(ffresty.HTTPConfigURL, url)
+	policyHookConf.Set(ffresty.HTTPConfigRetryEnabled, false)
+	if unavailable != "" {
+		policyHookConf.Set(PolicyHookUnavailable, unavailable)
+	}
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	sth.toolkit.EventHandler = meh
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), meh
+}
+
+func newTestPolicyHookPending() *pendingState {
+	return &pendingState{
+		mtx: &apitypes.ManagedTX{
+			ID:     "ns1:tx1",
+			Status: apitypes.TxStatusPending,
+			TransactionHeaders: ffcapi.TransactionHeaders{
+				From: "0xaaaa",
+				To:   "0xbbbb",
+			},
+			PolicyInfo: fftypes.JSONAnyPtr(`{}`),
+		},
+		info:      &simplePolicyInfo{},
+		subStatus: apitypes.TxSubStatusReceived,
+	}
+}
+
+func mockPolicyHookAssignNonce(mp *persistencemocks.Persistence) {
+	mp.On("AssignTransactionNextNonce", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(*apitypes.ManagedTX).Nonce = fftypes.NewFFBigInt(1)
+	}).Return(nil)
+}
+
+func matchPolicyDecision(decision string) interface{} {
+	return mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.JSONObject().GetString("decision") == decision
+	})
+}
+
+func TestPolicyHookAllow(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow","reason":"within limits"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mockPolicyHookAssignNonce(mp)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusReceived, apitypes.TxActionPolicyDecision, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.JSONObject().GetString("decision") == "allow" && info.JSONObject().GetString("reason") == "within limits"
+	}), (*fftypes.JSONAny)(nil), mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, (*fftypes.JSONAny)(nil), mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:tx1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return updates.PolicyInfo.JSONObject().GetBool("policyAllowed")
+	})).Return(nil)
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.True(t, pending.info.PolicyAllowed)
+	assert.Len(t, *requests, 1)
+	assert.Equal(t, "ns1:tx1", (*requests)[0].ID)
+	assert.Equal(t, "0xbbbb", (*requests)[0].To)
+	// The nonce is only assigned once the transaction is allowed
+	assert.Nil(t, (*requests)[0].Nonce)
+	assert.Equal(t, int64(1), pending.mtx.Nonce.Int64())
+
+	// The hook is not asked again once it has allowed the transaction
+	assert.False(t, sth.policyDecisionRequired(&RunContext{Context: sth.ctx, TX: pending.mtx, Info: pending.info}))
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookDeny(t *testing.T) {
+	url, _, done := newTestPolicyHookServer(t, 200, `{"decision":"deny","reason":"recipient not allowed"}`)
+	defer done()
+	sth, mp, meh := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusFailed, apitypes.TxActionPolicyDecision, matchPolicyDecision("deny"), (*fftypes.JSONAny)(nil), mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:tx1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.Status == apitypes.TxStatusFailed && updates.PolicyInfo.JSONObject().GetBool("policyDenied")
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed
+	})).Return(nil)
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Regexp(t, "FF21121.*recipient not allowed", pending.mtx.ErrorMessage)
+	// No nonce was assigned, so there is no gap left in the nonces of the signer
+	assert.Nil(t, pending.mtx.Nonce)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "AssignTransactionNextNonce", mock.Anything, mock.Anything, mock.Anything)
+	meh.AssertExpectations(t)
+}
+
+func TestPolicyHookDefer(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"defer","reason":"outside trading hours"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+	sth.policyLoopInterval = 1 * time.Hour
+
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusAwaitingPolicy, apitypes.TxActionPolicyDecision, matchPolicyDecision("defer"), (*fftypes.JSONAny)(nil), mock.Anything).Return(nil).Once()
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingPolicy, pending.subStatus)
+	assert.Nil(t, pending.mtx.FirstSubmit)
+
+	// Not asked again until the policy loop interval has passed
+	err = sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 1)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPolicyHookDeferRecordedOnce(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"defer","reason":"outside trading hours"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusAwaitingPolicy, apitypes.TxActionPolicyDecision, matchPolicyDecision("defer"), (*fftypes.JSONAny)(nil), mock.Anything).Return(nil).Once()
+
+	pending := newTestPolicyHookPending()
+	for i := 0; i < 3; i++ {
+		pending.lastPolicyCycle = time.Time{}
+		err := sth.execPolicy(sth.ctx, pending, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, apitypes.TxSubStatusAwaitingPolicy, pending.subStatus)
+	}
+	assert.Len(t, *requests, 3)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookAllowAssignNonceFail(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("AssignTransactionNextNonce", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	// Asked again later, as the transaction cannot be submitted without a nonce
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.info.PolicyAllowed)
+	assert.Len(t, *requests, 1)
+	assert.True(t, sth.policyDecisionRequired(&RunContext{Context: sth.ctx, TX: pending.mtx, Info: pending.info}))
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookNewTransactionsNoNonce(t *testing.T) {
+	sth, mp, _ := newTestPolicyHookHandler(t, "http://localhost:0", "")
+	mockFFCAPI := sth.toolkit.Connector.(*ffcapimocks.API)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Nonce == nil && mtx.Status == apitypes.TxStatusPending
+	})).Return(nil).Twice()
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.From = "0xaaaa"
+	mtx, _, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.NoError(t, err)
+	assert.Nil(t, mtx.Nonce)
+
+	batchReq := &apitypes.TransactionRequest{}
+	batchReq.From = "0xaaaa"
+	batch := []*txhandler.NewTransactionBatchItem{{TransactionRequest: batchReq}}
+	sth.HandleNewTransactionBatch(sth.ctx, batch)
+	assert.NoError(t, batch[0].Err)
+	assert.Nil(t, batch[0].ManagedTX.Nonce)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "InsertTransactionWithNextNonce", mock.Anything, mock.Anything, mock.Anything)
+	mp.AssertNotCalled(t, "InsertTransactionsWithNextNonce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPolicyHookDenyFilePersistence(t *testing.T) {
+	url, _, done := newTestPolicyHookServer(t, 200, `{"decision":"deny","reason":"recipient not allowed"}`)
+	defer done()
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactoryWithFilePersistence(t)
+	conf.Set(FixedGasPrice, `12345`)
+	policyHookConf := conf.SubSection(PolicyHookConfig)
+	policyHookConf.Set(ffresty.HTTPConfigURL, url)
+	policyHookConf.Set(ffresty.HTTPConfigRetryEnabled, false)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	tk.EventHandler.(*txhandlermocks.ManagedTxEventHandler).On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	// The transaction is stored without a nonce until the hook decides
+	txReq := &apitypes.TransactionRequest{}
+	txReq.From = "0xaaaa"
+	mtx, _, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.NoError(t, err)
+	assert.Nil(t, mtx.Nonce)
+	assert.True(t, sth.updateInflightSet(sth.ctx))
+	assert.Len(t, sth.inflight, 1)
+
+	// It fails without ever being assigned one
+	err = sth.execPolicy(sth.ctx, sth.inflight[0], nil)
+	assert.NoError(t, err)
+	stored, err := tk.TXPersistence.GetTransactionByID(sth.ctx, mtx.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusFailed, stored.Status)
+	assert.Nil(t, stored.Nonce)
+	mockFFCAPI.AssertNotCalled(t, "NextNonceForSigner", mock.Anything, mock.Anything)
+}
+
+func TestPolicyHookNewTransactionInsertFail(t *testing.T) {
+	sth, mp, _ := newTestPolicyHookHandler(t, "http://localhost:0", "")
+	mockFFCAPI := sth.toolkit.Connector.(*ffcapimocks.API)
+
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(&ffcapi.TransactionPrepareResponse{}, ffcapi.ErrorReason(""), nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, _, err := sth.HandleNewTransaction(sth.ctx, &apitypes.TransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestPolicyHookAfterDependencies(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:dep1").Return(&apitypes.ManagedTX{ID: "ns1:dep1", Status: apitypes.TxStatusSucceeded}, nil)
+	mockPolicyHookAssignNonce(mp)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusReceived, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:tx1", mock.Anything).Return(nil)
+
+	// The hook is asked once the dependencies have succeeded, and the nonce is only assigned once it allows the transaction
+	pending := newTestPolicyHookPending()
+	pending.mtx.DependsOn = []string{"ns1:dep1"}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Len(t, *requests, 1)
+	assert.True(t, pending.info.PolicyAllowed)
+	assert.Equal(t, int64(1), pending.mtx.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookDependenciesNotBeforeNotReached(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:dep1").Return(&apitypes.ManagedTX{ID: "ns1:dep1", Status: apitypes.TxStatusSucceeded}, nil)
+
+	pending := newTestPolicyHookPending()
+	pending.mtx.DependsOn = []string{"ns1:dep1"}
+	notBefore := fftypes.FFTime(time.Now().Add(1 * time.Hour))
+	pending.mtx.NotBefore = &notBefore
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, *requests)
+	assert.Nil(t, pending.mtx.Nonce)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookUnavailableDeny(t *testing.T) {
+	url, _, done := newTestPolicyHookServer(t, 500, `{"error":"pop"}`)
+	defer done()
+	sth, mp, meh := newTestPolicyHookHandler(t, url, PolicyDecisionDeny)
+
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusFailed, apitypes.TxActionPolicyDecision, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.JSONObject().GetString("decision") == "deny" && info.JSONObject().GetBool("unavailable")
+	}), mock.MatchedBy(func(errInfo *fftypes.JSONAny) bool {
+		return errInfo != nil && errInfo.JSONObject().GetString("error") != ""
+	}), mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:tx1", mock.Anything).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Regexp(t, "FF21121.*FF21122", pending.mtx.ErrorMessage)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookInvalidDecisionDefers(t *testing.T) {
+	url, _, done := newTestPolicyHookServer(t, 200, `{"decision":"maybe"}`)
+	defer done()
+	sth, mp, _ := newTestPolicyHookHandler(t, url, "")
+
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusAwaitingPolicy, apitypes.TxActionPolicyDecision, matchPolicyDecision("defer"), mock.MatchedBy(func(errInfo *fftypes.JSONAny) bool {
+		return errInfo != nil && errInfo.JSONObject().GetString("error") != ""
+	}), mock.Anything).Return(nil)
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.False(t, pending.remove)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookUnavailableAllow(t *testing.T) {
+	sth, mp, _ := newTestPolicyHookHandler(t, "http://localhost:0", PolicyDecisionAllow)
+
+	mockPolicyHookAssignNonce(mp)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:tx1", apitypes.TxSubStatusReceived, apitypes.TxActionPolicyDecision, matchPolicyDecision("allow"), mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:tx1", mock.Anything).Return(nil)
+
+	pending := newTestPolicyHookPending()
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.info.PolicyAllowed)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPolicyHookNotBeforeNotReached(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow"}`)
+	defer done()
+	sth, _, _ := newTestPolicyHookHandler(t, url, "")
+
+	pending := newTestPolicyHookPending()
+	notBefore := fftypes.FFTime(time.Now().Add(1 * time.Hour))
+	pending.mtx.NotBefore = &notBefore
+	assert.False(t, sth.policyDecisionRequired(&RunContext{Context: sth.ctx, TX: pending.mtx, Info: pending.info}))
+	assert.Empty(t, *requests)
+}
+
+func TestPolicyHookNotCalledWhenExpired(t *testing.T) {
+	url, requests, done := newTestPolicyHookServer(t, 200, `{"decision":"allow"}`)
+	defer done()
+	sth, _, _ := newTestPolicyHookHandler(t, url, "")
+
+	// The nonce of an expired transaction is used by a cancel replacement instead
+	pending := newTestPolicyHookPending()
+	expiry := fftypes.FFTime(time.Now().Add(-1 * time.Minute))
+	pending.mtx.Expiry = &expiry
+	assert.False(t, sth.policyDecisionRequired(&RunContext{Context: sth.ctx, TX: pending.mtx, Info: pending.info}))
+	assert.Empty(t, *requests)
+}
+
+func TestNewPolicyHookBadUnavailable(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	policyHookConf := conf.SubSection(PolicyHookConfig)
+	policyHookConf.Set(ffresty.HTTPConfigURL, "http://localhost:0")
+	policyHookConf.Set(PolicyHookUnavailable, "wrong")
+	_, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21124", err)
+}
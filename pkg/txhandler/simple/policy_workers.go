@@ -0,0 +1,132 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/log"
+)
+
+const metricsCounterPolicyWorkerTransactionsTotal = "tx_policy_worker_transactions_total"
+const metricsCounterPolicyWorkerTransactionsTotalDescription = "Number of in-flight transactions the policy engine was executed against, grouped by policy worker"
+
+const metricsHistogramPolicyWorkerDuration = "tx_policy_worker_duration_seconds"
+const metricsHistogramPolicyWorkerDurationDescription = "Time each policy worker spent executing the policy engine against the in-flight transactions of a signer, grouped by policy worker"
+
+const metricsLabelNameWorker = "worker"
+
+// execPolicies starts a run of the policy engine against the in-flight transactions of each signer. Each run is
+// executed by the next idle policy worker, independently of the policy loop and of the other signers - so the
+// transactions of a signer are executed in order, but a slow connector call for one signer holds up neither the
+// cycle nor the other signers. A signer whose previous run has not completed is skipped until a later cycle.
+func (sth *simpleTransactionHandler) execPolicies(ctx context.Context, inflight []*pendingState) {
+	signerGroups := make([][]*pendingState, 0)
+	groupBySigner := make(map[string]int)
+	for _, pending := range inflight {
+		i, ok := groupBySigner[pending.mtx.From]
+		if !ok {
+			i = len(signerGroups)
+			groupBySigner[pending.mtx.From] = i
+			signerGroups = append(signerGroups, nil)
+		}
+		signerGroups[i] = append(signerGroups[i], pending)
+	}
+
+	for _, group := range signerGroups {
+		signer := group[0].mtx.From
+		done, started := sth.startSignerRun(signer)
+		if !started {
+			log.L(ctx).Tracef("Policy run still in progress for signer %s", signer)
+			continue
+		}
+		go sth.policyWorker(ctx, signer, group, done)
+	}
+}
+
+func (sth *simpleTransactionHandler) initPolicyWorkers() {
+	sth.signerRuns = make(map[string]chan struct{})
+	sth.policyWorkerIDs = make(chan int, sth.policyWorkers)
+	for worker := 0; worker < sth.policyWorkers; worker++ {
+		sth.policyWorkerIDs <- worker
+	}
+}
+
+// startSignerRun claims a signer, so its transactions are only executed by one run at a time
+func (sth *simpleTransactionHandler) startSignerRun(signer string) (done chan struct{}, started bool) {
+	sth.signerRunsMux.Lock()
+	defer sth.signerRunsMux.Unlock()
+	if _, running := sth.signerRuns[signer]; running {
+		return nil, false
+	}
+	done = make(chan struct{})
+	sth.signerRuns[signer] = done
+	return done, true
+}
+
+func (sth *simpleTransactionHandler) endSignerRun(signer string, done chan struct{}) {
+	sth.signerRunsMux.Lock()
+	delete(sth.signerRuns, signer)
+	sth.signerRunsMux.Unlock()
+	close(done)
+
+	// Requests held back while the signer was being run are processed in the next cycle
+	sth.mux.Lock()
+	waiting := len(sth.policyEngineAPIRequests) > 0
+	sth.mux.Unlock()
+	if waiting {
+		sth.markInflightUpdate()
+	}
+}
+
+// waitSignerRuns waits for every run in progress to complete
+func (sth *simpleTransactionHandler) waitSignerRuns() {
+	sth.signerRunsMux.Lock()
+	running := make([]chan struct{}, 0, len(sth.signerRuns))
+	for _, done := range sth.signerRuns {
+		running = append(running, done)
+	}
+	sth.signerRunsMux.Unlock()
+	for _, done := range running {
+		<-done
+	}
+}
+
+func (sth *simpleTransactionHandler) policyWorker(ctx context.Context, signer string, group []*pendingState, done chan struct{}) {
+	defer sth.endSignerRun(signer, done)
+	var worker int
+	select {
+	case worker = <-sth.policyWorkerIDs:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { sth.policyWorkerIDs <- worker }()
+
+	labels := map[string]string{metricsLabelNameWorker: strconv.Itoa(worker)}
+	start := time.Now()
+	for _, pending := range group {
+		log.L(ctx).Tracef("Executing policy against tx-id=%v worker=%d", pending.mtx.ID, worker)
+		err := sth.execPolicy(ctx, pending, nil)
+		if err != nil {
+			log.L(ctx).Errorf("Failed policy cycle transaction=%s operation=%s: %s", pending.mtx.TransactionHash, pending.mtx.ID, err)
+		}
+		sth.toolkit.MetricsManager.IncTxHandlerCounterMetricWithLabels(ctx, metricsCounterPolicyWorkerTransactionsTotal, labels, nil)
+	}
+	sth.toolkit.MetricsManager.ObserveTxHandlerHistogramMetricWithLabels(ctx, metricsHistogramPolicyWorkerDuration, time.Since(start).Seconds(), labels, nil)
+}
@@ -0,0 +1,216 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPolicyWorkersSlowSignerDoesNotBlockOthers(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(PolicyWorkers, 2)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+	tk.EventHandler = meh
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	assert.Equal(t, 2, sth.policyWorkers)
+
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mp.On("UpdateTransaction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	slowSigner := "0xaaaa"
+	fastSigner := "0xbbbb"
+	newPending := func(signer string, nonce int64) *pendingState {
+		mtx := newTestSpendGuardTX(100)
+		mtx.From = signer
+		mtx.Nonce = fftypes.NewFFBigInt(nonce)
+		return &pendingState{mtx: mtx, info: &simplePolicyInfo{}, subStatus: apitypes.TxSubStatusReceived}
+	}
+	inflight := []*pendingState{
+		newPending(slowSigner, 1),
+		newPending(slowSigner, 2),
+		newPending(fastSigner, 1),
+	}
+
+	// The slow signer cannot submit until the fast signer has, so this would never
+	// complete if the signers were handled one after the other
+	fastSent := make(chan struct{})
+	var sendMux sync.Mutex
+	var slowNonces []int64
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.From == slowSigner
+	})).Run(func(args mock.Arguments) {
+		<-fastSent
+		sendMux.Lock()
+		defer sendMux.Unlock()
+		slowNonces = append(slowNonces, args[1].(*ffcapi.TransactionSendRequest).Nonce.Int64())
+	}).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x12345"}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.From == fastSigner
+	})).Run(func(args mock.Arguments) {
+		close(fastSent)
+	}).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x67890"}, ffcapi.ErrorReason(""), nil)
+
+	sth.execPolicies(sth.ctx, inflight)
+	sth.waitSignerRuns()
+
+	// The transactions of a signer are still submitted in order
+	assert.Equal(t, []int64{1, 2}, slowNonces)
+	for _, pending := range inflight {
+		assert.NotNil(t, pending.mtx.FirstSubmit)
+	}
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestPolicyWorkersCycleDoesNotWaitForSlowSigner(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(PolicyWorkers, 2)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	meh.On("HandleEvent", mock.Anything, mock.Anything).Return(nil)
+	tk.EventHandler = meh
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	mp.On("UpdateTransaction", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	slowTX := newTestSpendGuardTX(100)
+	slowTX.From = "0xaaaa"
+	slowTX.Nonce = fftypes.NewFFBigInt(1)
+	slowTX.Status = apitypes.TxStatusPending
+	fastTX := newTestSpendGuardTX(100)
+	fastTX.From = "0xbbbb"
+	fastTX.Nonce = fftypes.NewFFBigInt(1)
+	inflight := []*pendingState{
+		{mtx: slowTX, info: &simplePolicyInfo{}, subStatus: apitypes.TxSubStatusReceived},
+		{mtx: fastTX, info: &simplePolicyInfo{}, subStatus: apitypes.TxSubStatusReceived},
+	}
+	sth.inflight = inflight
+
+	slowStarted := make(chan struct{})
+	releaseSlow := make(chan struct{})
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.From == slowTX.From
+	})).Run(func(args mock.Arguments) {
+		close(slowStarted)
+		<-releaseSlow
+	}).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x12345"}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.From == fastTX.From
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0x67890"}, ffcapi.ErrorReason(""), nil).Once()
+
+	// The cycle returns without waiting for the slow signer, and the fast signer is not held up
+	sth.execPolicies(sth.ctx, inflight)
+	<-slowStarted
+	for {
+		sth.signerRunsMux.Lock()
+		_, fastRunning := sth.signerRuns[fastTX.From]
+		sth.signerRunsMux.Unlock()
+		if !fastRunning {
+			break
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+	assert.NotNil(t, fastTX.FirstSubmit)
+
+	// The next cycle skips the slow signer, rather than starting a second run of its transactions
+	sth.execPolicies(sth.ctx, inflight)
+
+	// A request for a transaction of the slow signer is held back until its run completes
+	response := make(chan policyEngineAPIResponse, 1)
+	sth.mux.Lock()
+	sth.policyEngineAPIRequests = []*policyEngineAPIRequest{{
+		requestType: ActionSuspend,
+		txID:        slowTX.ID,
+		startTime:   time.Now(),
+		response:    response,
+	}}
+	sth.mux.Unlock()
+	sth.processPolicyAPIRequests(sth.ctx)
+	sth.mux.Lock()
+	assert.Len(t, sth.policyEngineAPIRequests, 1)
+	sth.mux.Unlock()
+
+	close(releaseSlow)
+	<-sth.inflightUpdate // the completed run queues another cycle for the request
+	sth.waitSignerRuns()
+	assert.NotNil(t, slowTX.FirstSubmit)
+
+	sth.processPolicyAPIRequests(sth.ctx)
+	res := <-response
+	assert.NoError(t, res.err)
+	assert.Equal(t, apitypes.TxStatusSuspended, res.tx.Status)
+	assert.Empty(t, sth.policyEngineAPIRequests)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestPolicyWorkersCancelledWaitingForWorker(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+
+	// With the only worker busy, the run is given up when the context is cancelled
+	worker := <-sth.policyWorkerIDs
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mtx := newTestSpendGuardTX(100)
+	sth.execPolicies(ctx, []*pendingState{{mtx: mtx, info: &simplePolicyInfo{}}})
+	sth.waitSignerRuns()
+	assert.Nil(t, mtx.FirstSubmit)
+	sth.policyWorkerIDs <- worker
+}
+
+func TestPolicyWorkersAtLeastOne(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	conf.Set(PolicyWorkers, 0)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	sth := th.(*simpleTransactionHandler)
+	assert.Equal(t, 1, sth.policyWorkers)
+
+	// Nothing to do with nothing in-flight
+	sth.execPolicies(context.Background(), []*pendingState{})
+}
@@ -43,11 +43,16 @@ const (
 	ActionDelete
 	ActionSuspend
 	ActionResume
+	ActionSpeedUp
+	ActionApprove
+	ActionReject
 )
 
 type policyEngineAPIRequest struct {
 	requestType policyEngineAPIRequestType
 	txID        string
+	speedUp     *apitypes.SpeedUpTransactionRequest
+	approver    string
 	startTime   time.Time
 	response    chan policyEngineAPIResponse
 }
@@ -72,6 +77,8 @@ func (sth *simpleTransactionHandler) policyLoop() {
 		case <-ctx.Done():
 			ticker.Stop()
 			log.L(ctx).Infof("Receipt poller exiting")
+			sth.waitSignerRuns()
+			sth.waitNonceGapCheck()
 			return
 		}
 		// Pop whether we were marked stale
@@ -111,7 +118,10 @@ func (sth *simpleTransactionHandler) updateInflightSet(ctx context.Context) bool
 
 	// Run through removing those that are removed
 	for _, p := range oldInflight {
-		if !p.remove {
+		p.mux.Lock()
+		remove := p.remove
+		p.mux.Unlock()
+		if !remove {
 			sth.inflight = append(sth.inflight, p)
 		} else {
 			sth.incTransactionOperationCounter(ctx, p.mtx.Namespace(ctx), "removed")
@@ -122,16 +132,10 @@ func (sth *simpleTransactionHandler) updateInflightSet(ctx context.Context) bool
 	spaces := sth.maxInFlight - len(sth.inflight)
 	log.L(sth.ctx).Tracef("Number of spaces left '%v'", spaces)
 	if spaces > 0 {
-		var after string
-		if len(sth.inflight) > 0 {
-			after = sth.inflight[len(sth.inflight)-1].mtx.SequenceID
-		}
-		var additional []*apitypes.ManagedTX
-		// We retry the get from persistence indefinitely (until the context cancels)
-		err := sth.retry.Do(ctx, "get pending transactions", func(_ int) (retry bool, err error) {
-			additional, err = sth.toolkit.TXPersistence.ListTransactionsPending(ctx, after, spaces, 0)
-			return true, err
-		})
+		// The in-flight set is not a contiguous range of the pending transactions we could continue on from, as
+		// transactions are skipped by scheduling, and a transaction can become pending again after those received
+		// later (such as when it is approved). So the signers are read afresh, each in nonce order.
+		additional, err := sth.selectPending(ctx, spaces)
 		if err != nil {
 			log.L(ctx).Infof("Policy loop context cancelled while retrying")
 			return false
@@ -155,7 +159,7 @@ func (sth *simpleTransactionHandler) updateInflightSet(ctx context.Context) bool
 		}
 		newLen := len(sth.inflight)
 		if newLen > 0 {
-			log.L(ctx).Debugf("Inflight set updated with %d additional transactions, length is now %d head-id:%s head-seq=%s tail-id:%s tail-seq=%s old-tail=%s", len(additional), len(sth.inflight), sth.inflight[0].mtx.ID, sth.inflight[0].mtx.SequenceID, sth.inflight[newLen-1].mtx.ID, sth.inflight[newLen-1].mtx.SequenceID, after)
+			log.L(ctx).Debugf("Inflight set updated with %d additional transactions, length is now %d head-id:%s head-seq=%s tail-id:%s tail-seq=%s", len(additional), len(sth.inflight), sth.inflight[0].mtx.ID, sth.inflight[0].mtx.SequenceID, sth.inflight[newLen-1].mtx.ID, sth.inflight[newLen-1].mtx.SequenceID)
 		}
 	}
 	sth.setTransactionInflightQueueMetrics(ctx)
@@ -175,16 +179,16 @@ func (sth *simpleTransactionHandler) policyLoopCycle(ctx context.Context, inflig
 		}
 	}
 
+	if sth.nonceGapCheckEnabled && time.Since(sth.nonceGapLastCheck) >= sth.nonceGapCheckInterval {
+		sth.startNonceGapCheck(ctx)
+	}
+
+	sth.checkPausedSigners(ctx)
+
 	sth.inflightRWMux.RLock()
 	defer sth.inflightRWMux.RUnlock()
 	// Go through executing the policy engine against them
-	for _, pending := range sth.inflight {
-		log.L(ctx).Tracef("Executing policy against tx-id=%v", pending.mtx.ID)
-		err := sth.execPolicy(ctx, pending, nil)
-		if err != nil {
-			log.L(ctx).Errorf("Failed policy cycle transaction=%s operation=%s: %s", pending.mtx.TransactionHash, pending.mtx.ID, err)
-		}
-	}
+	sth.execPolicies(ctx, sth.inflight)
 
 }
 
@@ -209,6 +213,15 @@ func (sth *simpleTransactionHandler) processPolicyAPIRequests(ctx context.Contex
 	}
 	sth.mux.Unlock()
 
+	heldBack := make([]*policyEngineAPIRequest, 0)
+	defer func() {
+		if len(heldBack) > 0 {
+			// These go ahead of any new requests, once the runs of their signers complete
+			sth.mux.Lock()
+			sth.policyEngineAPIRequests = append(heldBack, sth.policyEngineAPIRequests...)
+			sth.mux.Unlock()
+		}
+	}()
 	for _, request := range requests {
 		var pending *pendingState
 
@@ -230,30 +243,53 @@ func (sth *simpleTransactionHandler) processPolicyAPIRequests(ctx context.Contex
 			}
 			// This transaction was valid, but outside of our in-flight set - we still evaluate the policy engine in-line for it.
 			// This does NOT cause it to be added to the in-flight set
-			pending = &pendingState{mtx: mtx, subStatus: apitypes.TxSubStatusReceived}
+			var info simplePolicyInfo
+			_ = json.Unmarshal(mtx.PolicyInfo.Bytes(), &info)
+			pending = &pendingState{mtx: mtx, info: &info, subStatus: apitypes.TxSubStatusReceived}
 		}
 
-		switch request.requestType {
-		case ActionDelete, ActionSuspend, ActionResume:
-			reqType := request.requestType
-			if err := sth.execPolicy(ctx, pending, &reqType); err != nil {
-				request.response <- policyEngineAPIResponse{err: err}
-			} else {
-				res := policyEngineAPIResponse{tx: pending.mtx, status: http.StatusAccepted}
-				if pending.remove || request.requestType == ActionResume /* always sync */ {
-					res.status = http.StatusOK // synchronously completed
-				}
-				request.response <- res
-			}
-		default:
-			request.response <- policyEngineAPIResponse{
-				err: i18n.NewError(ctx, tmmsgs.MsgTransactionHandlerRequestInvalid, request.requestType),
-			}
+		// The request must not be executed alongside a run of the policy engine for the same signer
+		done, started := sth.startSignerRun(pending.mtx.From)
+		if !started {
+			heldBack = append(heldBack, request)
+			continue
 		}
+		sth.processPolicyAPIRequest(ctx, request, pending)
+		sth.endSignerRun(pending.mtx.From, done)
 	}
 
 }
 
+func (sth *simpleTransactionHandler) processPolicyAPIRequest(ctx context.Context, request *policyEngineAPIRequest, pending *pendingState) {
+	switch request.requestType {
+	case ActionDelete, ActionSuspend, ActionResume, ActionSpeedUp, ActionApprove, ActionReject:
+		reqType := request.requestType
+		switch reqType {
+		case ActionSpeedUp:
+			pending.mux.Lock()
+			pending.speedUp = request.speedUp
+			pending.mux.Unlock()
+		case ActionApprove, ActionReject:
+			pending.mux.Lock()
+			pending.approver = request.approver
+			pending.mux.Unlock()
+		}
+		if err := sth.execPolicy(ctx, pending, &reqType); err != nil {
+			request.response <- policyEngineAPIResponse{err: err}
+		} else {
+			res := policyEngineAPIResponse{tx: pending.mtx, status: http.StatusAccepted}
+			if pending.remove || request.requestType == ActionResume || request.requestType == ActionSpeedUp || request.requestType == ActionApprove /* always sync */ {
+				res.status = http.StatusOK // synchronously completed
+			}
+			request.response <- res
+		}
+	default:
+		request.response <- policyEngineAPIResponse{
+			err: i18n.NewError(ctx, tmmsgs.MsgTransactionHandlerRequestInvalid, request.requestType),
+		}
+	}
+}
+
 func (sth *simpleTransactionHandler) pendingToRunContext(baseCtx context.Context, pending *pendingState, syncRequest *policyEngineAPIRequestType) (ctx *RunContext, err error) {
 
 	// Take a snapshot of the pending state under the lock
@@ -268,12 +304,22 @@ func (sth *simpleTransactionHandler) pendingToRunContext(baseCtx context.Context
 		Confirmations: pending.confirmations,
 		Receipt:       pending.receipt,
 		Info:          pending.info,
+
+		previousSubStatus: pending.subStatus,
 	}
 	confirmNotify := pending.confirmNotify
 	receiptNotify := pending.receiptNotify
 	if syncRequest != nil {
 		ctx.SyncAction = *syncRequest
 	}
+	if ctx.SyncAction == ActionSpeedUp {
+		ctx.speedUp = pending.speedUp
+		pending.speedUp = nil
+	}
+	if ctx.SyncAction == ActionApprove || ctx.SyncAction == ActionReject {
+		ctx.approver = pending.approver
+		pending.approver = ""
+	}
 
 	if ctx.SyncAction == ActionDelete && mtx.DeleteRequested == nil {
 		mtx.DeleteRequested = fftypes.Now()
@@ -283,6 +329,22 @@ func (sth *simpleTransactionHandler) pendingToRunContext(baseCtx context.Context
 
 	// Process any state updates that were queued to us from notifications from the confirmation manager
 	if receiptNotify != nil {
+		if pending.receiptHash != "" && pending.receiptHash != mtx.TransactionHash {
+			// An earlier submission was mined, so that is the hash of the transaction now
+			log.L(ctx).Infof("Earlier submission %s mined for transaction %s (latest=%s)", pending.receiptHash, mtx.ID, mtx.TransactionHash)
+			mtx.TransactionHash = pending.receiptHash
+			pending.trackingTransactionHash = pending.receiptHash
+			ctx.UpdateType = Update
+			ctx.TXUpdates.TransactionHash = &mtx.TransactionHash
+		}
+		if ctx.Info != nil && ctx.Info.CancelReplacement != nil && !ctx.Info.CancelReplacement.Failed &&
+			ctx.Info.CancelReplacement.TransactionHash != mtx.TransactionHash {
+			// The original transaction won the race with the cancel replacement, so complete it normally
+			log.L(ctx).Warnf("Transaction %s mined with hash %s before cancel replacement %s", mtx.ID, mtx.TransactionHash, ctx.Info.CancelReplacement.TransactionHash)
+			ctx.Info.CancelReplacement.Failed = true
+			ctx.UpdateType = Update
+			ctx.UpdatedInfo = true
+		}
 		log.L(ctx).Debugf("Receipt received for transaction %s at nonce %s / %d - hash: %s", pending.mtx.ID, pending.mtx.TransactionHeaders.From, pending.mtx.Nonce.Int64(), pending.mtx.TransactionHash)
 		if err := sth.toolkit.TXPersistence.SetTransactionReceipt(ctx, mtx.ID, ctx.Receipt); err != nil {
 			return nil, err
@@ -329,7 +391,19 @@ func (sth *simpleTransactionHandler) execPolicy(baseCtx context.Context, pending
 		log.L(sth.ctx).Tracef("Transaction '%s' confirmed", ctx.TX.ID)
 		completed = true
 		ctx.UpdateType = Update
-		if ctx.Receipt != nil && ctx.Receipt.Success {
+		if ctx.cancelReplacementMined() && mtx.DeleteRequested != nil {
+			// The transaction was successfully cancelled, so we can now remove it
+			log.L(ctx).Infof("Cancel replacement %s mined for transaction %s", mtx.TransactionHash, mtx.ID)
+			ctx.UpdateType = Delete
+		} else if ctx.cancelReplacementMined() {
+			// The transaction was cancelled after it expired
+			log.L(ctx).Infof("Cancel replacement %s mined for expired transaction %s", mtx.TransactionHash, mtx.ID)
+			mtx.Status = apitypes.TxStatusFailed
+			ctx.TXUpdates.Status = &mtx.Status
+			errMsg := i18n.NewError(ctx, tmmsgs.MsgTransactionExpired, mtx.Expiry).Error()
+			mtx.ErrorMessage = errMsg
+			ctx.TXUpdates.ErrorMessage = &errMsg
+		} else if ctx.Receipt != nil && ctx.Receipt.Success {
 			mtx.Status = apitypes.TxStatusSucceeded
 			ctx.TXUpdates.Status = &mtx.Status
 		} else {
@@ -351,6 +425,29 @@ func (sth *simpleTransactionHandler) execPolicy(baseCtx context.Context, pending
 			mtx.Status = apitypes.TxStatusPending
 			ctx.TXUpdates.Status = &mtx.Status
 		}
+	case ctx.SyncAction == ActionSpeedUp:
+		if err := sth.speedUpTX(ctx); err != nil {
+			// Any history of the attempt is still recorded, before the error is returned to the caller
+			_ = sth.flushChanges(ctx, pending, false)
+			return err
+		}
+		sth.trackTransactionHash(ctx, pending)
+	case ctx.SyncAction == ActionApprove:
+		if err := sth.approveTX(ctx); err != nil {
+			return err
+		}
+	case ctx.SyncAction == ActionReject:
+		if err := sth.rejectTX(ctx); err != nil {
+			return err
+		}
+		completed = true
+	case ctx.SyncAction == ActionNone && sth.expiryFailsTransaction(ctx):
+		completed = true
+		sth.failExpired(ctx, pending)
+	case ctx.SyncAction == ActionNone && awaitingDependencies(mtx):
+		completed = sth.processDependencies(ctx, pending)
+	case ctx.SyncAction == ActionNone && sth.policyDecisionRequired(ctx):
+		completed = sth.processPolicyDecision(ctx, pending)
 	default:
 		// We get woken for lots of reasons to go through the policy loop, but we only want
 		// to drive the policy engine at regular intervals.
@@ -368,46 +465,108 @@ func (sth *simpleTransactionHandler) execPolicy(baseCtx context.Context, pending
 				// Keep storing the latest error message onto the TX (sub-status updates handled in the processTransaction handler)
 				mtx.ErrorMessage = errMsg
 				ctx.TXUpdates.ErrorMessage = &errMsg
+				// A hash recorded when signing is tracked, even though the send failed, as it might still be mined
+				sth.trackTransactionHash(ctx, pending)
 			} else {
 				log.L(ctx).Debugf("Policy engine executed for tx %s (update=%d,status=%s,hash=%s)", mtx.ID, ctx.UpdateType, mtx.Status, mtx.TransactionHash)
-				if mtx.FirstSubmit != nil && pending.trackingTransactionHash != mtx.TransactionHash {
-
-					if pending.trackingTransactionHash != "" {
-						// if had a previous transaction hash, emit an event to for transaction hash removal
-						eventTX := *mtx
-						eventTX.TransactionHash = pending.trackingTransactionHash // using the previous hash
-						if err = sth.toolkit.EventHandler.HandleEvent(ctx, apitypes.ManagedTransactionEvent{
-							Type: apitypes.ManagedTXTransactionHashRemoved,
-							Tx:   &eventTX,
-						}); err != nil {
-							log.L(ctx).Infof("Error detected notifying confirmation manager to remove old transaction hash: %s", err.Error())
-						}
-					}
-
-					// If now submitted, add to confirmations manager for receipt checking
-					err = sth.toolkit.EventHandler.HandleEvent(ctx, apitypes.ManagedTransactionEvent{
-						Type: apitypes.ManagedTXTransactionHashAdded,
-						Tx:   mtx,
-					})
-					if err != nil {
-						log.L(ctx).Infof("Error detected notifying confirmation manager to add new transaction hash: %s", err.Error())
-
-						sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "tracking_failed")
-					} else {
-						pending.trackingTransactionHash = mtx.TransactionHash
-						sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "tracking")
-					}
-				}
+				sth.trackTransactionHash(ctx, pending)
 				pending.lastPolicyCycle = time.Now()
+				// Such as a transaction with a fee that could never fit within the spend limits
+				completed = mtx.Status == apitypes.TxStatusFailed
 			}
 		}
 	}
 	return sth.flushChanges(ctx, pending, completed)
 }
 
+// trackTransactionHash notifies the confirmation manager when a submission results in a new transaction hash.
+// Previous hashes stay tracked, as any of the submissions for the nonce might be the one that is mined.
+func (sth *simpleTransactionHandler) trackTransactionHash(ctx *RunContext, pending *pendingState) {
+	mtx := ctx.TX
+	// Every hash we record is tracked - including the first cancel replacement of a transaction that expired before it
+	// was submitted, and the hash recorded when signing a transaction that might have reached the node even though the
+	// send failed
+	if mtx.TransactionHash == "" || pending.trackingTransactionHash == mtx.TransactionHash {
+		return
+	}
+
+	if pending.trackingTransactionHash == "" && mtx.LastSubmit != nil && !mtx.LastSubmit.Equal(mtx.FirstSubmit) {
+		// The transaction was re-submitted before we started tracking it (such as before a restart),
+		// so we need to load the hashes of the earlier submissions
+		submittedHashes, err := sth.toolkit.TXPersistence.GetTransactionSubmittedHashes(ctx, mtx.ID)
+		if err != nil {
+			log.L(ctx).Errorf("Failed to load submitted hashes for transaction %s: %s", mtx.ID, err)
+			sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "tracking_failed")
+			return
+		}
+		for _, sh := range submittedHashes {
+			if sh.TransactionHash != mtx.TransactionHash {
+				_ = sth.addTrackedHash(ctx, pending, sh.TransactionHash)
+			}
+		}
+	}
+
+	// If now submitted, add to confirmations manager for receipt checking
+	if err := sth.addTrackedHash(ctx, pending, mtx.TransactionHash); err != nil {
+		sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "tracking_failed")
+	} else {
+		pending.trackingTransactionHash = mtx.TransactionHash
+		sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "tracking")
+	}
+}
+
+func (sth *simpleTransactionHandler) addTrackedHash(ctx *RunContext, pending *pendingState, transactionHash string) error {
+	for _, h := range pending.trackedHashes {
+		if h == transactionHash {
+			return nil
+		}
+	}
+	eventTX := ctx.TX
+	if transactionHash != eventTX.TransactionHash {
+		txCopy := *eventTX
+		txCopy.TransactionHash = transactionHash
+		eventTX = &txCopy
+	}
+	err := sth.toolkit.EventHandler.HandleEvent(ctx, apitypes.ManagedTransactionEvent{
+		Type: apitypes.ManagedTXTransactionHashAdded,
+		Tx:   eventTX,
+		ReceiptHandler: func(ctx context.Context, txID string, receipt *ffcapi.TransactionReceiptResponse) error {
+			return sth.handleTransactionReceipt(ctx, txID, transactionHash, receipt)
+		},
+	})
+	if err != nil {
+		log.L(ctx).Infof("Error detected notifying confirmation manager to add transaction hash %s: %s", transactionHash, err.Error())
+		return err
+	}
+	pending.trackedHashes = append(pending.trackedHashes, transactionHash)
+	return nil
+}
+
+// untrackHashes stops the confirmation manager looking for receipts for the tracked hashes, other than the one specified
+func (sth *simpleTransactionHandler) untrackHashes(ctx *RunContext, pending *pendingState, keepHash string) {
+	for _, transactionHash := range pending.trackedHashes {
+		if transactionHash == keepHash {
+			continue
+		}
+		eventTX := *ctx.TX
+		eventTX.TransactionHash = transactionHash
+		if err := sth.toolkit.EventHandler.HandleEvent(ctx, apitypes.ManagedTransactionEvent{
+			Type: apitypes.ManagedTXTransactionHashRemoved,
+			Tx:   &eventTX,
+		}); err != nil {
+			log.L(ctx).Infof("Error detected notifying confirmation manager to remove transaction hash %s: %s", transactionHash, err.Error())
+		}
+	}
+	pending.trackedHashes = nil
+}
+
 func (sth *simpleTransactionHandler) flushChanges(ctx *RunContext, pending *pendingState, completed bool) (err error) {
-	// flush any sub-status changes
-	pending.subStatus = ctx.SubStatus
+	// flush any sub-status changes - a run that does not set one leaves the transaction where it was
+	if ctx.SubStatus != "" {
+		pending.mux.Lock()
+		pending.subStatus = ctx.SubStatus
+		pending.mux.Unlock()
+	}
 	for _, historyUpdate := range ctx.HistoryUpdates {
 		if err := historyUpdate(sth.toolkit.TXHistory); err != nil {
 			return err
@@ -430,10 +589,17 @@ func (sth *simpleTransactionHandler) flushChanges(ctx *RunContext, pending *pend
 		if ctx.SyncAction == ActionResume {
 			log.L(ctx).Infof("Transaction %s resumed", mtx.ID)
 			sth.markInflightStale() // this won't be in the in-flight set, so we need to pull it in if there's space
+		} else if ctx.SyncAction == ActionApprove {
+			log.L(ctx).Infof("Transaction %s approved by '%s'", mtx.ID, ctx.approver)
+			sth.markInflightStale() // as with a resume, this is not yet in the in-flight set
 		} else if completed {
+			pending.mux.Lock()
 			pending.remove = true // for the next time round the loop
+			pending.mux.Unlock()
 			log.L(ctx).Infof("Transaction %s removed from tracking (status=%s): %s", mtx.ID, mtx.Status, err)
 			sth.markInflightStale()
+			// None of the other submissions can be mined now, so stop looking for their receipts
+			sth.untrackHashes(ctx, pending, mtx.TransactionHash)
 
 			// if and only if the transaction is now resolved dispatch an event to event handler
 			// and discard any handling errors.
@@ -458,8 +624,11 @@ func (sth *simpleTransactionHandler) flushChanges(ctx *RunContext, pending *pend
 			log.L(ctx).Errorf("Failed to delete transaction %s (status=%s): %s", mtx.ID, mtx.Status, err)
 			return err
 		}
+		pending.mux.Lock()
 		pending.remove = true // for the next time round the loop
+		pending.mux.Unlock()
 		sth.markInflightStale()
+		sth.untrackHashes(ctx, pending, mtx.TransactionHash)
 		// dispatch an event to event handler
 		// and discard any handling errors
 		_ = sth.toolkit.EventHandler.HandleEvent(ctx, apitypes.ManagedTransactionEvent{
@@ -514,7 +683,12 @@ func (sth *simpleTransactionHandler) HandleTransactionConfirmations(ctx context.
 	return
 }
 func (sth *simpleTransactionHandler) HandleTransactionReceiptReceived(ctx context.Context, txID string, receipt *ffcapi.TransactionReceiptResponse) (err error) {
-	log.L(ctx).Tracef("Handle transaction receipt received %s", txID)
+	return sth.handleTransactionReceipt(ctx, txID, "", receipt)
+}
+
+// handleTransactionReceipt records the receipt, along with the hash it was received for when known
+func (sth *simpleTransactionHandler) handleTransactionReceipt(ctx context.Context, txID, transactionHash string, receipt *ffcapi.TransactionReceiptResponse) (err error) {
+	log.L(ctx).Tracef("Handle transaction receipt received %s (hash=%s)", txID, transactionHash)
 	sth.inflightRWMux.RLock()
 	var pending *pendingState
 	for _, p := range sth.inflight {
@@ -532,6 +706,9 @@ func (sth *simpleTransactionHandler) HandleTransactionReceiptReceived(ctx contex
 	// Will be picked up on the next policy loop cycle - guaranteed to occur before Confirmed
 	pending.receiptNotify = fftypes.Now()
 	pending.receipt = receipt
+	if transactionHash != "" {
+		pending.receiptHash = transactionHash
+	}
 	pending.mux.Unlock()
 	// Will be picked up on the next policy loop cycle - guaranteed to occur before Confirmed
 	sth.markInflightUpdate()
@@ -35,7 +35,6 @@ import (
 	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
 	"github.com/hyperledger/firefly-transaction-manager/pkg/fftm"
-	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -149,14 +148,17 @@ func TestPolicyLoopE2EOk(t *testing.T) {
 	// Run the policy once to do the send
 	<-sth.inflightStale // from sending the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Equal(t, mtx.ID, sth.inflight[0].mtx.ID)
 	assert.Equal(t, apitypes.TxStatusPending, sth.inflight[0].mtx.Status)
 
 	// A second time will mark it complete for flush
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	<-sth.inflightStale // policy loop should have marked us stale, to clean up the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Empty(t, sth.inflight)
 
 	// Check the update is persisted
@@ -219,6 +221,7 @@ func TestPolicyLoopIgnoreTransactionInformationalEventHandlingErrors(t *testing.
 	// Run the policy once to do the send
 	<-sth.inflightStale // from sending the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Equal(t, 0, len(sth.inflight))
 
 	mc.AssertExpectations(t)
@@ -278,12 +281,17 @@ func TestPolicyLoopE2EReverted(t *testing.T) {
 	mmm := &metricsmocks.TransactionHandlerMetrics{}
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, metricsGaugeTransactionsInflightUsedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, metricsGaugeTransactionsInflightFreeDescription, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeSignersPaused, metricsGaugeSignersPausedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, metricsCounterTransactionProcessOperationsTotalDescription, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
 	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, metricsHistogramTransactionProcessOperationsDurationDescription, []float64{}, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
+	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, metricsCounterPolicyWorkerTransactionsTotalDescription, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, metricsHistogramPolicyWorkerDurationDescription, []float64{}, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 
 	tk.MetricsManager = mmm
 
@@ -331,14 +339,17 @@ func TestPolicyLoopE2EReverted(t *testing.T) {
 	// Run the policy once to do the send
 	<-sth.inflightStale // from sending the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Equal(t, mtx.ID, sth.inflight[0].mtx.ID)
 	assert.Equal(t, apitypes.TxStatusPending, sth.inflight[0].mtx.Status)
 
 	// A second time will mark it complete for flush
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	<-sth.inflightStale // policy loop should have marked us stale, to clean up the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Empty(t, sth.inflight)
 
 	// Check the update is persisted
@@ -383,7 +394,7 @@ func TestPolicyLoopResubmitNewTXID(t *testing.T) {
 			n.Transaction.TransactionHash == txHash1
 	})).Return(nil)
 	mc.On("Notify", mock.MatchedBy(func(n *confirmations.Notification) bool {
-		// Then we get notified to remove the old TX hash
+		// Once confirmed, we get notified to remove the old TX hash
 		return n.NotificationType == confirmations.RemovedTransaction &&
 			n.Transaction.TransactionHash == txHash1
 	})).Return(nil)
@@ -414,6 +425,7 @@ func TestPolicyLoopResubmitNewTXID(t *testing.T) {
 	// Run the policy once to do the send with the first hash
 	<-sth.inflightStale // from sending the TX
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Len(t, sth.inflight, 1)
 	assert.Equal(t, mtx.ID, sth.inflight[0].mtx.ID)
 	assert.Equal(t, apitypes.TxStatusPending, sth.inflight[0].mtx.Status)
@@ -421,6 +433,7 @@ func TestPolicyLoopResubmitNewTXID(t *testing.T) {
 
 	// Run again to confirm it does not change anything, when the state is the same
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 	assert.Len(t, sth.inflight, 1)
 	assert.Equal(t, mtx.ID, sth.inflight[0].mtx.ID)
 	assert.Equal(t, apitypes.TxStatusPending, sth.inflight[0].mtx.Status)
@@ -429,9 +442,18 @@ func TestPolicyLoopResubmitNewTXID(t *testing.T) {
 	// Reset the transaction so the policy manager resubmits it
 	sth.inflight[0].mtx.FirstSubmit = nil
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 	assert.Equal(t, mtx.ID, sth.inflight[0].mtx.ID)
 	assert.Equal(t, apitypes.TxStatusPending, sth.inflight[0].mtx.Status)
 	assert.Equal(t, txHash2, sth.inflight[0].mtx.TransactionHash)
+	// Both hashes are watched for a receipt
+	assert.Equal(t, []string{txHash1, txHash2}, sth.inflight[0].trackedHashes)
+
+	// Process the receipt and confirmations for the new hash, which completes the transaction
+	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
+	assert.Equal(t, apitypes.TxStatusSucceeded, sth.inflight[0].mtx.Status)
+	assert.Empty(t, sth.inflight[0].trackedHashes)
 
 	mc.AssertExpectations(t)
 	mfc.AssertExpectations(t)
@@ -448,12 +470,17 @@ func TestNotifyConfirmationMgrFail(t *testing.T) {
 	mmm := &metricsmocks.TransactionHandlerMetrics{}
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, metricsGaugeTransactionsInflightUsedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, metricsGaugeTransactionsInflightFreeDescription, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeSignersPaused, metricsGaugeSignersPausedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, metricsCounterTransactionProcessOperationsTotalDescription, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
 	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, metricsHistogramTransactionProcessOperationsDurationDescription, []float64{}, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
+	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, metricsCounterPolicyWorkerTransactionsTotalDescription, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, metricsHistogramPolicyWorkerDurationDescription, []float64{}, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 
 	tk.MetricsManager = mmm
 	sth := th.(*simpleTransactionHandler)
@@ -463,8 +490,6 @@ func TestNotifyConfirmationMgrFail(t *testing.T) {
 
 	txHash := "0x" + fftypes.NewRandB32().String()
 
-	removedTxHash := "0x" + fftypes.NewRandB32().String()
-
 	mfc := sth.toolkit.Connector.(*ffcapimocks.API)
 	mfc.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
 		TransactionHash: txHash,
@@ -483,11 +508,6 @@ func TestNotifyConfirmationMgrFail(t *testing.T) {
 		return n.NotificationType == confirmations.NewTransaction &&
 			n.Transaction.TransactionHash == txHash
 	})).Return(fmt.Errorf("pop")).Once()
-	mc.On("Notify", mock.MatchedBy(func(n *confirmations.Notification) bool {
-		// Then we get notified to remove the old TX hash
-		return n.NotificationType == confirmations.RemovedTransaction &&
-			n.Transaction.TransactionHash == removedTxHash
-	})).Return(fmt.Errorf("pop")).Once()
 	mc.On("Notify", mock.MatchedBy(func(n *confirmations.Notification) bool {
 		close(confirmation2Complete)
 		// Then we get the new TX hash, which we confirm
@@ -505,14 +525,15 @@ func TestNotifyConfirmationMgrFail(t *testing.T) {
 
 	// should emit 1 event to confirmation manager
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 
 	<-confirmation1Complete
 
-	// set a tracking transaction hash to test removal
-	sth.inflight[0].trackingTransactionHash = removedTxHash
+	assert.Empty(t, sth.inflight[0].trackedHashes)
 
-	// should emit 2 events to confirmation manager
+	// should retry the notification
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 	<-confirmation2Complete
 
 	mc.AssertExpectations(t)
@@ -534,10 +555,11 @@ func TestInflightSetListFailCancel(t *testing.T) {
 	sth.Init(sth.ctx, tk)
 	cancel()
 	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
-	mp.On("ListTransactionsPending", sth.ctx, "", sth.maxInFlight, txhandler.SortDirectionAscending).
+	mp.On("ListPendingSigners", sth.ctx, "", sth.pendingScanLimit).
 		Return(nil, fmt.Errorf("pop"))
 
 	sth.policyLoopCycle(sth.ctx, true)
+	sth.waitSignerRuns()
 
 	mp.AssertExpectations(t)
 
@@ -613,6 +635,7 @@ func TestPolicyLoopUpdateFail(t *testing.T) {
 	mp.On("Close", mock.Anything).Return(nil).Maybe()
 
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	mp.AssertExpectations(t)
 
@@ -688,6 +711,7 @@ func TestPolicyLoopUpdateEventHandlerError(t *testing.T) {
 	mp.On("Close", mock.Anything).Return(nil).Maybe()
 
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	mp.AssertExpectations(t)
 
@@ -702,12 +726,17 @@ func TestPolicyEngineFailStaleThenUpdated(t *testing.T) {
 	mmm := &metricsmocks.TransactionHandlerMetrics{}
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, metricsGaugeTransactionsInflightUsedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, metricsGaugeTransactionsInflightFreeDescription, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeSignersPaused, metricsGaugeSignersPausedDescription, false).Return(nil).Maybe()
 	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, metricsCounterTransactionProcessOperationsTotalDescription, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
 	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, metricsHistogramTransactionProcessOperationsDurationDescription, []float64{}, []string{metricsLabelNameOperation}, true).Return(nil).Maybe()
+	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, metricsCounterPolicyWorkerTransactionsTotalDescription, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
+	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, metricsHistogramPolicyWorkerDurationDescription, []float64{}, []string{metricsLabelNameWorker}, false).Return(nil).Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("SetTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 
 	tk.MetricsManager = mmm
 	sth := th.(*simpleTransactionHandler)
@@ -1135,7 +1164,7 @@ func TestExecPolicyIdempotentCancellation(t *testing.T) {
 	eh.WsServer = mws
 	sth.toolkit.EventHandler = eh
 	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
-	mp.On("ListTransactionsPending", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListPendingSigners", mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 	mp.On("DeleteTransaction", mock.Anything, testTxID.String()).Return(nil)
 	sth.inflight = []*pendingState{{
 		mtx: &apitypes.ManagedTX{
@@ -1184,7 +1213,7 @@ func TestPendingTransactionGetsRemoved(t *testing.T) {
 	sth.toolkit.EventHandler = eh
 	deleteCalled := make(chan struct{})
 	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
-	mp.On("ListTransactionsPending", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListPendingSigners", mock.Anything, mock.Anything, mock.Anything).Return([]string{}, nil)
 	mp.On("DeleteTransaction", mock.Anything, testTxID.String()).Return(nil).Once().Run(func(args mock.Arguments) {
 		close(deleteCalled)
 	})
@@ -1363,6 +1392,7 @@ func TestPolicyStoreReceiptFail(t *testing.T) {
 	mp.On("SetTransactionReceipt", mock.Anything, txID, mock.Anything).Return(fmt.Errorf("pop"))
 
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	mp.AssertExpectations(t)
 
@@ -1408,6 +1438,7 @@ func TestPolicyStoreConfirmationsFail(t *testing.T) {
 	mp.On("AddTransactionConfirmations", mock.Anything, txID, true, mock.Anything).Return(fmt.Errorf("pop"))
 
 	sth.policyLoopCycle(sth.ctx, false)
+	sth.waitSignerRuns()
 
 	mp.AssertExpectations(t)
 
@@ -1475,3 +1506,95 @@ func TestUpdateFailHistory(t *testing.T) {
 	mp.AssertExpectations(t)
 
 }
+
+func TestTrackAllSubmittedHashesEarlierMined(t *testing.T) {
+	sth, mp, _, meh := newTestDependenciesHandler(t)
+
+	// A transaction that was re-submitted before it was loaded into the in-flight set
+	mtx := newTestSubmittedTX(`1000`)
+	mtx.TransactionHash = "0x333333"
+	firstSubmit := fftypes.FFTime(time.Now().Add(-1 * time.Hour))
+	mtx.FirstSubmit = &firstSubmit
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}, lastPolicyCycle: time.Now()}
+	sth.inflight = []*pendingState{pending}
+	sth.policyLoopInterval = 1 * time.Hour
+
+	mp.On("GetTransactionSubmittedHashes", mock.Anything, mtx.ID).Return([]*apitypes.SubmittedHash{
+		{TransactionHash: "0x111111"},
+		{TransactionHash: "0x222222"},
+		{TransactionHash: "0x111111"}, // duplicates are ignored
+		{TransactionHash: "0x333333"},
+	}, nil).Once()
+	receiptHandlers := make(map[string]func(ctx context.Context, txID string, receipt *ffcapi.TransactionReceiptResponse) error)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded
+	})).Run(func(args mock.Arguments) {
+		e := args[1].(apitypes.ManagedTransactionEvent)
+		receiptHandlers[e.Tx.TransactionHash] = e.ReceiptHandler
+	}).Return(nil).Times(3)
+
+	ctx, err := sth.pendingToRunContext(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	sth.trackTransactionHash(ctx, pending)
+	assert.Equal(t, []string{"0x111111", "0x222222", "0x333333"}, pending.trackedHashes)
+	assert.Equal(t, "0x333333", pending.trackingTransactionHash)
+	assert.Equal(t, "0x333333", mtx.TransactionHash)
+
+	// The receipt arrives for the first submission
+	err = receiptHandlers["0x111111"](sth.ctx, mtx.ID, &ffcapi.TransactionReceiptResponse{ProtocolID: "000/111/222", Success: true})
+	assert.NoError(t, err)
+	err = sth.HandleTransactionConfirmations(sth.ctx, mtx.ID, &apitypes.ConfirmationsNotification{Confirmed: true})
+	assert.NoError(t, err)
+
+	mp.On("SetTransactionReceipt", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	mp.On("AddTransactionConfirmations", mock.Anything, mtx.ID, false).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.TransactionHash == "0x111111" && *updates.Status == apitypes.TxStatusSucceeded
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashRemoved && e.Tx.TransactionHash == "0x222222"
+	})).Return(fmt.Errorf("pop")).Once()
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashRemoved && e.Tx.TransactionHash == "0x333333"
+	})).Return(nil).Once()
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessSucceeded
+	})).Return(nil).Once()
+
+	err = sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.True(t, pending.remove)
+	assert.Equal(t, "0x111111", mtx.TransactionHash)
+	assert.Equal(t, apitypes.TxStatusSucceeded, mtx.Status)
+	assert.Empty(t, pending.trackedHashes)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestTrackAllSubmittedHashesLoadFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`1000`)
+	firstSubmit := fftypes.FFTime(time.Now().Add(-1 * time.Hour))
+	mtx.FirstSubmit = &firstSubmit
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}}
+
+	mp.On("GetTransactionSubmittedHashes", mock.Anything, mtx.ID).Return(nil, fmt.Errorf("pop"))
+
+	ctx, err := sth.pendingToRunContext(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	sth.trackTransactionHash(ctx, pending)
+	assert.Empty(t, pending.trackedHashes)
+	assert.Empty(t, pending.trackingTransactionHash)
+
+	mp.AssertExpectations(t)
+}
+
+func TestHandleTransactionReceiptReceivedNotInflight(t *testing.T) {
+	sth, _, _, _ := newTestDependenciesHandler(t)
+
+	err := sth.HandleTransactionReceiptReceived(sth.ctx, "tx1", &ffcapi.TransactionReceiptResponse{})
+	assert.Regexp(t, "FF21067", err)
+}
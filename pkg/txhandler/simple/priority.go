@@ -0,0 +1,103 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/hyperledger/firefly-common/pkg/config"
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+)
+
+type priorityGasPrice struct {
+	minPriority int
+	multiplier  float64
+}
+
+func parsePriorityGasPrices(ctx context.Context, multipliers config.ArraySection) ([]*priorityGasPrice, error) {
+	count := multipliers.ArraySize()
+	priorityGasPrices := make([]*priorityGasPrice, 0, count)
+	for i := 0; i < count; i++ {
+		entry := multipliers.ArrayEntry(i)
+		pgp := &priorityGasPrice{
+			minPriority: entry.GetInt(PriorityMinPriority),
+			multiplier:  entry.GetFloat64(PriorityMultiplier),
+		}
+		if pgp.multiplier <= 0 {
+			return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidPriorityGasPriceMultiplier, pgp.multiplier, pgp.minPriority)
+		}
+		priorityGasPrices = append(priorityGasPrices, pgp)
+	}
+	sort.SliceStable(priorityGasPrices, func(i, j int) bool {
+		return priorityGasPrices[i].minPriority < priorityGasPrices[j].minPriority
+	})
+	return priorityGasPrices, nil
+}
+
+// priorityGasPriceMultiplier returns the multiplier of the entry with the highest minimum priority that
+// the supplied priority reaches, or 1 if there is none
+func (sth *simpleTransactionHandler) priorityGasPriceMultiplier(priority int) float64 {
+	multiplier := 1.0
+	for _, pgp := range sth.priorityGasPrices {
+		if pgp.minPriority > priority {
+			break
+		}
+		multiplier = pgp.multiplier
+	}
+	return multiplier
+}
+
+func multiplyGasPriceValue(value *big.Int, multiplier float64) *big.Int {
+	// Calculate in integer basis points to avoid floating point error on large values, rounding up
+	result := new(big.Int).Mul(value, big.NewInt(int64(math.Round(multiplier*10000))))
+	result.Add(result, big.NewInt(9999))
+	return result.Quo(result, big.NewInt(10000))
+}
+
+// multiplyGasPrice applies a multiplier to a gas price, supporting the same numeric and object formats
+// as escalateGasPrice. Anything else is returned unchanged, with multiplied=false.
+func multiplyGasPrice(gasPrice *fftypes.JSONAny, multiplier float64) (result *fftypes.JSONAny, multiplied bool) {
+	if gasPrice.IsNil() {
+		return gasPrice, false
+	}
+
+	if value, format, ok := parseGasPriceNumber(json.RawMessage(gasPrice.Bytes())); ok {
+		return fftypes.JSONAnyPtrBytes(formatGasPriceNumber(multiplyGasPriceValue(value, multiplier), format)), true
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(gasPrice.Bytes(), &fields); err != nil || fields == nil {
+		return gasPrice, false
+	}
+	for k, v := range fields {
+		if value, format, ok := parseGasPriceNumber(v); ok {
+			fields[k] = formatGasPriceNumber(multiplyGasPriceValue(value, multiplier), format)
+			multiplied = true
+		}
+	}
+	if !multiplied {
+		return gasPrice, false
+	}
+	b, _ := json.Marshal(fields)
+	return fftypes.JSONAnyPtrBytes(b), true
+}
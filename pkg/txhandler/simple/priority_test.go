@@ -0,0 +1,273 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPriorityHandler(t *testing.T, simpleConfig map[string]interface{}) (*simpleTransactionHandler, *persistencemocks.Persistence, *ffcapimocks.API, error) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	// Array entries are only read from a config file, not values set directly
+	configJSON, _ := json.Marshal(map[string]interface{}{
+		"unittest": map[string]interface{}{
+			"simple": simpleConfig,
+		},
+	})
+	viper.SetConfigType("json")
+	err := viper.ReadConfig(bytes.NewReader(configJSON))
+	assert.NoError(t, err)
+	conf.Set(FixedGasPrice, `1000`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	return sth, tk.TXPersistence.(*persistencemocks.Persistence), mockFFCAPI, nil
+}
+
+func TestSelectPendingPriorityInheritedByEarlierNonces(t *testing.T) {
+	sth, mp, _, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityScheduling: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, sth.priorityScheduling)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 3)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 2)
+	txsB[1].Priority = 10
+	pending := append(append([]*apitypes.ManagedTX{}, txsA...), txsB...)
+	mockPendingBySigner(mp, pending)
+
+	// The earlier nonce of the signer goes ahead of its priority transaction, and both ahead of the other signer
+	selected, err := sth.selectPending(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsB[0], txsB[1], txsA[0]}, selected)
+
+	// Once the priority transaction is in-flight, the order received is kept
+	sth.inflight = []*pendingState{{mtx: txsB[0]}, {mtx: txsB[1]}}
+	selected, err = sth.selectPending(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsA[0], txsA[1], txsA[2]}, selected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingPriorityBehindSignerQueue(t *testing.T) {
+	sth, mp, _, err := newTestPriorityHandler(t, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.True(t, sth.priorityScheduling)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 2)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 20)
+	txsB[19].Priority = 10
+	pending := append(append([]*apitypes.ManagedTX{}, txsA...), txsB...)
+	mockPendingBySigner(mp, pending)
+
+	// Only the next transactions of each signer are read, but the urgent transaction far behind
+	// still puts its signer ahead
+	selected, err := sth.selectPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsB[0], txsB[1]}, selected)
+	mp.AssertCalled(t, "ListSignerTransactionsPending", mock.Anything, "0xbbbb", 2)
+
+	// The urgent transaction itself being in-flight does not raise the priority of the others
+	sth.inflight = []*pendingState{{mtx: txsB[19]}}
+	selected, err = sth.selectPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsA[0], txsA[1]}, selected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingPriorityReadFail(t *testing.T) {
+	sth, mp, _, err := newTestPriorityHandler(t, map[string]interface{}{})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mp.On("ListPendingSigners", mock.Anything, "", defaultPendingScanLimit).Return([]string{"0xaaaa"}, nil)
+	mp.On("ListSignerTransactionsPending", mock.Anything, "0xaaaa", 5).Return(newTestPendingTXs("ns1", "0xaaaa", 1), nil)
+	mp.On("ListTransactionsPendingByPriority", mock.Anything, 5).Return(nil, fmt.Errorf("pop"))
+
+	_, err = sth.selectPending(ctx, 5)
+	assert.Error(t, err)
+}
+
+func TestSelectPendingPriorityFair(t *testing.T) {
+	sth, mp, _, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityScheduling: true,
+		},
+		SchedulingConfig: map[string]interface{}{
+			SchedulingFair: true,
+		},
+	})
+	assert.NoError(t, err)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 3)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 2)
+	txsC := newTestPendingTXs("ns1", "0xcccc", 2)
+	txsB[0].Priority = 5
+	txsB[1].Priority = 5
+	txsC[1].Priority = 5
+	pending := append(append(append([]*apitypes.ManagedTX{}, txsA...), txsB...), txsC...)
+	mockPendingBySigner(mp, pending)
+
+	// The signers with priority transactions take turns, before the others get a look in
+	selected, err := sth.selectPending(context.Background(), 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0xbbbb", "0xcccc", "0xbbbb", "0xcccc", "0xaaaa", "0xaaaa"}, selectedSigners(selected))
+
+	mp.AssertExpectations(t)
+}
+
+func TestSelectPendingPriorityDisabled(t *testing.T) {
+	sth, mp, _, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityScheduling: false,
+		},
+		SchedulingConfig: map[string]interface{}{
+			SchedulingMaxInFlightPerSigner: 10,
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, sth.priorityScheduling)
+
+	txsA := newTestPendingTXs("ns1", "0xaaaa", 2)
+	txsB := newTestPendingTXs("ns1", "0xbbbb", 1)
+	txsB[0].Priority = 10
+	pending := append(append([]*apitypes.ManagedTX{}, txsA...), txsB...)
+	mockPendingBySigner(mp, pending)
+
+	selected, err := sth.selectPending(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*apitypes.ManagedTX{txsA[0], txsA[1]}, selected)
+
+	mp.AssertExpectations(t)
+}
+
+func TestPriorityGasPriceMultiplier(t *testing.T) {
+	sth, _, _, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityGasPriceMultipliers: []interface{}{
+				map[string]interface{}{
+					PriorityMinPriority: 10,
+					PriorityMultiplier:  2,
+				},
+				map[string]interface{}{
+					PriorityMinPriority: 5,
+					PriorityMultiplier:  1.5,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1.0, sth.priorityGasPriceMultiplier(0))
+	assert.Equal(t, 1.5, sth.priorityGasPriceMultiplier(5))
+	assert.Equal(t, 1.5, sth.priorityGasPriceMultiplier(9))
+	assert.Equal(t, 2.0, sth.priorityGasPriceMultiplier(100))
+}
+
+func TestPriorityGasPriceBadMultiplier(t *testing.T) {
+	_, _, _, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityGasPriceMultipliers: []interface{}{
+				map[string]interface{}{
+					PriorityMinPriority: 5,
+					PriorityMultiplier:  0,
+				},
+			},
+		},
+	})
+	assert.Regexp(t, "FF21103", err)
+}
+
+func TestMultiplyGasPrice(t *testing.T) {
+	gasPrice, multiplied := multiplyGasPrice(fftypes.JSONAnyPtr(`1000`), 1.5)
+	assert.True(t, multiplied)
+	assert.Equal(t, `1500`, gasPrice.String())
+
+	// Rounds up, and keeps strings as strings
+	gasPrice, multiplied = multiplyGasPrice(fftypes.JSONAnyPtr(`"0x3"`), 1.5)
+	assert.True(t, multiplied)
+	assert.Equal(t, `"0x5"`, gasPrice.String())
+
+	gasPrice, multiplied = multiplyGasPrice(fftypes.JSONAnyPtr(`{"maxFeePerGas":"2000","maxPriorityFeePerGas":10,"type":"eip1559"}`), 2)
+	assert.True(t, multiplied)
+	assert.JSONEq(t, `{"maxFeePerGas":"4000","maxPriorityFeePerGas":20,"type":"eip1559"}`, gasPrice.String())
+
+	gasPrice, multiplied = multiplyGasPrice(fftypes.JSONAnyPtr(`{"type":"eip1559"}`), 2)
+	assert.False(t, multiplied)
+	assert.Equal(t, `{"type":"eip1559"}`, gasPrice.String())
+
+	gasPrice, multiplied = multiplyGasPrice(fftypes.JSONAnyPtr(`"fast"`), 2)
+	assert.False(t, multiplied)
+	assert.Equal(t, `"fast"`, gasPrice.String())
+
+	gasPrice, multiplied = multiplyGasPrice(nil, 2)
+	assert.False(t, multiplied)
+	assert.Nil(t, gasPrice)
+}
+
+func TestSubmitTXPriorityGasPrice(t *testing.T) {
+	sth, _, mockFFCAPI, err := newTestPriorityHandler(t, map[string]interface{}{
+		PriorityConfig: map[string]interface{}{
+			PriorityGasPriceMultipliers: []interface{}{
+				map[string]interface{}{
+					PriorityMinPriority: 10,
+					PriorityMultiplier:  1.25,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.GasPrice.String() == `1250`
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil)
+
+	mtx := newTestSpendGuardTX(100)
+	mtx.Priority = 10
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, `1250`, mtx.GasPrice.String())
+	assert.Equal(t, apitypes.TxSubStatusTracking, rc.SubStatus)
+
+	mockFFCAPI.AssertExpectations(t)
+}
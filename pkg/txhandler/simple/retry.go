@@ -0,0 +1,168 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// HandleRetryTransaction creates a new transaction with a fresh nonce, as a new attempt at a failed transaction.
+// The failed transaction is left as it is, apart from recording the new attempt, so the whole chain of attempts
+// can be followed from either end.
+func (sth *simpleTransactionHandler) HandleRetryTransaction(ctx context.Context, txID string, req *apitypes.RetryTransactionRequest) (*apitypes.ManagedTX, error) {
+	// A transaction can only be retried once, so we do not allow two retries of the same transaction to race
+	sth.retryMux.Lock()
+	defer sth.retryMux.Unlock()
+
+	failed, err := sth.getTransactionByID(ctx, txID)
+	if err != nil {
+		return nil, err
+	}
+	if failed.Status != apitypes.TxStatusFailed {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgTransactionNotFailed, txID, failed.Status)
+	}
+	if failed.RetriedBy != "" {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgTransactionAlreadyRetried, txID, failed.RetriedBy)
+	}
+	var info simplePolicyInfo
+	_ = json.Unmarshal(failed.PolicyInfo.Bytes(), &info)
+	if info.External && req.Method == nil {
+		// We have no transaction data to re-use, for a transaction that was submitted by other tooling
+		return nil, i18n.NewError(ctx, tmmsgs.MsgTransactionSubmittedExternally, txID)
+	}
+	// A decision not to submit the transaction cannot be worked around by retrying it
+	if info.RejectedBy != "" {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgRetryRejectedTransaction, txID, info.RejectedBy)
+	}
+	if info.PolicyDenied {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgRetryPolicyDeniedTransaction, txID)
+	}
+	chain, err := sth.retryChain(ctx, failed)
+	if err != nil {
+		return nil, err
+	}
+
+	newID, err := sth.retryTransactionID(ctx, failed, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	txHeaders := failed.TransactionHeaders
+	txHeaders.Nonce = nil
+	// The address lists might have changed since the failed transaction was screened
+	if err := sth.screenAddresses(ctx, newID, &txHeaders); err != nil {
+		return nil, err
+	}
+	gas := failed.Gas
+	if req.Gas != nil {
+		gas = req.Gas
+	}
+	transactionData := failed.TransactionData
+	if req.Method != nil {
+		// Prepare the transaction again, for example to re-estimate the gas after a revert for out of gas
+		txHeaders.Gas = req.Gas
+		prepared, _, err := sth.toolkit.Connector.TransactionPrepare(ctx, &ffcapi.TransactionPrepareRequest{
+			TransactionInput: ffcapi.TransactionInput{
+				TransactionHeaders: txHeaders,
+				Method:             req.Method,
+				Params:             req.Params,
+				Errors:             req.Errors,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		transactionData = prepared.TransactionData
+		if req.Gas == nil {
+			gas = prepared.Gas
+		}
+	}
+
+	// The time based scheduling of the failed transaction does not carry over, as it was relative to the first attempt.
+	// Nor do its dependencies, as those must have succeeded for it to have been submitted.
+	mtx := sth.newManagedTx(newID, &txHeaders, gas, transactionData, &txSchedule{priority: failed.Priority})
+	mtx.RetryOf = failed.ID
+	if _, _, err = sth.insertManagedTx(ctx, mtx); err != nil {
+		return nil, err
+	}
+	chain = append(chain, mtx.ID)
+	if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, mtx.ID, apitypes.TxSubStatusReceived, apitypes.TxActionRetry, retryInfo("retryOf", failed.ID, chain), nil, fftypes.Now()); err != nil {
+		return nil, err
+	}
+
+	// Link the failed transaction forwards to the new one
+	if err := sth.toolkit.TXPersistence.UpdateTransaction(ctx, failed.ID, &apitypes.TXUpdates{RetriedBy: &mtx.ID}); err != nil {
+		return nil, err
+	}
+	if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, failed.ID, apitypes.TxSubStatusFailed, apitypes.TxActionRetried, retryInfo("retriedBy", mtx.ID, chain), nil, fftypes.Now()); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Transaction %s retried as transaction %s at nonce %s / %d (attempt %d)", failed.ID, mtx.ID, mtx.From, mtx.Nonce.Int64(), len(chain))
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "retried")
+	return mtx, nil
+}
+
+// retryTransactionID returns the ID for the new attempt, which must be in the same namespace as the failed transaction
+// so that its events are delivered to the same namespace
+func (sth *simpleTransactionHandler) retryTransactionID(ctx context.Context, failed *apitypes.ManagedTX, reqID string) (string, error) {
+	namespace := failed.Namespace(ctx)
+	if reqID == "" {
+		if namespace == "" {
+			return fftypes.NewUUID().String(), nil
+		}
+		return fftypes.NewNamespacedUUIDString(ctx, namespace, fftypes.NewUUID()), nil
+	}
+	if reqNamespace, _, _ := fftypes.ParseNamespacedUUID(ctx, reqID); reqNamespace != namespace {
+		return "", i18n.NewError(ctx, tmmsgs.MsgRetryNamespaceMismatch, reqID, namespace, failed.ID)
+	}
+	return sth.requestIDPreCheck(ctx, &apitypes.RequestHeaders{ID: reqID})
+}
+
+// retryChain returns the IDs of all the attempts up to and including the supplied transaction, oldest first
+func (sth *simpleTransactionHandler) retryChain(ctx context.Context, mtx *apitypes.ManagedTX) ([]string, error) {
+	chain := []string{mtx.ID}
+	visited := map[string]bool{mtx.ID: true}
+	for previousID := mtx.RetryOf; previousID != "" && !visited[previousID]; {
+		visited[previousID] = true
+		chain = append([]string{previousID}, chain...)
+		previous, err := sth.toolkit.TXPersistence.GetTransactionByID(ctx, previousID)
+		if err != nil {
+			return nil, err
+		}
+		if previous == nil {
+			// An earlier attempt has been deleted, so the chain starts here
+			break
+		}
+		previousID = previous.RetryOf
+	}
+	return chain, nil
+}
+
+func retryInfo(linkField, linkID string, chain []string) *fftypes.JSONAny {
+	b, _ := json.Marshal(map[string]interface{}{
+		linkField: linkID,
+		"chain":   chain,
+	})
+	return fftypes.JSONAnyPtrBytes(b)
+}
@@ -0,0 +1,361 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestFailedTX(id string) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID:       id,
+		Status:   apitypes.TxStatusFailed,
+		Priority: 5,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0xaaaa",
+			Gas:   fftypes.NewFFBigInt(10000),
+			To:    "0xbbbb",
+			Nonce: fftypes.NewFFBigInt(10),
+		},
+		TransactionData: "RAW_UNSIGNED_BYTES",
+	}
+}
+
+func mockRetryInsert(mp *persistencemocks.Persistence, match func(mtx *apitypes.ManagedTX) bool) {
+	mp.On("InsertTransactionWithNextNonce", mock.Anything, mock.MatchedBy(match), mock.Anything).Run(func(args mock.Arguments) {
+		args[1].(*apitypes.ManagedTX).Nonce = fftypes.NewFFBigInt(11)
+	}).Return(nil)
+}
+
+func TestRetryTransaction(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	attempt1 := newTestFailedTX("ns1:attempt1")
+	attempt1.RetriedBy = "ns1:attempt2"
+	attempt2 := newTestFailedTX("ns1:attempt2")
+	attempt2.RetryOf = "ns1:attempt1"
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt2").Return(attempt2, nil)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(attempt1, nil)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt3").Return(nil, nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool {
+		return mtx.ID == "ns1:attempt3" &&
+			mtx.RetryOf == "ns1:attempt2" &&
+			mtx.Nonce == nil &&
+			mtx.Status == apitypes.TxStatusPending &&
+			mtx.Priority == 5 &&
+			mtx.Gas.Int64() == 10000 &&
+			mtx.To == "0xbbbb" &&
+			mtx.TransactionData == "RAW_UNSIGNED_BYTES"
+	})
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:attempt3", apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:attempt3", apitypes.TxSubStatusReceived, apitypes.TxActionRetry, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"chain":["ns1:attempt1","ns1:attempt2","ns1:attempt3"],"retryOf":"ns1:attempt2"}`
+	}), mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:attempt2", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.RetriedBy == "ns1:attempt3"
+	})).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:attempt2", apitypes.TxSubStatusFailed, apitypes.TxActionRetried, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"chain":["ns1:attempt1","ns1:attempt2","ns1:attempt3"],"retriedBy":"ns1:attempt3"}`
+	}), mock.Anything, mock.Anything).Return(nil)
+
+	mtx, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt2", &apitypes.RetryTransactionRequest{ID: "ns1:attempt3"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1:attempt3", mtx.ID)
+	assert.Equal(t, int64(11), mtx.Nonce.Int64())
+
+	mp.AssertExpectations(t)
+}
+
+func TestRetryTransactionPrepare(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.From == "0xaaaa" && req.Nonce == nil && req.Gas == nil && req.Method.String() == `{"name":"set"}`
+	})).Return(&ffcapi.TransactionPrepareResponse{
+		Gas:             fftypes.NewFFBigInt(20000),
+		TransactionData: "NEW_UNSIGNED_BYTES",
+	}, ffcapi.ErrorReason(""), nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Gas.Int64() == 20000 && mtx.TransactionData == "NEW_UNSIGNED_BYTES"
+	})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:attempt1", mock.Anything).Return(nil)
+
+	mtx, err := sth.HandleRetryTransaction(context.Background(), "ns1:attempt1", &apitypes.RetryTransactionRequest{
+		Method: fftypes.JSONAnyPtr(`{"name":"set"}`),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1:attempt1", mtx.RetryOf)
+	// The generated ID is in the namespace of the failed transaction
+	namespace, _, err := fftypes.ParseNamespacedUUID(context.Background(), mtx.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1", namespace)
+
+	mp.AssertExpectations(t)
+}
+
+func TestRetryTransactionGasOverride(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool {
+		return mtx.Gas.Int64() == 30000 && mtx.TransactionData == "RAW_UNSIGNED_BYTES"
+	})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:attempt1", mock.Anything).Return(nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{
+		Gas:    fftypes.NewFFBigInt(30000),
+		Method: fftypes.JSONAnyPtr(`{"name":"set"}`),
+	})
+	assert.NoError(t, err)
+
+	mp.AssertExpectations(t)
+	mockFFCAPI.AssertCalled(t, "TransactionPrepare", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionPrepareRequest) bool {
+		return req.Gas.Int64() == 30000
+	}))
+}
+
+func TestRetryTransactionNotFound(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(nil, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21067", err)
+}
+
+func TestRetryTransactionGetFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionNotFailed(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt1")
+	mtx.Status = apitypes.TxStatusSucceeded
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(mtx, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21108", err)
+}
+
+func TestRetryTransactionExternal(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt1")
+	mtx.TransactionData = ""
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{"external":true}`)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(mtx, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21116", err)
+}
+
+func TestRetryTransactionGeneratedIDNoNamespace(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	failedID := fftypes.NewUUID().String()
+	mp.On("GetTransactionByID", mock.Anything, failedID).Return(newTestFailedTX(failedID), nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool {
+		_, err := fftypes.ParseUUID(context.Background(), mtx.ID)
+		return err == nil
+	})
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, failedID, mock.Anything).Return(nil)
+
+	mtx, err := sth.HandleRetryTransaction(sth.ctx, failedID, &apitypes.RetryTransactionRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, failedID, mtx.RetryOf)
+
+	mp.AssertExpectations(t)
+}
+
+func TestRetryTransactionNamespaceMismatch(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{ID: "ns2:attempt2"})
+	assert.Regexp(t, "FF21130.*ns2:attempt2.*ns1", err)
+
+	_, err = sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{ID: fftypes.NewUUID().String()})
+	assert.Regexp(t, "FF21130", err)
+
+	mp.AssertExpectations(t)
+	mp.AssertNotCalled(t, "InsertTransactionWithNextNonce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRetryTransactionRejected(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt1")
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{"rejectedBy":"approver1"}`)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(mtx, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21131.*approver1", err)
+}
+
+func TestRetryTransactionPolicyDenied(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt1")
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{"policyDenied":true}`)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(mtx, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21132", err)
+}
+
+func TestRetryTransactionAlreadyRetried(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt1")
+	mtx.RetriedBy = "ns1:attempt2"
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(mtx, nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "FF21109", err)
+}
+
+func TestRetryTransactionChainFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt2")
+	mtx.RetryOf = "ns1:attempt1"
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt2").Return(mtx, nil)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt2", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionDuplicateID(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{ID: "ns1:attempt1"})
+	assert.Regexp(t, "FF21065", err)
+}
+
+func TestRetryTransactionPrepareFail(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	_, err = sth.HandleRetryTransaction(context.Background(), "ns1:attempt1", &apitypes.RetryTransactionRequest{
+		Method: fftypes.JSONAnyPtr(`{"name":"set"}`),
+	})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionInsertFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mp.On("InsertTransactionWithNextNonce", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionHistoryFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool { return true })
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, apitypes.TxActionRetry, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionUpdateFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool { return true })
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:attempt1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryTransactionRetriedHistoryFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+	mp.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mockRetryInsert(mp, func(mtx *apitypes.ManagedTX) bool { return true })
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, apitypes.TxActionAssignNonce, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mock.Anything, mock.Anything, apitypes.TxActionRetry, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:attempt1", mock.Anything, apitypes.TxActionRetried, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mp.On("UpdateTransaction", mock.Anything, "ns1:attempt1", mock.Anything).Return(nil)
+
+	_, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestRetryChainDeletedAttempt(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestFailedTX("ns1:attempt2")
+	mtx.RetryOf = "ns1:attempt1"
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(nil, nil)
+
+	chain, err := sth.retryChain(sth.ctx, mtx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ns1:attempt1", "ns1:attempt2"}, chain)
+}
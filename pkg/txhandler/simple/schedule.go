@@ -0,0 +1,69 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+// txSchedule holds the timing constraints, the priority, and the dependencies, requested in the headers of a new transaction
+type txSchedule struct {
+	expiry         *fftypes.FFTime
+	notBefore      *fftypes.FFTime
+	notBeforeBlock *fftypes.FFBigInt
+	priority       int
+	dependsOn      []string
+}
+
+func newTXSchedule(ctx context.Context, reqHeaders *apitypes.RequestHeaders) (schedule *txSchedule, err error) {
+	schedule = &txSchedule{priority: reqHeaders.Priority, dependsOn: reqHeaders.DependsOn}
+	if schedule.expiry, err = reqHeaders.ExpiryTime(ctx); err != nil {
+		return nil, err
+	}
+	if schedule.notBefore, schedule.notBeforeBlock, err = reqHeaders.NotBeforeCondition(ctx); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// notBeforeReached returns true if a transaction scheduled for a later time, or block, is ready for its first submission.
+// Block numbers are checked against the highest block seen by the confirmation manager, so a transaction scheduled
+// for a block is never submitted when the block height is unknown.
+func (sth *simpleTransactionHandler) notBeforeReached(ctx *RunContext) bool {
+	mtx := ctx.TX
+	if mtx.NotBefore != nil && time.Now().Before(*mtx.NotBefore.Time()) {
+		log.L(ctx).Debugf("Transaction %s scheduled for submission at %s", mtx.ID, mtx.NotBefore)
+		return false
+	}
+	if mtx.NotBeforeBlock != nil {
+		var highestBlock uint64
+		if sth.toolkit.BlockHeight != nil {
+			highestBlock = sth.toolkit.BlockHeight.HighestBlockSeen()
+		}
+		if mtx.NotBeforeBlock.Int().IsUint64() && highestBlock >= mtx.NotBeforeBlock.Uint64() {
+			return true
+		}
+		log.L(ctx).Debugf("Transaction %s scheduled for submission at block %s (highest block seen %d)", mtx.ID, mtx.NotBeforeBlock, highestBlock)
+		return false
+	}
+	return true
+}
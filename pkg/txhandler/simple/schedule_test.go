@@ -0,0 +1,129 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testBlockHeight uint64
+
+func (h testBlockHeight) HighestBlockSeen() uint64 {
+	return uint64(h)
+}
+
+func newTestScheduleHandler(t *testing.T) (*simpleTransactionHandler, *ffcapi.TransactionHeaders) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil).Maybe()
+	return sth, &ffcapi.TransactionHeaders{
+		From: "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+	}
+}
+
+func TestScheduleInvalidNotBeforeOnNewTransaction(t *testing.T) {
+	sth, _ := newTestScheduleHandler(t)
+
+	_, _, err := sth.HandleNewTransaction(sth.ctx, &apitypes.TransactionRequest{
+		Headers: apitypes.RequestHeaders{
+			NotBefore: "wrong",
+		},
+	})
+	assert.Regexp(t, "FF21094", err)
+}
+
+func TestScheduleNotBeforeTime(t *testing.T) {
+	sth, txHeaders := newTestScheduleHandler(t)
+
+	notBefore := fftypes.FFTime(time.Now().Add(1 * time.Hour))
+	mtx := &apitypes.ManagedTX{
+		TransactionHeaders: *txHeaders,
+		NotBefore:          &notBefore,
+	}
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+	assert.Nil(t, mtx.FirstSubmit)
+
+	notBefore = fftypes.FFTime(time.Now().Add(-1 * time.Second))
+	rc = newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.NotNil(t, mtx.FirstSubmit)
+}
+
+func TestScheduleNotBeforeBlock(t *testing.T) {
+	sth, txHeaders := newTestScheduleHandler(t)
+
+	mtx := &apitypes.ManagedTX{
+		TransactionHeaders: *txHeaders,
+		NotBeforeBlock:     fftypes.NewFFBigInt(1000),
+	}
+
+	// Block height unknown
+	rc := newTestRunContext(mtx, nil)
+	assert.False(t, sth.notBeforeReached(rc))
+
+	sth.toolkit.BlockHeight = testBlockHeight(999)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, None, rc.UpdateType)
+	assert.Nil(t, mtx.FirstSubmit)
+
+	sth.toolkit.BlockHeight = testBlockHeight(1000)
+	rc = newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.NotNil(t, mtx.FirstSubmit)
+}
+
+func TestNewTXSchedule(t *testing.T) {
+	schedule, err := newTXSchedule(context.Background(), &apitypes.RequestHeaders{
+		Expiry:    "2h",
+		NotBefore: "1h",
+		Priority:  10,
+	})
+	assert.NoError(t, err)
+	assert.True(t, schedule.expiry.Time().After(*schedule.notBefore.Time()))
+	assert.Nil(t, schedule.notBeforeBlock)
+	assert.Equal(t, 10, schedule.priority)
+
+	_, err = newTXSchedule(context.Background(), &apitypes.RequestHeaders{
+		Expiry: "wrong",
+	})
+	assert.Regexp(t, "FF21091", err)
+}
@@ -0,0 +1,40 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// screenAddresses refuses a new transaction before it is prepared, if its signer or destination is blocked
+// by the address lists of the transaction manager. Nothing is persisted for a refused transaction.
+func (sth *simpleTransactionHandler) screenAddresses(ctx context.Context, txID string, txHeaders *ffcapi.TransactionHeaders) error {
+	if sth.toolkit.AddressScreener == nil {
+		return nil
+	}
+	if err := sth.toolkit.AddressScreener.ScreenAddresses(ctx, txHeaders.From, txHeaders.To); err != nil {
+		log.L(ctx).Warnf("Transaction %s refused by address screening: %s", txID, err)
+		namespace, _, _ := fftypes.ParseNamespacedUUID(ctx, txID)
+		sth.incTransactionOperationCounter(ctx, namespace, "address_rejected")
+		return err
+	}
+	return nil
+}
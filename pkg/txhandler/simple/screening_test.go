@@ -0,0 +1,135 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/metricsmocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testAddressScreener func(ctx context.Context, from, to string) error
+
+func (s testAddressScreener) ScreenAddresses(ctx context.Context, from, to string) error {
+	return s(ctx, from, to)
+}
+
+func newTestScreeningHandler(t *testing.T, screener testAddressScreener) (*simpleTransactionHandler, *ffcapimocks.API, *metricsmocks.TransactionHandlerMetrics) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	sth.toolkit.AddressScreener = screener
+	mmm := &metricsmocks.TransactionHandlerMetrics{}
+	sth.toolkit.MetricsManager = mmm
+	return sth, mockFFCAPI, mmm
+}
+
+func TestScreenAddressesRejectsNewTransaction(t *testing.T) {
+	sth, mockFFCAPI, mmm := newTestScreeningHandler(t, func(ctx context.Context, from, to string) error {
+		assert.Equal(t, "0xaaaa", from)
+		assert.Equal(t, "0xbbbb", to)
+		return fmt.Errorf("denied")
+	})
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal,
+		map[string]string{metricsLabelNameOperation: "address_rejected"}, mock.Anything).Return().Once()
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.From = "0xaaaa"
+	txReq.To = "0xbbbb"
+	mtx, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.Regexp(t, "denied", err)
+	assert.True(t, submissionRejected)
+	assert.Nil(t, mtx)
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionPrepare", mock.Anything, mock.Anything)
+	mmm.AssertExpectations(t)
+}
+
+func TestScreenAddressesRejectsNewContractDeployment(t *testing.T) {
+	sth, mockFFCAPI, mmm := newTestScreeningHandler(t, func(ctx context.Context, from, to string) error {
+		assert.Equal(t, "0xaaaa", from)
+		assert.Empty(t, to)
+		return fmt.Errorf("denied")
+	})
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal,
+		map[string]string{metricsLabelNameOperation: "address_rejected"}, mock.Anything).Return().Once()
+
+	deployReq := &apitypes.ContractDeployRequest{}
+	deployReq.From = "0xaaaa"
+	mtx, submissionRejected, err := sth.HandleNewContractDeployment(sth.ctx, deployReq)
+	assert.Regexp(t, "denied", err)
+	assert.True(t, submissionRejected)
+	assert.Nil(t, mtx)
+
+	mockFFCAPI.AssertNotCalled(t, "DeployContractPrepare", mock.Anything, mock.Anything)
+	mmm.AssertExpectations(t)
+}
+
+func TestScreenAddressesAllowedBeforePrepare(t *testing.T) {
+	screened := false
+	sth, mockFFCAPI, mmm := newTestScreeningHandler(t, func(ctx context.Context, from, to string) error {
+		screened = true
+		return nil
+	})
+	mockFFCAPI.On("TransactionPrepare", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	txReq := &apitypes.TransactionRequest{}
+	txReq.From = "0xaaaa"
+	_, submissionRejected, err := sth.HandleNewTransaction(sth.ctx, txReq)
+	assert.Regexp(t, "pop", err)
+	assert.False(t, submissionRejected)
+	assert.True(t, screened)
+
+	mockFFCAPI.AssertExpectations(t)
+	mmm.AssertExpectations(t)
+}
+
+func TestScreenAddressesRejectsRetry(t *testing.T) {
+	sth, mockFFCAPI, mmm := newTestScreeningHandler(t, func(ctx context.Context, from, to string) error {
+		assert.Equal(t, "0xaaaa", from)
+		assert.Equal(t, "0xbbbb", to)
+		return fmt.Errorf("denied")
+	})
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal,
+		map[string]string{metricsLabelNameOperation: "address_rejected"}, mock.Anything).Return().Once()
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("GetTransactionByID", mock.Anything, "ns1:attempt1").Return(newTestFailedTX("ns1:attempt1"), nil)
+
+	mtx, err := sth.HandleRetryTransaction(sth.ctx, "ns1:attempt1", &apitypes.RetryTransactionRequest{
+		Method: fftypes.JSONAnyPtr(`{}`),
+	})
+	assert.Regexp(t, "denied", err)
+	assert.Nil(t, mtx)
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionPrepare", mock.Anything, mock.Anything)
+	mp.AssertNotCalled(t, "InsertTransactionWithNextNonce", mock.Anything, mock.Anything, mock.Anything)
+	mp.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything, mock.Anything)
+	mmm.AssertExpectations(t)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// unsentSubmission is a transaction signed ahead of a submission that could not be confirmed as sent, such as
+// when the TransactionSend timed out. It might still reach the chain, so it is sent again as it is, rather than
+// signing a second transaction at the same nonce with a different hash.
+type unsentSubmission struct {
+	TransactionHash   string           `json:"transactionHash"`
+	SignedTransaction string           `json:"signedTransaction"`
+	GasPrice          *fftypes.JSONAny `json:"gasPrice"`
+}
+
+// clearUnsentSubmission is called once the node has told us the outcome of sending the signed transaction
+func (ctx *RunContext) clearUnsentSubmission() {
+	if ctx.Info != nil && ctx.Info.UnsentSubmission != nil {
+		ctx.Info.UnsentSubmission = nil
+		ctx.UpdateType = Update
+		ctx.UpdatedInfo = true
+	}
+}
+
+// signTX signs the transaction ahead of submission, and records the hash we expect onto the transaction.
+// The update is persisted even if the TransactionSend that follows fails, so we can track the transaction
+// if it reached the node despite the failure.
+// If an earlier signed submission was never confirmed as sent, and the gas price is unchanged, that is sent again.
+func (sth *simpleTransactionHandler) signTX(ctx *RunContext, signer ffcapi.TransactionSigner, sendTX *ffcapi.TransactionSendRequest) (reason ffcapi.ErrorReason, err error) {
+	mtx := ctx.TX
+	if unsent := ctx.Info.UnsentSubmission; unsent != nil && unsent.GasPrice.String() == sendTX.GasPrice.String() {
+		log.L(ctx).Infof("Transaction %s at nonce %s / %d sending earlier signed submission again with hash: %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), unsent.TransactionHash)
+		if mtx.TransactionHash != unsent.TransactionHash {
+			mtx.TransactionHash = unsent.TransactionHash
+			ctx.UpdateType = Update
+			ctx.TXUpdates.TransactionHash = &unsent.TransactionHash
+		}
+		sendTX.TransactionData = unsent.SignedTransaction
+		sendTX.PreSigned = true
+		return "", nil
+	}
+
+	res, reason, err := signer.TransactionSign(ctx, &ffcapi.TransactionSignRequest{
+		TransactionHeaders: sendTX.TransactionHeaders,
+		GasPrice:           sendTX.GasPrice,
+		TransactionData:    sendTX.TransactionData,
+	})
+	if err != nil {
+		ctx.AddSubStatusAction(apitypes.TxActionSignTransaction, fftypes.JSONAnyPtr(`{"reason":"`+string(reason)+`"}`), fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
+		return reason, err
+	}
+	ctx.AddSubStatusAction(apitypes.TxActionSignTransaction, fftypes.JSONAnyPtr(`{"transactionHash":"`+res.TransactionHash+`"}`), nil, fftypes.Now())
+	log.L(ctx).Debugf("Transaction %s at nonce %s / %d signed with expected hash: %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), res.TransactionHash)
+	mtx.TransactionHash = res.TransactionHash
+	ctx.UpdateType = Update
+	ctx.TXUpdates.TransactionHash = &res.TransactionHash
+	ctx.Info.UnsentSubmission = &unsentSubmission{
+		TransactionHash:   res.TransactionHash,
+		SignedTransaction: res.SignedTransaction,
+		GasPrice:          sendTX.GasPrice,
+	}
+	ctx.UpdatedInfo = true
+	sendTX.TransactionData = res.SignedTransaction
+	sendTX.PreSigned = true
+	return "", nil
+}
@@ -0,0 +1,341 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testSigningConnector struct {
+	*ffcapimocks.API
+	*ffcapimocks.TransactionSigner
+}
+
+func newTestSigningHandler(t *testing.T) (*simpleTransactionHandler, *ffcapimocks.API, *ffcapimocks.TransactionSigner) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `12345`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	mockSigner := ffcapimocks.NewTransactionSigner(t)
+	tk.Connector = &testSigningConnector{API: mockFFCAPI, TransactionSigner: mockSigner}
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	return sth, mockFFCAPI, mockSigner
+}
+
+func newTestSigningTX() *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID: "ns1:" + fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+			Nonce: fftypes.NewFFBigInt(42),
+			Gas:   fftypes.NewFFBigInt(100000),
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+		Status:          apitypes.TxStatusPending,
+	}
+}
+
+func TestSignBeforeFirstSubmit(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSignRequest) bool {
+		return req.TransactionData == "SOME_RAW_TX_BYTES" && req.Nonce.Int64() == 42 && req.GasPrice.String() == `12345`
+	})).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "SIGNED_TX_BYTES" && req.PreSigned
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.Equal(t, "0x01020304", *rc.TXUpdates.TransactionHash)
+	assert.NotNil(t, rc.TXUpdates.FirstSubmit)
+	assert.Equal(t, apitypes.TxSubStatusTracking, rc.SubStatus)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignRecordsHashWhenSendFails(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("timeout"))
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "timeout", err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.Equal(t, "0x01020304", *rc.TXUpdates.TransactionHash)
+	assert.Nil(t, mtx.FirstSubmit)
+	assert.True(t, rc.UpdatedInfo)
+	assert.Equal(t, "SIGNED_TX_BYTES", rc.Info.UnsentSubmission.SignedTransaction)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignResendsUnsentAfterLostSend(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("timeout")).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "SIGNED_TX_BYTES" && req.PreSigned && req.GasPrice.String() == `12345`
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x01020304",
+	}, ffcapi.ErrorReason(""), nil).Once()
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "timeout", err)
+
+	// The gas price has moved, but the transaction we signed might still be on its way to the chain
+	sth.fixedGasPrice = fftypes.JSONAnyPtr(`23456`)
+	info := rc.Info
+	rc = newTestRunContext(mtx, nil)
+	rc.Info = info
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01020304", mtx.TransactionHash)
+	assert.Equal(t, `12345`, mtx.GasPrice.String())
+	assert.Nil(t, rc.Info.UnsentSubmission)
+	assert.True(t, rc.UpdatedInfo)
+	assert.NotNil(t, rc.TXUpdates.FirstSubmit)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignEscalatedResubmitAfterLostSend(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	sth.resubmitInterval = 0
+	sth.gasEscalationPercentage = 10
+	mtx := newTestSigningTX()
+	mtx.FirstSubmit = fftypes.Now()
+	mtx.GasPrice = fftypes.JSONAnyPtr(`12345`)
+	mtx.TransactionHash = "0x01020304"
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSignRequest) bool {
+		return req.GasPrice.String() == `13580`
+	})).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "ESCALATED_TX_BYTES",
+		TransactionHash:   "0x05060708",
+	}, ffcapi.ErrorReason(""), nil).Once()
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "ESCALATED_TX_BYTES" && req.PreSigned
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x05060708",
+	}, ffcapi.ErrorReason(""), nil).Once()
+
+	rc := newTestRunContext(mtx, nil)
+	rc.Info.UnsentSubmission = &unsentSubmission{
+		TransactionHash:   "0x01020304",
+		SignedTransaction: "SIGNED_TX_BYTES",
+		GasPrice:          fftypes.JSONAnyPtr(`12345`),
+	}
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x05060708", *rc.TXUpdates.TransactionHash)
+	assert.Equal(t, `13580`, mtx.GasPrice.String())
+	assert.Nil(t, rc.Info.UnsentSubmission)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignRejectedSendClearsUnsent(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonInsufficientFunds, fmt.Errorf("pop"))
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, rc.Info.UnsentSubmission)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignKnownTransactionAfterLostSend(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorKnownTransaction, fmt.Errorf("Known transaction"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionReceiptRequest) bool {
+		return req.TransactionHash == "0x01020304"
+	})).Return(&ffcapi.TransactionReceiptResponse{}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, Update, rc.UpdateType)
+	assert.Equal(t, "0x01020304", mtx.TransactionHash)
+	assert.NotNil(t, rc.TXUpdates.LastSubmit)
+	assert.NotNil(t, rc.TXUpdates.FirstSubmit)
+	assert.Equal(t, apitypes.TxSubStatusTracking, rc.SubStatus)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignNonceTooLowNotMined(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorReasonNonceTooLow, fmt.Errorf("nonce too low"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorReasonNotFound, fmt.Errorf("not found"))
+
+	// A different transaction might hold the nonce, so this is not treated as a submission
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "nonce too low", err)
+	assert.Equal(t, "0x01020304", mtx.TransactionHash)
+	assert.Nil(t, mtx.LastSubmit)
+	assert.Nil(t, mtx.FirstSubmit)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignKnownTransactionReceiptFail(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorKnownTransaction, fmt.Errorf("Known transaction"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).
+		Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, mtx.FirstSubmit)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSignedHashTrackedWhenSendFails(t *testing.T) {
+	sth, mockFFCAPI, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{}`)
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSignResponse{
+		SignedTransaction: "SIGNED_TX_BYTES",
+		TransactionHash:   "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("timeout"))
+	mp := sth.toolkit.TXPersistence.(*persistencemocks.Persistence)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.TransactionHash == "0x01020304" && updates.FirstSubmit == nil
+	})).Return(nil)
+	meh := &txhandlermocks.ManagedTxEventHandler{}
+	sth.toolkit.EventHandler = meh
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0x01020304"
+	})).Return(nil).Once()
+
+	// The signed transaction might have reached the node, so its receipt is watched for straight away
+	pending := &pendingState{mtx: mtx, info: &simplePolicyInfo{}, subStatus: apitypes.TxSubStatusReceived}
+	err := sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01020304", pending.trackingTransactionHash)
+	assert.Nil(t, mtx.FirstSubmit)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestSignFail(t *testing.T) {
+	sth, _, mockSigner := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockSigner.On("TransactionSign", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	rc := newTestRunContext(mtx, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "pop", err)
+	assert.Empty(t, mtx.TransactionHash)
+	assert.Nil(t, rc.TXUpdates.TransactionHash)
+}
+
+func TestSignResendsUnsentRestoresHash(t *testing.T) {
+	sth, mockFFCAPI, _ := newTestSigningHandler(t)
+	mtx := newTestSigningTX()
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.TransactionData == "SIGNED_TX_BYTES" && req.PreSigned
+	})).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x01020304",
+	}, ffcapi.ErrorReason(""), nil)
+
+	rc := newTestRunContext(mtx, nil)
+	rc.Info.UnsentSubmission = &unsentSubmission{
+		TransactionHash:   "0x01020304",
+		SignedTransaction: "SIGNED_TX_BYTES",
+		GasPrice:          fftypes.JSONAnyPtr(`12345`),
+	}
+	err := sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01020304", *rc.TXUpdates.TransactionHash)
+
+	mockFFCAPI.AssertExpectations(t)
+}
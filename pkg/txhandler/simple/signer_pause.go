@@ -0,0 +1,223 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+const metricsGaugeSignersPaused = "tx_signers_paused_total"
+const metricsGaugeSignersPausedDescription = "Number of signers paused after a transaction was rejected for insufficient funds"
+
+// signerPause holds back every submission for a signer, after the node rejected one of its transactions for
+// insufficient funds. The balance of the signer is checked with a backoff, until it covers the estimated cost.
+type signerPause struct {
+	since            *fftypes.FFTime
+	reason           string
+	txID             string
+	requiredBalance  *big.Int
+	lastBalance      *big.Int
+	lastBalanceCheck *fftypes.FFTime
+	nextBalanceCheck time.Time
+	delay            time.Duration
+}
+
+// estimateTransactionCost returns the most the transaction could take from the balance of the signer,
+// which is the value plus the fee, or nil if the fee is unknown
+func estimateTransactionCost(mtx *apitypes.ManagedTX) *big.Int {
+	cost := estimateSubmissionFee(mtx)
+	if cost != nil && mtx.Value != nil {
+		cost.Add(cost, mtx.Value.Int())
+	}
+	return cost
+}
+
+func (sth *simpleTransactionHandler) pauseSigner(ctx *RunContext, err error) {
+	mtx := ctx.TX
+	pause := &signerPause{
+		since:           fftypes.Now(),
+		reason:          err.Error(),
+		txID:            mtx.ID,
+		requiredBalance: estimateTransactionCost(mtx),
+		delay:           sth.balanceCheckRetry.InitialDelay,
+	}
+	pause.nextBalanceCheck = pause.since.Time().Add(pause.delay)
+
+	sth.signerPauseMux.Lock()
+	if sth.pausedSigners == nil {
+		sth.pausedSigners = make(map[string]*signerPause)
+	}
+	sth.pausedSigners[mtx.From] = pause
+	pausedCount := len(sth.pausedSigners)
+	sth.signerPauseMux.Unlock()
+
+	log.L(ctx).Warnf("Paused submission for signer %s after transaction %s at nonce %d was rejected for insufficient funds (required balance %s)", mtx.From, mtx.ID, mtx.Nonce.Int64(), pause.requiredBalance)
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "signer_paused")
+	sth.setSignersPausedMetric(ctx, pausedCount)
+	ctx.SetSubStatus(apitypes.TxSubStatusAwaitingFunds)
+	ctx.AddSubStatusAction(apitypes.TxActionSignerPaused, pause.info(mtx.From), nil, fftypes.Now())
+}
+
+func (pause *signerPause) info(signer string) *fftypes.JSONAny {
+	info := map[string]interface{}{
+		"signer":   signer,
+		"pausedBy": pause.txID,
+	}
+	if pause.requiredBalance != nil {
+		info["requiredBalance"] = pause.requiredBalance.String()
+	}
+	b, _ := json.Marshal(info)
+	return fftypes.JSONAnyPtrBytes(b)
+}
+
+// holdForPausedSigner returns true if the signer of the transaction is paused, recording why in the history
+// when the transaction is first held
+func (sth *simpleTransactionHandler) holdForPausedSigner(ctx *RunContext) bool {
+	mtx := ctx.TX
+	sth.signerPauseMux.Lock()
+	pause := sth.pausedSigners[mtx.From]
+	var info *fftypes.JSONAny
+	if pause != nil {
+		info = pause.info(mtx.From)
+	}
+	sth.signerPauseMux.Unlock()
+	if pause == nil {
+		return false
+	}
+	log.L(ctx).Debugf("Transaction %s at nonce %s / %d held back, as the signer is paused for insufficient funds", mtx.ID, mtx.From, mtx.Nonce.Int64())
+	ctx.setHeldSubStatus(apitypes.TxSubStatusAwaitingFunds, apitypes.TxActionSignerPaused, info, nil)
+	return true
+}
+
+// checkPausedSigners queries the balance of each paused signer that is due a check
+func (sth *simpleTransactionHandler) checkPausedSigners(ctx context.Context) {
+	now := time.Now()
+	sth.signerPauseMux.Lock()
+	due := make([]string, 0)
+	for signer, pause := range sth.pausedSigners {
+		if !now.Before(pause.nextBalanceCheck) {
+			due = append(due, signer)
+		}
+	}
+	sth.signerPauseMux.Unlock()
+
+	for _, signer := range due {
+		sth.checkSignerBalance(ctx, signer)
+	}
+}
+
+func (sth *simpleTransactionHandler) checkSignerBalance(ctx context.Context, signer string) {
+	res, _, err := sth.toolkit.Connector.AddressBalance(ctx, &ffcapi.AddressBalanceRequest{
+		Address:  signer,
+		BlockTag: "latest",
+	})
+	var balance *big.Int
+	if err != nil {
+		log.L(ctx).Warnf("Balance check failed for paused signer %s: %s", signer, err)
+	} else if res.Balance != nil {
+		balance = res.Balance.Int()
+	}
+
+	sth.signerPauseMux.Lock()
+	pause := sth.pausedSigners[signer]
+	pause.lastBalanceCheck = fftypes.Now()
+	covered := false
+	switch {
+	case balance == nil:
+	case pause.requiredBalance != nil:
+		covered = balance.Cmp(pause.requiredBalance) >= 0
+	default:
+		// Without an estimate of the cost, resume when the balance goes up
+		covered = pause.lastBalance != nil && balance.Cmp(pause.lastBalance) > 0
+	}
+	if balance != nil {
+		pause.lastBalance = balance
+	}
+	if !covered {
+		pause.delay = sth.nextBalanceCheckDelay(pause.delay)
+		pause.nextBalanceCheck = time.Now().Add(pause.delay)
+		log.L(ctx).Infof("Balance %s of paused signer %s does not cover the required balance %s - next check in %s", balance, signer, pause.requiredBalance, pause.delay)
+		sth.signerPauseMux.Unlock()
+		return
+	}
+	delete(sth.pausedSigners, signer)
+	pausedCount := len(sth.pausedSigners)
+	sth.signerPauseMux.Unlock()
+
+	log.L(ctx).Infof("Resumed submission for signer %s with balance %s", signer, balance)
+	sth.incTransactionOperationCounter(ctx, "", "signer_resumed")
+	sth.setSignersPausedMetric(ctx, pausedCount)
+	sth.recordSignerResumed(ctx, signer, balance)
+	sth.markInflightUpdate()
+}
+
+func (sth *simpleTransactionHandler) nextBalanceCheckDelay(delay time.Duration) time.Duration {
+	factor := sth.balanceCheckRetry.Factor
+	if factor <= 0 {
+		factor = defaultBalanceCheckFactor
+	}
+	delay = time.Duration(float64(delay) * factor)
+	if maxDelay := sth.balanceCheckRetry.MaximumDelay; maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+// recordSignerResumed adds to the history of each in-flight transaction that was held back for the signer
+func (sth *simpleTransactionHandler) recordSignerResumed(ctx context.Context, signer string, balance *big.Int) {
+	info := fftypes.JSONAnyPtr(`{"signer":"` + signer + `","balance":"` + balance.String() + `"}`)
+	sth.inflightRWMux.RLock()
+	defer sth.inflightRWMux.RUnlock()
+	for _, pending := range sth.inflight {
+		pending.mux.Lock()
+		subStatus := pending.subStatus
+		pending.mux.Unlock()
+		if pending.mtx.From == signer && subStatus == apitypes.TxSubStatusAwaitingFunds {
+			if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, pending.mtx.ID, apitypes.TxSubStatusAwaitingFunds, apitypes.TxActionSignerResumed, info, nil, fftypes.Now()); err != nil {
+				log.L(ctx).Errorf("Failed to record resume of signer %s for transaction %s: %s", signer, pending.mtx.ID, err)
+			}
+		}
+	}
+}
+
+// SignerPause reports the pause of a signer, and the balance checks made since
+func (sth *simpleTransactionHandler) SignerPause(_ context.Context, signer string) *apitypes.SignerPause {
+	sth.signerPauseMux.Lock()
+	defer sth.signerPauseMux.Unlock()
+	pause := sth.pausedSigners[signer]
+	if pause == nil {
+		return nil
+	}
+	nextBalanceCheck := fftypes.FFTime(pause.nextBalanceCheck)
+	return &apitypes.SignerPause{
+		Reason:           pause.reason,
+		Since:            pause.since,
+		TransactionID:    pause.txID,
+		RequiredBalance:  (*fftypes.FFBigInt)(pause.requiredBalance),
+		LastBalance:      (*fftypes.FFBigInt)(pause.lastBalance),
+		LastBalanceCheck: pause.lastBalanceCheck,
+		NextBalanceCheck: &nextBalanceCheck,
+	}
+}
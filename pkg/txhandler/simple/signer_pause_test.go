@@ -0,0 +1,194 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/retry"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/ffcapimocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testPausedSigner = "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712"
+
+func newTestSignerPauseHandler(t *testing.T) (*simpleTransactionHandler, *persistencemocks.Persistence, *ffcapimocks.API) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `10`)
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+	return sth, tk.TXHistory.(*persistencemocks.Persistence), mockFFCAPI
+}
+
+func newTestSignerPauseTX(from string) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID: fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  from,
+			Gas:   fftypes.NewFFBigInt(100),
+			Nonce: fftypes.NewFFBigInt(1),
+			Value: fftypes.NewFFBigInt(5),
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+	}
+}
+
+func TestInsufficientFundsPausesSigner(t *testing.T) {
+	sth, mp, mockFFCAPI := newTestSignerPauseHandler(t)
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonInsufficientFunds, fmt.Errorf("insufficient funds")).Once()
+
+	mtx1 := newTestSignerPauseTX(testPausedSigner)
+	rc := newTestRunContext(mtx1, nil)
+	err := sth.processTransaction(rc)
+	assert.Regexp(t, "insufficient funds", err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingFunds, rc.SubStatus)
+	// retrieved gas price, submission failure, signer paused
+	assert.Len(t, rc.HistoryUpdates, 3)
+
+	pause := sth.SignerPause(context.Background(), testPausedSigner)
+	assert.NotNil(t, pause)
+	assert.Equal(t, mtx1.ID, pause.TransactionID)
+	assert.Equal(t, int64(1005), pause.RequiredBalance.Int64())
+	assert.Regexp(t, "insufficient funds", pause.Reason)
+	assert.Nil(t, pause.LastBalanceCheck)
+	assert.Nil(t, sth.SignerPause(context.Background(), "0xanother"))
+
+	// Later transactions for the signer are held back without being submitted
+	mtx2 := newTestSignerPauseTX(testPausedSigner)
+	rc = newTestRunContext(mtx2, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Nil(t, mtx2.FirstSubmit)
+	assert.Nil(t, mtx2.GasPrice)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingFunds, rc.SubStatus)
+	assert.Len(t, rc.HistoryUpdates, 1)
+
+	// The history only records the first cycle the transaction is held for
+	mp.On("AddSubStatusAction", mock.Anything, mtx2.ID, apitypes.TxSubStatusAwaitingFunds, apitypes.TxActionSignerPaused, mock.Anything, (*fftypes.JSONAny)(nil), mock.Anything).Return(nil).Once()
+	pending := &pendingState{mtx: mtx2, info: &simplePolicyInfo{}}
+	err = sth.flushChanges(rc, pending, false)
+	assert.NoError(t, err)
+	err = sth.flushChanges(newTestRunContext(mtx2, nil), pending, false)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingFunds, pending.subStatus)
+	rc, err = sth.pendingToRunContext(context.Background(), pending, nil)
+	assert.NoError(t, err)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingFunds, rc.SubStatus)
+	assert.Empty(t, rc.HistoryUpdates)
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestCheckPausedSignersBackoffAndResume(t *testing.T) {
+	sth, mp, mockFFCAPI := newTestSignerPauseHandler(t)
+
+	mtx := newTestSignerPauseTX(testPausedSigner)
+	mtxOther := newTestSignerPauseTX("0xanother")
+	sth.inflight = []*pendingState{
+		{mtx: mtx, subStatus: apitypes.TxSubStatusAwaitingFunds},
+		{mtx: mtxOther, subStatus: apitypes.TxSubStatusReceived},
+	}
+	sth.pausedSigners = map[string]*signerPause{
+		testPausedSigner: {
+			since:           fftypes.Now(),
+			txID:            mtx.ID,
+			requiredBalance: big.NewInt(1005),
+			delay:           30 * time.Second,
+		},
+		"0xnotdue": {
+			nextBalanceCheck: time.Now().Add(time.Hour),
+		},
+	}
+
+	mockFFCAPI.On("AddressBalance", mock.Anything, &ffcapi.AddressBalanceRequest{
+		Address:  testPausedSigner,
+		BlockTag: "latest",
+	}).Return(&ffcapi.AddressBalanceResponse{Balance: fftypes.NewFFBigInt(500)}, ffcapi.ErrorReason(""), nil).Once()
+	sth.checkPausedSigners(context.Background())
+
+	pause := sth.SignerPause(context.Background(), testPausedSigner)
+	assert.Equal(t, int64(500), pause.LastBalance.Int64())
+	assert.NotNil(t, pause.LastBalanceCheck)
+	assert.Equal(t, 60*time.Second, sth.pausedSigners[testPausedSigner].delay)
+	assert.True(t, pause.NextBalanceCheck.Time().After(time.Now().Add(59*time.Second)))
+
+	// Not yet due
+	sth.checkPausedSigners(context.Background())
+
+	mockFFCAPI.On("AddressBalance", mock.Anything, mock.Anything).Return(&ffcapi.AddressBalanceResponse{Balance: fftypes.NewFFBigInt(1005)}, ffcapi.ErrorReason(""), nil).Once()
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, apitypes.TxSubStatusAwaitingFunds, apitypes.TxActionSignerResumed, mock.Anything, (*fftypes.JSONAny)(nil), mock.Anything).Return(fmt.Errorf("pop")).Once()
+	sth.pausedSigners[testPausedSigner].nextBalanceCheck = time.Now()
+	sth.checkPausedSigners(context.Background())
+
+	assert.Nil(t, sth.SignerPause(context.Background(), testPausedSigner))
+	assert.NotNil(t, sth.SignerPause(context.Background(), "0xnotdue"))
+
+	mockFFCAPI.AssertExpectations(t)
+	mp.AssertExpectations(t)
+}
+
+func TestCheckPausedSignersUnknownCost(t *testing.T) {
+	sth, _, mockFFCAPI := newTestSignerPauseHandler(t)
+	sth.balanceCheckRetry.MaximumDelay = 45 * time.Second
+
+	sth.pausedSigners = map[string]*signerPause{
+		testPausedSigner: {
+			since: fftypes.Now(),
+			delay: 30 * time.Second,
+		},
+	}
+
+	// A failed balance check backs off
+	mockFFCAPI.On("AddressBalance", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop")).Once()
+	sth.checkPausedSigners(context.Background())
+	assert.Nil(t, sth.pausedSigners[testPausedSigner].lastBalance)
+	assert.Equal(t, 45*time.Second, sth.pausedSigners[testPausedSigner].delay)
+
+	// Without a cost estimate, the first balance only sets the baseline
+	mockFFCAPI.On("AddressBalance", mock.Anything, mock.Anything).Return(&ffcapi.AddressBalanceResponse{Balance: fftypes.NewFFBigInt(100)}, ffcapi.ErrorReason(""), nil).Once()
+	sth.pausedSigners[testPausedSigner].nextBalanceCheck = time.Now()
+	sth.checkPausedSigners(context.Background())
+	assert.NotNil(t, sth.pausedSigners[testPausedSigner])
+
+	mockFFCAPI.On("AddressBalance", mock.Anything, mock.Anything).Return(&ffcapi.AddressBalanceResponse{Balance: fftypes.NewFFBigInt(101)}, ffcapi.ErrorReason(""), nil).Once()
+	sth.pausedSigners[testPausedSigner].nextBalanceCheck = time.Now()
+	sth.checkPausedSigners(context.Background())
+	assert.Empty(t, sth.pausedSigners)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestNextBalanceCheckDelayDefaultFactor(t *testing.T) {
+	sth := &simpleTransactionHandler{balanceCheckRetry: &retry.Retry{}}
+	assert.Equal(t, 2*time.Second, sth.nextBalanceCheckDelay(time.Second))
+}
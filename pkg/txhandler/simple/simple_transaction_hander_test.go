@@ -118,6 +118,7 @@ func TestSupportDeprecatedPolicyEngineConfiguration(t *testing.T) {
 	f, _, _, conf := newTestTransactionHandlerFactory(t)
 	viper.SetDefault(string(tmconfig.TransactionsHandlerName), "")
 	viper.SetDefault(string(tmconfig.DeprecatedTransactionsMaxInFlight), 23412412)
+	f.InitConfig(tmconfig.TransactionHandlerBaseConfig.SubSection(f.Name()))
 
 	conf.Set(FixedGasPrice, `12345`)
 
@@ -128,6 +129,32 @@ func TestSupportDeprecatedPolicyEngineConfiguration(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDeprecatedPolicyEngineConfigurationReadsFeatureSettings(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	viper.SetDefault(string(tmconfig.TransactionsHandlerName), "")
+	handlerConf := tmconfig.TransactionHandlerBaseConfig.SubSection(f.Name())
+	f.InitConfig(handlerConf)
+
+	// The policy loop uses the deprecated settings, while the features are configured in the handler section
+	conf.Set(FixedGasPrice, `12345`)
+	handlerConf.SubSection(GasEscalationConfig).Set(GasEscalationPercentage, 10)
+	handlerConf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "2500")
+	handlerConf.SubSection(ApprovalConfig).Set(ApprovalSigners, []string{"0xAAAA"})
+
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	sth := th.(*simpleTransactionHandler)
+	assert.Equal(t, `12345`, sth.fixedGasPrice.String())
+	assert.Equal(t, float64(10), sth.gasEscalationPercentage)
+	assert.Equal(t, int64(2500), sth.spendGuardNamespaceMaxFee.Int64())
+	assert.True(t, sth.approvalSigners["0xaaaa"])
+
+	handlerConf.SubSection(CancelConfig).Set(CancelMode, "wrong")
+	_, err = f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21088", err)
+}
+
 func TestMissingGasConfig(t *testing.T) {
 	f, _, _, conf := newTestTransactionHandlerFactory(t)
 	conf.SubSection(GasOracleConfig).Set(GasOracleMode, GasOracleModeDisabled)
@@ -165,10 +192,15 @@ func TestFixedGasPriceOK(t *testing.T) {
 	mmm := &metricsmocks.TransactionHandlerMetrics{}
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightUsed, metricsGaugeTransactionsInflightUsedDescription, false).Return(fmt.Errorf("fail")).Once()
 	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeTransactionsInflightFree, metricsGaugeTransactionsInflightFreeDescription, false).Return(fmt.Errorf("fail")).Once()
+	mmm.On("InitTxHandlerGaugeMetric", mock.Anything, metricsGaugeSignersPaused, metricsGaugeSignersPausedDescription, false).Return(fmt.Errorf("fail")).Once()
 	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, metricsCounterTransactionProcessOperationsTotalDescription, []string{metricsLabelNameOperation}, true).Return(fmt.Errorf("fail")).Once()
 	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, metricsHistogramTransactionProcessOperationsDurationDescription, []float64{}, []string{metricsLabelNameOperation}, true).Return(fmt.Errorf("fail")).Once()
+	mmm.On("InitTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, metricsCounterPolicyWorkerTransactionsTotalDescription, []string{metricsLabelNameWorker}, false).Return(fmt.Errorf("fail")).Once()
+	mmm.On("InitTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, metricsHistogramPolicyWorkerDurationDescription, []float64{}, []string{metricsLabelNameWorker}, false).Return(fmt.Errorf("fail")).Once()
 	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterTransactionProcessOperationsTotal, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramTransactionProcessOperationsDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("IncTxHandlerCounterMetricWithLabels", mock.Anything, metricsCounterPolicyWorkerTransactionsTotal, mock.Anything, mock.Anything).Return().Maybe()
+	mmm.On("ObserveTxHandlerHistogramMetricWithLabels", mock.Anything, metricsHistogramPolicyWorkerDuration, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
 
 	tk.MetricsManager = mmm
 
@@ -651,6 +683,8 @@ func TestKnownTransactionHashKnown(t *testing.T) {
 
 	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).
 		Return(nil, ffcapi.ErrorKnownTransaction, fmt.Errorf("Known transaction"))
+	mockFFCAPI.On("TransactionReceipt", mock.Anything, mock.Anything).
+		Return(&ffcapi.TransactionReceiptResponse{}, ffcapi.ErrorReason(""), nil)
 
 	ctx := context.Background()
 	th.Init(ctx, tk)
@@ -839,7 +873,7 @@ func TestSendTXPersistFail(t *testing.T) {
 	err = json.Unmarshal([]byte(sampleSendTX), &txReq)
 	assert.NoError(t, err)
 
-	_, err = sth.createManagedTx(sth.ctx, "id1", &txReq.TransactionHeaders, fftypes.NewFFBigInt(12345), "0x123456")
+	_, err = sth.createManagedTx(sth.ctx, "id1", &txReq.TransactionHeaders, fftypes.NewFFBigInt(12345), "0x123456", nil)
 	assert.Regexp(t, "pop", err)
 
 }
@@ -875,7 +909,7 @@ func TestSendGetNextNonceFail(t *testing.T) {
 	err = json.Unmarshal([]byte(sampleSendTX), &txReq)
 	assert.NoError(t, err)
 
-	_, err = sth.createManagedTx(sth.ctx, "id1", &txReq.TransactionHeaders, fftypes.NewFFBigInt(12345), "0x123456")
+	_, err = sth.createManagedTx(sth.ctx, "id1", &txReq.TransactionHeaders, fftypes.NewFFBigInt(12345), "0x123456", nil)
 	assert.Regexp(t, "pop", err)
 
 }
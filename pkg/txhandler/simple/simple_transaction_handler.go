@@ -17,17 +17,14 @@
 package simple
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"html/template"
+	"errors"
+	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
-	"github.com/Masterminds/sprig/v3"
-	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger/firefly-common/pkg/config"
-	"github.com/hyperledger/firefly-common/pkg/ffresty"
 	"github.com/hyperledger/firefly-common/pkg/fftypes"
 	"github.com/hyperledger/firefly-common/pkg/i18n"
 	"github.com/hyperledger/firefly-common/pkg/log"
@@ -67,6 +64,10 @@ type RunContext struct {
 	Confirmations *apitypes.ConfirmationsNotification
 	Confirmed     bool
 	SyncAction    policyEngineAPIRequestType
+	speedUp       *apitypes.SpeedUpTransactionRequest
+	approver      string
+	// the sub-status the transaction was left in by an earlier run
+	previousSubStatus apitypes.TxSubStatus
 	// Input/output
 	SubStatus apitypes.TxSubStatus
 	Info      *simplePolicyInfo // must be updated in-place and set UpdatedInfo to true as well as UpdateType = Update
@@ -81,6 +82,16 @@ func (ctx *RunContext) SetSubStatus(subStatus apitypes.TxSubStatus) {
 	ctx.SubStatus = subStatus
 }
 
+// setHeldSubStatus records the sub-status of a transaction that is being held back. The action is only added to the
+// history when the transaction enters the sub-status, rather than on every policy cycle it is held for.
+func (ctx *RunContext) setHeldSubStatus(subStatus apitypes.TxSubStatus, action apitypes.TxAction, info *fftypes.JSONAny, err *fftypes.JSONAny) {
+	entered := ctx.previousSubStatus != subStatus
+	ctx.SetSubStatus(subStatus)
+	if entered {
+		ctx.AddSubStatusAction(action, info, err, fftypes.Now())
+	}
+}
+
 func (ctx *RunContext) AddSubStatusAction(action apitypes.TxAction, info *fftypes.JSONAny, err *fftypes.JSONAny, actionOccurred *fftypes.FFTime) {
 	subStatus := ctx.SubStatus // capture at time of action
 	ctx.HistoryUpdates = append(ctx.HistoryUpdates, func(p txhandler.TransactionHistoryPersistence) error {
@@ -96,6 +107,7 @@ func (f *TransactionHandlerFactory) Name() string {
 
 // simpleTransactionHandler is a base transaction handler forming an example for extension:
 // - It offers three ways of calculating gas price: use a fixed number, use the built-in API of a ethereum connector, use a RESTful gas oracle
+// - It can fall back between multiple gas oracles, or take the median or max of their gas prices
 // - It resubmits the transaction based on a configured interval until it succeed or fail
 func (f *TransactionHandlerFactory) NewTransactionHandler(ctx context.Context, conf config.Section) (txhandler.TransactionHandler, error) {
 	gasOracleConfig := conf.SubSection(GasOracleConfig)
@@ -103,17 +115,19 @@ func (f *TransactionHandlerFactory) NewTransactionHandler(ctx context.Context, c
 		resubmitInterval: conf.GetDuration(ResubmitInterval),
 		fixedGasPrice:    fftypes.JSONAnyPtr(conf.GetString(FixedGasPrice)),
 
-		gasOracleMethod:        gasOracleConfig.GetString(GasOracleMethod),
-		gasOracleQueryInterval: gasOracleConfig.GetDuration(GasOracleQueryInterval),
-		gasOracleMode:          gasOracleConfig.GetString(GasOracleMode),
-
 		inflightStale:  make(chan bool, 1),
 		inflightUpdate: make(chan bool, 1),
+		policyWorkers:  defaultPolicyWorkers,
 	}
 
+	// The features of the handler only have settings in the 'transactions.handler' section, so they are read from
+	// there with the deprecated configuration too
+	handlerConf := conf
+
 	// check whether we are using deprecated configuration
 	if config.GetString(tmconfig.TransactionsHandlerName) == "" {
 		log.L(ctx).Warnf("Initializing transaction handler with deprecated configurations. Please use 'transactions.handler' instead")
+		handlerConf = tmconfig.TransactionHandlerBaseConfig.SubSection(f.Name())
 		sth.maxInFlight = config.GetInt(tmconfig.DeprecatedTransactionsMaxInFlight)
 		sth.policyLoopInterval = config.GetDuration(tmconfig.DeprecatedPolicyLoopInterval)
 		sth.retry = &retry.Retry{
@@ -132,29 +146,120 @@ func (f *TransactionHandlerFactory) NewTransactionHandler(ctx context.Context, c
 		}
 	}
 
-	switch sth.gasOracleMode {
-	case GasOracleModeConnector:
-		// No initialization required
-	case GasOracleModeRESTAPI:
-		goc, err := ffresty.New(ctx, gasOracleConfig)
-		if err != nil {
-			return nil, err
+	handlerGasOracleConfig := handlerConf.SubSection(GasOracleConfig)
+	if workers := handlerConf.GetInt(PolicyWorkers); workers > 1 {
+		sth.policyWorkers = workers
+	}
+	sth.clientSuppliedNonces = handlerConf.GetBool(ClientSuppliedNonces)
+	gasEscalationConfig := handlerConf.SubSection(GasEscalationConfig)
+	sth.gasEscalationPercentage = gasEscalationConfig.GetFloat64(GasEscalationPercentage)
+	maxGasPrice, err := parseMaxGasPrice(ctx, gasEscalationConfig.GetString(GasEscalationMaxGasPrice))
+	if err != nil {
+		return nil, err
+	}
+	sth.gasEscalationMaxGasPrice = maxGasPrice
+	spendGuardConfig := handlerConf.SubSection(SpendGuardConfig)
+	if sth.spendGuardMaxGasPrice, err = parseSpendLimit(ctx, SpendGuardMaxGasPrice, spendGuardConfig.GetString(SpendGuardMaxGasPrice)); err != nil {
+		return nil, err
+	}
+	if sth.spendGuardNamespaceMaxFee, err = parseSpendLimit(ctx, SpendGuardNamespaceMaxFee, spendGuardConfig.GetString(SpendGuardNamespaceMaxFee)); err != nil {
+		return nil, err
+	}
+	sth.spendGuardNamespaceWindow = spendGuardConfig.GetDuration(SpendGuardNamespaceWindow)
+	schedulingConfig := handlerConf.SubSection(SchedulingConfig)
+	sth.fairScheduling = schedulingConfig.GetBool(SchedulingFair)
+	sth.maxInFlightPerSigner = schedulingConfig.GetInt(SchedulingMaxInFlightPerSigner)
+	sth.pendingScanLimit = schedulingConfig.GetInt(SchedulingPendingScanLimit)
+	namespaceWeights := initNamespaceWeightsConfig(schedulingConfig)
+	weightCount := namespaceWeights.ArraySize()
+	for i := 0; i < weightCount; i++ {
+		weightConfig := namespaceWeights.ArrayEntry(i)
+		namespace := weightConfig.GetString(SchedulingNamespace)
+		weight := weightConfig.GetInt(SchedulingWeight)
+		if weight <= 0 {
+			return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidNamespaceWeight, weight, namespace)
 		}
-		sth.gasOracleClient = goc
-		templateString := gasOracleConfig.GetString(GasOracleTemplate)
-		if templateString == "" {
-			return nil, i18n.NewError(ctx, tmmsgs.MsgMissingGOTemplate)
+		if sth.namespaceWeights == nil {
+			sth.namespaceWeights = make(map[string]int)
 		}
-		template, err := template.New("").Funcs(sprig.FuncMap()).Parse(templateString)
+		sth.namespaceWeights[namespace] = weight
+	}
+	priorityConfig := handlerConf.SubSection(PriorityConfig)
+	sth.priorityScheduling = priorityConfig.GetBool(PriorityScheduling)
+	if sth.priorityGasPrices, err = parsePriorityGasPrices(ctx, initPriorityGasPriceConfig(priorityConfig)); err != nil {
+		return nil, err
+	}
+	approvalConfig := handlerConf.SubSection(ApprovalConfig)
+	sth.approvalSigners = toLowerSet(approvalConfig.GetStringSlice(ApprovalSigners))
+	sth.approvalNamespaces = toLowerSet(approvalConfig.GetStringSlice(ApprovalNamespaces))
+	sth.approverHeader = approvalConfig.GetString(ApprovalApproverHeader)
+	if sth.policyHook, err = newPolicyHook(ctx, handlerConf.SubSection(PolicyHookConfig)); err != nil {
+		return nil, err
+	}
+	balanceCheckConfig := handlerConf.SubSection(BalanceCheckConfig)
+	sth.balanceCheckRetry = &retry.Retry{
+		InitialDelay: balanceCheckConfig.GetDuration(BalanceCheckInitialDelay),
+		MaximumDelay: balanceCheckConfig.GetDuration(BalanceCheckMaxDelay),
+		Factor:       balanceCheckConfig.GetFloat64(BalanceCheckFactor),
+	}
+	cancelConfig := handlerConf.SubSection(CancelConfig)
+	sth.cancelMode = cancelConfig.GetString(CancelMode)
+	sth.cancelGasBumpPercentage = cancelConfig.GetFloat64(CancelGasBumpPercentage)
+	switch sth.cancelMode {
+	case CancelModeDelete, CancelModeReplace:
+	default:
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidCancelMode, sth.cancelMode)
+	}
+	nonceGapCheckConfig := handlerConf.SubSection(NonceGapCheckConfig)
+	sth.nonceGapCheckEnabled = nonceGapCheckConfig.GetBool(NonceGapCheckEnabled)
+	sth.nonceGapCheckInterval = nonceGapCheckConfig.GetDuration(NonceGapCheckInterval)
+	sth.nonceGapFill = nonceGapCheckConfig.GetBool(NonceGapCheckFill)
+	sth.expirySubmittedStrategy = handlerConf.SubSection(ExpiryConfig).GetString(ExpirySubmittedStrategy)
+	switch sth.expirySubmittedStrategy {
+	case ExpiryStrategyStopTracking, ExpiryStrategyCancel:
+	default:
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidExpiryStrategy, sth.expirySubmittedStrategy)
+	}
+	sth.gasOracleAggregation = handlerGasOracleConfig.GetString(GasOracleAggregation)
+	switch sth.gasOracleAggregation {
+	case GasOracleAggregationFirstSuccess, GasOracleAggregationMedian, GasOracleAggregationMax:
+	default:
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidGasOracleAggregation, sth.gasOracleAggregation)
+	}
+	sth.gasPriceHistorySize = handlerGasOracleConfig.GetInt(GasOracleHistorySize)
+	sources := initGasOracleSourcesConfig(handlerGasOracleConfig)
+	sourceCount := sources.ArraySize()
+	for i := 0; i < sourceCount; i++ {
+		sourceConfig := sources.ArrayEntry(i)
+		name := sourceConfig.GetString(GasOracleSourceName)
+		if name == "" {
+			name = fmt.Sprintf("source%d", i)
+		}
+		source, err := newGasOracleSource(ctx, name, sourceConfig, sourceConfig.SubSection(GasOracleSourceHTTP))
 		if err != nil {
-			return nil, i18n.NewError(ctx, tmmsgs.MsgBadGOTemplate, err)
+			return nil, err
 		}
-		sth.gasOracleTemplate = template
-	default:
-		if sth.fixedGasPrice.IsNil() {
-			return nil, i18n.NewError(ctx, tmmsgs.MsgNoGasConfigSetForTransactionHandler)
+		source.maxFailures = sourceConfig.GetInt(GasOracleSourceMaxFailures)
+		source.recoveryInterval = sourceConfig.GetDuration(GasOracleSourceRecoveryInterval)
+		sth.gasOracleSources = append(sth.gasOracleSources, source)
+	}
+
+	if len(sth.gasOracleSources) == 0 {
+		// A single gas oracle configured directly in the gasOracle section, which is never dropped
+		switch mode := gasOracleConfig.GetString(GasOracleMode); mode {
+		case GasOracleModeConnector, GasOracleModeRESTAPI:
+			source, err := newGasOracleSource(ctx, mode, gasOracleConfig, gasOracleConfig)
+			if err != nil {
+				return nil, err
+			}
+			sth.gasOracleSources = []*gasOracleSource{source}
+		default:
+			if sth.fixedGasPrice.IsNil() {
+				return nil, i18n.NewError(ctx, tmmsgs.MsgNoGasConfigSetForTransactionHandler)
+			}
 		}
 	}
+	sth.initPolicyWorkers()
 	return sth, nil
 }
 
@@ -164,13 +269,37 @@ type simpleTransactionHandler struct {
 	fixedGasPrice    *fftypes.JSONAny
 	resubmitInterval time.Duration
 
-	gasOracleMode          string
-	gasOracleClient        *resty.Client
-	gasOracleMethod        string
-	gasOracleTemplate      *template.Template
-	gasOracleQueryInterval time.Duration
-	gasOracleQueryValue    *fftypes.JSONAny
-	gasOracleLastQueryTime *fftypes.FFTime
+	gasOracleSources     []*gasOracleSource
+	gasOracleAggregation string
+	gasOracleMux         sync.Mutex
+	gasPriceHistory      []*apitypes.GasPriceHistoryEntry
+	gasPriceHistorySize  int
+
+	gasEscalationPercentage  float64
+	gasEscalationMaxGasPrice *big.Int
+
+	cancelMode              string
+	cancelGasBumpPercentage float64
+
+	nonceGapCheckEnabled  bool
+	nonceGapCheckInterval time.Duration
+	nonceGapFill          bool
+	nonceGapLastCheck     time.Time
+	nonceGapCheckDone     chan struct{} // closed when the nonce gap check in progress completes
+
+	expirySubmittedStrategy string
+
+	spendGuardMaxGasPrice     *big.Int
+	spendGuardNamespaceMaxFee *big.Int
+	spendGuardNamespaceWindow time.Duration
+	spendGuardMux             sync.Mutex
+	namespaceSpend            map[string]map[string]*spendRecord
+
+	balanceCheckRetry *retry.Retry
+	signerPauseMux    sync.Mutex
+	pausedSigners     map[string]*signerPause
+
+	retryMux sync.Mutex
 
 	policyLoopInterval      time.Duration
 	policyLoopDone          chan struct{}
@@ -181,12 +310,32 @@ type simpleTransactionHandler struct {
 	inflight                []*pendingState
 	policyEngineAPIRequests []*policyEngineAPIRequest
 	maxInFlight             int
+	policyWorkers           int
+	policyWorkerIDs         chan int // the idle policy workers, which limit how many signers are run at once
+	signerRunsMux           sync.Mutex
+	signerRuns              map[string]chan struct{} // the signers being run, each closed when the run completes
+	clientSuppliedNonces    bool
 	retry                   *retry.Retry
+
+	fairScheduling       bool
+	maxInFlightPerSigner int
+	pendingScanLimit     int
+	namespaceWeights     map[string]int
+	priorityScheduling   bool
+	priorityGasPrices    []*priorityGasPrice
+
+	approvalSigners    map[string]bool
+	approvalNamespaces map[string]bool
+	approverHeader     string
+
+	policyHook *policyHook
 }
 
 type pendingState struct {
 	mtx                     *apitypes.ManagedTX
 	trackingTransactionHash string
+	trackedHashes           []string // every hash being watched for a receipt, as any submission for the nonce might be mined
+	receiptHash             string
 	lastPolicyCycle         time.Time
 	receipt                 *ffcapi.TransactionReceiptResponse
 	info                    *simplePolicyInfo
@@ -196,13 +345,21 @@ type pendingState struct {
 	confirmNotify           *fftypes.FFTime
 	remove                  bool
 	subStatus               apitypes.TxSubStatus
+	speedUp                 *apitypes.SpeedUpTransactionRequest
+	approver                string
 	// This mutex only works in a slice when the slice contains a pointer to this struct
 	// appends to a slice copy memory but when storing pointers it does not
 	mux sync.Mutex
 }
 
 type simplePolicyInfo struct {
-	LastWarnTime *fftypes.FFTime `json:"lastWarnTime"`
+	LastWarnTime      *fftypes.FFTime        `json:"lastWarnTime"`
+	CancelReplacement *cancelReplacementInfo `json:"cancelReplacement,omitempty"`
+	External          bool                   `json:"external,omitempty"`      // submitted by other tooling, so we do not have the transaction data to resubmit
+	PolicyAllowed     bool                   `json:"policyAllowed,omitempty"` // the policy hook allowed the transaction to be submitted
+	PolicyDenied      bool                   `json:"policyDenied,omitempty"`  // the policy hook denied the transaction, so it cannot be retried
+	RejectedBy        string                 `json:"rejectedBy,omitempty"`    // the approver that rejected the transaction, so it cannot be retried
+	UnsentSubmission  *unsentSubmission      `json:"unsentSubmission,omitempty"`
 }
 
 func (sth *simpleTransactionHandler) Init(ctx context.Context, toolkit *txhandler.Toolkit) {
@@ -214,6 +371,12 @@ func (sth *simpleTransactionHandler) Init(ctx context.Context, toolkit *txhandle
 
 func (sth *simpleTransactionHandler) Start(ctx context.Context) (done <-chan struct{}, err error) {
 	if sth.ctx == nil { // only start once
+		if sth.spendGuardNamespaceMaxFee != nil {
+			// Submissions made before a restart still count against the namespace limits
+			if err := sth.loadNamespaceSpend(ctx); err != nil {
+				return nil, err
+			}
+		}
 		sth.ctx = ctx // set the context for policy loop
 		sth.policyLoopDone = make(chan struct{})
 		sth.markInflightStale()
@@ -246,10 +409,37 @@ func (sth *simpleTransactionHandler) requestIDPreCheck(ctx context.Context, reqH
 }
 
 func (sth *simpleTransactionHandler) HandleNewTransaction(ctx context.Context, txReq *apitypes.TransactionRequest) (mtx *apitypes.ManagedTX, submissionRejected bool, err error) {
+	mtx, submissionRejected, err = sth.prepareNewTransaction(ctx, txReq)
+	if err != nil {
+		return nil, submissionRejected, err
+	}
+	return sth.insertManagedTx(ctx, mtx)
+}
+
+func (sth *simpleTransactionHandler) HandleNewContractDeployment(ctx context.Context, txReq *apitypes.ContractDeployRequest) (mtx *apitypes.ManagedTX, submissionRejected bool, err error) {
+	mtx, submissionRejected, err = sth.prepareNewContractDeployment(ctx, txReq)
+	if err != nil {
+		return nil, submissionRejected, err
+	}
+	return sth.insertManagedTx(ctx, mtx)
+}
+
+// prepareNewTransaction builds the managed transaction for a request, ready to be persisted
+func (sth *simpleTransactionHandler) prepareNewTransaction(ctx context.Context, txReq *apitypes.TransactionRequest) (mtx *apitypes.ManagedTX, submissionRejected bool, err error) {
 	txID, err := sth.requestIDPreCheck(ctx, &txReq.Headers)
 	if err != nil {
 		return nil, false, err
 	}
+	schedule, err := newTXSchedule(ctx, &txReq.Headers)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := sth.checkDependsOn(ctx, &txReq.Headers, &txReq.TransactionHeaders); err != nil {
+		return nil, false, err
+	}
+	if err := sth.screenAddresses(ctx, txID, &txReq.TransactionHeaders); err != nil {
+		return nil, true, err
+	}
 
 	// Prepare the transaction, which will mean we have a transaction that should be submittable.
 	// If we fail at this stage, we don't need to write any state as we are sure we haven't submitted
@@ -261,15 +451,25 @@ func (sth *simpleTransactionHandler) HandleNewTransaction(ctx context.Context, t
 		return nil, ffcapi.MapSubmissionRejected(reason), err
 	}
 
-	mtx, err = sth.createManagedTx(ctx, txID, &txReq.TransactionHeaders, prepared.Gas, prepared.TransactionData)
-	return mtx, false, err
+	return sth.newManagedTx(txID, &txReq.TransactionHeaders, prepared.Gas, prepared.TransactionData, schedule), false, nil
 }
 
-func (sth *simpleTransactionHandler) HandleNewContractDeployment(ctx context.Context, txReq *apitypes.ContractDeployRequest) (mtx *apitypes.ManagedTX, submissionRejected bool, err error) {
+// prepareNewContractDeployment builds the managed transaction for a deployment request, ready to be persisted
+func (sth *simpleTransactionHandler) prepareNewContractDeployment(ctx context.Context, txReq *apitypes.ContractDeployRequest) (mtx *apitypes.ManagedTX, submissionRejected bool, err error) {
 	txID, err := sth.requestIDPreCheck(ctx, &txReq.Headers)
 	if err != nil {
 		return nil, false, err
 	}
+	schedule, err := newTXSchedule(ctx, &txReq.Headers)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := sth.checkDependsOn(ctx, &txReq.Headers, &txReq.TransactionHeaders); err != nil {
+		return nil, false, err
+	}
+	if err := sth.screenAddresses(ctx, txID, &txReq.TransactionHeaders); err != nil {
+		return nil, true, err
+	}
 
 	// Prepare the transaction, which will mean we have a transaction that should be submittable.
 	// If we fail at this stage, we don't need to write any state as we are sure we haven't submitted
@@ -279,8 +479,73 @@ func (sth *simpleTransactionHandler) HandleNewContractDeployment(ctx context.Con
 		return nil, ffcapi.MapSubmissionRejected(reason), err
 	}
 
-	mtx, err = sth.createManagedTx(ctx, txID, &txReq.TransactionHeaders, prepared.Gas, prepared.TransactionData)
-	return mtx, false, err
+	return sth.newManagedTx(txID, &txReq.TransactionHeaders, prepared.Gas, prepared.TransactionData, schedule), false, nil
+}
+
+func (sth *simpleTransactionHandler) insertManagedTx(ctx context.Context, mtx *apitypes.ManagedTX) (*apitypes.ManagedTX, bool, error) {
+	var err error
+	switch {
+	case sth.requiresApproval(ctx, mtx):
+		// Nothing is submitted until the transaction is approved, so no nonce is assigned until then in approveTX(),
+		// unless the client coordinates the nonces for this signer
+		mtx.Status = apitypes.TxStatusAwaitingApproval
+		if !sth.clientSuppliedNonces {
+			mtx.Nonce = nil
+		}
+		err = sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx)
+		if isNonceConflict(err) {
+			return nil, true, err
+		} else if err != nil {
+			return nil, false, err
+		}
+		log.L(ctx).Infof("Transaction %s from %s is awaiting approval", mtx.ID, mtx.From)
+		return mtx, false, nil
+	case len(mtx.DependsOn) > 0:
+		// No nonce is assigned until the transactions this one depends on have succeeded, in processDependencies()
+		mtx.Nonce = nil
+		if err = sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx); err != nil {
+			return nil, false, err
+		}
+		log.L(ctx).Infof("Tracking transaction %s awaiting dependencies %v", mtx.ID, mtx.DependsOn)
+		sth.markInflightStale()
+		return mtx, false, nil
+	case sth.clientSuppliedNonces && mtx.Nonce != nil:
+		// The client coordinates the nonces for this signer, so we persist the one supplied - rejecting it
+		// if the signer already has a transaction with that nonce
+		err = sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx)
+		if isNonceConflict(err) {
+			return nil, true, err
+		}
+	case sth.policyHook != nil:
+		// No nonce is assigned until the policy hook allows the transaction in processPolicyDecision(), so a denied
+		// transaction does not leave a gap in the nonces of the signer
+		mtx.Nonce = nil
+		if err = sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx); err != nil {
+			return nil, false, err
+		}
+		log.L(ctx).Infof("Tracking transaction %s awaiting the policy hook", mtx.ID)
+		sth.markInflightStale()
+		return mtx, false, nil
+	default:
+		// Sequencing ID will be added as part of persistence logic - so we have a deterministic order of transactions
+		// Note: We must ensure persistence happens this within the nonce lock, to ensure that the nonce sequence and the
+		//       global transaction sequence line up.
+		err = sth.toolkit.TXPersistence.InsertTransactionWithNextNonce(ctx, mtx, sth.nextNonceForSigner)
+	}
+	if err == nil {
+		err = sth.recordNonceAssigned(ctx, mtx)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	sth.markInflightStale()
+
+	return mtx, false, nil
+}
+
+func isNonceConflict(err error) bool {
+	var ffErr i18n.FFError
+	return errors.As(err, &ffErr) && ffErr.MessageKey() == tmmsgs.MsgTransactionNonceConflict
 }
 
 func (sth *simpleTransactionHandler) HandleCancelTransaction(ctx context.Context, txID string) (mtx *apitypes.ManagedTX, err error) {
@@ -307,8 +572,12 @@ func (sth *simpleTransactionHandler) HandleResumeTransaction(ctx context.Context
 	return res.tx, res.err
 }
 
-func (sth *simpleTransactionHandler) createManagedTx(ctx context.Context, txID string, txHeaders *ffcapi.TransactionHeaders, gas *fftypes.FFBigInt, transactionData string) (*apitypes.ManagedTX, error) {
+func (sth *simpleTransactionHandler) createManagedTx(ctx context.Context, txID string, txHeaders *ffcapi.TransactionHeaders, gas *fftypes.FFBigInt, transactionData string, schedule *txSchedule) (*apitypes.ManagedTX, error) {
+	mtx, _, err := sth.insertManagedTx(ctx, sth.newManagedTx(txID, txHeaders, gas, transactionData, schedule))
+	return mtx, err
+}
 
+func (sth *simpleTransactionHandler) newManagedTx(txID string, txHeaders *ffcapi.TransactionHeaders, gas *fftypes.FFBigInt, transactionData string, schedule *txSchedule) *apitypes.ManagedTX {
 	if gas != nil {
 		txHeaders.Gas = gas
 	}
@@ -322,34 +591,42 @@ func (sth *simpleTransactionHandler) createManagedTx(ctx context.Context, txID s
 		Status:             apitypes.TxStatusPending,
 		PolicyInfo:         fftypes.JSONAnyPtr(`{}`),
 	}
+	if schedule != nil {
+		mtx.Expiry = schedule.expiry
+		mtx.NotBefore = schedule.notBefore
+		mtx.NotBeforeBlock = schedule.notBeforeBlock
+		mtx.Priority = schedule.priority
+		mtx.DependsOn = schedule.dependsOn
+	}
+	return mtx
+}
 
-	// Sequencing ID will be added as part of persistence logic - so we have a deterministic order of transactions
-	// Note: We must ensure persistence happens this within the nonce lock, to ensure that the nonce sequence and the
-	//       global transaction sequence line up.
-	err := sth.toolkit.TXPersistence.InsertTransactionWithNextNonce(ctx, mtx, func(ctx context.Context, signer string) (uint64, error) {
-		nextNonceRes, _, err := sth.toolkit.Connector.NextNonceForSigner(ctx, &ffcapi.NextNonceForSignerRequest{
-			Signer: signer,
-		})
-		if err != nil {
-			return 0, err
-		}
-		return nextNonceRes.Nonce.Uint64(), nil
+func (sth *simpleTransactionHandler) nextNonceForSigner(ctx context.Context, signer string) (uint64, error) {
+	nextNonceRes, _, err := sth.toolkit.Connector.NextNonceForSigner(ctx, &ffcapi.NextNonceForSignerRequest{
+		Signer: signer,
 	})
-	if err == nil {
-		err = sth.toolkit.TXHistory.AddSubStatusAction(ctx, txID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, fftypes.JSONAnyPtr(`{"nonce":"`+mtx.Nonce.String()+`"}`), nil, fftypes.Now())
-	}
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	log.L(ctx).Infof("Tracking transaction %s at nonce %s / %d", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64())
-	sth.markInflightStale()
+	return nextNonceRes.Nonce.Uint64(), nil
+}
 
-	return mtx, nil
+func (sth *simpleTransactionHandler) recordNonceAssigned(ctx context.Context, mtx *apitypes.ManagedTX) error {
+	if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, mtx.ID, apitypes.TxSubStatusReceived, apitypes.TxActionAssignNonce, fftypes.JSONAnyPtr(`{"nonce":"`+mtx.Nonce.String()+`"}`), nil, fftypes.Now()); err != nil {
+		return err
+	}
+	log.L(ctx).Infof("Tracking transaction %s at nonce %s / %d", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64())
+	return nil
 }
 
 func (sth *simpleTransactionHandler) submitTX(ctx *RunContext) (reason ffcapi.ErrorReason, err error) {
 	mtx := ctx.TX
 
+	if sth.holdForPausedSigner(ctx) {
+		return "", nil
+	}
+
+	previousGasPrice := mtx.GasPrice
 	mtx.GasPrice, err = sth.getGasPrice(ctx, sth.toolkit.Connector)
 	if err != nil {
 		ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, nil, fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
@@ -357,6 +634,48 @@ func (sth *simpleTransactionHandler) submitTX(ctx *RunContext) (reason ffcapi.Er
 	}
 	ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, fftypes.JSONAnyPtr(`{"gasPrice":`+string(*mtx.GasPrice)+`}`), nil, fftypes.Now())
 
+	// Higher priority transactions can be configured to pay more than the current gas price
+	if multiplier := sth.priorityGasPriceMultiplier(mtx.Priority); multiplier != 1 {
+		if gasPrice, multiplied := multiplyGasPrice(mtx.GasPrice, multiplier); multiplied {
+			mtx.GasPrice = gasPrice
+			ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, fftypes.JSONAnyPtr(fmt.Sprintf(`{"gasPrice":%s,"priority":%d,"multiplier":%v}`, *mtx.GasPrice, mtx.Priority, multiplier)), nil, fftypes.Now())
+		}
+	}
+
+	// When resubmitting, escalate from the gas price of the last submission so an underpriced
+	// transaction does not remain stuck in the transaction pool indefinitely
+	escalated := false
+	if mtx.FirstSubmit != nil && sth.gasEscalationPercentage > 0 {
+		var escalatedGasPrice *fftypes.JSONAny
+		if escalatedGasPrice, escalated = sth.escalateGasPrice(previousGasPrice, mtx.GasPrice, sth.gasEscalationPercentage); escalated {
+			log.L(ctx).Infof("Transaction %s at nonce %s / %d gas price escalated from %s to %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), previousGasPrice, escalatedGasPrice)
+			mtx.GasPrice = escalatedGasPrice
+			ctx.AddSubStatusAction(apitypes.TxActionRetrieveGasPrice, fftypes.JSONAnyPtr(`{"gasPrice":`+string(*mtx.GasPrice)+`,"previousGasPrice":`+string(*previousGasPrice)+`,"escalated":true}`), nil, fftypes.Now())
+		}
+	}
+
+	// A signed submission that might have reached the node is sent again at the same gas price, so it is not replaced
+	// by a second transaction we would have no record of - unless the gas price has been deliberately escalated
+	if ctx.Info.UnsentSubmission != nil && !escalated {
+		mtx.GasPrice = ctx.Info.UnsentSubmission.GasPrice
+	}
+
+	_, reason, err = sth.sendTX(ctx, previousGasPrice)
+	return reason, err
+}
+
+// sendTX sends the transaction at the gas price already set on it. If a spend limit holds the submission back,
+// the gas price of the previous submission is restored, and held is returned true without an error.
+func (sth *simpleTransactionHandler) sendTX(ctx *RunContext, previousGasPrice *fftypes.JSONAny) (held bool, reason ffcapi.ErrorReason, err error) {
+	mtx := ctx.TX
+
+	releaseSpend, ok := sth.checkSpendLimits(ctx)
+	if !ok {
+		// Keep the gas price of the last submission, and try again on a later cycle
+		mtx.GasPrice = previousGasPrice
+		return true, "", nil
+	}
+
 	sendTX := &ffcapi.TransactionSendRequest{
 		TransactionHeaders: mtx.TransactionHeaders,
 		GasPrice:           mtx.GasPrice,
@@ -364,6 +683,13 @@ func (sth *simpleTransactionHandler) submitTX(ctx *RunContext) (reason ffcapi.Er
 	}
 	sendTX.TransactionHeaders.Nonce = (*fftypes.FFBigInt)(mtx.Nonce.Int())
 	sendTX.TransactionHeaders.Gas = (*fftypes.FFBigInt)(mtx.Gas.Int())
+	signer, signed := sth.toolkit.Connector.(ffcapi.TransactionSigner)
+	if signed {
+		if reason, err := sth.signTX(ctx, signer, sendTX); err != nil {
+			releaseSpend()
+			return false, reason, err
+		}
+	}
 	log.L(ctx).Debugf("Sending transaction %s at nonce %s / %d (lastSubmit=%s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), mtx.LastSubmit)
 	transactionSendStartTime := time.Now()
 	res, reason, err := sth.toolkit.Connector.TransactionSend(ctx, sendTX)
@@ -378,40 +704,97 @@ func (sth *simpleTransactionHandler) submitTX(ctx *RunContext) (reason ffcapi.Er
 		ctx.TXUpdates.TransactionHash = &res.TransactionHash
 		ctx.TXUpdates.LastSubmit = mtx.LastSubmit
 		ctx.TXUpdates.GasPrice = mtx.GasPrice
+		ctx.clearUnsentSubmission()
 	} else {
+		// Only a submission that was accepted counts against the spend limit
+		releaseSpend()
+		if reason != "" {
+			// The node told us what happened to the signed transaction, so it does not need to be sent again as it is
+			ctx.clearUnsentSubmission()
+		}
 		ctx.AddSubStatusAction(apitypes.TxActionSubmitTransaction, fftypes.JSONAnyPtr(`{"reason":"`+string(reason)+`"}`), fftypes.JSONAnyPtr(`{"error":"`+err.Error()+`"}`), fftypes.Now())
 		// We have some simple rules for handling reasons from the connector, which could be enhanced by extending the connector.
 		switch reason {
 		case ffcapi.ErrorKnownTransaction, ffcapi.ErrorReasonNonceTooLow:
-			// If we already have a transaction hash, this is fine - we just return as if we submitted it
+			// If we already have a transaction hash, and it has been mined, we just return as if we submitted it.
+			// Otherwise the nonce might be held by a different transaction, so the error stands - the hash is
+			// still tracked, so a receipt for it completes the transaction.
 			if mtx.TransactionHash != "" {
+				mined, checkErr := sth.transactionMined(ctx, mtx.TransactionHash)
+				if checkErr != nil {
+					return false, "", checkErr
+				}
+				if !mined {
+					log.L(ctx).Infof("Transaction %s at nonce %s / %d with hash %s not found on chain (%s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), mtx.TransactionHash, err)
+					return false, reason, err
+				}
 				log.L(ctx).Debugf("Transaction %s at nonce %s / %d known with hash: %s (%s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), mtx.TransactionHash, err)
-				return "", nil
+				if signed && mtx.LastSubmit == nil {
+					// The response to an earlier TransactionSend was lost, but we know the hash from signing.
+					// Treat it as submitted, so we start tracking for a receipt
+					mtx.LastSubmit = fftypes.Now()
+					ctx.TXUpdates.LastSubmit = mtx.LastSubmit
+					ctx.SetSubStatus(apitypes.TxSubStatusTracking)
+				}
+				return false, "", nil
 			}
 			// Note: to cover the edge case where we had a timeout or other failure during the initial TransactionSend,
-			//       a policy engine implementation would need to be able to re-calculate the hash that we would expect for the transaction.
-			//       This would require a new FFCAPI API to calculate that hash, which requires the connector to perform the signing
-			//       without submission to the node. For example using `eth_signTransaction` for EVM JSON/RPC.
-			return reason, err
+			//       the connector needs to support the optional ffcapi.TransactionSigner interface, so we record
+			//       the hash we expect for the transaction before sending it.
+			return false, reason, err
+		case ffcapi.ErrorReasonInsufficientFunds:
+			// Every other transaction for the signer would be rejected in the same way, so stop submitting
+			// for the signer until its balance covers the cost of this transaction
+			sth.pauseSigner(ctx, err)
+			return false, reason, err
 		default:
-			return reason, err
+			return false, reason, err
 		}
 	}
 	log.L(ctx).Infof("Transaction %s at nonce %s / %d submitted. Hash: %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), mtx.TransactionHash)
 	ctx.SetSubStatus(apitypes.TxSubStatusTracking)
-	return "", nil
+	return false, "", nil
+}
+
+// transactionMined checks whether there is a receipt for the transaction hash
+func (sth *simpleTransactionHandler) transactionMined(ctx context.Context, transactionHash string) (bool, error) {
+	receipt, reason, err := sth.toolkit.Connector.TransactionReceipt(ctx, &ffcapi.TransactionReceiptRequest{
+		TransactionHash: transactionHash,
+	})
+	if err != nil && reason != ffcapi.ErrorReasonNotFound {
+		return false, err
+	}
+	return receipt != nil && err == nil, nil
 }
 
 func (sth *simpleTransactionHandler) processTransaction(ctx *RunContext) (err error) {
 
-	// Simply policy engine allows deletion of the transaction without additional checks ( ensuring the TX has not been submitted / gap filling the nonce etc. )
+	// By default the simple policy engine allows deletion of the transaction without additional checks ( ensuring the TX has not been submitted / gap filling the nonce etc. )
+	// When configured to cancel by replacement, a transaction that has already been submitted is kept until a replacement at the same nonce is mined.
 	mtx := ctx.TX
 	if mtx.DeleteRequested != nil {
+		if sth.cancelMode == CancelModeReplace && mtx.TransactionHash != "" {
+			return sth.processCancelReplacement(ctx)
+		}
 		ctx.UpdateType = Delete
 		return nil
 	}
 
+	// A transaction that expires after it has been submitted is only passed to us when configured to cancel it,
+	// and one that expires before it is submitted when it holds a nonce that must be used
+	cancelling := ctx.Info != nil && ctx.Info.CancelReplacement != nil
+	if cancelling || (ctx.Info != nil && ctx.expired()) {
+		if !cancelling {
+			ctx.AddSubStatusAction(apitypes.TxActionExpired, fftypes.JSONAnyPtr(`{"expiry":"`+mtx.Expiry.String()+`"}`), nil, fftypes.Now())
+		}
+		return sth.processCancelReplacement(ctx)
+	}
+
 	if mtx.FirstSubmit == nil {
+		if !sth.notBeforeReached(ctx) {
+			// Scheduled for later - the nonce is reserved, but we do not submit yet
+			return nil
+		}
 		// Submit the first time
 		if _, err := sth.submitTX(ctx); err != nil {
 			return err
@@ -430,6 +813,10 @@ func (sth *simpleTransactionHandler) processTransaction(ctx *RunContext) (err er
 			lastWarnTime = mtx.FirstSubmit
 		}
 		now := fftypes.Now()
+		if ctx.Info.External {
+			// We can only wait for the receipt of a transaction submitted by other tooling
+			return nil
+		}
 		if now.Time().Sub(*lastWarnTime.Time()) > sth.resubmitInterval {
 			secsSinceSubmit := float64(now.Time().Sub(*mtx.FirstSubmit.Time())) / float64(time.Second)
 			log.L(ctx).Infof("Transaction %s at nonce %s / %d has not been mined after %.2fs", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), secsSinceSubmit)
@@ -452,58 +839,13 @@ func (sth *simpleTransactionHandler) processTransaction(ctx *RunContext) (err er
 	return nil
 }
 
-// getGasPrice either uses a fixed gas price, or invokes a gas station API
+// getGasPrice either uses a fixed gas price, or queries the configured gas oracles
 func (sth *simpleTransactionHandler) getGasPrice(ctx context.Context, cAPI ffcapi.API) (gasPrice *fftypes.JSONAny, err error) {
-	if sth.gasOracleQueryValue != nil && sth.gasOracleLastQueryTime != nil &&
-		time.Since(*sth.gasOracleLastQueryTime.Time()) < sth.gasOracleQueryInterval {
-		return sth.gasOracleQueryValue, nil
-	}
-	switch sth.gasOracleMode {
-	case GasOracleModeRESTAPI:
-		// Make a REST call against an endpoint, and extract a value/structure to pass to the connector
-		gasPrice, err := sth.getGasPriceAPI(ctx)
-		if err != nil {
-			return nil, err
-		}
-		sth.gasOracleQueryValue = gasPrice
-		sth.gasOracleLastQueryTime = fftypes.Now()
-		return sth.gasOracleQueryValue, nil
-	case GasOracleModeConnector:
-		// Call the connector
-		res, _, err := cAPI.GasPriceEstimate(ctx, &ffcapi.GasPriceEstimateRequest{})
-		if err != nil {
-			return nil, err
-		}
-		sth.gasOracleQueryValue = res.GasPrice
-		sth.gasOracleLastQueryTime = fftypes.Now()
-		return sth.gasOracleQueryValue, nil
-	default:
+	if len(sth.gasOracleSources) == 0 {
 		// Disabled - just a fixed value - note that the fixed value can be any JSON structure,
 		// as interpreted by the connector. For example EVMConnect support a simple value, or a
 		// post EIP-1559 structure.
 		return sth.fixedGasPrice, nil
 	}
-}
-
-func (sth *simpleTransactionHandler) getGasPriceAPI(ctx context.Context) (gasPrice *fftypes.JSONAny, err error) {
-	res, err := sth.gasOracleClient.R().
-		Execute(sth.gasOracleMethod, "")
-	if err != nil {
-		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgErrorQueryingGasOracleAPI, -1, err.Error())
-	}
-	if res.IsError() {
-		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgErrorQueryingGasOracleAPI, res.StatusCode(), res.RawResponse)
-	}
-	// Parse the response body as JSON
-	var data map[string]interface{}
-	err = json.Unmarshal(res.Body(), &data)
-	if err != nil {
-		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgInvalidJSONGasObject)
-	}
-	buff := new(bytes.Buffer)
-	err = sth.gasOracleTemplate.Execute(buff, data)
-	if err != nil {
-		return nil, i18n.WrapError(ctx, err, tmmsgs.MsgGasOracleResultError)
-	}
-	return fftypes.JSONAnyPtr(buff.String()), nil
+	return sth.queryGasOracles(ctx, cAPI)
 }
@@ -0,0 +1,93 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+)
+
+func (sth *simpleTransactionHandler) HandleSpeedUpTransaction(ctx context.Context, txID string, req *apitypes.SpeedUpTransactionRequest) (mtx *apitypes.ManagedTX, err error) {
+	if req.GasPrice.IsNil() == (req.Percentage == 0) || req.Percentage < 0 {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidSpeedUpRequest)
+	}
+	res := sth.policyEngineAPIRequest(ctx, &policyEngineAPIRequest{
+		requestType: ActionSpeedUp,
+		txID:        txID,
+		speedUp:     req,
+	})
+	return res.tx, res.err
+}
+
+// speedUpTX resubmits a transaction that has already been submitted at the same nonce, with the gas price
+// requested by the operator, rather than waiting for the resubmit interval and the configured gas price
+func (sth *simpleTransactionHandler) speedUpTX(ctx *RunContext) error {
+	mtx := ctx.TX
+	if mtx.Status != apitypes.TxStatusPending || mtx.FirstSubmit == nil || ctx.speedUp == nil {
+		return i18n.NewError(ctx, tmmsgs.MsgTransactionNotSubmitted, mtx.ID, mtx.Status)
+	}
+	if ctx.Info.External {
+		return i18n.NewError(ctx, tmmsgs.MsgTransactionSubmittedExternally, mtx.ID)
+	}
+
+	previousGasPrice := mtx.GasPrice
+	gasPrice := ctx.speedUp.GasPrice
+	if ctx.speedUp.Percentage > 0 {
+		var bumped bool
+		if gasPrice, bumped = multiplyGasPrice(previousGasPrice, 1+ctx.speedUp.Percentage/100); !bumped {
+			return i18n.NewError(ctx, tmmsgs.MsgGasPriceNotBumpable, previousGasPrice, mtx.ID)
+		}
+	}
+	log.L(ctx).Infof("Transaction %s at nonce %s / %d speed up requested with gas price %s (previous=%s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), gasPrice, previousGasPrice)
+	mtx.GasPrice = gasPrice
+	ctx.AddSubStatusAction(apitypes.TxActionSpeedUp, speedUpInfo(previousGasPrice, gasPrice, ctx.speedUp.Percentage), nil, fftypes.Now())
+
+	held, _, err := sth.sendTX(ctx, previousGasPrice)
+	if err == nil && held {
+		// The caller is told the speed up did not happen, rather than it being retried on a later cycle
+		err = i18n.NewError(ctx, tmmsgs.MsgSpeedUpSpendLimitReached, mtx.ID, gasPrice)
+	}
+	if err != nil {
+		// The gas price of the last successful submission is the basis for any later escalation
+		mtx.GasPrice = previousGasPrice
+		ctx.UpdateType = Update
+		errMsg := err.Error()
+		mtx.ErrorMessage = errMsg
+		ctx.TXUpdates.ErrorMessage = &errMsg
+		return err
+	}
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "speed_up")
+	return nil
+}
+
+func speedUpInfo(previousGasPrice, gasPrice *fftypes.JSONAny, percentage float64) *fftypes.JSONAny {
+	info := map[string]interface{}{
+		"previousGasPrice": previousGasPrice,
+		"gasPrice":         gasPrice,
+	}
+	if percentage > 0 {
+		info["percentage"] = percentage
+	}
+	b, _ := json.Marshal(info)
+	return fftypes.JSONAnyPtrBytes(b)
+}
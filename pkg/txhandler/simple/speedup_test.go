@@ -0,0 +1,233 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestSubmittedTX(gasPrice string) *apitypes.ManagedTX {
+	mtx := &apitypes.ManagedTX{
+		ID:     "ns1:" + fftypes.NewUUID().String(),
+		Status: apitypes.TxStatusPending,
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From:  "0xaaaa",
+			Nonce: fftypes.NewFFBigInt(10),
+		},
+		TransactionData: "RAW_UNSIGNED_BYTES",
+		TransactionHash: "0xold",
+		FirstSubmit:     fftypes.Now(),
+		LastSubmit:      fftypes.Now(),
+		PolicyInfo:      fftypes.JSONAnyPtr(`{}`),
+	}
+	if gasPrice != "" {
+		mtx.GasPrice = fftypes.JSONAnyPtr(gasPrice)
+	}
+	return mtx
+}
+
+func speedUpAPIRequest(sth *simpleTransactionHandler, txID string, req *apitypes.SpeedUpTransactionRequest) policyEngineAPIResponse {
+	apiReq := &policyEngineAPIRequest{
+		requestType: ActionSpeedUp,
+		txID:        txID,
+		speedUp:     req,
+		response:    make(chan policyEngineAPIResponse, 1),
+	}
+	sth.policyEngineAPIRequests = append(sth.policyEngineAPIRequests, apiReq)
+	sth.processPolicyAPIRequests(sth.ctx)
+	return <-apiReq.response
+}
+
+func TestHandleSpeedUpTransactionQueued(t *testing.T) {
+	sth, _, _, _ := newTestDependenciesHandler(t)
+
+	result := make(chan error)
+	go func() {
+		_, err := sth.HandleSpeedUpTransaction(sth.ctx, "tx1", &apitypes.SpeedUpTransactionRequest{Percentage: 10})
+		result <- err
+	}()
+
+	for len(sth.policyEngineAPIRequests) == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	sth.mux.Lock()
+	req := sth.policyEngineAPIRequests[0]
+	sth.mux.Unlock()
+	assert.Equal(t, ActionSpeedUp, req.requestType)
+	assert.Equal(t, float64(10), req.speedUp.Percentage)
+	req.response <- policyEngineAPIResponse{}
+
+	err := <-result
+	assert.NoError(t, err)
+}
+
+func TestHandleSpeedUpTransactionInvalidRequest(t *testing.T) {
+	sth, _, _, _ := newTestDependenciesHandler(t)
+
+	_, err := sth.HandleSpeedUpTransaction(sth.ctx, "tx1", &apitypes.SpeedUpTransactionRequest{})
+	assert.Regexp(t, "FF21111", err)
+
+	_, err = sth.HandleSpeedUpTransaction(sth.ctx, "tx1", &apitypes.SpeedUpTransactionRequest{
+		GasPrice:   fftypes.JSONAnyPtr(`12345`),
+		Percentage: 10,
+	})
+	assert.Regexp(t, "FF21111", err)
+
+	_, err = sth.HandleSpeedUpTransaction(sth.ctx, "tx1", &apitypes.SpeedUpTransactionRequest{Percentage: -10})
+	assert.Regexp(t, "FF21111", err)
+}
+
+func TestSpeedUpPercentageInflight(t *testing.T) {
+	sth, mp, mockFFCAPI, meh := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`{"maxFeePerGas":"1000","maxPriorityFeePerGas":100}`)
+	sth.inflight = []*pendingState{{mtx: mtx, info: &simplePolicyInfo{}, trackingTransactionHash: "0xold", trackedHashes: []string{"0xold"}}}
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.GasPrice.String() == `{"maxFeePerGas":"1100","maxPriorityFeePerGas":110}` && req.Nonce.Int64() == 10
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0xnew"}, ffcapi.ErrorReason(""), nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSpeedUp, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"gasPrice":{"maxFeePerGas":"1100","maxPriorityFeePerGas":110},"percentage":10,"previousGasPrice":{"maxFeePerGas":"1000","maxPriorityFeePerGas":100}}`
+	}), mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSubmitTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.TransactionHash == "0xnew" && updates.GasPrice.String() == `{"maxFeePerGas":"1100","maxPriorityFeePerGas":110}`
+	})).Return(nil)
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0xnew"
+	})).Return(nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{Percentage: 10})
+	assert.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, "0xnew", res.tx.TransactionHash)
+	assert.Equal(t, "0xnew", sth.inflight[0].trackingTransactionHash)
+	assert.Equal(t, []string{"0xold", "0xnew"}, sth.inflight[0].trackedHashes) // the earlier submission is still watched
+	assert.Nil(t, sth.inflight[0].speedUp)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestSpeedUpExplicitGasPrice(t *testing.T) {
+	sth, mp, mockFFCAPI, meh := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`1000`)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.MatchedBy(func(req *ffcapi.TransactionSendRequest) bool {
+		return req.GasPrice.String() == `5000`
+	})).Return(&ffcapi.TransactionSendResponse{TransactionHash: "0xnew"}, ffcapi.ErrorReason(""), nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSpeedUp, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"gasPrice":5000,"previousGasPrice":1000}`
+	}), mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSubmitTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.Anything).Return(nil)
+	mp.On("GetTransactionSubmittedHashes", mock.Anything, mtx.ID).Return([]*apitypes.SubmittedHash{
+		{TransactionHash: "0xold"}, {TransactionHash: "0xnew"},
+	}, nil)
+	meh.On("HandleEvent", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{GasPrice: fftypes.JSONAnyPtr(`5000`)})
+	assert.NoError(t, res.err)
+	assert.Equal(t, `5000`, res.tx.GasPrice.String())
+
+	mp.AssertExpectations(t)
+}
+
+func TestSpeedUpNotSubmitted(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`1000`)
+	mtx.FirstSubmit = nil
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{Percentage: 10})
+	assert.Regexp(t, "FF21112", res.err)
+}
+
+func TestSpeedUpExternal(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`1000`)
+	mtx.PolicyInfo = fftypes.JSONAnyPtr(`{"external":true}`)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{Percentage: 10})
+	assert.Regexp(t, "FF21116", res.err)
+}
+
+func TestSpeedUpNotBumpable(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`"fast"`)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{Percentage: 10})
+	assert.Regexp(t, "FF21113", res.err)
+}
+
+func TestSpeedUpSendFail(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestDependenciesHandler(t)
+
+	mtx := newTestSubmittedTX(`1000`)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReasonTransactionUnderpriced, fmt.Errorf("underpriced"))
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSpeedUp, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSubmitTransaction, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.ErrorMessage == "underpriced" && updates.GasPrice == nil
+	})).Return(nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{GasPrice: fftypes.JSONAnyPtr(`1001`)})
+	assert.Regexp(t, "underpriced", res.err)
+	assert.Equal(t, `1000`, mtx.GasPrice.String())
+
+	mp.AssertExpectations(t)
+}
+
+func TestSpeedUpSpendLimitReached(t *testing.T) {
+	sth, mp, mockFFCAPI, _ := newTestDependenciesHandler(t)
+	sth.spendGuardMaxGasPrice = big.NewInt(2000)
+
+	mtx := newTestSubmittedTX(`1000`)
+	mp.On("GetTransactionByID", mock.Anything, mtx.ID).Return(mtx, nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSpeedUp, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, mtx.ID, mock.Anything, apitypes.TxActionSpendLimitReached, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, mtx.ID, mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return updates.ErrorMessage != nil && strings.Contains(*updates.ErrorMessage, "FF21129") && updates.GasPrice == nil
+	})).Return(nil)
+
+	res := speedUpAPIRequest(sth, mtx.ID, &apitypes.SpeedUpTransactionRequest{GasPrice: fftypes.JSONAnyPtr(`5000`)})
+	assert.Regexp(t, "FF21129", res.err)
+	assert.Equal(t, http.StatusBadRequest, res.err.(i18n.FFError).HTTPStatus())
+	assert.Equal(t, `1000`, mtx.GasPrice.String())
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionSend", mock.Anything, mock.Anything)
+	mp.AssertExpectations(t)
+}
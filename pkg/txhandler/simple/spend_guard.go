@@ -0,0 +1,256 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+)
+
+const spendLoadPageSize = 100
+
+// spendRecord is the fee of the latest submission of a transaction, counted against the limit for its namespace
+type spendRecord struct {
+	submitted time.Time
+	fee       *big.Int
+}
+
+func parseSpendLimit(ctx context.Context, key, value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	limit, ok := new(big.Int).SetString(value, 0)
+	if !ok || limit.Sign() <= 0 {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidSpendLimit, key, value)
+	}
+	return limit, nil
+}
+
+// highestGasPriceValue returns the gas price if it is a simple numeric value, or the largest numeric
+// field of an object such as an EIP-1559 structure. Returns nil if the gas price contains no numbers.
+func highestGasPriceValue(gasPrice *fftypes.JSONAny) *big.Int {
+	if gasPrice.IsNil() {
+		return nil
+	}
+	if value, _, ok := parseGasPriceNumber(json.RawMessage(gasPrice.Bytes())); ok {
+		return value
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(gasPrice.Bytes(), &fields); err != nil {
+		return nil
+	}
+	var highest *big.Int
+	for _, v := range fields {
+		if value, _, ok := parseGasPriceNumber(v); ok && (highest == nil || value.Cmp(highest) > 0) {
+			highest = value
+		}
+	}
+	return highest
+}
+
+// estimateSubmissionFee returns the most a submission could cost, which is the gas limit multiplied by
+// the highest value in the gas price, or nil if either is unknown
+func estimateSubmissionFee(mtx *apitypes.ManagedTX) *big.Int {
+	price := highestGasPriceValue(mtx.GasPrice)
+	if price == nil || mtx.Gas == nil {
+		return nil
+	}
+	return new(big.Int).Mul(mtx.Gas.Int(), price)
+}
+
+// namespaceSpent returns the total fee of submissions in the namespace within the window, other than those
+// of the supplied transaction. Submissions that have dropped out of the window are discarded.
+func (sth *simpleTransactionHandler) namespaceSpent(namespace, txID string, now time.Time) *big.Int {
+	spent := new(big.Int)
+	for id, record := range sth.namespaceSpend[namespace] {
+		if now.Sub(record.submitted) > sth.spendGuardNamespaceWindow {
+			delete(sth.namespaceSpend[namespace], id)
+		} else if id != txID {
+			spent.Add(spent, record.fee)
+		}
+	}
+	return spent
+}
+
+// checkSpendLimits is called with the gas price that is about to be submitted. If it breaks the gas price ceiling,
+// or would take the namespace over its fee limit for the window, the transaction moves to the AwaitingGasPrice
+// sub-status and false is returned so the submission is retried on a later cycle.
+// A first submission with a fee above the namespace limit on its own could never be sent, so the transaction is
+// failed instead. A resubmission is still held, as an earlier submission at the nonce can be mined.
+// Otherwise the fee is reserved against the namespace limit under the lock, so submissions for other signers made
+// in parallel by other policy workers cannot together exceed it. The returned function releases the reservation,
+// and must be called if the submission then fails.
+func (sth *simpleTransactionHandler) checkSpendLimits(ctx *RunContext) (release func(), ok bool) {
+	mtx := ctx.TX
+	if sth.spendGuardMaxGasPrice != nil {
+		if price := highestGasPriceValue(mtx.GasPrice); price != nil && price.Cmp(sth.spendGuardMaxGasPrice) > 0 {
+			log.L(ctx).Warnf("Transaction %s at nonce %s / %d held back as gas price %s exceeds the maximum of %s", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), mtx.GasPrice, sth.spendGuardMaxGasPrice)
+			sth.holdForGasPrice(ctx, map[string]interface{}{
+				"reason":      "maxGasPrice",
+				"gasPrice":    mtx.GasPrice,
+				"maxGasPrice": sth.spendGuardMaxGasPrice.String(),
+			})
+			return nil, false
+		}
+	}
+
+	if sth.spendGuardNamespaceMaxFee != nil {
+		fee := estimateSubmissionFee(mtx)
+		if fee == nil {
+			return func() {}, true
+		}
+		namespace := mtx.Namespace(ctx)
+		if mtx.FirstSubmit == nil && fee.Cmp(sth.spendGuardNamespaceMaxFee) > 0 {
+			sth.failOverNamespaceMaxFee(ctx, namespace, fee)
+			return nil, false
+		}
+		sth.spendGuardMux.Lock()
+		defer sth.spendGuardMux.Unlock()
+		spent := sth.namespaceSpent(namespace, mtx.ID, time.Now())
+		if new(big.Int).Add(spent, fee).Cmp(sth.spendGuardNamespaceMaxFee) > 0 {
+			log.L(ctx).Warnf("Transaction %s at nonce %s / %d held back as fee %s would take namespace '%s' over its limit of %s (spent %s in the last %s)", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), fee, namespace, sth.spendGuardNamespaceMaxFee, spent, sth.spendGuardNamespaceWindow)
+			sth.holdForGasPrice(ctx, map[string]interface{}{
+				"reason":    "namespaceMaxFee",
+				"namespace": namespace,
+				"gasPrice":  mtx.GasPrice,
+				"fee":       fee.String(),
+				"spent":     spent.String(),
+				"maxFee":    sth.spendGuardNamespaceMaxFee.String(),
+				"window":    sth.spendGuardNamespaceWindow.String(),
+			})
+			return nil, false
+		}
+		return sth.reserveSpendLocked(namespace, mtx.ID, fee), true
+	}
+	return func() {}, true
+}
+
+func (sth *simpleTransactionHandler) failOverNamespaceMaxFee(ctx *RunContext, namespace string, fee *big.Int) {
+	mtx := ctx.TX
+	log.L(ctx).Warnf("Transaction %s at nonce %s / %d failed as fee %s is above the limit of %s for namespace '%s'", mtx.ID, mtx.TransactionHeaders.From, mtx.Nonce.Int64(), fee, sth.spendGuardNamespaceMaxFee, namespace)
+	ctx.UpdateType = Update
+	mtx.Status = apitypes.TxStatusFailed
+	ctx.TXUpdates.Status = &mtx.Status
+	errMsg := i18n.NewError(ctx, tmmsgs.MsgFeeExceedsNamespaceMaxFee, fee, sth.spendGuardNamespaceMaxFee, namespace).Error()
+	mtx.ErrorMessage = errMsg
+	ctx.TXUpdates.ErrorMessage = &errMsg
+	ctx.SetSubStatus(apitypes.TxSubStatusFailed)
+	b, _ := json.Marshal(map[string]interface{}{
+		"reason":    "namespaceMaxFee",
+		"namespace": namespace,
+		"gasPrice":  mtx.GasPrice,
+		"fee":       fee.String(),
+		"maxFee":    sth.spendGuardNamespaceMaxFee.String(),
+	})
+	ctx.AddSubStatusAction(apitypes.TxActionSpendLimitReached, fftypes.JSONAnyPtrBytes(b), nil, fftypes.Now())
+	sth.incTransactionOperationCounter(ctx, namespace, "spend_limit_failed")
+}
+
+func (sth *simpleTransactionHandler) holdForGasPrice(ctx *RunContext, info map[string]interface{}) {
+	b, _ := json.Marshal(info)
+	ctx.setHeldSubStatus(apitypes.TxSubStatusAwaitingGasPrice, apitypes.TxActionSpendLimitReached, fftypes.JSONAnyPtrBytes(b), nil)
+}
+
+// reserveSpendLocked counts a submission against the fee limit of its namespace. A resubmission replaces
+// the earlier record for the transaction, as only one of the submissions at that nonce can be mined.
+// The returned function puts back the earlier record, for when the submission fails.
+func (sth *simpleTransactionHandler) reserveSpendLocked(namespace, txID string, fee *big.Int) func() {
+	if sth.namespaceSpend == nil {
+		sth.namespaceSpend = make(map[string]map[string]*spendRecord)
+	}
+	if sth.namespaceSpend[namespace] == nil {
+		sth.namespaceSpend[namespace] = make(map[string]*spendRecord)
+	}
+	previous := sth.namespaceSpend[namespace][txID]
+	sth.namespaceSpend[namespace][txID] = &spendRecord{submitted: time.Now(), fee: fee}
+	return func() {
+		sth.spendGuardMux.Lock()
+		defer sth.spendGuardMux.Unlock()
+		if previous != nil {
+			sth.namespaceSpend[namespace][txID] = previous
+		} else {
+			delete(sth.namespaceSpend[namespace], txID)
+		}
+	}
+}
+
+// loadNamespaceSpend rebuilds the fees counted against the namespace limits on startup, from the last submission of
+// each transaction within the window. Pending transactions are all checked, as they can have been created long
+// before their last submission, along with the transactions created within the window that have since completed.
+func (sth *simpleTransactionHandler) loadNamespaceSpend(ctx context.Context) error {
+	now := time.Now()
+	sth.spendGuardMux.Lock()
+	defer sth.spendGuardMux.Unlock()
+	sth.namespaceSpend = make(map[string]map[string]*spendRecord)
+
+	afterSequence := ""
+	for {
+		page, err := sth.toolkit.TXPersistence.ListTransactionsPending(ctx, afterSequence, spendLoadPageSize, txhandler.SortDirectionAscending)
+		if err != nil {
+			return err
+		}
+		for _, mtx := range page {
+			sth.addSpendRecordLocked(ctx, mtx, now)
+			afterSequence = mtx.SequenceID
+		}
+		if len(page) < spendLoadPageSize {
+			break
+		}
+	}
+
+	var after *apitypes.ManagedTX
+	for {
+		page, err := sth.toolkit.TXPersistence.ListTransactionsByCreateTime(ctx, after, spendLoadPageSize, txhandler.SortDirectionDescending)
+		if err != nil {
+			return err
+		}
+		for _, mtx := range page {
+			if now.Sub(*mtx.Created.Time()) > sth.spendGuardNamespaceWindow {
+				return nil
+			}
+			sth.addSpendRecordLocked(ctx, mtx, now)
+			after = mtx
+		}
+		if len(page) < spendLoadPageSize {
+			return nil
+		}
+	}
+}
+
+func (sth *simpleTransactionHandler) addSpendRecordLocked(ctx context.Context, mtx *apitypes.ManagedTX, now time.Time) {
+	if mtx.LastSubmit == nil || now.Sub(*mtx.LastSubmit.Time()) > sth.spendGuardNamespaceWindow {
+		return
+	}
+	fee := estimateSubmissionFee(mtx)
+	if fee == nil {
+		return
+	}
+	namespace := mtx.Namespace(ctx)
+	if sth.namespaceSpend[namespace] == nil {
+		sth.namespaceSpend[namespace] = make(map[string]*spendRecord)
+	}
+	sth.namespaceSpend[namespace][mtx.ID] = &spendRecord{submitted: *mtx.LastSubmit.Time(), fee: fee}
+}
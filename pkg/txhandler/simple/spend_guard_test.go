@@ -0,0 +1,444 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/persistencemocks"
+	"github.com/hyperledger/firefly-transaction-manager/mocks/txhandlermocks"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/txhandler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestSpendGuardTX(gas int64) *apitypes.ManagedTX {
+	return &apitypes.ManagedTX{
+		ID: "ns1:" + fftypes.NewUUID().String(),
+		TransactionHeaders: ffcapi.TransactionHeaders{
+			From: "0x6b7cfa4cf9709d3b3f5f7c22de123d2e16aee712",
+			Gas:  fftypes.NewFFBigInt(gas),
+		},
+		TransactionData: "SOME_RAW_TX_BYTES",
+	}
+}
+
+func TestSpendGuardMaxGasPriceHoldsFirstSubmit(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `{"maxFeePerGas":"2000","maxPriorityFeePerGas":"10"}`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardMaxGasPrice, "1000")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mtx := newTestSpendGuardTX(100)
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Nil(t, mtx.FirstSubmit)
+	assert.Nil(t, mtx.GasPrice)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+	// retrieved gas price, spend limit reached
+	assert.Len(t, rc.HistoryUpdates, 2)
+
+	// A later cycle that is still held does not add the spend limit to the history again
+	rc = newTestRunContext(mtx, nil)
+	rc.previousSubStatus = apitypes.TxSubStatusAwaitingGasPrice
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+	assert.Len(t, rc.HistoryUpdates, 1)
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionSend", mock.Anything, mock.Anything)
+}
+
+func TestSpendGuardMaxGasPriceAllowsSubmit(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardMaxGasPrice, "1000")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mtx := newTestSpendGuardTX(100)
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.NotNil(t, mtx.FirstSubmit)
+	assert.Equal(t, apitypes.TxSubStatusTracking, rc.SubStatus)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSpendGuardMaxGasPriceHoldsEscalatedResubmit(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.Set(ResubmitInterval, "1s")
+	conf.SubSection(GasEscalationConfig).Set(GasEscalationPercentage, 10)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardMaxGasPrice, "2100")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	submitTime := fftypes.FFTime(time.Now().Add(-100 * time.Hour))
+	mtx := newTestSpendGuardTX(100)
+	mtx.FirstSubmit = &submitTime
+	mtx.GasPrice = fftypes.JSONAnyPtr(`2000`)
+	rc := newTestRunContext(mtx, nil)
+	err = sth.processTransaction(rc)
+	assert.NoError(t, err)
+	assert.Equal(t, `2000`, mtx.GasPrice.String())
+	assert.Nil(t, rc.TXUpdates.GasPrice)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionSend", mock.Anything, mock.Anything)
+}
+
+func TestSpendGuardNamespaceMaxFee(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `"0x0a"`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "2500")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(&ffcapi.TransactionSendResponse{
+		TransactionHash: "0x12345",
+	}, ffcapi.ErrorReason(""), nil)
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	// 100 gas at 10 each is a fee of 1000, so only two fit within the limit
+	mtx1 := newTestSpendGuardTX(100)
+	rc := newTestRunContext(mtx1, nil)
+	assert.NoError(t, sth.processTransaction(rc))
+	assert.NotNil(t, mtx1.FirstSubmit)
+
+	mtx2 := newTestSpendGuardTX(100)
+	rc = newTestRunContext(mtx2, nil)
+	assert.NoError(t, sth.processTransaction(rc))
+	assert.NotNil(t, mtx2.FirstSubmit)
+
+	mtx3 := newTestSpendGuardTX(100)
+	rc = newTestRunContext(mtx3, nil)
+	assert.NoError(t, sth.processTransaction(rc))
+	assert.Nil(t, mtx3.FirstSubmit)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+	mp := &persistencemocks.Persistence{}
+	mp.On("AddSubStatusAction", mock.Anything, mtx3.ID, apitypes.TxSubStatusAwaitingGasPrice, apitypes.TxActionSpendLimitReached, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		jo := info.JSONObject()
+		return jo.GetString("reason") == "namespaceMaxFee" && jo.GetString("namespace") == "ns1" && jo.GetString("spent") == "2000"
+	}), (*fftypes.JSONAny)(nil), mock.Anything).Return(nil)
+	assert.NoError(t, rc.HistoryUpdates[len(rc.HistoryUpdates)-1](mp))
+	mp.AssertExpectations(t)
+
+	// Another namespace has its own limit
+	mtx4 := newTestSpendGuardTX(100)
+	mtx4.ID = "ns2:" + fftypes.NewUUID().String()
+	rc = newTestRunContext(mtx4, nil)
+	assert.NoError(t, sth.processTransaction(rc))
+	assert.NotNil(t, mtx4.FirstSubmit)
+
+	// Once the earlier submissions leave the window, the held transaction is submitted
+	for _, record := range sth.namespaceSpend["ns1"] {
+		record.submitted = time.Now().Add(-2 * time.Hour)
+	}
+	rc = newTestRunContext(mtx3, nil)
+	assert.NoError(t, sth.processTransaction(rc))
+	assert.NotNil(t, mtx3.FirstSubmit)
+	assert.Len(t, sth.namespaceSpend["ns1"], 1)
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSpendGuardNamespaceMaxFeeResubmitReplacesSpend(t *testing.T) {
+	sth := &simpleTransactionHandler{
+		spendGuardNamespaceMaxFee: big.NewInt(1500),
+		spendGuardNamespaceWindow: time.Hour,
+	}
+	mtx := newTestSpendGuardTX(100)
+	mtx.GasPrice = fftypes.JSONAnyPtr(`10`)
+	_, ok := sth.checkSpendLimits(newTestRunContext(mtx, nil))
+	assert.True(t, ok)
+	mtx.GasPrice = fftypes.JSONAnyPtr(`12`)
+	_, ok = sth.checkSpendLimits(newTestRunContext(mtx, nil))
+	assert.True(t, ok)
+	assert.Len(t, sth.namespaceSpend["ns1"], 1)
+
+	// The fee of the earlier submission of the same transaction is not counted
+	mtx.GasPrice = fftypes.JSONAnyPtr(`15`)
+	release, ok := sth.checkSpendLimits(newTestRunContext(mtx, nil))
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), sth.namespaceSpent("ns1", mtx.ID, time.Now()).Int64())
+	assert.Equal(t, int64(1500), sth.namespaceSpent("ns1", "other", time.Now()).Int64())
+
+	// Releasing a failed resubmission puts back the earlier submission
+	release()
+	assert.Equal(t, int64(1200), sth.namespaceSpent("ns1", "other", time.Now()).Int64())
+}
+
+func TestSpendGuardNamespaceMaxFeeReservedAtCheck(t *testing.T) {
+	sth := &simpleTransactionHandler{
+		spendGuardNamespaceMaxFee: big.NewInt(1500),
+		spendGuardNamespaceWindow: time.Hour,
+	}
+	mtx1 := newTestSpendGuardTX(100)
+	mtx1.GasPrice = fftypes.JSONAnyPtr(`10`)
+	mtx2 := newTestSpendGuardTX(100)
+	mtx2.GasPrice = fftypes.JSONAnyPtr(`10`)
+
+	// Once the first is allowed, the second cannot also pass the check before the first is sent
+	release, ok := sth.checkSpendLimits(newTestRunContext(mtx1, nil))
+	assert.True(t, ok)
+	rc := newTestRunContext(mtx2, nil)
+	_, ok = sth.checkSpendLimits(rc)
+	assert.False(t, ok)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+
+	// If the first submission fails, the space is available again
+	release()
+	assert.Empty(t, sth.namespaceSpend["ns1"])
+	_, ok = sth.checkSpendLimits(newTestRunContext(mtx2, nil))
+	assert.True(t, ok)
+}
+
+func TestSpendGuardNamespaceMaxFeeReleasedOnSendFailure(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `10`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "1500")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+
+	mockFFCAPI.On("TransactionSend", mock.Anything, mock.Anything).Return(nil, ffcapi.ErrorReason(""), fmt.Errorf("pop"))
+
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	mtx := newTestSpendGuardTX(100)
+	rc := newTestRunContext(mtx, nil)
+	assert.Regexp(t, "pop", sth.processTransaction(rc))
+	assert.Nil(t, mtx.FirstSubmit)
+	assert.Empty(t, sth.namespaceSpend["ns1"])
+
+	mockFFCAPI.AssertExpectations(t)
+}
+
+func TestSpendGuardNamespaceMaxFeeFailsOversizedTX(t *testing.T) {
+	f, tk, mockFFCAPI, conf := newTestTransactionHandlerFactoryWithFilePersistence(t)
+	conf.Set(FixedGasPrice, `10`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "2500")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	sth.ctx = context.Background()
+
+	tk.EventHandler.(*txhandlermocks.ManagedTxEventHandler).On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXProcessFailed
+	})).Return(nil).Once()
+
+	// 300 gas at 10 each is a fee of 3000, which could never fit within the limit
+	mtx := newTestSpendGuardTX(300)
+	mtx.Status = apitypes.TxStatusPending
+	mtx.Nonce = fftypes.NewFFBigInt(1)
+	mtx.Created = fftypes.Now()
+	assert.NoError(t, tk.TXPersistence.InsertTransactionPreAssignedNonce(sth.ctx, mtx))
+	assert.True(t, sth.updateInflightSet(sth.ctx))
+	assert.Len(t, sth.inflight, 1)
+
+	err = sth.execPolicy(sth.ctx, sth.inflight[0], nil)
+	assert.NoError(t, err)
+	assert.True(t, sth.inflight[0].remove)
+	stored, err := tk.TXPersistence.GetTransactionByIDWithStatus(sth.ctx, mtx.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, apitypes.TxStatusFailed, stored.Status)
+	assert.Regexp(t, "FF21133.*3000.*2500.*ns1", stored.ErrorMessage)
+	assert.Nil(t, stored.FirstSubmit)
+	failed := stored.History[len(stored.History)-1]
+	assert.Equal(t, apitypes.TxSubStatusFailed, failed.Status)
+	assert.Equal(t, apitypes.TxActionSpendLimitReached, failed.Actions[len(failed.Actions)-1].Action)
+	assert.Empty(t, sth.namespaceSpend["ns1"])
+
+	mockFFCAPI.AssertNotCalled(t, "TransactionSend", mock.Anything, mock.Anything)
+	tk.EventHandler.(*txhandlermocks.ManagedTxEventHandler).AssertExpectations(t)
+}
+
+func TestSpendGuardNamespaceMaxFeeHoldsOversizedResubmit(t *testing.T) {
+	sth := &simpleTransactionHandler{
+		spendGuardNamespaceMaxFee: big.NewInt(2500),
+		spendGuardNamespaceWindow: time.Hour,
+	}
+	// An earlier submission at the nonce can still be mined, so the escalated resubmission is held rather than failed
+	submitTime := fftypes.Now()
+	mtx := newTestSpendGuardTX(300)
+	mtx.Status = apitypes.TxStatusPending
+	mtx.FirstSubmit = submitTime
+	mtx.GasPrice = fftypes.JSONAnyPtr(`10`)
+	rc := newTestRunContext(mtx, nil)
+	_, ok := sth.checkSpendLimits(rc)
+	assert.False(t, ok)
+	assert.Equal(t, apitypes.TxStatusPending, mtx.Status)
+	assert.Equal(t, apitypes.TxSubStatusAwaitingGasPrice, rc.SubStatus)
+}
+
+func TestSpendGuardLoadNamespaceSpend(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `10`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "2500")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+
+	recent := fftypes.FFTime(time.Now().Add(-10 * time.Minute))
+	old := fftypes.FFTime(time.Now().Add(-2 * time.Hour))
+	submittedTX := func(id string, gasPrice string, created, lastSubmit *fftypes.FFTime) *apitypes.ManagedTX {
+		mtx := newTestSpendGuardTX(100)
+		mtx.ID = id
+		mtx.SequenceID = id
+		mtx.GasPrice = fftypes.JSONAnyPtr(gasPrice)
+		mtx.Created = created
+		mtx.LastSubmit = lastSubmit
+		return mtx
+	}
+
+	// A full page of pending transactions, one of them created before the window but resubmitted within it
+	pendingPage := make([]*apitypes.ManagedTX, spendLoadPageSize)
+	for i := range pendingPage {
+		pendingPage[i] = submittedTX(fmt.Sprintf("ns1:pending%d", i), `1`, &old, nil)
+	}
+	pendingPage[0] = submittedTX("ns1:resubmitted", `10`, &old, &recent)
+	pendingPage[1] = submittedTX("ns2:submitted", `20`, &recent, &recent)
+	pendingPage[2] = submittedTX("ns1:stale", `10`, &old, &old)
+	mp.On("ListTransactionsPending", mock.Anything, "", spendLoadPageSize, txhandler.SortDirectionAscending).Return(pendingPage, nil).Once()
+	mp.On("ListTransactionsPending", mock.Anything, pendingPage[spendLoadPageSize-1].SequenceID, spendLoadPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{}, nil).Once()
+
+	// Completed transactions are read back until one was created before the window
+	completed := submittedTX("ns1:completed", `5`, &recent, &recent)
+	mp.On("ListTransactionsByCreateTime", mock.Anything, (*apitypes.ManagedTX)(nil), spendLoadPageSize, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{
+		completed,
+		submittedTX("ns1:unknownfee", `{"type":"custom"}`, &recent, &recent),
+		submittedTX("ns1:oldcompleted", `10`, &old, &recent),
+	}, nil).Once()
+
+	assert.NoError(t, sth.loadNamespaceSpend(context.Background()))
+	assert.Len(t, sth.namespaceSpend["ns1"], 2)
+	assert.Equal(t, int64(1500), sth.namespaceSpent("ns1", "", time.Now()).Int64())
+	assert.Equal(t, int64(2000), sth.namespaceSpent("ns2", "", time.Now()).Int64())
+	mp.AssertExpectations(t)
+}
+
+func TestSpendGuardLoadNamespaceSpendFail(t *testing.T) {
+	f, tk, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `10`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "2500")
+	th, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.NoError(t, err)
+	th.Init(context.Background(), tk)
+	sth := th.(*simpleTransactionHandler)
+	mp := tk.TXPersistence.(*persistencemocks.Persistence)
+
+	mp.On("ListTransactionsPending", mock.Anything, "", spendLoadPageSize, txhandler.SortDirectionAscending).Return(nil, fmt.Errorf("pop")).Once()
+	_, err = sth.Start(context.Background())
+	assert.Regexp(t, "pop", err)
+	assert.Nil(t, sth.ctx)
+
+	mp.On("ListTransactionsPending", mock.Anything, "", spendLoadPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListTransactionsByCreateTime", mock.Anything, (*apitypes.ManagedTX)(nil), spendLoadPageSize, txhandler.SortDirectionDescending).Return(nil, fmt.Errorf("pop")).Once()
+	assert.Regexp(t, "pop", sth.loadNamespaceSpend(context.Background()))
+}
+
+func TestSpendGuardLoadNamespaceSpendPages(t *testing.T) {
+	mp := &persistencemocks.Persistence{}
+	sth := &simpleTransactionHandler{
+		toolkit:                   &txhandler.Toolkit{TXPersistence: mp},
+		spendGuardNamespaceMaxFee: big.NewInt(2500),
+		spendGuardNamespaceWindow: time.Hour,
+	}
+	recent := fftypes.FFTime(time.Now().Add(-10 * time.Minute))
+	page := make([]*apitypes.ManagedTX, spendLoadPageSize)
+	for i := range page {
+		page[i] = newTestSpendGuardTX(1)
+		page[i].GasPrice = fftypes.JSONAnyPtr(`1`)
+		page[i].Created = &recent
+		page[i].LastSubmit = &recent
+	}
+	mp.On("ListTransactionsPending", mock.Anything, "", spendLoadPageSize, txhandler.SortDirectionAscending).Return([]*apitypes.ManagedTX{}, nil)
+	mp.On("ListTransactionsByCreateTime", mock.Anything, (*apitypes.ManagedTX)(nil), spendLoadPageSize, txhandler.SortDirectionDescending).Return(page, nil).Once()
+	mp.On("ListTransactionsByCreateTime", mock.Anything, page[spendLoadPageSize-1], spendLoadPageSize, txhandler.SortDirectionDescending).Return([]*apitypes.ManagedTX{}, nil).Once()
+
+	assert.NoError(t, sth.loadNamespaceSpend(context.Background()))
+	assert.Equal(t, int64(spendLoadPageSize), sth.namespaceSpent("ns1", "", time.Now()).Int64())
+	mp.AssertExpectations(t)
+}
+
+func TestSpendGuardNamespaceMaxFeeUnknownFee(t *testing.T) {
+	sth := &simpleTransactionHandler{
+		spendGuardNamespaceMaxFee: big.NewInt(1),
+		spendGuardNamespaceWindow: time.Hour,
+	}
+	mtx := newTestSpendGuardTX(100)
+	mtx.GasPrice = fftypes.JSONAnyPtr(`{"type":"custom"}`)
+	release, ok := sth.checkSpendLimits(newTestRunContext(mtx, nil))
+	assert.True(t, ok)
+	release()
+	assert.Nil(t, sth.namespaceSpend)
+}
+
+func TestSpendGuardBadConfig(t *testing.T) {
+	f, _, _, conf := newTestTransactionHandlerFactory(t)
+	conf.Set(FixedGasPrice, `1000`)
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardMaxGasPrice, "-1")
+	_, err := f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21101.*maxGasPrice", err)
+
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardMaxGasPrice, "")
+	conf.SubSection(SpendGuardConfig).Set(SpendGuardNamespaceMaxFee, "lots")
+	_, err = f.NewTransactionHandler(context.Background(), conf)
+	assert.Regexp(t, "FF21101.*namespaceMaxFee", err)
+}
+
+func TestHighestGasPriceValue(t *testing.T) {
+	assert.Nil(t, highestGasPriceValue(nil))
+	assert.Equal(t, int64(100), highestGasPriceValue(fftypes.JSONAnyPtr(`100`)).Int64())
+	assert.Equal(t, int64(255), highestGasPriceValue(fftypes.JSONAnyPtr(`"0xff"`)).Int64())
+	assert.Equal(t, int64(30), highestGasPriceValue(fftypes.JSONAnyPtr(`{"maxFeePerGas":"30","maxPriorityFeePerGas":2,"type":"eip1559"}`)).Int64())
+	assert.Nil(t, highestGasPriceValue(fftypes.JSONAnyPtr(`[1,2]`)))
+}
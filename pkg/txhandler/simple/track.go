@@ -0,0 +1,67 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-common/pkg/i18n"
+	"github.com/hyperledger/firefly-common/pkg/log"
+	"github.com/hyperledger/firefly-transaction-manager/internal/tmmsgs"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/ffcapi"
+)
+
+// HandleTrackTransaction adopts a transaction submitted by other tooling. Nothing is submitted - the transaction
+// is inserted as already submitted, so the policy loop registers the hash for receipts and confirmations,
+// and never resubmits it.
+func (sth *simpleTransactionHandler) HandleTrackTransaction(ctx context.Context, req *apitypes.TrackTransactionRequest) (*apitypes.ManagedTX, error) {
+	if req.TransactionHash == "" || req.From == "" || req.Nonce == nil {
+		return nil, i18n.NewError(ctx, tmmsgs.MsgInvalidTrackRequest)
+	}
+	txID, err := sth.requestIDPreCheck(ctx, &apitypes.RequestHeaders{ID: req.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	mtx := sth.newManagedTx(txID, &ffcapi.TransactionHeaders{From: req.From, Nonce: req.Nonce}, nil, "", nil)
+	infoBytes, _ := json.Marshal(&simplePolicyInfo{External: true})
+	mtx.PolicyInfo = fftypes.JSONAnyPtrBytes(infoBytes)
+	mtx.TransactionHash = req.TransactionHash
+	mtx.FirstSubmit = mtx.Created
+	mtx.LastSubmit = mtx.Created
+	// The nonce is already spent on chain, so a clash with another transaction for the signer is rejected
+	if err := sth.toolkit.TXPersistence.InsertTransactionPreAssignedNonce(ctx, mtx); err != nil {
+		return nil, err
+	}
+	// Record the hash as a submission, so the transaction can be looked up by it
+	if err := sth.toolkit.TXPersistence.UpdateTransaction(ctx, mtx.ID, &apitypes.TXUpdates{
+		TransactionHash: &mtx.TransactionHash,
+		LastSubmit:      mtx.LastSubmit,
+	}); err != nil {
+		return nil, err
+	}
+	if err := sth.toolkit.TXHistory.AddSubStatusAction(ctx, mtx.ID, apitypes.TxSubStatusTracking, apitypes.TxActionTrackExternal, fftypes.JSONAnyPtr(`{"transactionHash":"`+mtx.TransactionHash+`"}`), nil, fftypes.Now()); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Tracking externally submitted transaction %s at nonce %s / %d - hash: %s", mtx.ID, mtx.From, mtx.Nonce.Int64(), mtx.TransactionHash)
+	sth.incTransactionOperationCounter(ctx, mtx.Namespace(ctx), "track_external")
+	sth.markInflightStale()
+	return mtx, nil
+}
@@ -0,0 +1,130 @@
+// Copyright © 2024 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simple
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/firefly-common/pkg/fftypes"
+	"github.com/hyperledger/firefly-transaction-manager/pkg/apitypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestTrackRequest() *apitypes.TrackTransactionRequest {
+	return &apitypes.TrackTransactionRequest{
+		ID:              "ns1:external1",
+		TransactionHash: "0x111111",
+		From:            "0xaaaa",
+		Nonce:           fftypes.NewFFBigInt(10),
+	}
+}
+
+func TestTrackTransaction(t *testing.T) {
+	sth, mp, _, meh := newTestDependenciesHandler(t)
+	sth.resubmitInterval = 0
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:external1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.MatchedBy(func(mtx *apitypes.ManagedTX) bool {
+		return mtx.ID == "ns1:external1" &&
+			mtx.Status == apitypes.TxStatusPending &&
+			mtx.From == "0xaaaa" &&
+			mtx.Nonce.Int64() == 10 &&
+			mtx.TransactionHash == "0x111111" &&
+			mtx.FirstSubmit != nil &&
+			mtx.PolicyInfo.String() == `{"lastWarnTime":null,"external":true}`
+	})).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:external1", mock.MatchedBy(func(updates *apitypes.TXUpdates) bool {
+		return *updates.TransactionHash == "0x111111" && updates.LastSubmit != nil
+	})).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:external1", apitypes.TxSubStatusTracking, apitypes.TxActionTrackExternal, mock.MatchedBy(func(info *fftypes.JSONAny) bool {
+		return info.String() == `{"transactionHash":"0x111111"}`
+	}), mock.Anything, mock.Anything).Return(nil)
+
+	mtx, err := sth.HandleTrackTransaction(sth.ctx, newTestTrackRequest())
+	assert.NoError(t, err)
+	assert.Equal(t, "ns1:external1", mtx.ID)
+
+	// The policy loop registers the hash, and never resubmits the transaction
+	meh.On("HandleEvent", mock.Anything, mock.MatchedBy(func(e apitypes.ManagedTransactionEvent) bool {
+		return e.Type == apitypes.ManagedTXTransactionHashAdded && e.Tx.TransactionHash == "0x111111"
+	})).Return(nil).Once()
+	var info simplePolicyInfo
+	pending := &pendingState{mtx: mtx, info: &info}
+	assert.NoError(t, json.Unmarshal(mtx.PolicyInfo.Bytes(), &info))
+	time.Sleep(1 * time.Millisecond)
+	err = sth.execPolicy(sth.ctx, pending, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x111111"}, pending.trackedHashes)
+
+	mp.AssertExpectations(t)
+	meh.AssertExpectations(t)
+}
+
+func TestTrackTransactionInvalidRequest(t *testing.T) {
+	sth, _, _, _ := newTestDependenciesHandler(t)
+
+	req := newTestTrackRequest()
+	req.Nonce = nil
+	_, err := sth.HandleTrackTransaction(sth.ctx, req)
+	assert.Regexp(t, "FF21115", err)
+}
+
+func TestTrackTransactionDuplicateID(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:external1").Return(&apitypes.ManagedTX{}, nil)
+
+	_, err := sth.HandleTrackTransaction(sth.ctx, newTestTrackRequest())
+	assert.Regexp(t, "FF21065", err)
+}
+
+func TestTrackTransactionInsertFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:external1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleTrackTransaction(sth.ctx, newTestTrackRequest())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestTrackTransactionUpdateFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:external1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:external1", mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleTrackTransaction(sth.ctx, newTestTrackRequest())
+	assert.Regexp(t, "pop", err)
+}
+
+func TestTrackTransactionHistoryFail(t *testing.T) {
+	sth, mp, _, _ := newTestDependenciesHandler(t)
+
+	mp.On("GetTransactionByID", mock.Anything, "ns1:external1").Return(nil, nil)
+	mp.On("InsertTransactionPreAssignedNonce", mock.Anything, mock.Anything).Return(nil)
+	mp.On("UpdateTransaction", mock.Anything, "ns1:external1", mock.Anything).Return(nil)
+	mp.On("AddSubStatusAction", mock.Anything, "ns1:external1", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := sth.HandleTrackTransaction(sth.ctx, newTestTrackRequest())
+	assert.Regexp(t, "pop", err)
+}
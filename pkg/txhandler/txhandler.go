@@ -39,12 +39,18 @@ type TransactionPersistence interface {
 	ListTransactionsByCreateTime(ctx context.Context, after *apitypes.ManagedTX, limit int, dir SortDirection) ([]*apitypes.ManagedTX, error)         // reverse create time order
 	ListTransactionsByNonce(ctx context.Context, signer string, after *fftypes.FFBigInt, limit int, dir SortDirection) ([]*apitypes.ManagedTX, error) // reverse nonce order within signer
 	ListTransactionsPending(ctx context.Context, afterSequenceID string, limit int, dir SortDirection) ([]*apitypes.ManagedTX, error)                 // reverse insertion order, only those in pending state
+	ListSigners(ctx context.Context, after string, limit int) ([]string, error)                                                                       // signers with at least one transaction, in ascending order
+	ListPendingSigners(ctx context.Context, after string, limit int) ([]string, error)                                                                // signers with at least one transaction in pending state, in ascending order
+	ListSignerTransactionsPending(ctx context.Context, signer string, limit int) ([]*apitypes.ManagedTX, error)                                       // nonce order within signer, only those in pending state, with those waiting for a nonce last
+	ListTransactionsPendingByPriority(ctx context.Context, limit int) ([]*apitypes.ManagedTX, error)                                                  // highest priority first then insertion order, only those in pending state with a priority above zero
 	GetTransactionByID(ctx context.Context, txID string) (*apitypes.ManagedTX, error)
 	GetTransactionByIDWithStatus(ctx context.Context, txID string, history bool) (*apitypes.TXWithStatus, error)
 	GetTransactionByNonce(ctx context.Context, signer string, nonce *fftypes.FFBigInt) (*apitypes.ManagedTX, error)
 	InsertTransactionPreAssignedNonce(ctx context.Context, tx *apitypes.ManagedTX) error
 	InsertTransactionWithNextNonce(ctx context.Context, tx *apitypes.ManagedTX, lookupNextNonce NextNonceCallback) error
-	UpdateTransaction(ctx context.Context, txID string, updates *apitypes.TXUpdates) error
+	InsertTransactionsWithNextNonce(ctx context.Context, txs []*apitypes.ManagedTX, lookupNextNonce NextNonceCallback) []error // one error slot per transaction, in order
+	AssignTransactionNextNonce(ctx context.Context, tx *apitypes.ManagedTX, lookupNextNonce NextNonceCallback) error           // for a transaction inserted without a nonce, such as one waiting on dependencies
+	UpdateTransaction(ctx context.Context, txID string, updates *apitypes.TXUpdates) error                                     // setting SupersededBy also clears the nonce, for the superseding transaction to take
 	DeleteTransaction(ctx context.Context, txID string) error
 
 	GetTransactionReceipt(ctx context.Context, txID string) (receipt *ffcapi.TransactionReceiptResponse, err error)
@@ -52,6 +58,10 @@ type TransactionPersistence interface {
 
 	GetTransactionConfirmations(ctx context.Context, txID string) ([]*apitypes.Confirmation, error)
 	AddTransactionConfirmations(ctx context.Context, txID string, clearExisting bool, confirmations ...*apitypes.Confirmation) error
+
+	// Every TransactionHash set through UpdateTransaction is recorded, so the transaction can be found by any of them
+	GetTransactionByHash(ctx context.Context, transactionHash string) (*apitypes.ManagedTX, error)
+	GetTransactionSubmittedHashes(ctx context.Context, txID string) ([]*apitypes.SubmittedHash, error) // in submission order
 }
 
 type RichQuery interface {
@@ -95,6 +105,19 @@ type TransactionMetrics interface {
 	ObserveTxHandlerSummaryMetricWithLabels(ctx context.Context, metricName string, number float64, labels map[string]string, defaultLabels *metric.FireflyDefaultLabels)
 }
 
+// BlockHeightTracker reports the head of the chain, as seen by the block listener of the transaction manager
+type BlockHeightTracker interface {
+	// HighestBlockSeen returns the highest block number seen, or zero if no blocks have been seen yet
+	HighestBlockSeen() uint64
+}
+
+// AddressScreener checks the signer and destination of a new transaction against the address allow/deny lists
+// of the transaction manager, before the transaction is prepared
+type AddressScreener interface {
+	// ScreenAddresses returns an error if the transaction must be refused. The "to" address is empty for a contract deployment
+	ScreenAddresses(ctx context.Context, from, to string) error
+}
+
 type Toolkit struct {
 	// Connector toolkit contains methods to interact with the plugged-in JSON-RPC endpoint of a Blockchain network
 	Connector ffcapi.API
@@ -114,6 +137,14 @@ type Toolkit struct {
 
 	// Event Handler toolkit contains methods to handle a defined set of events when processing managed transactions
 	EventHandler ManagedTxEventHandler
+
+	// Block Height toolkit provides the latest block number, for transaction handlers that need to act at a particular block.
+	// This will be nil if the transaction handler is initialized outside of the transaction manager.
+	BlockHeight BlockHeightTracker
+
+	// Address Screener toolkit refuses transactions from signers, or to destinations, that are blocked by the address lists.
+	// This will be nil if the transaction handler is initialized outside of the transaction manager.
+	AddressScreener AddressScreener
 }
 
 // Handler checks received transaction process events and dispatch them to an event
@@ -172,3 +203,66 @@ type TransactionHandler interface {
 	// HandleTransactionReceiptReceived - handles receipt of blockchain transactions for a managed transaction
 	HandleTransactionReceiptReceived(ctx context.Context, txID string, receipt *ffcapi.TransactionReceiptResponse) (err error)
 }
+
+// BatchTransactionHandler can optionally be implemented by a Transaction Handler, to accept a batch of new transactions and
+// contract deployments in a single call - for example to persist them, and assign their nonces, together.
+// Transaction Handlers that do not implement it are passed each item in the batch individually.
+type BatchTransactionHandler interface {
+	// HandleNewTransactionBatch - handles a batch of new transactions and contract deployments, setting the outcome on each item
+	HandleNewTransactionBatch(ctx context.Context, batch []*NewTransactionBatchItem)
+}
+
+// RetryTransactionHandler can optionally be implemented by a Transaction Handler, to retry a failed transaction as a new
+// transaction with a fresh nonce. The two transactions are linked to each other, through RetryOf and RetriedBy.
+type RetryTransactionHandler interface {
+	// HandleRetryTransaction - handles a request to retry a failed managed transaction, returning the new transaction
+	HandleRetryTransaction(ctx context.Context, txID string, req *apitypes.RetryTransactionRequest) (mtx *apitypes.ManagedTX, err error)
+}
+
+// SpeedUpTransactionHandler can optionally be implemented by a Transaction Handler, to allow an operator to push
+// through an individual transaction that is stuck, by resubmitting it immediately at a higher gas price.
+type SpeedUpTransactionHandler interface {
+	// HandleSpeedUpTransaction - handles a request to resubmit a pending managed transaction at the same nonce with a higher gas price
+	HandleSpeedUpTransaction(ctx context.Context, txID string, req *apitypes.SpeedUpTransactionRequest) (mtx *apitypes.ManagedTX, err error)
+}
+
+// TrackTransactionHandler can optionally be implemented by a Transaction Handler, to adopt transactions that were
+// submitted by other tooling, so receipts and confirmations are handled for them like any other managed transaction.
+type TrackTransactionHandler interface {
+	// HandleTrackTransaction - handles a request to track an externally submitted transaction, without submitting anything
+	HandleTrackTransaction(ctx context.Context, req *apitypes.TrackTransactionRequest) (mtx *apitypes.ManagedTX, err error)
+}
+
+// ApprovalTransactionHandler can optionally be implemented by a Transaction Handler that holds transactions in the
+// AwaitingApproval status, so they are only submitted once approved. The approver is identified from the context.
+type ApprovalTransactionHandler interface {
+	// HandleApproveTransaction - handles a request to approve a managed transaction that is awaiting approval
+	HandleApproveTransaction(ctx context.Context, txID string) (mtx *apitypes.ManagedTX, err error)
+	// HandleRejectTransaction - handles a request to reject a managed transaction that is awaiting approval
+	HandleRejectTransaction(ctx context.Context, txID string) (mtx *apitypes.ManagedTX, err error)
+}
+
+// GasPriceHistoryHandler can optionally be implemented by a Transaction Handler that queries gas oracles, to report
+// the gas prices it obtained recently, and the source of each.
+type GasPriceHistoryHandler interface {
+	// GasPriceHistory - returns the recent gas prices, newest first
+	GasPriceHistory(ctx context.Context) ([]*apitypes.GasPriceHistoryEntry, error)
+}
+
+// SignerPauseHandler can optionally be implemented by a Transaction Handler that pauses submission for a signer,
+// for example when the node rejects a transaction for insufficient funds, to report the pause in the signer status.
+type SignerPauseHandler interface {
+	// SignerPause - returns nil if submission is not paused for the signer
+	SignerPause(ctx context.Context, signer string) *apitypes.SignerPause
+}
+
+// NewTransactionBatchItem is a single request in a batch. Exactly one of TransactionRequest or ContractDeployRequest is set
+// on input, and the Transaction Handler sets either ManagedTX, or Err (and SubmissionRejected) on output.
+type NewTransactionBatchItem struct {
+	TransactionRequest    *apitypes.TransactionRequest
+	ContractDeployRequest *apitypes.ContractDeployRequest
+
+	ManagedTX          *apitypes.ManagedTX
+	SubmissionRejected bool
+	Err                error
+}